@@ -22,6 +22,8 @@ Admin service through gRPC
 Arguments
 -allowUnvalidatedConfig <allow configuration for devices without a corresponding model plugin>
 
+-reconcileConfigDrift <by default, set the intended config back on a device when its running config has drifted>
+
 -modelPlugin (repeated) <the location of a shared object library that implements the Model Plugin interface>
 
 -caPath <the location of a CA certificate>
@@ -30,34 +32,61 @@ Arguments
 
 -certPath <the location of a client certificate>
 
+-rbacPolicy <the location of a policy file of the operations allowed to each role on the gNMI northbound>
+
+-jwtAuthentication <authenticate the clients with a JWT, and take their roles from its claims>
+
+-auditLog <the location of the file to which the audit records of the northbound operations are appended>
+
+-auditLogKey <the location of the file holding the key that the audit records are sealed with, kept apart from the audit log>
+
+-auditLogAcceptTampered <move an audit log that has been tampered with aside and start a new one, rather than refusing to start>
+
+-deviceWorkers <the number of devices whose changes are validated and computed at the same time>
+
+-applyTimeout (repeated) <how long a device is given to answer a set request, as a duration or as <device type>=<duration>>
+
+-maxReconcileAttempts <the number of times in a row a change or snapshot may fail to reconcile before it is given up on>
+
 
 See ../../docs/run.md for how to run the application.
 */
 package main
 
 import (
+	"bytes"
 	"flag"
 	"fmt"
+	"io/ioutil"
 	"os"
 	"time"
 
 	"github.com/onosproject/onos-lib-go/pkg/atomix"
 	"github.com/onosproject/onos-lib-go/pkg/cluster"
 
+	"github.com/onosproject/onos-config/pkg/audit"
 	"github.com/onosproject/onos-config/pkg/config"
+	"github.com/onosproject/onos-config/pkg/controller"
+	devicechangectl "github.com/onosproject/onos-config/pkg/controller/change/device"
 	"github.com/onosproject/onos-config/pkg/manager"
 	"github.com/onosproject/onos-config/pkg/northbound/admin"
 	"github.com/onosproject/onos-config/pkg/northbound/diags"
 	"github.com/onosproject/onos-config/pkg/northbound/gnmi"
+	"github.com/onosproject/onos-config/pkg/rbac"
+	"github.com/onosproject/onos-config/pkg/southbound/synchronizer"
 	"github.com/onosproject/onos-config/pkg/store/change/device"
 	"github.com/onosproject/onos-config/pkg/store/change/device/state"
+	"github.com/onosproject/onos-config/pkg/store/change/metadata"
 	"github.com/onosproject/onos-config/pkg/store/change/network"
+	"github.com/onosproject/onos-config/pkg/store/deadletter"
 	devicestore "github.com/onosproject/onos-config/pkg/store/device"
 	"github.com/onosproject/onos-config/pkg/store/device/cache"
 	"github.com/onosproject/onos-config/pkg/store/leadership"
+	"github.com/onosproject/onos-config/pkg/store/lock"
 	"github.com/onosproject/onos-config/pkg/store/mastership"
 	devicesnap "github.com/onosproject/onos-config/pkg/store/snapshot/device"
 	networksnap "github.com/onosproject/onos-config/pkg/store/snapshot/network"
+	"github.com/onosproject/onos-config/pkg/utils"
 	"github.com/onosproject/onos-lib-go/pkg/certs"
 	"github.com/onosproject/onos-lib-go/pkg/logging"
 	"github.com/onosproject/onos-lib-go/pkg/northbound"
@@ -89,11 +118,21 @@ var ClusterFactory = func(configuration config.Config) (cluster.Cluster, error)
 func main() {
 	var modelPlugins arrayFlags
 	allowUnvalidatedConfig := flag.Bool("allowUnvalidatedConfig", false, "allow configuration for devices without a corresponding model plugin")
+	reconcileConfigDrift := flag.Bool("reconcileConfigDrift", false, "by default, set the intended config back on a device when its running config has drifted")
 	flag.Var(&modelPlugins, "modelPlugin", "names of model plugins to load (repeated)")
 	caPath := flag.String("caPath", "", "path to CA certificate")
 	keyPath := flag.String("keyPath", "", "path to client private key")
 	certPath := flag.String("certPath", "", "path to client certificate")
 	topoEndpoint := flag.String("topoEndpoint", "onos-topo:5150", "topology service endpoint")
+	rbacPolicy := flag.String("rbacPolicy", "", "path to the policy file of the operations allowed to each role on the gNMI northbound")
+	jwtAuthentication := flag.Bool("jwtAuthentication", false, "authenticate clients with a JWT, whose claims give their roles")
+	auditLog := flag.String("auditLog", "", "path to the audit log file of the northbound operations")
+	auditLogKey := flag.String("auditLogKey", "", "path to the file holding the key that the audit records are sealed with")
+	auditLogAcceptTampered := flag.Bool("auditLogAcceptTampered", false, "move an audit log that has been tampered with aside and start a new one")
+	applyTimeouts := devicechangectl.NewApplyTimeouts()
+	flag.Var(applyTimeouts, "applyTimeout", "how long a device is given to answer a set request, as <duration> or <device type>=<duration> (repeated)")
+	maxReconcileAttempts := flag.Int("maxReconcileAttempts", controller.DefaultMaxAttempts, "number of times in a row a change or snapshot may fail to reconcile before it is given up on, 0 for no limit")
+	deviceWorkers := flag.Int("deviceWorkers", manager.DefaultDeviceWorkers, "number of devices whose changes are validated and computed at the same time")
 	//This flag is used in logging.init()
 	flag.Bool("debug", false, "enable debug logging")
 	flag.Parse()
@@ -136,6 +175,11 @@ func main() {
 		log.Fatal("Cannot load network atomix store ", err)
 	}
 
+	networkMetadataStore, err := metadata.NewAtomixStore(configuration)
+	if err != nil {
+		log.Fatal("Cannot load network change metadata atomix store ", err)
+	}
+
 	networkSnapshotStore, err := networksnap.NewAtomixStore(cluster, configuration)
 	if err != nil {
 		log.Fatal("Cannot load network snapshot atomix store ", err)
@@ -146,6 +190,16 @@ func main() {
 		log.Fatal("Cannot load network atomix store ", err)
 	}
 
+	deviceLockStore, err := lock.NewAtomixStore(configuration)
+	if err != nil {
+		log.Fatal("Cannot load device lock atomix store ", err)
+	}
+
+	deadLetterStore, err := deadletter.NewAtomixStore(configuration)
+	if err != nil {
+		log.Fatal("Cannot load dead letter atomix store ", err)
+	}
+
 	deviceStateStore, err := state.NewStore(networkChangesStore, deviceSnapshotStore)
 	if err != nil {
 		log.Fatal("Cannot load device store with address %s:", *topoEndpoint, err)
@@ -164,8 +218,16 @@ func main() {
 	log.Infof("Topology service connected with endpoint %s", *topoEndpoint)
 
 	mgr := manager.NewManager(leadershipStore, mastershipStore, deviceChangesStore,
-		deviceStateStore, deviceStore, deviceCache, networkChangesStore, networkSnapshotStore,
-		deviceSnapshotStore, *allowUnvalidatedConfig)
+		deviceStateStore, deviceStore, deviceCache, networkChangesStore, networkMetadataStore,
+		networkSnapshotStore, deviceSnapshotStore, deviceLockStore, deadLetterStore,
+		*allowUnvalidatedConfig)
+	if *reconcileConfigDrift {
+		mgr.ConfigDriftPolicy = synchronizer.DriftPolicyReconcile
+	}
+	mgr.DeviceWorkers = *deviceWorkers
+	mgr.MaxReconcileAttempts = *maxReconcileAttempts
+	mgr.ApplyTimeouts.Default = applyTimeouts.Default
+	mgr.ApplyTimeouts.DeviceTypes = applyTimeouts.DeviceTypes
 	log.Info("Manager created")
 
 	defer func() {
@@ -184,19 +246,46 @@ func main() {
 		}
 	}
 
+	// The identity of a client is taken from its JWT only when the JWTs are authenticated
+	utils.SetJwtAuthentication(*jwtAuthentication)
+
+	var authorizer *rbac.Authorizer
+	if *rbacPolicy != "" {
+		policy, err := rbac.LoadPolicy(*rbacPolicy)
+		if err != nil {
+			log.Fatal("Cannot load rbac policy ", err)
+		}
+		authorizer = rbac.NewAuthorizer(policy)
+	}
+
+	if *auditLog != "" {
+		key, err := ioutil.ReadFile(*auditLogKey)
+		if err != nil {
+			log.Fatal("Cannot read audit log key ", err)
+		}
+		mgr.AuditLog, err = audit.NewFileLog(*auditLog, bytes.TrimSpace(key), *auditLogAcceptTampered)
+		if err != nil {
+			log.Fatal("Cannot open audit log ", err)
+		}
+		defer mgr.AuditLog.Close()
+	}
+
 	mgr.Run()
-	err = startServer(*caPath, *keyPath, *certPath)
+	err = startServer(*caPath, *keyPath, *certPath, *jwtAuthentication, authorizer)
 	if err != nil {
 		log.Fatal("Unable to start onos-config ", err)
 	}
 }
 
 // Creates gRPC server and registers various services; then serves.
-func startServer(caPath string, keyPath string, certPath string) error {
-	s := northbound.NewServer(northbound.NewServerCfg(caPath, keyPath, certPath, 5150, true, northbound.SecurityConfig{}))
+func startServer(caPath string, keyPath string, certPath string, jwtAuthentication bool, authorizer *rbac.Authorizer) error {
+	security := northbound.SecurityConfig{
+		AuthenticationEnabled: jwtAuthentication,
+	}
+	s := northbound.NewServer(northbound.NewServerCfg(caPath, keyPath, certPath, 5150, true, security))
 	s.AddService(admin.Service{})
 	s.AddService(diags.Service{})
-	s.AddService(gnmi.Service{})
+	s.AddService(gnmi.Service{Authorizer: authorizer})
 	s.AddService(logging.Service{})
 
 	return s.Serve(func(started string) {
@@ -0,0 +1,418 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package admin defines the ConfigAdminExtService, the administrative gRPC service of onos-config for the
+// operations that the onos-api ConfigAdminService does not provide. Its messages are encoded with the JSON codec.
+package admin
+
+import (
+	"context"
+	"time"
+
+	networkchange "github.com/onosproject/onos-api/go/onos/config/change/network"
+	devicetype "github.com/onosproject/onos-api/go/onos/config/device"
+	"github.com/onosproject/onos-config/pkg/api/codec"
+	"google.golang.org/grpc"
+)
+
+// RollbackToNetworkChangeRequest requests the rollback of every network change made after the named one
+type RollbackToNetworkChangeRequest struct {
+	// Name is the ID of the network change to roll back to
+	Name networkchange.ID `json:"name"`
+}
+
+// RollbackToNetworkChangeResponse gives the network changes that were rolled back, latest first
+type RollbackToNetworkChangeResponse struct {
+	Message    string             `json:"message,omitempty"`
+	RolledBack []networkchange.ID `json:"rolled_back,omitempty"`
+}
+
+// ConfirmNetworkChangeRequest requests the confirmation of a network change made with a confirm timeout
+type ConfirmNetworkChangeRequest struct {
+	// Name is the ID of the network change to confirm
+	Name networkchange.ID `json:"name"`
+}
+
+// ConfirmNetworkChangeResponse is the response to the confirmation of a network change
+type ConfirmNetworkChangeResponse struct {
+	Message string `json:"message,omitempty"`
+}
+
+// LockDevicesRequest requests locks on the configuration of devices for the calling client
+type LockDevicesRequest struct {
+	DeviceIDs []devicetype.ID `json:"device_ids"`
+	// TTL is how long the locks are held for unless they are renewed, or the default if not given
+	TTL *time.Duration `json:"ttl,omitempty"`
+}
+
+// DeviceLock is a lock held by a client on the configuration of a device
+type DeviceLock struct {
+	DeviceID devicetype.ID `json:"device_id"`
+	Owner    string        `json:"owner"`
+	Expires  time.Time     `json:"expires"`
+}
+
+// LockDevicesResponse gives the locks that were taken
+type LockDevicesResponse struct {
+	Locks []*DeviceLock `json:"locks,omitempty"`
+}
+
+// UnlockDevicesRequest requests the release of the locks held by the calling client on devices
+type UnlockDevicesRequest struct {
+	DeviceIDs []devicetype.ID `json:"device_ids"`
+}
+
+// UnlockDevicesResponse is the response to the release of device locks
+type UnlockDevicesResponse struct {
+	Message string `json:"message,omitempty"`
+}
+
+// ListAuditRecordsRequest requests the records of the audit log of the instance that matches all the filters
+// that are given
+type ListAuditRecordsRequest struct {
+	Client          string `json:"client,omitempty"`
+	Operation       string `json:"operation,omitempty"`
+	NetworkChangeID string `json:"network_change_id,omitempty"`
+	// Since is the time from which the records are listed
+	Since *time.Time `json:"since,omitempty"`
+}
+
+// AuditRecord is a record of the audit log
+type AuditRecord struct {
+	Index           uint64    `json:"index"`
+	Timestamp       time.Time `json:"timestamp"`
+	Operation       string    `json:"operation"`
+	Client          string    `json:"client,omitempty"`
+	Paths           []string  `json:"paths,omitempty"`
+	NetworkChangeID string    `json:"network_change_id,omitempty"`
+	Details         string    `json:"details,omitempty"`
+	Outcome         string    `json:"outcome"`
+	Error           string    `json:"error,omitempty"`
+	PrevHash        string    `json:"prev_hash,omitempty"`
+	Hash            string    `json:"hash"`
+}
+
+// ListDeadLettersRequest requests the requests that the controllers have given up on
+type ListDeadLettersRequest struct {
+	// Controller is the name of the controller whose dead letters are listed, or all the controllers if not given
+	Controller string `json:"controller,omitempty"`
+}
+
+// DeadLetter is a request that a controller gave up on after failing to reconcile it too many times in a row
+type DeadLetter struct {
+	Controller string    `json:"controller"`
+	ID         string    `json:"id"`
+	Attempts   uint32    `json:"attempts"`
+	Error      string    `json:"error,omitempty"`
+	Time       time.Time `json:"time"`
+}
+
+// RequeueDeadLettersRequest requests a new set of attempts for requests that the controllers have given up on
+type RequeueDeadLettersRequest struct {
+	// Controller is the name of the controller whose dead letters are requeued, or all the controllers if not given
+	Controller string `json:"controller,omitempty"`
+	// IDs are the identifiers of the requests to requeue, or all the requests if none is given
+	IDs []string `json:"ids,omitempty"`
+}
+
+// RequeueDeadLettersResponse gives the requests that were requeued
+type RequeueDeadLettersResponse struct {
+	DeadLetters []*DeadLetter `json:"dead_letters,omitempty"`
+}
+
+// ConfigAdminExtServiceClient is the client API for the ConfigAdminExtService
+type ConfigAdminExtServiceClient interface {
+	// RollbackToNetworkChange rolls back every network change made after the named network change, latest first
+	RollbackToNetworkChange(ctx context.Context, in *RollbackToNetworkChangeRequest, opts ...grpc.CallOption) (*RollbackToNetworkChangeResponse, error)
+
+	// ConfirmNetworkChange confirms a network change made with a confirm timeout, so that it is not rolled back
+	ConfirmNetworkChange(ctx context.Context, in *ConfirmNetworkChangeRequest, opts ...grpc.CallOption) (*ConfirmNetworkChangeResponse, error)
+
+	// LockDevices locks the configuration of devices for the calling client until the TTL expires
+	LockDevices(ctx context.Context, in *LockDevicesRequest, opts ...grpc.CallOption) (*LockDevicesResponse, error)
+
+	// UnlockDevices releases the locks held by the calling client on devices
+	UnlockDevices(ctx context.Context, in *UnlockDevicesRequest, opts ...grpc.CallOption) (*UnlockDevicesResponse, error)
+
+	// ListAuditRecords gets a stream of the records of the audit log of the instance, oldest first
+	ListAuditRecords(ctx context.Context, in *ListAuditRecordsRequest, opts ...grpc.CallOption) (ListAuditRecordsClient, error)
+
+	// ListDeadLetters gets a stream of the requests that the controllers have given up on, oldest first
+	ListDeadLetters(ctx context.Context, in *ListDeadLettersRequest, opts ...grpc.CallOption) (ListDeadLettersClient, error)
+
+	// RequeueDeadLetters gives requests that the controllers have given up on a new set of attempts
+	RequeueDeadLetters(ctx context.Context, in *RequeueDeadLettersRequest, opts ...grpc.CallOption) (*RequeueDeadLettersResponse, error)
+}
+
+type configAdminExtServiceClient struct {
+	cc *grpc.ClientConn
+}
+
+// NewConfigAdminExtServiceClient returns a new ConfigAdminExtService client
+func NewConfigAdminExtServiceClient(cc *grpc.ClientConn) ConfigAdminExtServiceClient {
+	return &configAdminExtServiceClient{cc}
+}
+
+func (c *configAdminExtServiceClient) RollbackToNetworkChange(ctx context.Context, in *RollbackToNetworkChangeRequest, opts ...grpc.CallOption) (*RollbackToNetworkChangeResponse, error) {
+	out := new(RollbackToNetworkChangeResponse)
+	if err := c.invoke(ctx, "RollbackToNetworkChange", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *configAdminExtServiceClient) ConfirmNetworkChange(ctx context.Context, in *ConfirmNetworkChangeRequest, opts ...grpc.CallOption) (*ConfirmNetworkChangeResponse, error) {
+	out := new(ConfirmNetworkChangeResponse)
+	if err := c.invoke(ctx, "ConfirmNetworkChange", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *configAdminExtServiceClient) LockDevices(ctx context.Context, in *LockDevicesRequest, opts ...grpc.CallOption) (*LockDevicesResponse, error) {
+	out := new(LockDevicesResponse)
+	if err := c.invoke(ctx, "LockDevices", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *configAdminExtServiceClient) UnlockDevices(ctx context.Context, in *UnlockDevicesRequest, opts ...grpc.CallOption) (*UnlockDevicesResponse, error) {
+	out := new(UnlockDevicesResponse)
+	if err := c.invoke(ctx, "UnlockDevices", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *configAdminExtServiceClient) ListAuditRecords(ctx context.Context, in *ListAuditRecordsRequest, opts ...grpc.CallOption) (ListAuditRecordsClient, error) {
+	stream, err := c.newStream(ctx, "ListAuditRecords", in, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &configAdminExtServiceListAuditRecordsClient{stream}, nil
+}
+
+func (c *configAdminExtServiceClient) ListDeadLetters(ctx context.Context, in *ListDeadLettersRequest, opts ...grpc.CallOption) (ListDeadLettersClient, error) {
+	stream, err := c.newStream(ctx, "ListDeadLetters", in, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &configAdminExtServiceListDeadLettersClient{stream}, nil
+}
+
+func (c *configAdminExtServiceClient) RequeueDeadLetters(ctx context.Context, in *RequeueDeadLettersRequest, opts ...grpc.CallOption) (*RequeueDeadLettersResponse, error) {
+	out := new(RequeueDeadLettersResponse)
+	if err := c.invoke(ctx, "RequeueDeadLetters", in, out, opts...); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// invoke calls the given unary method
+func (c *configAdminExtServiceClient) invoke(ctx context.Context, method string, in interface{}, out interface{}, opts ...grpc.CallOption) error {
+	return c.cc.Invoke(ctx, "/"+serviceName+"/"+method, in, out, append(opts, codec.CallOption())...)
+}
+
+// newStream opens a server stream to the given method and sends the request on it
+func (c *configAdminExtServiceClient) newStream(ctx context.Context, method string, in interface{}, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	desc := &grpc.StreamDesc{StreamName: method, ServerStreams: true}
+	stream, err := c.cc.NewStream(ctx, desc, "/"+serviceName+"/"+method, append(opts, codec.CallOption())...)
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
+
+// ListAuditRecordsClient is the client stream of ListAuditRecords
+type ListAuditRecordsClient interface {
+	Recv() (*AuditRecord, error)
+	grpc.ClientStream
+}
+
+type configAdminExtServiceListAuditRecordsClient struct {
+	grpc.ClientStream
+}
+
+func (x *configAdminExtServiceListAuditRecordsClient) Recv() (*AuditRecord, error) {
+	m := new(AuditRecord)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ListDeadLettersClient is the client stream of ListDeadLetters
+type ListDeadLettersClient interface {
+	Recv() (*DeadLetter, error)
+	grpc.ClientStream
+}
+
+type configAdminExtServiceListDeadLettersClient struct {
+	grpc.ClientStream
+}
+
+func (x *configAdminExtServiceListDeadLettersClient) Recv() (*DeadLetter, error) {
+	m := new(DeadLetter)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ConfigAdminExtServiceServer is the server API for the ConfigAdminExtService
+type ConfigAdminExtServiceServer interface {
+	// RollbackToNetworkChange rolls back every network change made after the named network change, latest first
+	RollbackToNetworkChange(context.Context, *RollbackToNetworkChangeRequest) (*RollbackToNetworkChangeResponse, error)
+
+	// ConfirmNetworkChange confirms a network change made with a confirm timeout, so that it is not rolled back
+	ConfirmNetworkChange(context.Context, *ConfirmNetworkChangeRequest) (*ConfirmNetworkChangeResponse, error)
+
+	// LockDevices locks the configuration of devices for the calling client until the TTL expires
+	LockDevices(context.Context, *LockDevicesRequest) (*LockDevicesResponse, error)
+
+	// UnlockDevices releases the locks held by the calling client on devices
+	UnlockDevices(context.Context, *UnlockDevicesRequest) (*UnlockDevicesResponse, error)
+
+	// ListAuditRecords gets a stream of the records of the audit log of the instance, oldest first
+	ListAuditRecords(*ListAuditRecordsRequest, ListAuditRecordsServer) error
+
+	// ListDeadLetters gets a stream of the requests that the controllers have given up on, oldest first
+	ListDeadLetters(*ListDeadLettersRequest, ListDeadLettersServer) error
+
+	// RequeueDeadLetters gives requests that the controllers have given up on a new set of attempts
+	RequeueDeadLetters(context.Context, *RequeueDeadLettersRequest) (*RequeueDeadLettersResponse, error)
+}
+
+// RegisterConfigAdminExtServiceServer registers the ConfigAdminExtService with the gRPC server
+func RegisterConfigAdminExtServiceServer(s *grpc.Server, srv ConfigAdminExtServiceServer) {
+	s.RegisterService(&configAdminExtServiceDesc, srv)
+}
+
+func configAdminExtServiceListAuditRecordsHandler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListAuditRecordsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ConfigAdminExtServiceServer).ListAuditRecords(m, &configAdminExtServiceListAuditRecordsServer{stream})
+}
+
+// ListAuditRecordsServer is the server stream of ListAuditRecords
+type ListAuditRecordsServer interface {
+	Send(*AuditRecord) error
+	grpc.ServerStream
+}
+
+type configAdminExtServiceListAuditRecordsServer struct {
+	grpc.ServerStream
+}
+
+func (x *configAdminExtServiceListAuditRecordsServer) Send(m *AuditRecord) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func configAdminExtServiceListDeadLettersHandler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListDeadLettersRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ConfigAdminExtServiceServer).ListDeadLetters(m, &configAdminExtServiceListDeadLettersServer{stream})
+}
+
+// ListDeadLettersServer is the server stream of ListDeadLetters
+type ListDeadLettersServer interface {
+	Send(*DeadLetter) error
+	grpc.ServerStream
+}
+
+type configAdminExtServiceListDeadLettersServer struct {
+	grpc.ServerStream
+}
+
+func (x *configAdminExtServiceListDeadLettersServer) Send(m *DeadLetter) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+// unaryHandler returns the handler of a unary method, which decodes the request into a new value of the request
+// type and calls the method of the server
+func unaryHandler(method string, newRequest func() interface{},
+	call func(srv interface{}, ctx context.Context, req interface{}) (interface{}, error)) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: method,
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			in := newRequest()
+			if err := dec(in); err != nil {
+				return nil, err
+			}
+			if interceptor == nil {
+				return call(srv, ctx, in)
+			}
+			info := &grpc.UnaryServerInfo{
+				Server:     srv,
+				FullMethod: "/" + serviceName + "/" + method,
+			}
+			handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+				return call(srv, ctx, req)
+			}
+			return interceptor(ctx, in, info, handler)
+		},
+	}
+}
+
+const serviceName = "onos.config.admin.ConfigAdminExtService"
+
+var configAdminExtServiceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*ConfigAdminExtServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		unaryHandler("RollbackToNetworkChange", func() interface{} { return new(RollbackToNetworkChangeRequest) },
+			func(srv interface{}, ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.(ConfigAdminExtServiceServer).RollbackToNetworkChange(ctx, req.(*RollbackToNetworkChangeRequest))
+			}),
+		unaryHandler("ConfirmNetworkChange", func() interface{} { return new(ConfirmNetworkChangeRequest) },
+			func(srv interface{}, ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.(ConfigAdminExtServiceServer).ConfirmNetworkChange(ctx, req.(*ConfirmNetworkChangeRequest))
+			}),
+		unaryHandler("LockDevices", func() interface{} { return new(LockDevicesRequest) },
+			func(srv interface{}, ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.(ConfigAdminExtServiceServer).LockDevices(ctx, req.(*LockDevicesRequest))
+			}),
+		unaryHandler("UnlockDevices", func() interface{} { return new(UnlockDevicesRequest) },
+			func(srv interface{}, ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.(ConfigAdminExtServiceServer).UnlockDevices(ctx, req.(*UnlockDevicesRequest))
+			}),
+		unaryHandler("RequeueDeadLetters", func() interface{} { return new(RequeueDeadLettersRequest) },
+			func(srv interface{}, ctx context.Context, req interface{}) (interface{}, error) {
+				return srv.(ConfigAdminExtServiceServer).RequeueDeadLetters(ctx, req.(*RequeueDeadLettersRequest))
+			}),
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListAuditRecords",
+			Handler:       configAdminExtServiceListAuditRecordsHandler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ListDeadLetters",
+			Handler:       configAdminExtServiceListDeadLettersHandler,
+			ServerStreams: true,
+		},
+	},
+}
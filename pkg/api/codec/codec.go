@@ -0,0 +1,51 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package codec implements the gRPC codec of the onos-config services whose messages are not defined in onos-api.
+// Their messages are plain Go structs, which are encoded as JSON.
+package codec
+
+import (
+	"encoding/json"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/encoding"
+)
+
+// Name is the name of the codec, used as the content subtype of the requests
+const Name = "json"
+
+func init() {
+	encoding.RegisterCodec(jsonCodec{})
+}
+
+// CallOption returns the option that makes a call use the JSON codec
+func CallOption() grpc.CallOption {
+	return grpc.CallContentSubtype(Name)
+}
+
+// jsonCodec is a gRPC codec that encodes messages as JSON
+type jsonCodec struct{}
+
+func (jsonCodec) Marshal(v interface{}) ([]byte, error) {
+	return json.Marshal(v)
+}
+
+func (jsonCodec) Unmarshal(data []byte, v interface{}) error {
+	return json.Unmarshal(data, v)
+}
+
+func (jsonCodec) Name() string {
+	return Name
+}
@@ -0,0 +1,29 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package diags
+
+import (
+	"google.golang.org/grpc"
+)
+
+// ChangeExtServiceClientFactory : Default ChangeExtServiceClient creation.
+var ChangeExtServiceClientFactory = func(cc *grpc.ClientConn) ChangeExtServiceClient {
+	return NewChangeExtServiceClient(cc)
+}
+
+// CreateChangeExtServiceClient creates and returns a new change ext service client
+func CreateChangeExtServiceClient(cc *grpc.ClientConn) ChangeExtServiceClient {
+	return ChangeExtServiceClientFactory(cc)
+}
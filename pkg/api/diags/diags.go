@@ -0,0 +1,421 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package diags defines the ChangeExtService, the diagnostic gRPC service of onos-config for the information
+// that the onos-api ChangeService does not provide. Its messages are encoded with the JSON codec.
+package diags
+
+import (
+	"context"
+	"time"
+
+	changetypes "github.com/onosproject/onos-api/go/onos/config/change"
+	devicechange "github.com/onosproject/onos-api/go/onos/config/change/device"
+	networkchange "github.com/onosproject/onos-api/go/onos/config/change/network"
+	devicetype "github.com/onosproject/onos-api/go/onos/config/device"
+	"github.com/onosproject/onos-api/go/onos/config/diags"
+	"github.com/onosproject/onos-config/pkg/api/codec"
+	"google.golang.org/grpc"
+)
+
+// ListConfigDriftRequest requests the config drift of the devices
+type ListConfigDriftRequest struct {
+	// DeviceID is the device to list the drift of, which may contain a wildcard. All devices if empty
+	DeviceID devicetype.ID `json:"device_id,omitempty"`
+}
+
+// ListConfigDriftResponse is a path where the running config of a device differs from its intended config
+type ListConfigDriftResponse struct {
+	DeviceID devicetype.ID `json:"device_id,omitempty"`
+	Path     string        `json:"path,omitempty"`
+	// IntendedValue is the value held in onos-config
+	IntendedValue *devicechange.TypedValue `json:"intended_value,omitempty"`
+	// ActualValue is the value on the device, nil if the path is missing from the device
+	ActualValue *devicechange.TypedValue `json:"actual_value,omitempty"`
+	// ChangeID is the network change raised to set the intended value back on the device, if any
+	ChangeID networkchange.ID `json:"change_id,omitempty"`
+}
+
+// ListConfigDiffRequest requests the differences in the config of the devices between two network changes
+type ListConfigDiffRequest struct {
+	// FromChangeID is the network change just after which the config is compared from
+	FromChangeID networkchange.ID `json:"from_change_id"`
+	// ToChangeID is the network change just after which the config is compared to
+	ToChangeID networkchange.ID `json:"to_change_id"`
+	// DeviceID is the device to compare, which may contain a wildcard. All the changed devices if empty
+	DeviceID devicetype.ID `json:"device_id,omitempty"`
+}
+
+// DiffType is the kind of a difference in the config of a device
+type DiffType string
+
+const (
+	// DiffAdded is a path that is only set in the later config
+	DiffAdded DiffType = "ADDED"
+	// DiffRemoved is a path that is only set in the earlier config
+	DiffRemoved DiffType = "REMOVED"
+	// DiffUpdated is a path whose value differs between the two configs
+	DiffUpdated DiffType = "UPDATED"
+)
+
+// ListConfigDiffResponse is a path where the config of a device differs between two network changes
+type ListConfigDiffResponse struct {
+	DeviceID      devicetype.ID      `json:"device_id,omitempty"`
+	DeviceVersion devicetype.Version `json:"device_version,omitempty"`
+	Path          string             `json:"path,omitempty"`
+	Type          DiffType           `json:"type,omitempty"`
+	// OldValue is the value just after the first network change, nil if the path has been added
+	OldValue *devicechange.TypedValue `json:"old_value,omitempty"`
+	// NewValue is the value just after the second network change, nil if the path has been removed
+	NewValue *devicechange.TypedValue `json:"new_value,omitempty"`
+}
+
+// ListNetworkChangesWithMetadataRequest requests the network changes along with their author, description and
+// labels, optionally filtered on them
+type ListNetworkChangesWithMetadataRequest struct {
+	// ChangeID is the network change to list, which may contain a wildcard. All network changes if empty
+	ChangeID networkchange.ID `json:"change_id,omitempty"`
+	// Subscribe keeps the stream open to send the later updates of the network changes
+	Subscribe bool `json:"subscribe,omitempty"`
+	// WithoutReplay only sends the later updates of the network changes when subscribing
+	WithoutReplay bool `json:"without_replay,omitempty"`
+	// Author only lists the network changes made by this client
+	Author string `json:"author,omitempty"`
+	// Description only lists the network changes whose description contains this text, ignoring case
+	Description string `json:"description,omitempty"`
+	// Labels only lists the network changes that have all of these labels
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// ListNetworkChangesWithMetadataResponse is a network change along with its author, description, labels, apply
+// policy, final status on each device and devices that did not answer in time
+type ListNetworkChangesWithMetadataResponse struct {
+	Change      *networkchange.NetworkChange `json:"change,omitempty"`
+	Type        diags.Type                   `json:"type,omitempty"`
+	Author      string                       `json:"author,omitempty"`
+	Description string                       `json:"description,omitempty"`
+	Labels      map[string]string            `json:"labels,omitempty"`
+	// ApplyPolicy is how the change is applied when some of its devices are not connected, atomic if empty
+	ApplyPolicy string `json:"apply_policy,omitempty"`
+	// Quorum is the percentage of the devices that must be connected for a change with the quorum policy
+	Quorum uint32 `json:"quorum,omitempty"`
+	// Devices is the final status of the change on each of its devices, once the change is complete
+	Devices map[devicetype.ID]*changetypes.Status `json:"devices,omitempty"`
+	// ApplyTimeouts are the devices that did not answer the set request of the change, or of its rollback, within
+	// their apply timeout, along with that timeout. The device change then failed with an ERROR reason.
+	ApplyTimeouts map[devicetype.ID]time.Duration `json:"apply_timeouts,omitempty"`
+}
+
+// ListPendingChangesRequest requests the network changes that have been PENDING for longer than a threshold
+type ListPendingChangesRequest struct {
+	// Threshold is how long the changes have been PENDING for at least. The server default if nil
+	Threshold *time.Duration `json:"threshold,omitempty"`
+}
+
+// ListPendingChangesResponse is a network change that has been PENDING for longer than the threshold
+type ListPendingChangesResponse struct {
+	NetworkChange *networkchange.NetworkChange `json:"network_change,omitempty"`
+	// DeviceChanges are the device changes of the network change that are still PENDING
+	DeviceChanges []*devicechange.DeviceChange `json:"device_changes,omitempty"`
+	// PendingSince is the time at which the network change became PENDING
+	PendingSince time.Time `json:"pending_since"`
+}
+
+// ChangeExtServiceClient is the client API for the ChangeExtService
+type ChangeExtServiceClient interface {
+	// ListConfigDrift gets a stream of the paths where the running config of a device was found to differ
+	// from its intended config
+	ListConfigDrift(ctx context.Context, in *ListConfigDriftRequest, opts ...grpc.CallOption) (ListConfigDriftClient, error)
+
+	// ListConfigDiff gets a stream of the paths where the config of the devices differs between two network changes
+	ListConfigDiff(ctx context.Context, in *ListConfigDiffRequest, opts ...grpc.CallOption) (ListConfigDiffClient, error)
+
+	// ListNetworkChangesWithMetadata gets a stream of the network changes along with their author, description
+	// and labels
+	ListNetworkChangesWithMetadata(ctx context.Context, in *ListNetworkChangesWithMetadataRequest, opts ...grpc.CallOption) (ListNetworkChangesWithMetadataClient, error)
+
+	// ListPendingChanges gets a stream of the network changes that have been PENDING for longer than a threshold,
+	// along with their device changes that are still PENDING
+	ListPendingChanges(ctx context.Context, in *ListPendingChangesRequest, opts ...grpc.CallOption) (ListPendingChangesClient, error)
+}
+
+type changeExtServiceClient struct {
+	cc *grpc.ClientConn
+}
+
+// NewChangeExtServiceClient returns a new ChangeExtService client
+func NewChangeExtServiceClient(cc *grpc.ClientConn) ChangeExtServiceClient {
+	return &changeExtServiceClient{cc}
+}
+
+func (c *changeExtServiceClient) ListConfigDrift(ctx context.Context, in *ListConfigDriftRequest, opts ...grpc.CallOption) (ListConfigDriftClient, error) {
+	stream, err := c.newStream(ctx, "ListConfigDrift", in, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &changeExtServiceListConfigDriftClient{stream}, nil
+}
+
+func (c *changeExtServiceClient) ListConfigDiff(ctx context.Context, in *ListConfigDiffRequest, opts ...grpc.CallOption) (ListConfigDiffClient, error) {
+	stream, err := c.newStream(ctx, "ListConfigDiff", in, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &changeExtServiceListConfigDiffClient{stream}, nil
+}
+
+func (c *changeExtServiceClient) ListNetworkChangesWithMetadata(ctx context.Context, in *ListNetworkChangesWithMetadataRequest, opts ...grpc.CallOption) (ListNetworkChangesWithMetadataClient, error) {
+	stream, err := c.newStream(ctx, "ListNetworkChangesWithMetadata", in, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &changeExtServiceListNetworkChangesWithMetadataClient{stream}, nil
+}
+
+func (c *changeExtServiceClient) ListPendingChanges(ctx context.Context, in *ListPendingChangesRequest, opts ...grpc.CallOption) (ListPendingChangesClient, error) {
+	stream, err := c.newStream(ctx, "ListPendingChanges", in, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return &changeExtServiceListPendingChangesClient{stream}, nil
+}
+
+// newStream opens a server stream to the given method and sends the request on it
+func (c *changeExtServiceClient) newStream(ctx context.Context, method string, in interface{}, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+	desc := &grpc.StreamDesc{StreamName: method, ServerStreams: true}
+	stream, err := c.cc.NewStream(ctx, desc, "/"+serviceName+"/"+method, append(opts, codec.CallOption())...)
+	if err != nil {
+		return nil, err
+	}
+	if err := stream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := stream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return stream, nil
+}
+
+// ListConfigDriftClient is the client stream of ListConfigDrift
+type ListConfigDriftClient interface {
+	Recv() (*ListConfigDriftResponse, error)
+	grpc.ClientStream
+}
+
+type changeExtServiceListConfigDriftClient struct {
+	grpc.ClientStream
+}
+
+func (x *changeExtServiceListConfigDriftClient) Recv() (*ListConfigDriftResponse, error) {
+	m := new(ListConfigDriftResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ListConfigDiffClient is the client stream of ListConfigDiff
+type ListConfigDiffClient interface {
+	Recv() (*ListConfigDiffResponse, error)
+	grpc.ClientStream
+}
+
+type changeExtServiceListConfigDiffClient struct {
+	grpc.ClientStream
+}
+
+func (x *changeExtServiceListConfigDiffClient) Recv() (*ListConfigDiffResponse, error) {
+	m := new(ListConfigDiffResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ListNetworkChangesWithMetadataClient is the client stream of ListNetworkChangesWithMetadata
+type ListNetworkChangesWithMetadataClient interface {
+	Recv() (*ListNetworkChangesWithMetadataResponse, error)
+	grpc.ClientStream
+}
+
+type changeExtServiceListNetworkChangesWithMetadataClient struct {
+	grpc.ClientStream
+}
+
+func (x *changeExtServiceListNetworkChangesWithMetadataClient) Recv() (*ListNetworkChangesWithMetadataResponse, error) {
+	m := new(ListNetworkChangesWithMetadataResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ListPendingChangesClient is the client stream of ListPendingChanges
+type ListPendingChangesClient interface {
+	Recv() (*ListPendingChangesResponse, error)
+	grpc.ClientStream
+}
+
+type changeExtServiceListPendingChangesClient struct {
+	grpc.ClientStream
+}
+
+func (x *changeExtServiceListPendingChangesClient) Recv() (*ListPendingChangesResponse, error) {
+	m := new(ListPendingChangesResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// ChangeExtServiceServer is the server API for the ChangeExtService
+type ChangeExtServiceServer interface {
+	// ListConfigDrift gets a stream of the paths where the running config of a device was found to differ
+	// from its intended config
+	ListConfigDrift(*ListConfigDriftRequest, ListConfigDriftServer) error
+
+	// ListConfigDiff gets a stream of the paths where the config of the devices differs between two network changes
+	ListConfigDiff(*ListConfigDiffRequest, ListConfigDiffServer) error
+
+	// ListNetworkChangesWithMetadata gets a stream of the network changes along with their author, description
+	// and labels
+	ListNetworkChangesWithMetadata(*ListNetworkChangesWithMetadataRequest, ListNetworkChangesWithMetadataServer) error
+
+	// ListPendingChanges gets a stream of the network changes that have been PENDING for longer than a threshold,
+	// along with their device changes that are still PENDING
+	ListPendingChanges(*ListPendingChangesRequest, ListPendingChangesServer) error
+}
+
+// RegisterChangeExtServiceServer registers the ChangeExtService with the gRPC server
+func RegisterChangeExtServiceServer(s *grpc.Server, srv ChangeExtServiceServer) {
+	s.RegisterService(&changeExtServiceDesc, srv)
+}
+
+func changeExtServiceListConfigDriftHandler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListConfigDriftRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ChangeExtServiceServer).ListConfigDrift(m, &changeExtServiceListConfigDriftServer{stream})
+}
+
+// ListConfigDriftServer is the server stream of ListConfigDrift
+type ListConfigDriftServer interface {
+	Send(*ListConfigDriftResponse) error
+	grpc.ServerStream
+}
+
+type changeExtServiceListConfigDriftServer struct {
+	grpc.ServerStream
+}
+
+func (x *changeExtServiceListConfigDriftServer) Send(m *ListConfigDriftResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func changeExtServiceListConfigDiffHandler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListConfigDiffRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ChangeExtServiceServer).ListConfigDiff(m, &changeExtServiceListConfigDiffServer{stream})
+}
+
+// ListConfigDiffServer is the server stream of ListConfigDiff
+type ListConfigDiffServer interface {
+	Send(*ListConfigDiffResponse) error
+	grpc.ServerStream
+}
+
+type changeExtServiceListConfigDiffServer struct {
+	grpc.ServerStream
+}
+
+func (x *changeExtServiceListConfigDiffServer) Send(m *ListConfigDiffResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func changeExtServiceListNetworkChangesWithMetadataHandler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListNetworkChangesWithMetadataRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ChangeExtServiceServer).ListNetworkChangesWithMetadata(m, &changeExtServiceListNetworkChangesWithMetadataServer{stream})
+}
+
+// ListNetworkChangesWithMetadataServer is the server stream of ListNetworkChangesWithMetadata
+type ListNetworkChangesWithMetadataServer interface {
+	Send(*ListNetworkChangesWithMetadataResponse) error
+	grpc.ServerStream
+}
+
+type changeExtServiceListNetworkChangesWithMetadataServer struct {
+	grpc.ServerStream
+}
+
+func (x *changeExtServiceListNetworkChangesWithMetadataServer) Send(m *ListNetworkChangesWithMetadataResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func changeExtServiceListPendingChangesHandler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(ListPendingChangesRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ChangeExtServiceServer).ListPendingChanges(m, &changeExtServiceListPendingChangesServer{stream})
+}
+
+// ListPendingChangesServer is the server stream of ListPendingChanges
+type ListPendingChangesServer interface {
+	Send(*ListPendingChangesResponse) error
+	grpc.ServerStream
+}
+
+type changeExtServiceListPendingChangesServer struct {
+	grpc.ServerStream
+}
+
+func (x *changeExtServiceListPendingChangesServer) Send(m *ListPendingChangesResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+const serviceName = "onos.config.diags.ChangeExtService"
+
+var changeExtServiceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*ChangeExtServiceServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ListConfigDrift",
+			Handler:       changeExtServiceListConfigDriftHandler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ListConfigDiff",
+			Handler:       changeExtServiceListConfigDiffHandler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ListNetworkChangesWithMetadata",
+			Handler:       changeExtServiceListNetworkChangesWithMetadataHandler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "ListPendingChanges",
+			Handler:       changeExtServiceListPendingChangesHandler,
+			ServerStreams: true,
+		},
+	},
+}
@@ -0,0 +1,134 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package audit implements a tamper-evident log of the operations made on the northbound, in which each record
+// is sealed with an HMAC and holds the HMAC of the record before it. The key of the HMAC is kept outside of
+// the log, so that whoever can write the log can not forge records that pass the checks.
+//
+// The log is kept by each instance of onos-config for the operations made on its own northbound; the logs
+// of all the instances make up the record of the operations on the cluster.
+package audit
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/onosproject/onos-config/pkg/utils"
+	"github.com/onosproject/onos-lib-go/pkg/logging"
+)
+
+var log = logging.GetLogger("audit")
+
+// Operation is an audited operation
+type Operation string
+
+const (
+	// OperationSet is a gNMI Set
+	OperationSet Operation = "set"
+	// OperationRollback is the rollback of a network change, or of the network changes made after it
+	OperationRollback Operation = "rollback"
+	// OperationConfirm is the confirmation of a network change made with a confirm timeout
+	OperationConfirm Operation = "confirm"
+	// OperationCompact is the compaction of the network changes into snapshots
+	OperationCompact Operation = "compact"
+	// OperationUploadModel is the upload and registration of a model plugin
+	OperationUploadModel Operation = "upload-model"
+	// OperationLock is the locking of devices
+	OperationLock Operation = "lock"
+	// OperationUnlock is the unlocking of devices
+	OperationUnlock Operation = "unlock"
+	// OperationApply is the completion or failure of a network change on the devices
+	OperationApply Operation = "apply"
+)
+
+// Outcome is the outcome of an audited operation
+type Outcome string
+
+const (
+	// OutcomeSuccess is an operation that succeeded
+	OutcomeSuccess Outcome = "SUCCESS"
+	// OutcomeFailure is an operation that failed
+	OutcomeFailure Outcome = "FAILURE"
+)
+
+// Record is a record of the audit log
+type Record struct {
+	// Index is the position of the record in the log, starting from 1
+	Index uint64 `json:"index"`
+	// Timestamp is the time at which the record was made
+	Timestamp time.Time `json:"timestamp"`
+	// Operation is the operation that is recorded
+	Operation Operation `json:"operation"`
+	// Client is the identity of the client that made the operation
+	Client string `json:"client"`
+	// Paths are the paths of the request as device:path, or the devices for the operations on whole devices
+	Paths []string `json:"paths,omitempty"`
+	// NetworkChangeID is the network change resulting from or targeted by the operation
+	NetworkChangeID string `json:"networkChangeId,omitempty"`
+	// Details describes the operation where the other fields do not
+	Details string `json:"details,omitempty"`
+	// Outcome is the outcome of the operation
+	Outcome Outcome `json:"outcome"`
+	// Error is the error of an operation that failed
+	Error string `json:"error,omitempty"`
+	// PrevHash is the hash of the previous record, empty for the first record
+	PrevHash string `json:"prevHash"`
+	// Hash is the HMAC of this record with the key of the log, including the hash of the previous record
+	Hash string `json:"hash"`
+}
+
+// NewRecord returns a new record of an operation made by the client of a request, which failed if err is set
+func NewRecord(ctx context.Context, op Operation, err error) *Record {
+	record := &Record{
+		Operation: op,
+		Client:    utils.GetClientIdentity(ctx),
+		Outcome:   OutcomeSuccess,
+	}
+	if err != nil {
+		record.Outcome = OutcomeFailure
+		record.Error = err.Error()
+	}
+	return record
+}
+
+// computeHash computes the HMAC of the record with the key, which covers all of its fields other than the
+// hash itself
+func (r *Record) computeHash(key []byte) (string, error) {
+	unhashed := *r
+	unhashed.Hash = ""
+	bytes, err := json.Marshal(&unhashed)
+	if err != nil {
+		return "", err
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(bytes)
+	return hex.EncodeToString(mac.Sum(nil)), nil
+}
+
+// Log is an append-only log of audit records
+type Log interface {
+	io.Closer
+
+	// Append sets the index, timestamp and hashes of a record and appends it to the log
+	Append(record *Record) error
+
+	// Scan calls fn for each record of the log made at or after since, oldest first, after checking that it
+	// has not been tampered with. An Invalid error is returned at the first record that fails the check.
+	Scan(since time.Time, fn func(*Record) error) error
+}
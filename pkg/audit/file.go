@@ -0,0 +1,229 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"bufio"
+	"crypto/hmac"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+)
+
+// maxRecordSize is the size of the longest record that can be read back from a file
+const maxRecordSize = 1024 * 1024
+
+// NewFileLog opens the audit log in a file, one JSON record per line, creating the file if it does not exist.
+// The records are sealed with the key, which must be kept apart from the log. A log that does not pass the
+// checks is refused, unless acceptTampered is set: it is then moved aside for investigation and a new log
+// is started in its place.
+func NewFileLog(path string, key []byte, acceptTampered bool) (Log, error) {
+	if len(key) == 0 {
+		return nil, errors.NewInvalid("no key given for the audit log %s", path)
+	}
+	l := &fileLog{
+		path: path,
+		key:  key,
+	}
+
+	err := l.load()
+	if err != nil && !os.IsNotExist(err) {
+		if !errors.IsInvalid(err) {
+			return nil, err
+		}
+		if !acceptTampered {
+			return nil, errors.NewInvalid("audit log %s has been tampered with: %s", path, err.Error())
+		}
+		tampered := fmt.Sprintf("%s.tampered-%d", path, time.Now().Unix())
+		log.Errorf("Audit log %s has been tampered with: %s. It is moved to %s", path, err, tampered)
+		if err := os.Rename(path, tampered); err != nil {
+			return nil, err
+		}
+		l.positions = nil
+		l.lastHash = ""
+	}
+
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return nil, err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, err
+	}
+	l.file = file
+	l.size = info.Size()
+	log.Infof("Opened audit log %s at record %d", path, len(l.positions))
+	return l, nil
+}
+
+// recordPosition is where a record starts in the file, and when it was made
+type recordPosition struct {
+	offset    int64
+	timestamp time.Time
+}
+
+// fileLog is an audit log in a local file. The position of each record is kept in memory, so that the
+// records made since a given time are read without going through the file from the start.
+type fileLog struct {
+	path      string
+	key       []byte
+	mu        sync.RWMutex
+	file      *os.File
+	size      int64
+	positions []recordPosition
+	lastHash  string
+}
+
+// load checks the whole file and finds the position of each record in it
+func (l *fileLog) load() error {
+	file, err := os.Open(l.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return l.read(file, 0, 1, func(record *Record, offset int64) error {
+		l.positions = append(l.positions, recordPosition{offset: offset, timestamp: record.Timestamp})
+		l.lastHash = record.Hash
+		return nil
+	})
+}
+
+func (l *fileLog) Append(record *Record) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	record.Index = uint64(len(l.positions)) + 1
+	record.Timestamp = time.Now().UTC()
+	// The timestamps never go back, so that the records can be looked up by time
+	if len(l.positions) > 0 && record.Timestamp.Before(l.positions[len(l.positions)-1].timestamp) {
+		record.Timestamp = l.positions[len(l.positions)-1].timestamp
+	}
+	record.PrevHash = l.lastHash
+	hash, err := record.computeHash(l.key)
+	if err != nil {
+		return errors.NewInvalid("record encoding failed: %v", err)
+	}
+	record.Hash = hash
+	bytes, err := json.Marshal(record)
+	if err != nil {
+		return errors.NewInvalid("record encoding failed: %v", err)
+	}
+	bytes = append(bytes, '\n')
+
+	if _, err := l.file.Write(bytes); err != nil {
+		return err
+	}
+	if err := l.file.Sync(); err != nil {
+		return err
+	}
+	l.positions = append(l.positions, recordPosition{offset: l.size, timestamp: record.Timestamp})
+	l.size += int64(len(bytes))
+	l.lastHash = record.Hash
+	return nil
+}
+
+func (l *fileLog) Scan(since time.Time, fn func(*Record) error) error {
+	// Only the records that were fully written when the scan started are read
+	l.mu.RLock()
+	positions, size := l.positions, l.size
+	l.mu.RUnlock()
+
+	start := sort.Search(len(positions), func(i int) bool {
+		return !positions[i].timestamp.Before(since)
+	})
+	if start == len(positions) {
+		return nil
+	}
+	// The record before the first one is read too, to check that the first one is chained to it
+	from := start
+	if from > 0 {
+		from--
+	}
+
+	file, err := os.Open(l.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	offset := positions[from].offset
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		return err
+	}
+
+	return l.read(io.LimitReader(file, size-offset), offset, uint64(from)+1, func(record *Record, _ int64) error {
+		if record.Index <= uint64(start) {
+			return nil
+		}
+		return fn(record)
+	})
+}
+
+// read reads the records from the reader, which starts at the given offset of the file with the record of
+// the given index, and calls fn for each record once it is checked
+func (l *fileLog) read(reader io.Reader, offset int64, index uint64, fn func(*Record, int64) error) error {
+	scanner := bufio.NewScanner(reader)
+	scanner.Buffer(make([]byte, 64*1024), maxRecordSize)
+
+	var prev *Record
+	for ; scanner.Scan(); index++ {
+		record := &Record{}
+		if err := json.Unmarshal(scanner.Bytes(), record); err != nil {
+			return errors.NewInvalid("record %d can not be decoded: %v", index, err)
+		}
+		if err := l.verify(index, prev, record); err != nil {
+			return err
+		}
+		if err := fn(record, offset); err != nil {
+			return err
+		}
+		offset += int64(len(scanner.Bytes())) + 1
+		prev = record
+	}
+	return scanner.Err()
+}
+
+// verify checks that a record is at its index, follows on from the previous one if it was read, and has not
+// been modified
+func (l *fileLog) verify(index uint64, prev *Record, record *Record) error {
+	if record.Index != index {
+		return errors.NewInvalid("record %d is missing, found record %d", index, record.Index)
+	}
+	if (prev != nil && record.PrevHash != prev.Hash) || (index == 1 && record.PrevHash != "") {
+		return errors.NewInvalid("record %d is not chained to record %d", record.Index, record.Index-1)
+	}
+	hash, err := record.computeHash(l.key)
+	if err != nil {
+		return errors.NewInvalid("record %d can not be encoded: %v", record.Index, err)
+	}
+	if !hmac.Equal([]byte(hash), []byte(record.Hash)) {
+		return errors.NewInvalid("record %d has been modified", record.Index)
+	}
+	return nil
+}
+
+func (l *fileLog) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.file.Close()
+}
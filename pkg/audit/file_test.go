@@ -0,0 +1,139 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package audit
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"gotest.tools/assert"
+)
+
+var testKey = []byte("audit-key")
+
+func scanAll(l Log) ([]*Record, error) {
+	return scanSince(l, time.Time{})
+}
+
+func scanSince(l Log, since time.Time) ([]*Record, error) {
+	records := make([]*Record, 0)
+	err := l.Scan(since, func(record *Record) error {
+		records = append(records, record)
+		return nil
+	})
+	return records, err
+}
+
+func Test_FileLog(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+
+	l, err := NewFileLog(path, testKey, false)
+	assert.NilError(t, err)
+	set := NewRecord(context.Background(), OperationSet, nil)
+	set.Paths = []string{"device-1:/a/b"}
+	set.NetworkChangeID = "change-1"
+	assert.NilError(t, l.Append(set))
+	assert.NilError(t, l.Append(NewRecord(context.Background(), OperationRollback, fmt.Errorf("not found"))))
+	assert.NilError(t, l.Close())
+
+	// The chain carries on when the log is opened again
+	l, err = NewFileLog(path, testKey, false)
+	assert.NilError(t, err)
+	defer l.Close()
+	assert.NilError(t, l.Append(NewRecord(context.Background(), OperationCompact, nil)))
+
+	records, err := scanAll(l)
+	assert.NilError(t, err)
+	assert.Equal(t, len(records), 3)
+	assert.Equal(t, records[0].Index, uint64(1))
+	assert.Equal(t, records[0].PrevHash, "")
+	assert.Equal(t, records[0].Operation, OperationSet)
+	assert.Equal(t, records[0].NetworkChangeID, "change-1")
+	assert.DeepEqual(t, records[0].Paths, []string{"device-1:/a/b"})
+	assert.Equal(t, records[0].Outcome, OutcomeSuccess)
+	assert.Equal(t, records[1].Outcome, OutcomeFailure)
+	assert.Equal(t, records[1].Error, "not found")
+	assert.Equal(t, records[1].PrevHash, records[0].Hash)
+	assert.Equal(t, records[2].Index, uint64(3))
+	assert.Equal(t, records[2].PrevHash, records[1].Hash)
+
+	// The records made since a time are found without reading the log from the start
+	records, err = scanSince(l, records[2].Timestamp)
+	assert.NilError(t, err)
+	assert.Equal(t, len(records), 1)
+	assert.Equal(t, records[0].Index, uint64(3))
+	records, err = scanSince(l, time.Now().Add(time.Minute))
+	assert.NilError(t, err)
+	assert.Equal(t, len(records), 0)
+
+	// The log can not be opened without its key, nor with another one
+	_, err = NewFileLog(path, nil, false)
+	assert.Assert(t, errors.IsInvalid(err))
+	_, err = NewFileLog(path, []byte("another-key"), false)
+	assert.ErrorContains(t, err, "record 1 has been modified")
+}
+
+func Test_FileLogTampered(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "audit.log")
+
+	l, err := NewFileLog(path, testKey, false)
+	assert.NilError(t, err)
+	for i := 0; i < 3; i++ {
+		record := NewRecord(context.Background(), OperationSet, nil)
+		record.NetworkChangeID = fmt.Sprintf("change-%d", i+1)
+		assert.NilError(t, l.Append(record))
+	}
+	assert.NilError(t, l.Close())
+
+	data, err := ioutil.ReadFile(path)
+	assert.NilError(t, err)
+	lines := strings.SplitAfter(string(data), "\n")
+
+	tampered := map[string]string{
+		"record 2 has been modified": lines[0] + strings.Replace(lines[1], "change-2", "change-9", 1) + lines[2],
+		"record 2 is missing":        lines[0] + lines[2],
+		"record 1 is missing":        lines[1] + lines[2],
+	}
+	for expected, content := range tampered {
+		assert.NilError(t, ioutil.WriteFile(path, []byte(content), 0600))
+		_, err := NewFileLog(path, testKey, false)
+		assert.Assert(t, errors.IsInvalid(err), expected)
+		assert.ErrorContains(t, err, expected)
+	}
+
+	// When the operator accepts it, the tampered log is moved aside and a new log is started
+	l, err = NewFileLog(path, testKey, true)
+	assert.NilError(t, err)
+	defer l.Close()
+	records, err := scanAll(l)
+	assert.NilError(t, err)
+	assert.Equal(t, len(records), 0)
+	moved, err := filepath.Glob(path + ".tampered-*")
+	assert.NilError(t, err)
+	assert.Equal(t, len(moved), 1)
+}
@@ -0,0 +1,107 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"io"
+	"strings"
+	"text/template"
+	"time"
+
+	adminapi "github.com/onosproject/onos-config/pkg/api/admin"
+	"github.com/onosproject/onos-lib-go/pkg/cli"
+	"github.com/spf13/cobra"
+)
+
+const auditRecordsHeader = "INDEX  TIMESTAMP                  OPERATION     CLIENT           CHANGE                                OUTCOME\n"
+
+const auditRecordsFormat = "{{printf \"%-6d %-26s %-13s %-16s %-37s %s\" .Index (.Timestamp.Format \"2006-01-02T15:04:05.000Z07:00\") .Operation .Client .NetworkChangeID .Outcome}}" +
+	"{{if .Error}} {{.Error}}{{end}}\n"
+
+const auditRecordsFormatVerbose = auditRecordsFormat +
+	"{{if .Paths}}\tPaths: {{join .Paths \", \"}}\n{{end}}" +
+	"{{if .Details}}\tDetails: {{.Details}}\n{{end}}" +
+	"\tHash: {{.Hash}}\n"
+
+func getListAuditRecordsCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "audit-records",
+		Short: "List the records of the audit log",
+		Args:  cobra.NoArgs,
+		RunE:  runListAuditRecordsCommand,
+	}
+	cmd.Flags().String("client", "", "only the operations made by this client")
+	cmd.Flags().String("operation", "", "only this operation, e.g. set, rollback, compact, upload-model, lock")
+	cmd.Flags().String("change", "", "only the operations on this network change")
+	cmd.Flags().Duration("since", 0, "only the operations made within this duration, e.g. 24h")
+	cmd.Flags().BoolP("verbose", "v", false, "whether to print the records with verbose output")
+	cmd.Flags().Bool("no-headers", false, "disables output headers")
+	return cmd
+}
+
+func runListAuditRecordsCommand(cmd *cobra.Command, args []string) error {
+	client, _ := cmd.Flags().GetString("client")
+	operation, _ := cmd.Flags().GetString("operation")
+	changeID, _ := cmd.Flags().GetString("change")
+	since, _ := cmd.Flags().GetDuration("since")
+	verbose, _ := cmd.Flags().GetBool("verbose")
+	noHeaders, _ := cmd.Flags().GetBool("no-headers")
+
+	clientConnection, clientConnectionError := cli.GetConnection(cmd)
+
+	if clientConnectionError != nil {
+		return clientConnectionError
+	}
+	adminClient := adminapi.CreateConfigAdminExtServiceClient(clientConnection)
+	request := adminapi.ListAuditRecordsRequest{
+		Client:          client,
+		Operation:       operation,
+		NetworkChangeID: changeID,
+	}
+	if since > 0 {
+		sinceTime := time.Now().Add(-since)
+		request.Since = &sinceTime
+	}
+
+	format := auditRecordsFormat
+	if verbose {
+		format = auditRecordsFormatVerbose
+	}
+	tmplAuditRecords, _ := template.New("auditRecords").Funcs(template.FuncMap{"join": strings.Join}).Parse(format)
+
+	stream, err := adminClient.ListAuditRecords(context.Background(), &request)
+	if err != nil {
+		return err
+	}
+
+	if !noHeaders {
+		cli.GetOutput().Write([]byte(auditRecordsHeader))
+	}
+	for {
+		in, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		err = tmplAuditRecords.Execute(cli.GetOutput(), in)
+		if err != nil {
+			cli.Output("ERROR on template: %s", format)
+			return err
+		}
+	}
+}
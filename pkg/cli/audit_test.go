@@ -0,0 +1,88 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Unit tests for the audit CLI
+package cli
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	adminapi "github.com/onosproject/onos-config/pkg/api/admin"
+	"github.com/onosproject/onos-lib-go/pkg/cli"
+	"gotest.tools/assert"
+)
+
+func Test_ListAuditRecords(t *testing.T) {
+	outputBuffer := bytes.NewBufferString("")
+	cli.CaptureOutput(outputBuffer)
+
+	records := []*adminapi.AuditRecord{
+		{
+			Index:           1,
+			Timestamp:       time.Date(2020, 11, 2, 10, 30, 0, 0, time.UTC),
+			Operation:       "set",
+			Client:          "alice",
+			Paths:           []string{"device-1:/aa/bb", "device-1:/aa/cc"},
+			NetworkChangeID: "change-1",
+			Outcome:         "SUCCESS",
+			Hash:            "0a1b2c",
+		},
+		{
+			Index:     2,
+			Timestamp: time.Date(2020, 11, 2, 10, 31, 0, 0, time.UTC),
+			Operation: "lock",
+			Client:    "bob",
+			Paths:     []string{"device-1"},
+			Outcome:   "FAILURE",
+			Error:     "device device-1 is locked by alice",
+			PrevHash:  "0a1b2c",
+			Hash:      "3d4e5f",
+		},
+	}
+	next := 0
+	auditClient := MockConfigAdminExtServiceListAuditRecordsClient{
+		recvFn: func() (*adminapi.AuditRecord, error) {
+			if next < len(records) {
+				next++
+				return records[next-1], nil
+			}
+			return nil, io.EOF
+		},
+	}
+
+	setUpMockClients(MockClientsConfig{
+		listAuditRecordsClient: &auditClient,
+	})
+
+	auditCmd := getListAuditRecordsCommand()
+	assert.NilError(t, auditCmd.Flags().Set("client", "alice"))
+	assert.NilError(t, auditCmd.Flags().Set("since", "1h"))
+	assert.NilError(t, auditCmd.Flags().Set("verbose", "true"))
+	err := auditCmd.RunE(auditCmd, []string{})
+	assert.NilError(t, err)
+
+	assert.Equal(t, LastCreatedClient.auditRecordsRequest.Client, "alice")
+	assert.Assert(t, LastCreatedClient.auditRecordsRequest.Since != nil)
+	output := outputBuffer.String()
+	assert.Assert(t, strings.Contains(output, "OPERATION"))
+	assert.Assert(t, strings.Contains(output, "2020-11-02T10:30:00.000Z"))
+	assert.Assert(t, strings.Contains(output, "change-1"))
+	assert.Assert(t, strings.Contains(output, "Paths: device-1:/aa/bb, device-1:/aa/cc"))
+	assert.Assert(t, strings.Contains(output, "FAILURE device device-1 is locked by alice"))
+	assert.Assert(t, strings.Contains(output, "Hash: 3d4e5f"))
+}
@@ -0,0 +1,50 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	networkchange "github.com/onosproject/onos-api/go/onos/config/change/network"
+	adminapi "github.com/onosproject/onos-config/pkg/api/admin"
+	"github.com/onosproject/onos-lib-go/pkg/cli"
+	"github.com/spf13/cobra"
+)
+
+func getConfirmCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "confirm <changeId>",
+		Short: "Confirms a network change so that it is not rolled back",
+		Args:  cobra.ExactArgs(1),
+		RunE:  runConfirmCommand,
+	}
+	return cmd
+}
+
+func runConfirmCommand(cmd *cobra.Command, args []string) error {
+	clientConnection, clientConnectionError := cli.GetConnection(cmd)
+
+	if clientConnectionError != nil {
+		return clientConnectionError
+	}
+	client := adminapi.CreateConfigAdminExtServiceClient(clientConnection)
+
+	resp, err := client.ConfirmNetworkChange(
+		context.Background(), &adminapi.ConfirmNetworkChangeRequest{Name: networkchange.ID(args[0])})
+	if err != nil {
+		return err
+	}
+	cli.Output("Confirm success %s\n", resp.Message)
+	return nil
+}
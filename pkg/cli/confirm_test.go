@@ -0,0 +1,37 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Unit tests for confirm CLI
+package cli
+
+import (
+	"bytes"
+	"github.com/onosproject/onos-lib-go/pkg/cli"
+	"gotest.tools/assert"
+	"strings"
+	"testing"
+)
+
+func Test_confirm(t *testing.T) {
+	outputBuffer := bytes.NewBufferString("")
+	cli.CaptureOutput(outputBuffer)
+
+	setUpMockClients(MockClientsConfig{})
+	confirm := getConfirmCommand()
+	err := confirm.RunE(confirm, []string{"ABCD1234"})
+	assert.NilError(t, err)
+	assert.Equal(t, LastCreatedClient.confirmID, "ABCD1234")
+	output := outputBuffer.String()
+	assert.Assert(t, strings.Contains(output, "Confirm was successful"))
+}
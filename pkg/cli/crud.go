@@ -21,7 +21,7 @@ import (
 
 func getGetCommand() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "get {device-changes,network-changes,plugins,opstate,snapshots} [args]",
+		Use:   "get {device-changes,network-changes,plugins,opstate,snapshots,audit-records,pending-changes,dead-letters} [args]",
 		Short: "Get config resources",
 	}
 	cmd.AddCommand(getListNetworkChangesCommand())
@@ -29,6 +29,9 @@ func getGetCommand() *cobra.Command {
 	cmd.AddCommand(getGetPluginsCommand())
 	cmd.AddCommand(getGetOpstateCommand())
 	cmd.AddCommand(getListSnapshotsCommand())
+	cmd.AddCommand(getListAuditRecordsCommand())
+	cmd.AddCommand(getListPendingChangesCommand())
+	cmd.AddCommand(getListDeadLettersCommand())
 	return cmd
 }
 
@@ -0,0 +1,107 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"io"
+
+	adminapi "github.com/onosproject/onos-config/pkg/api/admin"
+	"github.com/onosproject/onos-lib-go/pkg/cli"
+	"github.com/spf13/cobra"
+)
+
+const deadLettersHeader = "CONTROLLER       ID                                              ATTEMPTS  TIME                       ERROR\n"
+
+const deadLettersFormat = "%-16s %-47s %-9d %-26s %s\n"
+
+func getListDeadLettersCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "dead-letters",
+		Short: "Lists the changes and snapshots that the controllers have given up on reconciling",
+		Args:  cobra.NoArgs,
+		RunE:  runListDeadLettersCommand,
+	}
+	cmd.Flags().String("controller", "", "only the dead letters of this controller, e.g. NetworkChange, DeviceChange")
+	cmd.Flags().Bool("no-headers", false, "disables output headers")
+	return cmd
+}
+
+func runListDeadLettersCommand(cmd *cobra.Command, args []string) error {
+	controller, _ := cmd.Flags().GetString("controller")
+	noHeaders, _ := cmd.Flags().GetBool("no-headers")
+
+	clientConnection, clientConnectionError := cli.GetConnection(cmd)
+
+	if clientConnectionError != nil {
+		return clientConnectionError
+	}
+	client := adminapi.CreateConfigAdminExtServiceClient(clientConnection)
+
+	stream, err := client.ListDeadLetters(context.Background(), &adminapi.ListDeadLettersRequest{Controller: controller})
+	if err != nil {
+		return err
+	}
+	if !noHeaders {
+		cli.Output(deadLettersHeader)
+	}
+	for {
+		in, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		cli.Output(deadLettersFormat, in.Controller, in.ID, in.Attempts,
+			in.Time.Format("2006-01-02T15:04:05.000Z07:00"), in.Error)
+	}
+}
+
+func getRequeueCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "requeue [<id>...]",
+		Short: "Requeues the changes and snapshots that the controllers have given up on, or all of them if no ID is given",
+		Args:  cobra.ArbitraryArgs,
+		RunE:  runRequeueCommand,
+	}
+	cmd.Flags().String("controller", "", "only the dead letters of this controller, e.g. NetworkChange, DeviceChange")
+	return cmd
+}
+
+func runRequeueCommand(cmd *cobra.Command, args []string) error {
+	controller, _ := cmd.Flags().GetString("controller")
+
+	clientConnection, clientConnectionError := cli.GetConnection(cmd)
+
+	if clientConnectionError != nil {
+		return clientConnectionError
+	}
+	client := adminapi.CreateConfigAdminExtServiceClient(clientConnection)
+
+	resp, err := client.RequeueDeadLetters(
+		context.Background(), &adminapi.RequeueDeadLettersRequest{Controller: controller, IDs: args})
+	if err != nil {
+		return err
+	}
+	if len(resp.DeadLetters) == 0 {
+		cli.Output("No dead letter to requeue\n")
+		return nil
+	}
+	for _, letter := range resp.DeadLetters {
+		cli.Output("Requeued %s in controller %s\n", letter.ID, letter.Controller)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Unit tests for the dead letters CLI
+package cli
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	adminapi "github.com/onosproject/onos-config/pkg/api/admin"
+	"github.com/onosproject/onos-lib-go/pkg/cli"
+	"gotest.tools/assert"
+)
+
+func Test_ListDeadLetters(t *testing.T) {
+	outputBuffer := bytes.NewBufferString("")
+	cli.CaptureOutput(outputBuffer)
+
+	letters := []*adminapi.DeadLetter{
+		{
+			Controller: "NetworkChange",
+			ID:         "change-1",
+			Attempts:   50,
+			Error:      "write failed",
+			Time:       time.Date(2020, 11, 2, 10, 30, 0, 0, time.UTC),
+		},
+	}
+	next := 0
+	deadLettersClient := MockConfigAdminExtServiceListDeadLettersClient{
+		recvFn: func() (*adminapi.DeadLetter, error) {
+			if next < len(letters) {
+				next++
+				return letters[next-1], nil
+			}
+			return nil, io.EOF
+		},
+	}
+
+	setUpMockClients(MockClientsConfig{
+		listDeadLettersClient: &deadLettersClient,
+	})
+
+	deadLettersCmd := getListDeadLettersCommand()
+	assert.NilError(t, deadLettersCmd.Flags().Set("controller", "NetworkChange"))
+	err := deadLettersCmd.RunE(deadLettersCmd, []string{})
+	assert.NilError(t, err)
+
+	assert.Equal(t, LastCreatedClient.deadLettersRequest.Controller, "NetworkChange")
+	output := outputBuffer.String()
+	assert.Assert(t, strings.Contains(output, "ATTEMPTS"))
+	assert.Assert(t, strings.Contains(output, "change-1"))
+	assert.Assert(t, strings.Contains(output, "2020-11-02T10:30:00.000Z"))
+	assert.Assert(t, strings.Contains(output, "write failed"))
+}
+
+func Test_Requeue(t *testing.T) {
+	outputBuffer := bytes.NewBufferString("")
+	cli.CaptureOutput(outputBuffer)
+
+	setUpMockClients(MockClientsConfig{})
+	requeue := getRequeueCommand()
+	assert.NilError(t, requeue.Flags().Set("controller", "DeviceChange"))
+	err := requeue.RunE(requeue, []string{"change-1:device-1:1.0.0"})
+	assert.NilError(t, err)
+	assert.Equal(t, LastCreatedClient.requeueRequest.Controller, "DeviceChange")
+	assert.DeepEqual(t, LastCreatedClient.requeueRequest.IDs, []string{"change-1:device-1:1.0.0"})
+	output := outputBuffer.String()
+	assert.Assert(t, strings.Contains(output, "Requeued change-1:device-1:1.0.0 in controller DeviceChange"))
+}
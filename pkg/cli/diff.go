@@ -0,0 +1,97 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"fmt"
+	"io"
+
+	devicechange "github.com/onosproject/onos-api/go/onos/config/change/device"
+	networkchange "github.com/onosproject/onos-api/go/onos/config/change/network"
+	"github.com/onosproject/onos-api/go/onos/config/device"
+	diagsapi "github.com/onosproject/onos-config/pkg/api/diags"
+	"github.com/onosproject/onos-lib-go/pkg/cli"
+	"github.com/spf13/cobra"
+)
+
+const diffHeader = "Diff from network change %s to %s\n"
+
+func getDiffCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "diff <changeId> <changeId>",
+		Short: "Shows the config differences between two network changes",
+		Args:  cobra.ExactArgs(2),
+		RunE:  runDiffCommand,
+	}
+	cmd.Flags().StringP("device", "d", "", "an optional device ID, which may contain wildcards")
+	cmd.Flags().Bool("no-headers", false, "disables output headers")
+	return cmd
+}
+
+func runDiffCommand(cmd *cobra.Command, args []string) error {
+	deviceID, _ := cmd.Flags().GetString("device")
+	noHeaders, _ := cmd.Flags().GetBool("no-headers")
+
+	clientConnection, clientConnectionError := cli.GetConnection(cmd)
+
+	if clientConnectionError != nil {
+		return clientConnectionError
+	}
+	client := diagsapi.CreateChangeExtServiceClient(clientConnection)
+	diffReq := diagsapi.ListConfigDiffRequest{
+		FromChangeID: networkchange.ID(args[0]),
+		ToChangeID:   networkchange.ID(args[1]),
+		DeviceID:     device.ID(deviceID),
+	}
+
+	stream, err := client.ListConfigDiff(context.Background(), &diffReq)
+	if err != nil {
+		return err
+	}
+	if !noHeaders {
+		cli.Output(diffHeader, args[0], args[1])
+	}
+	lastDevice := device.VersionedID("")
+	for {
+		in, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		versionedID := device.NewVersionedID(in.DeviceID, in.DeviceVersion)
+		if versionedID != lastDevice {
+			cli.Output("Device: %s (%s)\n", in.DeviceID, in.DeviceVersion)
+			lastDevice = versionedID
+		}
+		switch in.Type {
+		case diagsapi.DiffAdded:
+			cli.Output("+ %s %s\n", in.Path, diffValueToString(in.NewValue))
+		case diagsapi.DiffRemoved:
+			cli.Output("- %s %s\n", in.Path, diffValueToString(in.OldValue))
+		default:
+			cli.Output("~ %s %s -> %s\n", in.Path, diffValueToString(in.OldValue), diffValueToString(in.NewValue))
+		}
+	}
+}
+
+func diffValueToString(value *devicechange.TypedValue) string {
+	if value == nil {
+		return "<none>"
+	}
+	return fmt.Sprintf("(%s) %s", value.Type, value.ValueToString())
+}
@@ -0,0 +1,83 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Unit tests for the diff CLI
+package cli
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+
+	devicechange "github.com/onosproject/onos-api/go/onos/config/change/device"
+	diagsapi "github.com/onosproject/onos-config/pkg/api/diags"
+	"github.com/onosproject/onos-lib-go/pkg/cli"
+	"gotest.tools/assert"
+)
+
+func Test_Diff(t *testing.T) {
+	outputBuffer := bytes.NewBufferString("")
+	cli.CaptureOutput(outputBuffer)
+
+	diffs := []*diagsapi.ListConfigDiffResponse{
+		{
+			DeviceID:      "device-1",
+			DeviceVersion: "1.0.0",
+			Path:          "/aa/bb/cc",
+			Type:          diagsapi.DiffAdded,
+			NewValue:      devicechange.NewTypedValueString("added"),
+		},
+		{
+			DeviceID:      "device-1",
+			DeviceVersion: "1.0.0",
+			Path:          "/aa/bb/dd",
+			Type:          diagsapi.DiffUpdated,
+			OldValue:      devicechange.NewTypedValueString("before"),
+			NewValue:      devicechange.NewTypedValueString("after"),
+		},
+		{
+			DeviceID:      "device-2",
+			DeviceVersion: "1.0.0",
+			Path:          "/aa/bb/ee",
+			Type:          diagsapi.DiffRemoved,
+			OldValue:      devicechange.NewTypedValueString("removed"),
+		},
+	}
+	next := 0
+	diffClient := MockChangeExtServiceListConfigDiffClient{
+		recvFn: func() (*diagsapi.ListConfigDiffResponse, error) {
+			if next < len(diffs) {
+				next++
+				return diffs[next-1], nil
+			}
+			return nil, io.EOF
+		},
+	}
+
+	setUpMockClients(MockClientsConfig{
+		listConfigDiffClient: &diffClient,
+	})
+
+	diffCmd := getDiffCommand()
+	err := diffCmd.RunE(diffCmd, []string{"change-1", "change-2"})
+	assert.NilError(t, err)
+	output := outputBuffer.String()
+	assert.Assert(t, strings.Contains(output, "Diff from network change change-1 to change-2"))
+	assert.Equal(t, strings.Count(output, "Device: device-1 (1.0.0)"), 1)
+	assert.Equal(t, strings.Count(output, "Device: device-2 (1.0.0)"), 1)
+	assert.Assert(t, strings.Contains(output, "+ /aa/bb/cc (STRING) added"))
+	assert.Assert(t, strings.Contains(output, "~ /aa/bb/dd (STRING) before -> (STRING) after"))
+	assert.Assert(t, strings.Contains(output, "- /aa/bb/ee (STRING) removed"))
+}
@@ -39,6 +39,7 @@ const (
 	tlsCertPathFlag = "tls-cert-path"
 	tlsKeyPathFlag  = "tls-key-path"
 	noTLSFlag       = "no-tls"
+	notBeforeFlag   = "not-before"
 )
 
 func getLoadCommand() *cobra.Command {
@@ -49,6 +50,7 @@ func getLoadCommand() *cobra.Command {
 	cmd.AddCommand(getYamlCommand())
 	cmd.AddCommand(getProtoCommand())
 	cmd.PersistentFlags().StringP("name", "n", "", "Optional name for gNMI Set")
+	cmd.PersistentFlags().String(notBeforeFlag, "", "Optional time (RFC 3339) before which the change is not applied")
 	return cmd
 }
 
@@ -64,6 +66,10 @@ func getYamlCommand() *cobra.Command {
 
 func runLoadYamlCommand(cmd *cobra.Command, args []string) error {
 	gnmiName, _ := cmd.Flags().GetString("name")
+	ext105NotBefore, err := getNotBeforeExtension(cmd)
+	if err != nil {
+		return err
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer cancel()
@@ -121,6 +127,9 @@ func runLoadYamlCommand(cmd *cobra.Command, args []string) error {
 				Ext: &ext100Name,
 			})
 		}
+		if ext105NotBefore != nil {
+			gnmiSetRequest.Extension = append(gnmiSetRequest.Extension, ext105NotBefore)
+		}
 
 		resp, err := gnmiClient.Set(ctx, gnmiSetRequest)
 		if err != nil {
@@ -148,6 +157,10 @@ func getProtoCommand() *cobra.Command {
 
 func runLoadProtoCommand(cmd *cobra.Command, args []string) error {
 	gnmiName, _ := cmd.Flags().GetString("name")
+	ext105NotBefore, err := getNotBeforeExtension(cmd)
+	if err != nil {
+		return err
+	}
 
 	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
 	defer cancel()
@@ -209,6 +222,9 @@ func runLoadProtoCommand(cmd *cobra.Command, args []string) error {
 				Ext: &ext100Name,
 			})
 		}
+		if ext105NotBefore != nil {
+			gnmiSetRequest.Extension = append(gnmiSetRequest.Extension, ext105NotBefore)
+		}
 
 		resp, err := gnmiClient.Set(ctx, gnmiSetRequest)
 		if err != nil {
@@ -224,6 +240,25 @@ func runLoadProtoCommand(cmd *cobra.Command, args []string) error {
 	return nil
 }
 
+// getNotBeforeExtension returns the extension that schedules the change, if the not-before flag is given
+func getNotBeforeExtension(cmd *cobra.Command) (*gnmi_ext.Extension, error) {
+	notBefore, _ := cmd.Flags().GetString(notBeforeFlag)
+	if notBefore == "" {
+		return nil, nil
+	}
+	if _, err := time.Parse(time.RFC3339, notBefore); err != nil {
+		return nil, fmt.Errorf("invalid --%s %s: %v", notBeforeFlag, notBefore, err)
+	}
+	return &gnmi_ext.Extension{
+		Ext: &gnmi_ext.Extension_RegisteredExt{
+			RegisteredExt: &gnmi_ext.RegisteredExtension{
+				Id:  gnmi.GnmiExtensionNotBefore,
+				Msg: []byte(notBefore),
+			},
+		},
+	}, nil
+}
+
 func getAddress(cmd *cobra.Command) string {
 	address, _ := cmd.Flags().GetString(addressFlag)
 	if address == "" {
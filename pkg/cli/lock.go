@@ -0,0 +1,91 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"time"
+
+	devicetype "github.com/onosproject/onos-api/go/onos/config/device"
+	adminapi "github.com/onosproject/onos-config/pkg/api/admin"
+	"github.com/onosproject/onos-lib-go/pkg/cli"
+	"github.com/spf13/cobra"
+)
+
+func getLockCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "lock <deviceId> [<deviceId>...]",
+		Short: "Locks the configuration of devices against changes from other clients",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  runLockCommand,
+	}
+	cmd.Flags().Duration("ttl", 10*time.Minute, "how long to hold the lock for unless it is renewed")
+	return cmd
+}
+
+func runLockCommand(cmd *cobra.Command, args []string) error {
+	ttl, _ := cmd.Flags().GetDuration("ttl")
+	clientConnection, clientConnectionError := cli.GetConnection(cmd)
+
+	if clientConnectionError != nil {
+		return clientConnectionError
+	}
+	client := adminapi.CreateConfigAdminExtServiceClient(clientConnection)
+
+	resp, err := client.LockDevices(
+		context.Background(), &adminapi.LockDevicesRequest{DeviceIDs: toDeviceIDs(args), TTL: &ttl})
+	if err != nil {
+		return err
+	}
+	for _, lock := range resp.Locks {
+		cli.Output("Locked %s for %s until %s\n", lock.DeviceID, lock.Owner, lock.Expires.Format(time.RFC3339))
+	}
+	return nil
+}
+
+func getUnlockCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "unlock <deviceId> [<deviceId>...]",
+		Short: "Releases locks on the configuration of devices",
+		Args:  cobra.MinimumNArgs(1),
+		RunE:  runUnlockCommand,
+	}
+	return cmd
+}
+
+func runUnlockCommand(cmd *cobra.Command, args []string) error {
+	clientConnection, clientConnectionError := cli.GetConnection(cmd)
+
+	if clientConnectionError != nil {
+		return clientConnectionError
+	}
+	client := adminapi.CreateConfigAdminExtServiceClient(clientConnection)
+
+	resp, err := client.UnlockDevices(
+		context.Background(), &adminapi.UnlockDevicesRequest{DeviceIDs: toDeviceIDs(args)})
+	if err != nil {
+		return err
+	}
+	cli.Output("Unlock success %s\n", resp.Message)
+	return nil
+}
+
+func toDeviceIDs(args []string) []devicetype.ID {
+	deviceIDs := make([]devicetype.ID, len(args))
+	for i, arg := range args {
+		deviceIDs[i] = devicetype.ID(arg)
+	}
+	return deviceIDs
+}
@@ -0,0 +1,57 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Unit tests for lock CLI
+package cli
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+
+	devicetype "github.com/onosproject/onos-api/go/onos/config/device"
+	"github.com/onosproject/onos-lib-go/pkg/cli"
+	"gotest.tools/assert"
+)
+
+func Test_lock(t *testing.T) {
+	outputBuffer := bytes.NewBufferString("")
+	cli.CaptureOutput(outputBuffer)
+
+	setUpMockClients(MockClientsConfig{})
+	lock := getLockCommand()
+	err := lock.Flags().Set("ttl", "5m")
+	assert.NilError(t, err)
+	err = lock.RunE(lock, []string{"device-1", "device-2"})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, LastCreatedClient.lockIDs, []devicetype.ID{"device-1", "device-2"})
+	assert.Equal(t, LastCreatedClient.lockTTL, 5*time.Minute)
+	output := outputBuffer.String()
+	assert.Assert(t, strings.Contains(output, "Locked device-1 for client-1 until"))
+	assert.Assert(t, strings.Contains(output, "Locked device-2 for client-1 until"))
+}
+
+func Test_unlock(t *testing.T) {
+	outputBuffer := bytes.NewBufferString("")
+	cli.CaptureOutput(outputBuffer)
+
+	setUpMockClients(MockClientsConfig{})
+	unlock := getUnlockCommand()
+	err := unlock.RunE(unlock, []string{"device-1"})
+	assert.NilError(t, err)
+	assert.DeepEqual(t, LastCreatedClient.lockIDs, []devicetype.ID{"device-1"})
+	output := outputBuffer.String()
+	assert.Assert(t, strings.Contains(output, "Unlock was successful"))
+}
@@ -18,9 +18,13 @@ package cli
 import (
 	"context"
 	"github.com/onosproject/onos-api/go/onos/config/admin"
+	devicetype "github.com/onosproject/onos-api/go/onos/config/device"
 	"github.com/onosproject/onos-api/go/onos/config/diags"
+	adminapi "github.com/onosproject/onos-config/pkg/api/admin"
+	diagsapi "github.com/onosproject/onos-config/pkg/api/diags"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/metadata"
+	"time"
 )
 
 // MockClientConfig is used by tests to set up which mock clients they want to use
@@ -28,12 +32,22 @@ type MockClientsConfig struct {
 	registeredModelsClient   *MockConfigAdminServiceListRegisteredModelsClient
 	opstateClient            *MockOpStateDiagsGetOpStateClient
 	listDeviceChangesClient  *MockChangeServiceListDeviceChangesClient
-	listNetworkChangesClient *MockChangeServiceListNetworkChangesClient
+	listNetworkChangesClient *MockChangeExtServiceListNetworkChangesWithMetadataClient
+	listConfigDiffClient     *MockChangeExtServiceListConfigDiffClient
+	listAuditRecordsClient   *MockConfigAdminExtServiceListAuditRecordsClient
+	listPendingChangesClient *MockChangeExtServiceListPendingChangesClient
+	listDeadLettersClient    *MockConfigAdminExtServiceListDeadLettersClient
 }
 
 // mockConfigAdminServiceClient is the mock for the ConfigAdminServiceClient
 type mockConfigAdminServiceClient struct {
 	rollBackID             string
+	confirmID              string
+	lockIDs                []devicetype.ID
+	lockTTL                time.Duration
+	auditRecordsRequest    *adminapi.ListAuditRecordsRequest
+	deadLettersRequest     *adminapi.ListDeadLettersRequest
+	requeueRequest         *adminapi.RequeueDeadLettersRequest
 	registeredModelsClient *MockConfigAdminServiceListRegisteredModelsClient
 }
 
@@ -72,6 +86,28 @@ func (c mockConfigAdminServiceClient) CompactChanges(ctx context.Context, in *ad
 	return nil, nil
 }
 
+// MockConfigAdminExtServiceListDeadLettersClient is a mock of the ListDeadLettersClient
+// Function pointers are used to allow mocking specific APIs
+type MockConfigAdminExtServiceListDeadLettersClient struct {
+	grpc.ClientStream
+	recvFn func() (*adminapi.DeadLetter, error)
+}
+
+func (c MockConfigAdminExtServiceListDeadLettersClient) Recv() (*adminapi.DeadLetter, error) {
+	return c.recvFn()
+}
+
+// MockConfigAdminExtServiceListAuditRecordsClient is a mock of the ListAuditRecordsClient
+// Function pointers are used to allow mocking specific APIs
+type MockConfigAdminExtServiceListAuditRecordsClient struct {
+	grpc.ClientStream
+	recvFn func() (*adminapi.AuditRecord, error)
+}
+
+func (c MockConfigAdminExtServiceListAuditRecordsClient) Recv() (*adminapi.AuditRecord, error) {
+	return c.recvFn()
+}
+
 // MockConfigAdminServiceListRegisteredModelsClient is a mock of the ConfigAdminServiceListRegisteredModelsClient
 // Function pointers are used to allow mocking specific APIs
 type MockConfigAdminServiceListRegisteredModelsClient struct {
@@ -201,59 +237,147 @@ func (c MockChangeServiceListDeviceChangesClient) RecvMsg(m interface{}) error {
 	return c.recvMsgFn(m)
 }
 
-// MockChangeServiceListNetworkChangesClient is a mock of the ChangeService_ListNetworkChangesClient
-// Function pointers are used to allow mocking specific APIs
-type MockChangeServiceListNetworkChangesClient struct {
-	recvFn      func() (*diags.ListNetworkChangeResponse, error)
-	headerFn    func() (metadata.MD, error)
-	trailerFn   func() metadata.MD
-	closeSendFn func() error
-	contextFn   func() context.Context
-	sendMsgFn   func(interface{}) error
-	recvMsgFn   func(interface{}) error
-	recvCounter func() int
+// mockChangeServiceClient is the mock for the ChangeServiceClient
+type mockChangeServiceClient struct {
+	getChangeServiceClientDeviceChanges diags.ChangeService_ListDeviceChangesClient
 }
 
-func (c MockChangeServiceListNetworkChangesClient) Recv() (*diags.ListNetworkChangeResponse, error) {
-	return c.recvFn()
+func (m mockChangeServiceClient) ListNetworkChanges(ctx context.Context, in *diags.ListNetworkChangeRequest, opts ...grpc.CallOption) (diags.ChangeService_ListNetworkChangesClient, error) {
+	return nil, nil
 }
 
-func (c MockChangeServiceListNetworkChangesClient) Header() (metadata.MD, error) {
-	return c.headerFn()
+func (m mockChangeServiceClient) ListDeviceChanges(ctx context.Context, in *diags.ListDeviceChangeRequest, opts ...grpc.CallOption) (diags.ChangeService_ListDeviceChangesClient, error) {
+	return m.getChangeServiceClientDeviceChanges, nil
 }
 
-func (c MockChangeServiceListNetworkChangesClient) Trailer() metadata.MD {
-	return c.trailerFn()
+// mockConfigAdminExtServiceClient is a mock of the ConfigAdminExtServiceClient
+type mockConfigAdminExtServiceClient struct {
+	auditRecordsClient *MockConfigAdminExtServiceListAuditRecordsClient
+	deadLettersClient  *MockConfigAdminExtServiceListDeadLettersClient
 }
 
-func (c MockChangeServiceListNetworkChangesClient) CloseSend() error {
-	return c.closeSendFn()
+func (c mockConfigAdminExtServiceClient) RollbackToNetworkChange(ctx context.Context, in *adminapi.RollbackToNetworkChangeRequest, opts ...grpc.CallOption) (*adminapi.RollbackToNetworkChangeResponse, error) {
+	response := &adminapi.RollbackToNetworkChangeResponse{
+		Message: "Rollback to change was successful",
+	}
+	LastCreatedClient.rollBackID = string(in.Name)
+	return response, nil
 }
 
-func (c MockChangeServiceListNetworkChangesClient) Context() context.Context {
-	return c.contextFn()
+func (c mockConfigAdminExtServiceClient) ConfirmNetworkChange(ctx context.Context, in *adminapi.ConfirmNetworkChangeRequest, opts ...grpc.CallOption) (*adminapi.ConfirmNetworkChangeResponse, error) {
+	response := &adminapi.ConfirmNetworkChangeResponse{
+		Message: "Confirm was successful",
+	}
+	LastCreatedClient.confirmID = string(in.Name)
+	return response, nil
 }
 
-func (c MockChangeServiceListNetworkChangesClient) SendMsg(m interface{}) error {
-	return c.sendMsgFn(m)
+func (c mockConfigAdminExtServiceClient) LockDevices(ctx context.Context, in *adminapi.LockDevicesRequest, opts ...grpc.CallOption) (*adminapi.LockDevicesResponse, error) {
+	response := &adminapi.LockDevicesResponse{}
+	for _, deviceID := range in.DeviceIDs {
+		response.Locks = append(response.Locks, &adminapi.DeviceLock{
+			DeviceID: deviceID,
+			Owner:    "client-1",
+			Expires:  time.Now().Add(*in.TTL),
+		})
+	}
+	LastCreatedClient.lockIDs = in.DeviceIDs
+	LastCreatedClient.lockTTL = *in.TTL
+	return response, nil
 }
 
-func (c MockChangeServiceListNetworkChangesClient) RecvMsg(m interface{}) error {
-	return c.recvMsgFn(m)
+func (c mockConfigAdminExtServiceClient) UnlockDevices(ctx context.Context, in *adminapi.UnlockDevicesRequest, opts ...grpc.CallOption) (*adminapi.UnlockDevicesResponse, error) {
+	response := &adminapi.UnlockDevicesResponse{
+		Message: "Unlock was successful",
+	}
+	LastCreatedClient.lockIDs = in.DeviceIDs
+	return response, nil
 }
 
-// mockChangeServiceClient is the mock for the ChangeServiceClient
-type mockChangeServiceClient struct {
-	getChangeServiceClientDeviceChanges  diags.ChangeService_ListDeviceChangesClient
-	getChangeServiceClientNetworkChanges diags.ChangeService_ListNetworkChangesClient
+func (c mockConfigAdminExtServiceClient) ListAuditRecords(ctx context.Context, in *adminapi.ListAuditRecordsRequest, opts ...grpc.CallOption) (adminapi.ListAuditRecordsClient, error) {
+	LastCreatedClient.auditRecordsRequest = in
+	return c.auditRecordsClient, nil
 }
 
-func (m mockChangeServiceClient) ListNetworkChanges(ctx context.Context, in *diags.ListNetworkChangeRequest, opts ...grpc.CallOption) (diags.ChangeService_ListNetworkChangesClient, error) {
-	return m.getChangeServiceClientNetworkChanges, nil
+func (c mockConfigAdminExtServiceClient) ListDeadLetters(ctx context.Context, in *adminapi.ListDeadLettersRequest, opts ...grpc.CallOption) (adminapi.ListDeadLettersClient, error) {
+	LastCreatedClient.deadLettersRequest = in
+	return c.deadLettersClient, nil
 }
 
-func (m mockChangeServiceClient) ListDeviceChanges(ctx context.Context, in *diags.ListDeviceChangeRequest, opts ...grpc.CallOption) (diags.ChangeService_ListDeviceChangesClient, error) {
-	return m.getChangeServiceClientDeviceChanges, nil
+func (c mockConfigAdminExtServiceClient) RequeueDeadLetters(ctx context.Context, in *adminapi.RequeueDeadLettersRequest, opts ...grpc.CallOption) (*adminapi.RequeueDeadLettersResponse, error) {
+	response := &adminapi.RequeueDeadLettersResponse{}
+	for _, id := range in.IDs {
+		response.DeadLetters = append(response.DeadLetters, &adminapi.DeadLetter{
+			Controller: "DeviceChange",
+			ID:         id,
+		})
+	}
+	LastCreatedClient.requeueRequest = in
+	return response, nil
+}
+
+// mockChangeExtServiceClient is a mock of the ChangeExtServiceClient
+type mockChangeExtServiceClient struct {
+	listConfigDiffClient     diagsapi.ListConfigDiffClient
+	listNetworkChangesClient diagsapi.ListNetworkChangesWithMetadataClient
+	listPendingChangesClient diagsapi.ListPendingChangesClient
+}
+
+func (m mockChangeExtServiceClient) ListConfigDrift(ctx context.Context, in *diagsapi.ListConfigDriftRequest, opts ...grpc.CallOption) (diagsapi.ListConfigDriftClient, error) {
+	return nil, nil
+}
+
+func (m mockChangeExtServiceClient) ListConfigDiff(ctx context.Context, in *diagsapi.ListConfigDiffRequest, opts ...grpc.CallOption) (diagsapi.ListConfigDiffClient, error) {
+	return m.listConfigDiffClient, nil
+}
+
+// LastListNetworkChangesRequest is the last request made to list network changes
+var LastListNetworkChangesRequest *diagsapi.ListNetworkChangesWithMetadataRequest
+
+func (m mockChangeExtServiceClient) ListNetworkChangesWithMetadata(ctx context.Context, in *diagsapi.ListNetworkChangesWithMetadataRequest, opts ...grpc.CallOption) (diagsapi.ListNetworkChangesWithMetadataClient, error) {
+	LastListNetworkChangesRequest = in
+	return m.listNetworkChangesClient, nil
+}
+
+// LastListPendingChangesRequest is the last request made to list pending changes
+var LastListPendingChangesRequest *diagsapi.ListPendingChangesRequest
+
+func (m mockChangeExtServiceClient) ListPendingChanges(ctx context.Context, in *diagsapi.ListPendingChangesRequest, opts ...grpc.CallOption) (diagsapi.ListPendingChangesClient, error) {
+	LastListPendingChangesRequest = in
+	return m.listPendingChangesClient, nil
+}
+
+// MockChangeExtServiceListConfigDiffClient is a mock of the ListConfigDiffClient
+// Function pointers are used to allow mocking specific APIs
+type MockChangeExtServiceListConfigDiffClient struct {
+	grpc.ClientStream
+	recvFn func() (*diagsapi.ListConfigDiffResponse, error)
+}
+
+func (c MockChangeExtServiceListConfigDiffClient) Recv() (*diagsapi.ListConfigDiffResponse, error) {
+	return c.recvFn()
+}
+
+// MockChangeExtServiceListNetworkChangesWithMetadataClient is a mock of the ListNetworkChangesWithMetadataClient
+// Function pointers are used to allow mocking specific APIs
+type MockChangeExtServiceListNetworkChangesWithMetadataClient struct {
+	grpc.ClientStream
+	recvFn func() (*diagsapi.ListNetworkChangesWithMetadataResponse, error)
+}
+
+func (c MockChangeExtServiceListNetworkChangesWithMetadataClient) Recv() (*diagsapi.ListNetworkChangesWithMetadataResponse, error) {
+	return c.recvFn()
+}
+
+// MockChangeExtServiceListPendingChangesClient is a mock of the ListPendingChangesClient
+// Function pointers are used to allow mocking specific APIs
+type MockChangeExtServiceListPendingChangesClient struct {
+	grpc.ClientStream
+	recvFn func() (*diagsapi.ListPendingChangesResponse, error)
+}
+
+func (c MockChangeExtServiceListPendingChangesClient) Recv() (*diagsapi.ListPendingChangesResponse, error) {
+	return c.recvFn()
 }
 
 // setUpMockClients sets up factories to create mocks of top level clients used by the CLI
@@ -265,15 +389,28 @@ func setUpMockClients(config MockClientsConfig) {
 		}
 		return LastCreatedClient
 	}
+	adminapi.ConfigAdminExtServiceClientFactory = func(cc *grpc.ClientConn) adminapi.ConfigAdminExtServiceClient {
+		LastCreatedClient = &mockConfigAdminServiceClient{}
+		return mockConfigAdminExtServiceClient{
+			auditRecordsClient: config.listAuditRecordsClient,
+			deadLettersClient:  config.listDeadLettersClient,
+		}
+	}
 	diags.OpStateDiagsClientFactory = func(cc *grpc.ClientConn) diags.OpStateDiagsClient {
 		return mockOpStateDiagsClient{
 			getOpStateClient: config.opstateClient,
 		}
 	}
+	diagsapi.ChangeExtServiceClientFactory = func(cc *grpc.ClientConn) diagsapi.ChangeExtServiceClient {
+		return mockChangeExtServiceClient{
+			listConfigDiffClient:     config.listConfigDiffClient,
+			listNetworkChangesClient: config.listNetworkChangesClient,
+			listPendingChangesClient: config.listPendingChangesClient,
+		}
+	}
 	diags.ChangeServiceClientFactory = func(cc *grpc.ClientConn) diags.ChangeServiceClient {
 		return mockChangeServiceClient{
-			getChangeServiceClientDeviceChanges:  config.listDeviceChangesClient,
-			getChangeServiceClientNetworkChanges: config.listNetworkChangesClient,
+			getChangeServiceClientDeviceChanges: config.listDeviceChangesClient,
 		}
 	}
 }
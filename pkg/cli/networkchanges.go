@@ -16,12 +16,15 @@ package cli
 
 import (
 	"context"
+	changetypes "github.com/onosproject/onos-api/go/onos/config/change"
 	networkchange "github.com/onosproject/onos-api/go/onos/config/change/network"
-	"github.com/onosproject/onos-api/go/onos/config/diags"
+	devicetype "github.com/onosproject/onos-api/go/onos/config/device"
+	diagsapi "github.com/onosproject/onos-config/pkg/api/diags"
 	"github.com/onosproject/onos-lib-go/pkg/cli"
 	"github.com/spf13/cobra"
 	"io"
 	"text/template"
+	"time"
 )
 
 const changeHeader = "CHANGE                          INDEX  REVISION  PHASE    STATE     REASON   MESSAGE\n"
@@ -32,14 +35,34 @@ const typedValueFormat = "\t{{wrappath .Path 50 1| printf \"|%-50s|\"}}{{valueto
 
 const deviceIDFormat = "Device: {{.DeviceID}} ({{.DeviceVersion}})"
 
-const networkChangeTemplate = changeHeaderFormat +
+const changeMetadataFormat = "{{if .Author}}\tAuthor: {{.Author}}\n{{end}}" +
+	"{{if .Description}}\tDescription: {{.Description}}\n{{end}}" +
+	"{{if .Labels}}\tLabels:{{range $key, $value := .Labels}} {{$key}}={{$value}}{{end}}\n{{end}}" +
+	"{{if .ApplyPolicy}}\tApply policy: {{.ApplyPolicy}}{{if .Quorum}} {{.Quorum}}%{{end}}\n{{end}}" +
+	"{{range $device, $status := .Devices}}\tOn {{$device}}: {{$status.State}}" +
+	"{{if $status.Message}} {{$status.Message}}{{end}}\n{{end}}" +
+	"{{range $device, $timeout := .ApplyTimeouts}}\tNo answer from {{$device}} within {{$timeout}}\n{{end}}"
+
+const networkChangeTemplate = changeHeaderFormat + changeMetadataFormat +
 	"{{range .Changes}}\t" + deviceIDFormat + "\n{{end}}\n"
 
-const networkChangeTemplateVerbose = changeHeaderFormat +
+const networkChangeTemplateVerbose = changeHeaderFormat + changeMetadataFormat +
 	"{{range .Changes}}\t" + deviceIDFormat + "\n" +
 	"{{range .Values}}" + typedValueFormat + "{{end}}\n" +
 	"{{end}}\n"
 
+// networkChangeWithMetadata is a network change along with the metadata it is printed with
+type networkChangeWithMetadata struct {
+	*networkchange.NetworkChange
+	Author        string
+	Description   string
+	Labels        map[string]string
+	ApplyPolicy   string
+	Quorum        uint32
+	Devices       map[devicetype.ID]*changetypes.Status
+	ApplyTimeouts map[devicetype.ID]time.Duration
+}
+
 func getWatchNetworkChangesCommand() *cobra.Command {
 	cmd := &cobra.Command{
 		Use:   "network-changes [changeId wildcard]",
@@ -49,6 +72,7 @@ func getWatchNetworkChangesCommand() *cobra.Command {
 	}
 	cmd.Flags().BoolP("verbose", "v", false, "whether to print the change with verbose output")
 	cmd.Flags().Bool("no-headers", false, "disables output headers")
+	addChangeMetadataFlags(cmd)
 	return cmd
 }
 
@@ -61,9 +85,17 @@ func getListNetworkChangesCommand() *cobra.Command {
 	}
 	cmd.Flags().BoolP("verbose", "v", false, "whether to print the change with verbose output")
 	cmd.Flags().Bool("no-headers", false, "disables output headers")
+	addChangeMetadataFlags(cmd)
 	return cmd
 }
 
+// addChangeMetadataFlags adds the flags to filter network changes on their metadata
+func addChangeMetadataFlags(cmd *cobra.Command) {
+	cmd.Flags().String("author", "", "only the changes made by this client")
+	cmd.Flags().String("description", "", "only the changes whose description contains this text")
+	cmd.Flags().StringToString("label", nil, "only the changes with this label, as key=value; may be repeated")
+}
+
 func runWatchNetworkChangesCommand(cmd *cobra.Command, args []string) error {
 	return networkChangesCommand(cmd, true, args)
 }
@@ -79,16 +111,22 @@ func networkChangesCommand(cmd *cobra.Command, subscribe bool, args []string) er
 	}
 	verbose, _ := cmd.Flags().GetBool("verbose")
 	noHeaders, _ := cmd.Flags().GetBool("no-headers")
+	author, _ := cmd.Flags().GetString("author")
+	description, _ := cmd.Flags().GetString("description")
+	labels, _ := cmd.Flags().GetStringToString("label")
 
 	clientConnection, clientConnectionError := cli.GetConnection(cmd)
 
 	if clientConnectionError != nil {
 		return clientConnectionError
 	}
-	client := diags.CreateChangeServiceClient(clientConnection)
-	changesReq := diags.ListNetworkChangeRequest{
-		Subscribe: subscribe,
-		ChangeID:  id,
+	client := diagsapi.CreateChangeExtServiceClient(clientConnection)
+	changesReq := diagsapi.ListNetworkChangesWithMetadataRequest{
+		Subscribe:   subscribe,
+		ChangeID:    id,
+		Author:      author,
+		Description: description,
+		Labels:      labels,
 	}
 
 	var tmplChanges *template.Template
@@ -97,7 +135,7 @@ func networkChangesCommand(cmd *cobra.Command, subscribe bool, args []string) er
 		tmplChanges, _ = template.New("change").Funcs(funcMapChanges).Parse(networkChangeTemplateVerbose)
 	}
 
-	stream, err := client.ListNetworkChanges(context.Background(), &changesReq)
+	stream, err := client.ListNetworkChangesWithMetadata(context.Background(), &changesReq)
 	if err != nil {
 		return err
 	}
@@ -113,6 +151,15 @@ func networkChangesCommand(cmd *cobra.Command, subscribe bool, args []string) er
 		if err != nil {
 			return err
 		}
-		_ = tmplChanges.Execute(cli.GetOutput(), in.Change)
+		_ = tmplChanges.Execute(cli.GetOutput(), networkChangeWithMetadata{
+			NetworkChange: in.Change,
+			Author:        in.Author,
+			Description:   in.Description,
+			Labels:        in.Labels,
+			ApplyPolicy:   in.ApplyPolicy,
+			Quorum:        in.Quorum,
+			Devices:       in.Devices,
+			ApplyTimeouts: in.ApplyTimeouts,
+		})
 	}
 }
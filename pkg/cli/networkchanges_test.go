@@ -21,7 +21,8 @@ import (
 	changetypes "github.com/onosproject/onos-api/go/onos/config/change"
 	devicechange "github.com/onosproject/onos-api/go/onos/config/change/device"
 	networkchange "github.com/onosproject/onos-api/go/onos/config/change/network"
-	"github.com/onosproject/onos-api/go/onos/config/diags"
+	devicetype "github.com/onosproject/onos-api/go/onos/config/device"
+	diagsapi "github.com/onosproject/onos-config/pkg/api/diags"
 	"github.com/onosproject/onos-lib-go/pkg/cli"
 	"gotest.tools/assert"
 	"io"
@@ -98,7 +99,7 @@ func Test_WatchNetworkChanges(t *testing.T) {
 	generateDeviceChangeData(4)
 	generateNetworkChangeData(4)
 
-	configsClient := MockChangeServiceListNetworkChangesClient{
+	configsClient := MockChangeExtServiceListNetworkChangesWithMetadataClient{
 		recvFn: recvWatchNetworkChangesMock,
 	}
 
@@ -115,12 +116,12 @@ func Test_WatchNetworkChanges(t *testing.T) {
 
 var nextWatchNwChIndex int
 
-func recvWatchNetworkChangesMock() (*diags.ListNetworkChangeResponse, error) {
+func recvWatchNetworkChangesMock() (*diagsapi.ListNetworkChangesWithMetadataResponse, error) {
 	if nextWatchNwChIndex < len(networkChanges) {
 		netw := networkChanges[nextWatchNwChIndex]
 		nextWatchNwChIndex++
 
-		return &diags.ListNetworkChangeResponse{
+		return &diagsapi.ListNetworkChangesWithMetadataResponse{
 			Change: &netw,
 		}, nil
 	}
@@ -132,11 +133,9 @@ func Test_GetNetworkChanges(t *testing.T) {
 	cli.CaptureOutput(outputBuffer)
 	generateDeviceChangeData(4)
 	generateNetworkChangeData(4)
-	var nextNwChIndex = 0
 
-	configsClient := MockChangeServiceListNetworkChangesClient{
-		recvFn:      recvListNetworkChangesMock,
-		recvCounter: func() int { return nextNwChIndex },
+	configsClient := MockChangeExtServiceListNetworkChangesWithMetadataClient{
+		recvFn: recvListNetworkChangesMock,
 	}
 
 	setUpMockClients(MockClientsConfig{
@@ -153,14 +152,65 @@ func Test_GetNetworkChanges(t *testing.T) {
 
 var nextListNwChIndex int
 
-func recvListNetworkChangesMock() (*diags.ListNetworkChangeResponse, error) {
+func recvListNetworkChangesMock() (*diagsapi.ListNetworkChangesWithMetadataResponse, error) {
 	if nextListNwChIndex < len(networkChanges) {
 		netw := networkChanges[nextListNwChIndex]
 		nextListNwChIndex++
 
-		return &diags.ListNetworkChangeResponse{
+		return &diagsapi.ListNetworkChangesWithMetadataResponse{
 			Change: &netw,
 		}, nil
 	}
 	return nil, io.EOF
 }
+
+func Test_GetNetworkChangesByMetadata(t *testing.T) {
+	outputBuffer := bytes.NewBufferString("")
+	cli.CaptureOutput(outputBuffer)
+	generateNetworkChangeData(1)
+	sent := false
+
+	configsClient := MockChangeExtServiceListNetworkChangesWithMetadataClient{
+		recvFn: func() (*diagsapi.ListNetworkChangesWithMetadataResponse, error) {
+			if sent {
+				return nil, io.EOF
+			}
+			sent = true
+			return &diagsapi.ListNetworkChangesWithMetadataResponse{
+				Change:      &networkChanges[0],
+				Author:      "onos-cli",
+				Description: "Maintenance of device-1",
+				Labels:      map[string]string{"ticket": "OPS-123"},
+				ApplyPolicy: "quorum",
+				Quorum:      60,
+				Devices: map[devicetype.ID]*changetypes.Status{
+					"device-1": {State: changetypes.State_PENDING},
+				},
+				ApplyTimeouts: map[devicetype.ID]time.Duration{"device-2": time.Minute},
+			}, nil
+		},
+	}
+
+	setUpMockClients(MockClientsConfig{
+		listNetworkChangesClient: &configsClient,
+	})
+
+	networkChangesCmd := getListNetworkChangesCommand()
+	assert.NilError(t, networkChangesCmd.Flags().Set("author", "onos-cli"))
+	assert.NilError(t, networkChangesCmd.Flags().Set("description", "maintenance"))
+	assert.NilError(t, networkChangesCmd.Flags().Set("label", "ticket=OPS-123"))
+	err := networkChangesCmd.RunE(networkChangesCmd, nil)
+	assert.NilError(t, err)
+
+	assert.Equal(t, LastListNetworkChangesRequest.Author, "onos-cli")
+	assert.Equal(t, LastListNetworkChangesRequest.Description, "maintenance")
+	assert.DeepEqual(t, LastListNetworkChangesRequest.Labels, map[string]string{"ticket": "OPS-123"})
+
+	output := outputBuffer.String()
+	assert.Assert(t, strings.Contains(output, "Author: onos-cli"))
+	assert.Assert(t, strings.Contains(output, "Description: Maintenance of device-1"))
+	assert.Assert(t, strings.Contains(output, "Labels: ticket=OPS-123"))
+	assert.Assert(t, strings.Contains(output, "Apply policy: quorum 60%"))
+	assert.Assert(t, strings.Contains(output, "On device-1: PENDING\n"))
+	assert.Assert(t, strings.Contains(output, "No answer from device-2 within 1m0s"))
+}
@@ -0,0 +1,81 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cli
+
+import (
+	"context"
+	"io"
+	"time"
+
+	diagsapi "github.com/onosproject/onos-config/pkg/api/diags"
+	"github.com/onosproject/onos-lib-go/pkg/cli"
+	"github.com/spf13/cobra"
+)
+
+const pendingChangesHeader = "CHANGE                                PHASE     INCARNATION  PENDING FOR  MESSAGE\n"
+
+func getListPendingChangesCommand() *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "pending-changes",
+		Short: "Lists the network changes that have been PENDING for a while, and the devices they are waiting for",
+		Args:  cobra.NoArgs,
+		RunE:  runListPendingChangesCommand,
+	}
+	cmd.Flags().Duration("threshold", 0, "only the changes PENDING for longer than this, e.g. 10m (default 5m)")
+	cmd.Flags().Bool("no-headers", false, "disables output headers")
+	return cmd
+}
+
+func runListPendingChangesCommand(cmd *cobra.Command, args []string) error {
+	threshold, _ := cmd.Flags().GetDuration("threshold")
+	noHeaders, _ := cmd.Flags().GetBool("no-headers")
+
+	clientConnection, clientConnectionError := cli.GetConnection(cmd)
+
+	if clientConnectionError != nil {
+		return clientConnectionError
+	}
+	client := diagsapi.CreateChangeExtServiceClient(clientConnection)
+	request := diagsapi.ListPendingChangesRequest{}
+	if threshold > 0 {
+		request.Threshold = &threshold
+	}
+
+	stream, err := client.ListPendingChanges(context.Background(), &request)
+	if err != nil {
+		return err
+	}
+	if !noHeaders {
+		cli.Output(pendingChangesHeader)
+	}
+	for {
+		in, err := stream.Recv()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		change := in.NetworkChange
+		pendingFor := time.Since(in.PendingSince).Round(time.Second)
+		cli.Output("%-37s %-9s %-12d %-12s %s\n", change.ID, change.Status.Phase, change.Status.Incarnation,
+			pendingFor, change.Status.Message)
+		for _, deviceChange := range in.DeviceChanges {
+			cli.Output("\t%s (%s) %s %s incarnation %d\n", deviceChange.Change.DeviceID,
+				deviceChange.Change.DeviceVersion, deviceChange.Status.Phase, deviceChange.Status.State,
+				deviceChange.Status.Incarnation)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Unit tests for the pending changes CLI
+package cli
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	changetypes "github.com/onosproject/onos-api/go/onos/config/change"
+	devicechange "github.com/onosproject/onos-api/go/onos/config/change/device"
+	networkchange "github.com/onosproject/onos-api/go/onos/config/change/network"
+	diagsapi "github.com/onosproject/onos-config/pkg/api/diags"
+	"github.com/onosproject/onos-lib-go/pkg/cli"
+	"gotest.tools/assert"
+)
+
+func Test_ListPendingChanges(t *testing.T) {
+	outputBuffer := bytes.NewBufferString("")
+	cli.CaptureOutput(outputBuffer)
+
+	responses := []*diagsapi.ListPendingChangesResponse{
+		{
+			NetworkChange: &networkchange.NetworkChange{
+				ID: "change-1",
+				Status: changetypes.Status{
+					Phase:       changetypes.Phase_CHANGE,
+					State:       changetypes.State_PENDING,
+					Incarnation: 2,
+				},
+			},
+			DeviceChanges: []*devicechange.DeviceChange{
+				{
+					Change: &devicechange.Change{
+						DeviceID:      "device-1",
+						DeviceVersion: "1.0.0",
+					},
+					Status: changetypes.Status{
+						Phase:       changetypes.Phase_CHANGE,
+						State:       changetypes.State_PENDING,
+						Incarnation: 2,
+					},
+				},
+			},
+			PendingSince: time.Now().Add(-10 * time.Minute),
+		},
+	}
+	next := 0
+	pendingClient := MockChangeExtServiceListPendingChangesClient{
+		recvFn: func() (*diagsapi.ListPendingChangesResponse, error) {
+			if next < len(responses) {
+				next++
+				return responses[next-1], nil
+			}
+			return nil, io.EOF
+		},
+	}
+
+	setUpMockClients(MockClientsConfig{
+		listPendingChangesClient: &pendingClient,
+	})
+
+	pendingCmd := getListPendingChangesCommand()
+	assert.NilError(t, pendingCmd.Flags().Set("threshold", "5m"))
+	err := pendingCmd.RunE(pendingCmd, []string{})
+	assert.NilError(t, err)
+
+	assert.Assert(t, LastListPendingChangesRequest.Threshold != nil)
+	assert.Equal(t, *LastListPendingChangesRequest.Threshold, 5*time.Minute)
+	output := outputBuffer.String()
+	assert.Assert(t, strings.Contains(output, "PENDING FOR"))
+	assert.Assert(t, strings.Contains(output, "change-1"))
+	assert.Assert(t, strings.Contains(output, "10m0s"))
+	assert.Assert(t, strings.Contains(output, "device-1 (1.0.0) CHANGE PENDING incarnation 2"))
+}
@@ -16,18 +16,22 @@ package cli
 
 import (
 	"context"
+	"fmt"
 	"github.com/onosproject/onos-api/go/onos/config/admin"
+	networkchange "github.com/onosproject/onos-api/go/onos/config/change/network"
+	adminapi "github.com/onosproject/onos-config/pkg/api/admin"
 	"github.com/onosproject/onos-lib-go/pkg/cli"
 	"github.com/spf13/cobra"
 )
 
 func getRollbackCommand() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "rollback <changeId>",
+		Use:   "rollback {<changeId> | --to <changeId>}",
 		Short: "Rolls-back a network change",
 		Args:  cobra.MaximumNArgs(1),
 		RunE:  runRollbackCommand,
 	}
+	cmd.Flags().String("to", "", "roll back every network change made after the given one, latest first")
 	return cmd
 }
 
@@ -44,6 +48,21 @@ func runRollbackCommand(cmd *cobra.Command, args []string) error {
 		changeID = args[0]
 	}
 
+	toChangeID, _ := cmd.Flags().GetString("to")
+	if toChangeID != "" {
+		if changeID != "" {
+			return fmt.Errorf("a change ID can not be given along with --to")
+		}
+		extClient := adminapi.CreateConfigAdminExtServiceClient(clientConnection)
+		resp, err := extClient.RollbackToNetworkChange(
+			context.Background(), &adminapi.RollbackToNetworkChangeRequest{Name: networkchange.ID(toChangeID)})
+		if err != nil {
+			return err
+		}
+		cli.Output("Rollback success %s\n", resp.Message)
+		return nil
+	}
+
 	resp, err := client.RollbackNetworkChange(
 		context.Background(), &admin.RollbackRequest{Name: changeID})
 	if err != nil {
@@ -37,3 +37,21 @@ func Test_rollback(t *testing.T) {
 	output := outputBuffer.String()
 	assert.Assert(t, strings.Contains(output, "Rollback was successful"))
 }
+
+func Test_rollbackTo(t *testing.T) {
+	outputBuffer := bytes.NewBufferString("")
+	cli.CaptureOutput(outputBuffer)
+
+	setUpMockClients(MockClientsConfig{})
+	rollback := getRollbackCommand()
+	err := rollback.Flags().Set("to", "ABCD1234")
+	assert.NilError(t, err)
+	err = rollback.RunE(rollback, []string{})
+	assert.NilError(t, err)
+	assert.Equal(t, LastCreatedClient.rollBackID, "ABCD1234")
+	output := outputBuffer.String()
+	assert.Assert(t, strings.Contains(output, "Rollback to change was successful"))
+
+	err = rollback.RunE(rollback, []string{"EFGH5678"})
+	assert.ErrorContains(t, err, "can not be given along with --to")
+}
@@ -39,7 +39,7 @@ func Init() {
 // GetCommand returns the root command for the config service.
 func GetCommand() *cobra.Command {
 	cmd := &cobra.Command{
-		Use:   "config {get,add,rollback,snapshot,compact-changes,watch,load} [args]",
+		Use:   "config {get,add,rollback,confirm,lock,unlock,snapshot,compact-changes,requeue,watch,load,diff} [args]",
 		Short: "ONOS configuration subsystem commands",
 	}
 
@@ -49,9 +49,14 @@ func GetCommand() *cobra.Command {
 	cmd.AddCommand(getGetCommand())
 	cmd.AddCommand(getAddCommand())
 	cmd.AddCommand(getRollbackCommand())
+	cmd.AddCommand(getConfirmCommand())
+	cmd.AddCommand(getLockCommand())
+	cmd.AddCommand(getUnlockCommand())
 	cmd.AddCommand(getCompactCommand())
+	cmd.AddCommand(getRequeueCommand())
 	cmd.AddCommand(getWatchCommand())
 	cmd.AddCommand(getLoadCommand())
+	cmd.AddCommand(getDiffCommand())
 	cmd.AddCommand(loglib.GetCommand())
 	return cmd
 }
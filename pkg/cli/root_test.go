@@ -61,12 +61,17 @@ func Test_SubCommands(t *testing.T) {
 	}{
 		{commandName: "Config", expectedShort: "Manage the CLI configuration"},
 		{commandName: "Rollback", expectedShort: "Rolls-back a network change"},
+		{commandName: "Confirm", expectedShort: "Confirms a network change so that it is not rolled back"},
+		{commandName: "Lock", expectedShort: "Locks the configuration of devices against changes from other clients"},
+		{commandName: "Unlock", expectedShort: "Releases locks on the configuration of devices"},
 		{commandName: "Add", expectedShort: "Add a config resource"},
 		{commandName: "Get", expectedShort: "Get config resources"},
 		{commandName: "Compact-Changes", expectedShort: "Takes a snapshot of network and device changes"},
 		{commandName: "Watch", expectedShort: "Watch for updates to a config resource type"},
 		{commandName: "Log", expectedShort: "logging api commands"},
 		{commandName: "Load", expectedShort: "Load configuration from a file"},
+		{commandName: "Diff", expectedShort: "Shows the config differences between two network changes"},
+		{commandName: "Requeue", expectedShort: "Requeues the changes and snapshots that the controllers have given up on, or all of them if no ID is given"},
 	}
 
 	var subCommandsFound = make(map[string]bool)
@@ -0,0 +1,106 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package device
+
+import (
+	"strings"
+
+	changetypes "github.com/onosproject/onos-api/go/onos/config/change"
+	devicechange "github.com/onosproject/onos-api/go/onos/config/change/device"
+)
+
+// getPendingChanges returns the run of consecutive device changes that are waiting to be applied to the device
+// of the given change, in index order, so that they can be pushed to the device together
+func (r *Reconciler) getPendingChanges(change *devicechange.DeviceChange) ([]*devicechange.DeviceChange, error) {
+	ch := make(chan *devicechange.DeviceChange)
+	ctx, err := r.changes.List(change.Change.GetVersionedDeviceID(), ch)
+	if err != nil {
+		return nil, err
+	}
+	defer ctx.Close()
+
+	// Read the whole list so that the store is not left blocked on sending
+	pending := make([]*devicechange.DeviceChange, 0)
+	done := false
+	for deviceChange := range ch {
+		if done {
+			continue
+		}
+		if isPendingChange(deviceChange) {
+			pending = append(pending, deviceChange)
+		} else if indexOfChange(pending, change.ID) >= 0 {
+			done = true
+		} else {
+			pending = pending[:0]
+		}
+	}
+
+	i := indexOfChange(pending, change.ID)
+	if i < 0 {
+		return []*devicechange.DeviceChange{change}, nil
+	}
+	// The change being reconciled may be more recent than the one that was listed
+	pending[i] = change
+	return pending, nil
+}
+
+// indexOfChange returns the position of the change with the given ID in a list of changes, or -1
+func indexOfChange(changes []*devicechange.DeviceChange, id devicechange.ID) int {
+	for i, change := range changes {
+		if change.ID == id {
+			return i
+		}
+	}
+	return -1
+}
+
+// isPendingChange indicates whether the given device change is waiting to be applied to the device
+func isPendingChange(change *devicechange.DeviceChange) bool {
+	return change.Status.Incarnation > 0 &&
+		change.Status.Phase == changetypes.Phase_CHANGE &&
+		change.Status.State == changetypes.State_PENDING
+}
+
+// mergeChanges merges the values of the given device changes, in order, into a single change. A later value
+// for a path replaces an earlier one, and the removal of a path prunes the earlier values at or below it.
+func mergeChanges(changes []*devicechange.DeviceChange) *devicechange.Change {
+	first := changes[0].Change
+	merged := &devicechange.Change{
+		DeviceID:      first.DeviceID,
+		DeviceVersion: first.DeviceVersion,
+		DeviceType:    first.DeviceType,
+		Values:        make([]*devicechange.ChangeValue, 0, len(first.Values)),
+	}
+	for _, change := range changes {
+		for _, value := range change.Change.Values {
+			values := merged.Values[:0]
+			for _, prevValue := range merged.Values {
+				if !isReplacedBy(prevValue, value) {
+					values = append(values, prevValue)
+				}
+			}
+			merged.Values = append(values, value)
+		}
+	}
+	return merged
+}
+
+// isReplacedBy indicates whether an earlier value is superseded by a later value
+func isReplacedBy(prevValue *devicechange.ChangeValue, value *devicechange.ChangeValue) bool {
+	if prevValue.Path == value.Path {
+		return true
+	}
+	return value.Removed && strings.HasPrefix(prevValue.Path, strings.TrimSuffix(value.Path, "/")+"/")
+}
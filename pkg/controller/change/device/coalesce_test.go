@@ -0,0 +1,172 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package device
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	types "github.com/onosproject/onos-api/go/onos/config"
+	changetypes "github.com/onosproject/onos-api/go/onos/config/change"
+	devicechange "github.com/onosproject/onos-api/go/onos/config/change/device"
+	topodevice "github.com/onosproject/onos-config/pkg/device"
+	"github.com/onosproject/onos-config/pkg/southbound"
+	southboundmock "github.com/onosproject/onos-config/pkg/test/mocks/southbound"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMergeChanges(t *testing.T) {
+	deviceChange1 := newChangeInterface(1, device1, v1, 1)
+	deviceChange2 := newChangeInterface(2, device1, v1, 2)
+	deviceChange3 := newChangeInterfaceRemove(3, device1, v1, 1)
+	deviceChange4 := newChange(4, device1, v1)
+	deviceChange4.Change.Values = []*devicechange.ChangeValue{
+		{
+			Path:  eth2Hi,
+			Value: devicechange.NewTypedValueString(healthUp),
+		},
+	}
+
+	merged := mergeChanges([]*devicechange.DeviceChange{deviceChange1, deviceChange2, deviceChange3, deviceChange4})
+	assert.Equal(t, device1, merged.DeviceID)
+	assert.Equal(t, v1, string(merged.DeviceVersion))
+
+	// The removal of eth1 prunes its updates, and the later health indicator of eth2 wins
+	assert.Equal(t, 4, len(merged.Values))
+	assert.Equal(t, eth2Name, merged.Values[0].Path)
+	assert.Equal(t, eth2Enabled, merged.Values[1].Path)
+	assert.Equal(t, "/interfaces/interface[name=eth1]/config/", merged.Values[2].Path)
+	assert.True(t, merged.Values[2].Removed)
+	assert.Equal(t, eth2Hi, merged.Values[3].Path)
+	assert.Equal(t, healthUp, merged.Values[3].Value.ValueToString())
+}
+
+func TestReconcilerCoalesceChanges(t *testing.T) {
+	devices, deviceChanges := newStores(t)
+	defer deviceChanges.Close()
+
+	reconciler := &Reconciler{
+		devices: devices,
+		changes: deviceChanges,
+	}
+
+	// Replace the device-1 target to check that a single set request is made
+	ctrl := gomock.NewController(t)
+	targetCtx := context.TODO()
+	var setRequest *gnmi.SetRequest
+	target := southboundmock.NewMockTargetIf(ctrl)
+	target.EXPECT().Context().Return(&targetCtx).AnyTimes()
+	target.EXPECT().Set(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, request *gnmi.SetRequest) (*gnmi.SetResponse, error) {
+			setRequest = request
+			return &gnmi.SetResponse{}, nil
+		}).Times(1)
+	southbound.Targets[topodevice.ID(device1)] = target
+
+	// Create two device-1 changes that are both being applied, and a third one that is not yet
+	deviceChange1 := newChangeInterface(1, device1, v1, 1)
+	deviceChange2 := newChangeInterface(2, device1, v1, 2)
+	deviceChange3 := newChangeInterface(3, device1, v1, 3)
+	for _, deviceChange := range []*devicechange.DeviceChange{deviceChange1, deviceChange2, deviceChange3} {
+		err := deviceChanges.Create(deviceChange)
+		assert.NoError(t, err)
+	}
+	for _, deviceChange := range []*devicechange.DeviceChange{deviceChange1, deviceChange2} {
+		deviceChange.Status.Incarnation++
+		err := deviceChanges.Update(deviceChange)
+		assert.NoError(t, err)
+	}
+
+	// Reconcile the second change, which should apply the first one along with it
+	_, err := reconciler.Reconcile(types.ID(deviceChange2.ID))
+	assert.NoError(t, err)
+	assert.NotNil(t, setRequest)
+	assert.Equal(t, 6, len(setRequest.Update))
+
+	deviceChange1, err = deviceChanges.Get(deviceChange1.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, changetypes.State_COMPLETE, deviceChange1.Status.State)
+
+	deviceChange2, err = deviceChanges.Get(deviceChange2.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, changetypes.State_COMPLETE, deviceChange2.Status.State)
+
+	deviceChange3, err = deviceChanges.Get(deviceChange3.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, changetypes.State_PENDING, deviceChange3.Status.State)
+	assert.Equal(t, uint64(0), uint64(deviceChange3.Status.Incarnation))
+
+	// Reconciling the first change again does not apply it a second time
+	_, err = reconciler.Reconcile(types.ID(deviceChange1.ID))
+	assert.NoError(t, err)
+}
+
+func TestReconcilerCoalesceChangesFailure(t *testing.T) {
+	devices, deviceChanges := newStores(t)
+	defer deviceChanges.Close()
+
+	reconciler := &Reconciler{
+		devices: devices,
+		changes: deviceChanges,
+	}
+
+	// Replace the device-1 target to refuse the coalesced request and then the second change alone
+	ctrl := gomock.NewController(t)
+	targetCtx := context.TODO()
+	setRequests := make([]*gnmi.SetRequest, 0)
+	target := southboundmock.NewMockTargetIf(ctrl)
+	target.EXPECT().Context().Return(&targetCtx).AnyTimes()
+	target.EXPECT().Set(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, request *gnmi.SetRequest) (*gnmi.SetResponse, error) {
+			setRequests = append(setRequests, request)
+			if len(setRequests) == 2 {
+				return &gnmi.SetResponse{}, nil
+			}
+			return nil, errors.New("refused")
+		}).Times(3)
+	southbound.Targets[topodevice.ID(device1)] = target
+
+	deviceChange1 := newChangeInterface(1, device1, v1, 1)
+	deviceChange2 := newChangeInterface(2, device1, v1, 2)
+	for _, deviceChange := range []*devicechange.DeviceChange{deviceChange1, deviceChange2} {
+		err := deviceChanges.Create(deviceChange)
+		assert.NoError(t, err)
+		deviceChange.Status.Incarnation++
+		err = deviceChanges.Update(deviceChange)
+		assert.NoError(t, err)
+	}
+
+	// The changes are applied together, then one by one
+	_, err := reconciler.Reconcile(types.ID(deviceChange2.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(setRequests))
+	assert.Equal(t, 6, len(setRequests[0].Update))
+	assert.Equal(t, 3, len(setRequests[1].Update))
+	assert.Equal(t, 3, len(setRequests[2].Update))
+
+	// Only the change that the device refused fails
+	deviceChange1, err = deviceChanges.Get(deviceChange1.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, changetypes.State_COMPLETE, deviceChange1.Status.State)
+
+	deviceChange2, err = deviceChanges.Get(deviceChange2.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, changetypes.State_FAILED, deviceChange2.Status.State)
+	assert.Equal(t, changetypes.Reason_ERROR, deviceChange2.Status.Reason)
+	assert.Equal(t, "refused", deviceChange2.Status.Message)
+}
@@ -15,30 +15,38 @@
 package device
 
 import (
+	"context"
 	"fmt"
 	"github.com/onosproject/onos-api/go/onos/topo"
 	"strings"
+	"time"
 
 	types "github.com/onosproject/onos-api/go/onos/config"
 	changetypes "github.com/onosproject/onos-api/go/onos/config/change"
 	devicechange "github.com/onosproject/onos-api/go/onos/config/change/device"
+	networkchange "github.com/onosproject/onos-api/go/onos/config/change/network"
+	devicetype "github.com/onosproject/onos-api/go/onos/config/device"
 	"github.com/onosproject/onos-config/pkg/controller"
 	topodevice "github.com/onosproject/onos-config/pkg/device"
 	"github.com/onosproject/onos-config/pkg/southbound"
 	changestore "github.com/onosproject/onos-config/pkg/store/change/device"
 	devicechangeutils "github.com/onosproject/onos-config/pkg/store/change/device/utils"
+	metadatastore "github.com/onosproject/onos-config/pkg/store/change/metadata"
 	devicestore "github.com/onosproject/onos-config/pkg/store/device"
 	"github.com/onosproject/onos-config/pkg/store/device/cache"
 	mastershipstore "github.com/onosproject/onos-config/pkg/store/mastership"
 	"github.com/onosproject/onos-config/pkg/utils/values"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
 	"github.com/onosproject/onos-lib-go/pkg/logging"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var log = logging.GetLogger("controller", "change", "device")
 
 // NewController returns a new network controller
 func NewController(mastership mastershipstore.Store, devices devicestore.Store,
-	cache cache.Cache, changes changestore.Store) *controller.Controller {
+	cache cache.Cache, changes changestore.Store, networkMetadata metadatastore.Store, timeouts *ApplyTimeouts) *controller.Controller {
 
 	c := controller.NewController("DeviceChange")
 	c.Filter(&controller.MastershipFilter{
@@ -51,8 +59,10 @@ func NewController(mastership mastershipstore.Store, devices devicestore.Store,
 		ChangeStore: changes,
 	})
 	c.Reconcile(&Reconciler{
-		devices: devices,
-		changes: changes,
+		devices:         devices,
+		changes:         changes,
+		networkMetadata: networkMetadata,
+		timeouts:        timeouts,
 	})
 	return c
 }
@@ -68,8 +78,10 @@ func (r *Resolver) Resolve(id types.ID) (topodevice.ID, error) {
 
 // Reconciler is a device change reconciler
 type Reconciler struct {
-	devices devicestore.Store
-	changes changestore.Store
+	devices         devicestore.Store
+	changes         changestore.Store
+	networkMetadata metadatastore.Store
+	timeouts        *ApplyTimeouts
 }
 
 // Reconcile reconciles the state of a device change
@@ -114,36 +126,98 @@ func (r *Reconciler) Reconcile(id types.ID) (controller.Result, error) {
 	return controller.Result{}, nil
 }
 
-// reconcileChange reconciles a CHANGE in the RUNNING state
+// reconcileChange reconciles a CHANGE in the RUNNING state. Consecutive changes that are waiting to be applied to
+// the same device are coalesced into a single set request, but the result is still recorded on each change.
+// If the device refuses the coalesced request, the changes are applied one by one so that only the changes
+// that the device refuses fail.
 func (r *Reconciler) reconcileChange(change *devicechange.DeviceChange) (controller.Result, error) {
-	// Attempt to apply the change to the device and update the change with the result
-	if err := r.doChange(change); err != nil {
-		change.Status.State = changetypes.State_FAILED
-		change.Status.Reason = changetypes.Reason_ERROR
-		change.Status.Message = err.Error()
-		log.Infof("Failing DeviceChange %v", change)
-	} else {
-		change.Status.State = changetypes.State_COMPLETE
-		log.Infof("Completing DeviceChange %v", change)
+	changes, err := r.getPendingChanges(change)
+	if err != nil {
+		return controller.Result{}, err
 	}
 
-	// Update the change status in the store
-	if err := r.changes.Update(change); err != nil {
-		return controller.Result{}, err
+	if len(changes) > 1 {
+		mergedChange := mergeChanges(changes)
+		log.Infof("Applying %d changes to %s as %v", len(changes), mergedChange.DeviceID, mergedChange)
+		if err := r.translateAndSendChange(mergedChange); err != nil {
+			log.Warnf("Failed to apply %d changes to %s together, applying them one by one: %v",
+				len(changes), mergedChange.DeviceID, err)
+		} else {
+			for _, pendingChange := range changes {
+				if err := r.updateChange(pendingChange, nil); err != nil {
+					return controller.Result{}, err
+				}
+			}
+			return controller.Result{}, nil
+		}
+	}
+
+	for _, pendingChange := range changes {
+		// Skip a change that was updated since it was listed, e.g. a change that was canceled
+		latestChange, err := r.changes.Get(pendingChange.ID)
+		if err != nil {
+			return controller.Result{}, err
+		} else if !isSameIncarnation(latestChange, pendingChange) {
+			log.Infof("Skipping DeviceChange %s that is no longer pending", pendingChange.ID)
+			continue
+		}
+
+		// Attempt to apply the change to the device and update the change with the result
+		log.Infof("Applying change %v ", latestChange.Change)
+		err = r.translateAndSendChange(latestChange.Change)
+		if err := r.updateChange(latestChange, err); err != nil {
+			return controller.Result{}, err
+		}
 	}
 	return controller.Result{}, nil
 }
 
-// doChange pushes the given change to the device
-func (r *Reconciler) doChange(change *devicechange.DeviceChange) error {
-	log.Infof("Applying change %v ", change.Change)
-	return r.translateAndSendChange(change.Change)
+// updateChange records the result of applying a change on the change as it is now in the store, unless the
+// change is no longer the pending incarnation that was applied
+func (r *Reconciler) updateChange(change *devicechange.DeviceChange, err error) error {
+	latestChange, getErr := r.changes.Get(change.ID)
+	if getErr != nil {
+		return getErr
+	} else if !isSameIncarnation(latestChange, change) {
+		log.Infof("Not recording the result of DeviceChange %s that is no longer pending", change.ID)
+		return nil
+	}
+
+	if err != nil {
+		if errors.IsTimeout(err) {
+			if err := r.recordApplyTimeout(latestChange); err != nil {
+				return err
+			}
+		}
+		latestChange.Status.State = changetypes.State_FAILED
+		latestChange.Status.Reason = changetypes.Reason_ERROR
+		latestChange.Status.Message = err.Error()
+		log.Infof("Failing DeviceChange %v", latestChange)
+	} else {
+		latestChange.Status.State = changetypes.State_COMPLETE
+		log.Infof("Completing DeviceChange %v", latestChange)
+	}
+
+	// Update the change status in the store
+	return r.changes.Update(latestChange)
+}
+
+// isSameIncarnation indicates whether the latest version of a change is still pending in the same
+// incarnation as the given change
+func isSameIncarnation(latestChange *devicechange.DeviceChange, change *devicechange.DeviceChange) bool {
+	return latestChange != nil && isPendingChange(latestChange) &&
+		latestChange.Status.Incarnation == change.Status.Incarnation
 }
 
 // reconcileRollback reconciles a ROLLBACK in the RUNNING state
 func (r *Reconciler) reconcileRollback(change *devicechange.DeviceChange) (controller.Result, error) {
 	// Attempt to roll back the change to the device and update the change with the result
 	if err := r.doRollback(change); err != nil {
+		if errors.IsTimeout(err) {
+			if err := r.recordApplyTimeout(change); err != nil {
+				return controller.Result{}, err
+			}
+		}
 		change.Status.State = changetypes.State_FAILED
 		change.Status.Reason = changetypes.Reason_ERROR
 		change.Status.Message = err.Error()
@@ -183,15 +257,49 @@ func (r *Reconciler) translateAndSendChange(change *devicechange.Change) error {
 		return fmt.Errorf("Device not connected %s, error %s", change.DeviceID, err.Error())
 	}
 	log.Infof("Target for device %s: %v %v", change.DeviceID, deviceTarget, deviceTarget.Context())
-	setResponse, err := deviceTarget.Set(*deviceTarget.Context(), setRequest)
+	// Do not wait forever for a device that accepted the connection but does not answer
+	timeout := r.timeouts.Get(change.DeviceType)
+	ctx, cancel := context.WithTimeout(*deviceTarget.Context(), timeout)
+	defer cancel()
+	setResponse, err := deviceTarget.Set(ctx, setRequest)
 	if err != nil {
 		log.Error("Error while doing set: ", err)
+		if ctx.Err() == context.DeadlineExceeded || status.Code(err) == codes.DeadlineExceeded {
+			return errors.NewTimeout("device %s did not answer the set request within %v", change.DeviceID, timeout)
+		}
 		return err
 	}
 	log.Info(change.DeviceID, " SetResponse ", setResponse)
 	return nil
 }
 
+// recordApplyTimeout records in the metadata of the network change of a device change that the device did not
+// answer within its apply timeout. The metadata is read again if the network change controller updated it meanwhile.
+func (r *Reconciler) recordApplyTimeout(change *devicechange.DeviceChange) error {
+	networkChangeID := networkchange.ID(change.NetworkChange.ID)
+	for {
+		changeMetadata, err := r.networkMetadata.Get(networkChangeID)
+		if err != nil {
+			return err
+		} else if changeMetadata == nil {
+			changeMetadata = &metadatastore.Metadata{ID: networkChangeID}
+		}
+		if changeMetadata.ApplyTimeouts == nil {
+			changeMetadata.ApplyTimeouts = make(map[devicetype.ID]time.Duration)
+		}
+		changeMetadata.ApplyTimeouts[change.Change.DeviceID] = r.timeouts.Get(change.Change.DeviceType)
+
+		if changeMetadata.Revision == 0 {
+			err = r.networkMetadata.Create(changeMetadata)
+		} else {
+			err = r.networkMetadata.Update(changeMetadata)
+		}
+		if err == nil || !(errors.IsConflict(err) || errors.IsAlreadyExists(err)) {
+			return err
+		}
+	}
+}
+
 func getProtocolState(device *topodevice.Device) topo.ChannelState {
 	// Find the gNMI protocol state for the device
 	var protocol *topo.ProtocolState
@@ -0,0 +1,92 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package device
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	devicetype "github.com/onosproject/onos-api/go/onos/config/device"
+)
+
+// DefaultApplyTimeout is how long a device is given to answer a set request, unless another timeout is
+// configured for its type
+const DefaultApplyTimeout = time.Minute
+
+// ApplyTimeouts are how long devices are given to answer a set request, by device type. A change that is not
+// answered in time fails, and is rolled back. The device is then listed in the ApplyTimeouts of the metadata of
+// the network change.
+// It is a flag.Value, set with either a duration for the default timeout or <device type>=<duration>.
+type ApplyTimeouts struct {
+	Default     time.Duration
+	DeviceTypes map[devicetype.Type]time.Duration
+}
+
+// NewApplyTimeouts returns the default apply timeouts
+func NewApplyTimeouts() *ApplyTimeouts {
+	return &ApplyTimeouts{
+		Default:     DefaultApplyTimeout,
+		DeviceTypes: make(map[devicetype.Type]time.Duration),
+	}
+}
+
+// Get returns the apply timeout of the given device type
+func (t *ApplyTimeouts) Get(deviceType devicetype.Type) time.Duration {
+	if t == nil {
+		return DefaultApplyTimeout
+	}
+	if timeout, ok := t.DeviceTypes[deviceType]; ok {
+		return timeout
+	}
+	return t.Default
+}
+
+func (t *ApplyTimeouts) String() string {
+	if t == nil {
+		return DefaultApplyTimeout.String()
+	}
+	timeouts := []string{t.Default.String()}
+	for deviceType, timeout := range t.DeviceTypes {
+		timeouts = append(timeouts, fmt.Sprintf("%s=%s", deviceType, timeout))
+	}
+	sort.Strings(timeouts[1:])
+	return strings.Join(timeouts, ",")
+}
+
+// Set sets either the default timeout, given as a duration, or the timeout of a device type, given as
+// <device type>=<duration>
+func (t *ApplyTimeouts) Set(value string) error {
+	deviceType := ""
+	if i := strings.LastIndex(value, "="); i >= 0 {
+		deviceType, value = value[:i], value[i+1:]
+		if deviceType == "" {
+			return fmt.Errorf("no device type given for timeout %s", value)
+		}
+	}
+	timeout, err := time.ParseDuration(value)
+	if err != nil {
+		return err
+	} else if timeout <= 0 {
+		return fmt.Errorf("timeout %s must be positive", value)
+	}
+	if deviceType == "" {
+		t.Default = timeout
+	} else {
+		t.DeviceTypes[devicetype.Type(deviceType)] = timeout
+	}
+	return nil
+}
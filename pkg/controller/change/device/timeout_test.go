@@ -0,0 +1,104 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package device
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	types "github.com/onosproject/onos-api/go/onos/config"
+	changetypes "github.com/onosproject/onos-api/go/onos/config/change"
+	networkchange "github.com/onosproject/onos-api/go/onos/config/change/network"
+	devicetype "github.com/onosproject/onos-api/go/onos/config/device"
+	topodevice "github.com/onosproject/onos-config/pkg/device"
+	"github.com/onosproject/onos-config/pkg/southbound"
+	metadatastore "github.com/onosproject/onos-config/pkg/store/change/metadata"
+	southboundmock "github.com/onosproject/onos-config/pkg/test/mocks/southbound"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplyTimeouts(t *testing.T) {
+	timeouts := NewApplyTimeouts()
+	assert.Equal(t, DefaultApplyTimeout, timeouts.Get("Devicesim"))
+
+	assert.NoError(t, timeouts.Set("30s"))
+	assert.NoError(t, timeouts.Set("Stratum=2m"))
+	assert.Equal(t, 30*time.Second, timeouts.Get("Devicesim"))
+	assert.Equal(t, 2*time.Minute, timeouts.Get("Stratum"))
+	assert.Equal(t, "30s,Stratum=2m0s", timeouts.String())
+
+	assert.Error(t, timeouts.Set("=2m"))
+	assert.Error(t, timeouts.Set("Stratum=soon"))
+	assert.Error(t, timeouts.Set("-1s"))
+
+	var noTimeouts *ApplyTimeouts
+	assert.Equal(t, DefaultApplyTimeout, noTimeouts.Get(devicetype.Type("Devicesim")))
+}
+
+func TestReconcilerChangeTimeout(t *testing.T) {
+	devices, deviceChanges := newStores(t)
+	defer deviceChanges.Close()
+
+	networkMetadata, err := metadatastore.NewLocalStore()
+	assert.NoError(t, err)
+	defer networkMetadata.Close()
+
+	timeouts := NewApplyTimeouts()
+	assert.NoError(t, timeouts.Set("100ms"))
+	reconciler := &Reconciler{
+		devices:         devices,
+		changes:         deviceChanges,
+		networkMetadata: networkMetadata,
+		timeouts:        timeouts,
+	}
+
+	// Replace the device-1 target with one that never answers a set request
+	ctrl := gomock.NewController(t)
+	targetCtx := context.TODO()
+	target := southboundmock.NewMockTargetIf(ctrl)
+	target.EXPECT().Context().Return(&targetCtx).AnyTimes()
+	target.EXPECT().Set(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, request *gnmi.SetRequest) (*gnmi.SetResponse, error) {
+			<-ctx.Done()
+			return nil, ctx.Err()
+		}).Times(1)
+	southbound.Targets[topodevice.ID(device1)] = target
+
+	deviceChange1 := newChange(1, device1, v1)
+	err = deviceChanges.Create(deviceChange1)
+	assert.NoError(t, err)
+	deviceChange1.Status.Incarnation++
+	err = deviceChanges.Update(deviceChange1)
+	assert.NoError(t, err)
+
+	_, err = reconciler.Reconcile(types.ID(deviceChange1.ID))
+	assert.NoError(t, err)
+
+	// The change should fail, so that the network change is rolled back
+	deviceChange1, err = deviceChanges.Get(deviceChange1.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, changetypes.State_FAILED, deviceChange1.Status.State)
+	assert.Equal(t, changetypes.Reason_ERROR, deviceChange1.Status.Reason)
+	assert.Equal(t, "device device-1 did not answer the set request within 100ms", deviceChange1.Status.Message)
+
+	// The timeout is recorded in the metadata of the network change
+	changeMetadata, err := networkMetadata.Get(networkchange.ID(deviceChange1.NetworkChange.ID))
+	assert.NoError(t, err)
+	assert.NotNil(t, changeMetadata)
+	assert.Equal(t, map[devicetype.ID]time.Duration{device1: 100 * time.Millisecond}, changeMetadata.ApplyTimeouts)
+}
@@ -15,18 +15,26 @@
 package network
 
 import (
+	"fmt"
+	"strings"
+	"time"
+
 	types "github.com/onosproject/onos-api/go/onos/config"
 	changetypes "github.com/onosproject/onos-api/go/onos/config/change"
 	devicechange "github.com/onosproject/onos-api/go/onos/config/change/device"
 	networkchange "github.com/onosproject/onos-api/go/onos/config/change/network"
+	devicetype "github.com/onosproject/onos-api/go/onos/config/device"
 	"github.com/onosproject/onos-api/go/onos/topo"
 	"github.com/onosproject/onos-config/pkg/controller"
 	devicetopo "github.com/onosproject/onos-config/pkg/device"
 	devicechangestore "github.com/onosproject/onos-config/pkg/store/change/device"
+	metadatastore "github.com/onosproject/onos-config/pkg/store/change/metadata"
 	networkchangestore "github.com/onosproject/onos-config/pkg/store/change/network"
+	networkchangeutils "github.com/onosproject/onos-config/pkg/store/change/network/utils"
 	devicestore "github.com/onosproject/onos-config/pkg/store/device"
 	"github.com/onosproject/onos-config/pkg/store/device/cache"
 	leadershipstore "github.com/onosproject/onos-config/pkg/store/leadership"
+	lockstore "github.com/onosproject/onos-config/pkg/store/lock"
 	"github.com/onosproject/onos-lib-go/pkg/logging"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
@@ -34,8 +42,11 @@ import (
 
 var log = logging.GetLogger("controller", "change", "network")
 
+// lockRetryInterval is the longest a change held by a device lock waits before checking the lock again
+const lockRetryInterval = 10 * time.Second
+
 // NewController returns a new config controller
-func NewController(leadership leadershipstore.Store, deviceCache cache.Cache, devices devicestore.Store, networkChanges networkchangestore.Store, deviceChanges devicechangestore.Store) *controller.Controller {
+func NewController(leadership leadershipstore.Store, deviceCache cache.Cache, devices devicestore.Store, networkChanges networkchangestore.Store, networkMetadata metadatastore.Store, deviceChanges devicechangestore.Store, locks lockstore.Store) *controller.Controller {
 	c := controller.NewController("NetworkChange")
 	c.Activate(&controller.LeadershipActivator{
 		Store: leadership,
@@ -49,18 +60,22 @@ func NewController(leadership leadershipstore.Store, deviceCache cache.Cache, de
 		ChangeStore: deviceChanges,
 	})
 	c.Reconcile(&Reconciler{
-		networkChanges: networkChanges,
-		deviceChanges:  deviceChanges,
-		devices:        devices,
+		networkChanges:  networkChanges,
+		networkMetadata: networkMetadata,
+		deviceChanges:   deviceChanges,
+		devices:         devices,
+		locks:           locks,
 	})
 	return c
 }
 
 // Reconciler is a config reconciler
 type Reconciler struct {
-	networkChanges networkchangestore.Store
-	deviceChanges  devicechangestore.Store
-	devices        devicestore.Store
+	networkChanges  networkchangestore.Store
+	networkMetadata metadatastore.Store
+	deviceChanges   devicechangestore.Store
+	devices         devicestore.Store
+	locks           lockstore.Store
 }
 
 // Reconcile reconciles the state of a network configuration
@@ -97,6 +112,31 @@ func (r *Reconciler) reconcileChange(change *networkchange.NetworkChange) (contr
 }
 
 func (r *Reconciler) reconcilePendingChange(change *networkchange.NetworkChange) (controller.Result, error) {
+	// Get the attributes of the change that are kept apart from it, if it has any
+	metadata, err := r.networkMetadata.Get(change.ID)
+	if err != nil {
+		return controller.Result{}, err
+	}
+
+	// If the change was made by a compare-and-set, fail it if a change made after the index it was based on
+	// touches the same device paths. All the changes before it are known, so the check is not racy.
+	if change.Status.Incarnation == 0 && metadata != nil && metadata.BaseIndex != nil {
+		conflicts, err := r.getConflictingChanges(change, *metadata.BaseIndex)
+		if err != nil {
+			return controller.Result{}, err
+		} else if len(conflicts) > 0 {
+			change.Status.State = changetypes.State_FAILED
+			change.Status.Reason = changetypes.Reason_ERROR
+			change.Status.Message = fmt.Sprintf("changes made after index %d intersect with the change: %s",
+				*metadata.BaseIndex, strings.Join(conflicts, "; "))
+			log.Infof("Failing NetworkChange %v", change)
+			if err := r.networkChanges.Update(change); err != nil {
+				return controller.Result{}, err
+			}
+			return controller.Result{}, nil
+		}
+	}
+
 	// Create device changes if necessary
 	if !hasDeviceChanges(change) {
 		return r.createDeviceChanges(change)
@@ -109,13 +149,13 @@ func (r *Reconciler) reconcilePendingChange(change *networkchange.NetworkChange)
 	}
 
 	// Ensure device changes are pending for the current incarnation
-	changed, err := r.ensureDeviceChangesPending(change, deviceChanges)
+	changed, err := r.ensureDeviceChangesPending(change, metadata, deviceChanges)
 	if changed || err != nil {
 		return controller.Result{}, err
 	}
 
 	// If the network change can be applied, apply it by incrementing the incarnation number
-	apply, err := r.canTryChange(change, deviceChanges)
+	apply, err := r.canTryChange(change, metadata, deviceChanges)
 	if err != nil {
 		return controller.Result{}, err
 	} else if apply {
@@ -130,9 +170,33 @@ func (r *Reconciler) reconcilePendingChange(change *networkchange.NetworkChange)
 		return controller.Result{}, nil
 	}
 
+	// If the change is scheduled to be applied later, requeue it for when it is due
+	if wait := getScheduleDelay(metadata); wait > 0 {
+		return controller.Result{Requeue: types.ID(change.ID), RequeueAfter: wait}, nil
+	}
+
 	// If all device changes are complete, complete the network change
-	if r.isDeviceChangesComplete(change, deviceChanges) {
+	if r.isDeviceChangesComplete(change, metadata, deviceChanges) {
+		// Record the final status of the change on each device. If the change must be confirmed, start the
+		// confirmation timeout once it is complete.
+		if metadata == nil {
+			metadata = &metadatastore.Metadata{ID: change.ID}
+		}
+		metadata.Devices = getDeviceResults(deviceChanges)
+		if metadata.ConfirmTimeout != nil {
+			deadline := time.Now().Add(*metadata.ConfirmTimeout)
+			metadata.ConfirmDeadline = &deadline
+		}
+		if metadata.Revision == 0 {
+			err = r.networkMetadata.Create(metadata)
+		} else {
+			err = r.networkMetadata.Update(metadata)
+		}
+		if err != nil {
+			return controller.Result{}, err
+		}
 		change.Status.State = changetypes.State_COMPLETE
+		change.Status.Message = getDeferredDevicesMessage(metadata, len(deviceChanges))
 		log.Infof("Completing NetworkChange %v", change)
 		if err := r.networkChanges.Update(change); err != nil {
 			return controller.Result{}, err
@@ -141,14 +205,66 @@ func (r *Reconciler) reconcilePendingChange(change *networkchange.NetworkChange)
 	}
 
 	// If any device change has failed, roll back all device changes
-	if r.isDeviceChangesFailed(change, deviceChanges) {
-		return r.ensureDeviceChangeRollbacks(change, deviceChanges)
+	if r.isDeviceChangesFailed(change, metadata, deviceChanges) {
+		return r.ensureDeviceChangeRollbacks(change, metadata, deviceChanges)
+	}
+
+	// If the change is held by an earlier change to its devices that awaits confirmation, report it and have the
+	// earlier change reconciled when its deadline is due
+	unconfirmedChange, unconfirmedMetadata, err := r.getUnconfirmedChange(change)
+	if err != nil {
+		return controller.Result{}, err
+	} else if unconfirmedChange != nil {
+		if message := getUnconfirmedMessage(unconfirmedChange); message != change.Status.Message {
+			change.Status.Message = message
+			log.Infof("Holding NetworkChange %v", change)
+			if err := r.networkChanges.Update(change); err != nil {
+				return controller.Result{}, err
+			}
+			return controller.Result{}, nil
+		}
+		return controller.Result{
+			Requeue:      types.ID(unconfirmedChange.ID),
+			RequeueAfter: time.Until(*unconfirmedMetadata.ConfirmDeadline),
+		}, nil
+	}
+
+	// If the change is held by another client's lock on one of its devices, check the lock again later since
+	// releasing it does not trigger a reconciliation
+	lock, err := r.getConflictingLock(change, metadata)
+	if err != nil {
+		return controller.Result{}, err
+	} else if lock != nil {
+		wait := time.Until(lock.Expires)
+		if wait > lockRetryInterval {
+			wait = lockRetryInterval
+		}
+		return controller.Result{Requeue: types.ID(change.ID), RequeueAfter: wait}, nil
 	}
 	return controller.Result{}, nil
 }
 
 // reconcileCompleteChange reconciles a change in the COMPLETE state during the CHANGE phase
 func (r *Reconciler) reconcileCompleteChange(change *networkchange.NetworkChange) (controller.Result, error) {
+	metadata, err := r.networkMetadata.Get(change.ID)
+	if err != nil {
+		return controller.Result{}, err
+	}
+
+	// If the change was deferred on some of its devices, apply it to each of them once it is connected and
+	// record the result once it has been applied
+	if hasPendingDevices(metadata) {
+		updated, err := r.ensureDeferredDeviceChanges(change, metadata)
+		if updated || err != nil {
+			return controller.Result{}, err
+		}
+	}
+
+	// If the change has not been confirmed yet, hold later changes until it is confirmed or rolled back
+	if isAwaitingConfirmation(metadata) {
+		return r.reconcileUnconfirmedChange(change, metadata)
+	}
+
 	nextChange, err := r.networkChanges.GetNext(change.Index)
 	if err != nil {
 		return controller.Result{}, err
@@ -170,6 +286,90 @@ func (r *Reconciler) reconcileCompleteChange(change *networkchange.NetworkChange
 	return controller.Result{}, nil
 }
 
+// reconcileUnconfirmedChange reconciles a complete change that is awaiting confirmation. Later changes to the
+// same devices are held until the change is confirmed, and the change is rolled back once its deadline has passed.
+func (r *Reconciler) reconcileUnconfirmedChange(change *networkchange.NetworkChange, metadata *metadatastore.Metadata) (controller.Result, error) {
+	wait := time.Until(*metadata.ConfirmDeadline)
+	if wait <= 0 {
+		rolledBack := time.Now()
+		metadata.RolledBack = &rolledBack
+		if err := r.networkMetadata.Update(metadata); err != nil {
+			return controller.Result{}, err
+		}
+		change.Status.Incarnation++
+		change.Status.Phase = changetypes.Phase_ROLLBACK
+		change.Status.State = changetypes.State_PENDING
+		change.Status.Reason = changetypes.Reason_NONE
+		change.Status.Message = fmt.Sprintf("Not confirmed within %v", *metadata.ConfirmTimeout)
+		log.Infof("Rolling back unconfirmed NetworkChange %v", change)
+		if err := r.networkChanges.Update(change); err != nil {
+			return controller.Result{}, err
+		}
+		return controller.Result{}, nil
+	}
+
+	// Requeue the next pending change to the same devices so that it reports that it is held, and requeues this
+	// change for when its deadline is due. If there is none, requeue this change for its deadline.
+	nextChange, err := r.networkChanges.GetNext(change.Index)
+	if err != nil {
+		return controller.Result{}, err
+	}
+	for nextChange != nil {
+		if isIntersectingChange(change, nextChange) {
+			if nextChange.Status.Phase == changetypes.Phase_CHANGE && nextChange.Status.State == changetypes.State_PENDING &&
+				nextChange.Status.Message != getUnconfirmedMessage(change) {
+				return controller.Result{Requeue: types.ID(nextChange.ID)}, nil
+			}
+			break
+		}
+		nextChange, err = r.networkChanges.GetNext(nextChange.Index)
+		if err != nil {
+			return controller.Result{}, err
+		}
+	}
+	return controller.Result{Requeue: types.ID(change.ID), RequeueAfter: wait}, nil
+}
+
+// getUnconfirmedChange returns the last earlier change to the devices of the given change if it is complete and
+// awaiting confirmation, along with its metadata
+func (r *Reconciler) getUnconfirmedChange(change *networkchange.NetworkChange) (*networkchange.NetworkChange, *metadatastore.Metadata, error) {
+	prevChange, err := r.networkChanges.GetPrev(change.Index)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	for prevChange != nil {
+		if isIntersectingChange(change, prevChange) && prevChange.Status.Phase == changetypes.Phase_CHANGE {
+			if prevChange.Status.State != changetypes.State_COMPLETE {
+				return nil, nil, nil
+			}
+			metadata, err := r.networkMetadata.Get(prevChange.ID)
+			if err != nil {
+				return nil, nil, err
+			} else if isAwaitingConfirmation(metadata) {
+				return prevChange, metadata, nil
+			}
+			return nil, nil, nil
+		}
+
+		prevChange, err = r.networkChanges.GetPrev(prevChange.Index)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	return nil, nil, nil
+}
+
+// getUnconfirmedMessage returns the message of a change held by the given change awaiting confirmation
+func getUnconfirmedMessage(unconfirmedChange *networkchange.NetworkChange) string {
+	return fmt.Sprintf("Waiting for NetworkChange %s to be confirmed", unconfirmedChange.ID)
+}
+
+// isAwaitingConfirmation indicates whether the complete change with the given metadata still has to be confirmed
+func isAwaitingConfirmation(metadata *metadatastore.Metadata) bool {
+	return metadata != nil && metadata.ConfirmTimeout != nil && metadata.ConfirmDeadline != nil && !metadata.Confirmed
+}
+
 // hasDeviceChanges indicates whether the given change has created device changes
 func hasDeviceChanges(change *networkchange.NetworkChange) bool {
 	return change.Refs != nil && len(change.Refs) > 0
@@ -177,12 +377,13 @@ func hasDeviceChanges(change *networkchange.NetworkChange) bool {
 
 // createDeviceChanges creates device changes in sequential order
 func (r *Reconciler) createDeviceChanges(networkChange *networkchange.NetworkChange) (controller.Result, error) {
-	// If the previous network change has not created device changes, requeue to wait for changes to be propagated
+	// If the previous network change has not created device changes, requeue to wait for changes to be propagated.
+	// A change that failed before creating device changes, e.g. a compare-and-set change, never creates them.
 	// TODO devices changes should be written to stores by index to avoid having to manage index order
 	prevChange, err := r.networkChanges.GetByIndex(networkChange.Index - 1)
 	if err != nil {
 		return controller.Result{}, err
-	} else if prevChange != nil && !hasDeviceChanges(prevChange) {
+	} else if prevChange != nil && !hasDeviceChanges(prevChange) && prevChange.Status.State != changetypes.State_FAILED {
 		return controller.Result{Requeue: types.ID(networkChange.ID)}, nil
 	}
 
@@ -215,10 +416,20 @@ func (r *Reconciler) createDeviceChanges(networkChange *networkchange.NetworkCha
 }
 
 // canTryChange returns a bool indicating whether the change can be attempted
-func (r *Reconciler) canTryChange(change *networkchange.NetworkChange, deviceChanges []*devicechange.DeviceChange) (bool, error) {
+func (r *Reconciler) canTryChange(change *networkchange.NetworkChange, metadata *metadatastore.Metadata, deviceChanges []*devicechange.DeviceChange) (bool, error) {
+	// If the change is scheduled, hold it in PENDING until it is due. Since changes to the same devices are
+	// applied in order, a later change to any of its devices is held along with it.
+	if wait := getScheduleDelay(metadata); wait > 0 {
+		log.Infof("Cannot apply NetworkChange %v: scheduled for %v", change.ID, metadata.NotBefore)
+		return false, nil
+	}
+
 	// If the incarnation number is positive, verify all device changes have been rolled back
 	if change.Status.Incarnation > 0 {
 		for _, deviceChange := range deviceChanges {
+			if isDeferredDevice(metadata, deviceChange.Change.DeviceID) {
+				continue
+			}
 			if deviceChange.Status.Incarnation != change.Status.Incarnation ||
 				deviceChange.Status.Phase != changetypes.Phase_ROLLBACK ||
 				deviceChange.Status.State != changetypes.State_COMPLETE {
@@ -227,19 +438,32 @@ func (r *Reconciler) canTryChange(change *networkchange.NetworkChange, deviceCha
 		}
 	}
 
-	// First, check if the devices affected by the change are available
-	for _, deviceChange := range change.Changes {
-		device, err := r.devices.Get(devicetopo.ID(deviceChange.DeviceID))
-		if err != nil && status.Code(err) != codes.NotFound {
-			return false, err
-		} else if device == nil {
-			return false, nil
-		}
-		state := getProtocolState(device)
-		if state != topo.ChannelState_CONNECTED {
-			log.Infof("Cannot apply NetworkChange %v: %v is offline", change.ID, deviceChange.DeviceID)
-			return false, nil
-		}
+	// First, check if enough of the devices affected by the change are available for its apply policy
+	connected, err := r.getConnectedDevices(change)
+	if err != nil {
+		return false, err
+	} else if !hasEnoughDevices(metadata, len(connected), len(change.Changes)) {
+		log.Infof("Cannot apply NetworkChange %v: %d of %d devices are connected, policy %s", change.ID,
+			len(connected), len(change.Changes), getApplyPolicy(metadata))
+		return false, nil
+	}
+
+	// Hold the change while another client has locked any of its devices
+	lock, err := r.getConflictingLock(change, metadata)
+	if err != nil {
+		return false, err
+	} else if lock != nil {
+		log.Infof("Cannot apply NetworkChange %v: %v is locked by %s", change.ID, lock.DeviceID, lock.Owner)
+		return false, nil
+	}
+
+	// Hold the change while an earlier change that was deferred on any of its devices has not been applied to it
+	deferredChange, err := r.getDeferredChange(change)
+	if err != nil {
+		return false, err
+	} else if deferredChange != nil {
+		log.Infof("Cannot apply NetworkChange %v: %v has not been applied to all of its devices", change.ID, deferredChange.ID)
+		return false, nil
 	}
 
 	// If the devices are available, ensure the change does not intersect prior changes
@@ -254,8 +478,28 @@ func (r *Reconciler) canTryChange(change *networkchange.NetworkChange, deviceCha
 			// If the change is in the CHANGE phase, verify it's complete
 			// If the change is in the ROLLBACK phase, verify it's complete but continue iterating
 			// back to the last CHANGE phase change
+			// A complete change that is awaiting confirmation holds the change until it is confirmed or rolled back
+			// A change that is being applied does not hold a change to other paths of its devices, so that the
+			// device change controller may push both of them to the device in a single set request
 			if prevChange.Status.Phase == changetypes.Phase_CHANGE {
-				return prevChange.Status.State != changetypes.State_PENDING, nil
+				if prevChange.Status.State == changetypes.State_PENDING {
+					if prevChange.Status.Incarnation == 0 || len(networkchangeutils.GetIntersectingPaths(prevChange, change)) > 0 {
+						return false, nil
+					}
+					prevChange, err = r.networkChanges.GetPrev(prevChange.Index)
+					if err != nil {
+						return false, err
+					}
+					continue
+				}
+				prevMetadata, err := r.networkMetadata.Get(prevChange.ID)
+				if err != nil {
+					return false, err
+				} else if isAwaitingConfirmation(prevMetadata) {
+					log.Infof("Cannot apply NetworkChange %v: %v is awaiting confirmation", change.ID, prevChange.ID)
+					return false, nil
+				}
+				return true, nil
 			} else if prevChange.Status.Phase == changetypes.Phase_ROLLBACK {
 				if prevChange.Status.State == changetypes.State_PENDING {
 					return false, nil
@@ -271,16 +515,112 @@ func (r *Reconciler) canTryChange(change *networkchange.NetworkChange, deviceCha
 	return true, nil
 }
 
-// ensureDeviceChangesPending ensures device changes are pending
-func (r *Reconciler) ensureDeviceChangesPending(networkChange *networkchange.NetworkChange, changes []*devicechange.DeviceChange) (bool, error) {
+// getConnectedDevices returns the devices affected by the change that are connected
+func (r *Reconciler) getConnectedDevices(change *networkchange.NetworkChange) (map[devicetype.ID]bool, error) {
+	connected := make(map[devicetype.ID]bool)
+	for _, deviceChange := range change.Changes {
+		device, err := r.devices.Get(devicetopo.ID(deviceChange.DeviceID))
+		if err != nil && status.Code(err) != codes.NotFound {
+			return nil, err
+		} else if device == nil {
+			continue
+		}
+		state := getProtocolState(device)
+		if state != topo.ChannelState_CONNECTED {
+			log.Infof("NetworkChange %v: %v is offline", change.ID, deviceChange.DeviceID)
+			continue
+		}
+		connected[deviceChange.DeviceID] = true
+	}
+	return connected, nil
+}
+
+// hasEnoughDevices indicates whether enough of the devices affected by the change with the given metadata are
+// connected for the change to be applied under its policy. An atomic change needs all of its devices,
+// a best-effort change any one of them, and a quorum change the given percentage of them.
+func hasEnoughDevices(metadata *metadatastore.Metadata, connected int, devices int) bool {
+	switch getApplyPolicy(metadata) {
+	case metadatastore.ApplyPolicyBestEffort:
+		return connected > 0
+	case metadatastore.ApplyPolicyQuorum:
+		return connected > 0 && connected*100 >= int(metadata.Quorum)*devices
+	default:
+		return connected == devices
+	}
+}
+
+// getApplyPolicy returns the apply policy of the change with the given metadata
+func getApplyPolicy(metadata *metadatastore.Metadata) metadatastore.ApplyPolicy {
+	if metadata == nil {
+		return metadatastore.ApplyPolicyAtomic
+	}
+	return metadata.ApplyPolicy
+}
+
+// getConflictingLock returns a lock held on one of the devices of the change by a client other than the one
+// that made the change, or nil if there is none
+func (r *Reconciler) getConflictingLock(change *networkchange.NetworkChange, metadata *metadatastore.Metadata) (*lockstore.DeviceLock, error) {
+	var username string
+	if metadata != nil {
+		username = metadata.Username
+	}
+	for _, deviceChange := range change.Changes {
+		lock, err := r.locks.Get(deviceChange.DeviceID)
+		if err != nil {
+			return nil, err
+		} else if lock != nil && lock.Owner != username {
+			return lock, nil
+		}
+	}
+	return nil, nil
+}
+
+// getScheduleDelay returns the time remaining until the change with the given metadata may be applied
+func getScheduleDelay(metadata *metadatastore.Metadata) time.Duration {
+	if metadata == nil || metadata.NotBefore == nil {
+		return 0
+	}
+	return time.Until(*metadata.NotBefore)
+}
+
+// ensureDeviceChangesPending ensures device changes are pending. Unless the change is atomic, the change to a
+// device that is not connected is deferred: it is left PENDING in its earlier incarnation, so that it is not
+// tried on the device, and the device is recorded in the metadata of the network change until it connects.
+func (r *Reconciler) ensureDeviceChangesPending(networkChange *networkchange.NetworkChange, metadata *metadatastore.Metadata, changes []*devicechange.DeviceChange) (bool, error) {
 	// Ensure all device changes are being applied
 	updated := false
+	var connected map[devicetype.ID]bool
 	for _, deviceChange := range changes {
 		if deviceChange.Status.Incarnation < networkChange.Status.Incarnation {
+			if getApplyPolicy(metadata) != metadatastore.ApplyPolicyAtomic {
+				if connected == nil {
+					var err error
+					if connected, err = r.getConnectedDevices(networkChange); err != nil {
+						return false, err
+					}
+				}
+				deviceID := deviceChange.Change.DeviceID
+				if !connected[deviceID] {
+					if !isDeferredDevice(metadata, deviceID) {
+						metadata.Deferred = append(metadata.Deferred, deviceID)
+						log.Infof("Deferring DeviceChange %v until %s is connected", deviceChange.ID, deviceID)
+						if err := r.networkMetadata.Update(metadata); err != nil {
+							return false, err
+						}
+					}
+					continue
+				} else if isDeferredDevice(metadata, deviceID) {
+					metadata.Deferred = removeDevice(metadata.Deferred, deviceID)
+					if err := r.networkMetadata.Update(metadata); err != nil {
+						return false, err
+					}
+				}
+			}
 			deviceChange.Status.Incarnation = networkChange.Status.Incarnation
 			deviceChange.Status.Phase = changetypes.Phase_CHANGE
 			deviceChange.Status.State = changetypes.State_PENDING
 			deviceChange.Status.Reason = changetypes.Reason_NONE
+			deviceChange.Status.Message = ""
 			log.Infof("Running DeviceChange %v", deviceChange)
 			if err := r.deviceChanges.Update(deviceChange); err != nil {
 				return false, err
@@ -291,6 +631,81 @@ func (r *Reconciler) ensureDeviceChangesPending(networkChange *networkchange.Net
 	return updated, nil
 }
 
+// ensureDeferredDeviceChanges applies a complete network change to the devices it was deferred on that are now
+// connected, and records the status of the change on each device once it has been applied to it
+func (r *Reconciler) ensureDeferredDeviceChanges(networkChange *networkchange.NetworkChange, metadata *metadatastore.Metadata) (bool, error) {
+	deviceChanges, err := r.getDeviceChanges(networkChange)
+	if err != nil {
+		return false, err
+	}
+
+	updated, err := r.ensureDeviceChangesPending(networkChange, metadata, deviceChanges)
+	if updated || err != nil {
+		return updated, err
+	}
+
+	results := getDeviceResults(deviceChanges)
+	for deviceID, result := range results {
+		if status, ok := metadata.Devices[deviceID]; !ok || status.State != result.State {
+			metadata.Devices = results
+			if err := r.networkMetadata.Update(metadata); err != nil {
+				return false, err
+			}
+			break
+		}
+	}
+
+	if message := getDeferredDevicesMessage(metadata, len(deviceChanges)); message != networkChange.Status.Message {
+		networkChange.Status.Message = message
+		log.Infof("Updating NetworkChange %v", networkChange)
+		if err := r.networkChanges.Update(networkChange); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// getDeferredChange returns the last earlier change to any of the devices of the given change if it was deferred
+// on that device and has not been applied to it yet, or nil if there is none
+func (r *Reconciler) getDeferredChange(change *networkchange.NetworkChange) (*networkchange.NetworkChange, error) {
+	devices := make(map[devicetype.ID]bool)
+	for _, deviceChange := range change.Changes {
+		devices[deviceChange.DeviceID] = true
+	}
+
+	prevChange, err := r.networkChanges.GetPrev(change.Index)
+	if err != nil {
+		return nil, err
+	}
+	for prevChange != nil && len(devices) > 0 {
+		var metadata *metadatastore.Metadata
+		for _, deviceChange := range prevChange.Changes {
+			if !devices[deviceChange.DeviceID] {
+				continue
+			}
+			delete(devices, deviceChange.DeviceID)
+			if prevChange.Status.Phase != changetypes.Phase_CHANGE {
+				continue
+			}
+			if metadata == nil {
+				if metadata, err = r.networkMetadata.Get(prevChange.ID); err != nil {
+					return nil, err
+				}
+			}
+			if isDeferredDevice(metadata, deviceChange.DeviceID) {
+				return prevChange, nil
+			}
+		}
+
+		prevChange, err = r.networkChanges.GetPrev(prevChange.Index)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return nil, nil
+}
+
 // getDeviceChanges gets the device changes for the given network change
 func (r *Reconciler) getDeviceChanges(networkChange *networkchange.NetworkChange) ([]*devicechange.DeviceChange, error) {
 	deviceChanges := make([]*devicechange.DeviceChange, len(networkChange.Changes))
@@ -304,21 +719,31 @@ func (r *Reconciler) getDeviceChanges(networkChange *networkchange.NetworkChange
 	return deviceChanges, nil
 }
 
-// isDeviceChangesComplete checks whether the device changes are complete
-func (r *Reconciler) isDeviceChangesComplete(networkChange *networkchange.NetworkChange, changes []*devicechange.DeviceChange) bool {
+// isDeviceChangesComplete checks whether the device changes are complete. Device changes that were deferred
+// because their device was not connected do not prevent the network change from completing, as long as it
+// was applied to one device.
+func (r *Reconciler) isDeviceChangesComplete(networkChange *networkchange.NetworkChange, metadata *metadatastore.Metadata, changes []*devicechange.DeviceChange) bool {
+	complete := false
 	for _, change := range changes {
+		if isDeferredDevice(metadata, change.Change.DeviceID) {
+			continue
+		}
 		if change.Status.Incarnation != networkChange.Status.Incarnation ||
 			change.Status.Phase != changetypes.Phase_CHANGE ||
 			change.Status.State != changetypes.State_COMPLETE {
 			return false
 		}
+		complete = true
 	}
-	return true
+	return complete
 }
 
 // isDeviceChangesFailed checks whether any device change has failed for the current incarnation
-func (r *Reconciler) isDeviceChangesFailed(networkChange *networkchange.NetworkChange, changes []*devicechange.DeviceChange) bool {
+func (r *Reconciler) isDeviceChangesFailed(networkChange *networkchange.NetworkChange, metadata *metadatastore.Metadata, changes []*devicechange.DeviceChange) bool {
 	for _, change := range changes {
+		if isDeferredDevice(metadata, change.Change.DeviceID) {
+			continue
+		}
 		if change.Status.Incarnation == networkChange.Status.Incarnation && change.Status.State == changetypes.State_FAILED {
 			return true
 		}
@@ -327,8 +752,12 @@ func (r *Reconciler) isDeviceChangesFailed(networkChange *networkchange.NetworkC
 }
 
 // ensureDeviceChangeRollbacks ensures device changes are being rolled back
-func (r *Reconciler) ensureDeviceChangeRollbacks(networkChange *networkchange.NetworkChange, changes []*devicechange.DeviceChange) (controller.Result, error) {
+func (r *Reconciler) ensureDeviceChangeRollbacks(networkChange *networkchange.NetworkChange, metadata *metadatastore.Metadata, changes []*devicechange.DeviceChange) (controller.Result, error) {
 	for _, deviceChange := range changes {
+		// A device change that was deferred while its device was offline has nothing to roll back
+		if isDeferredDevice(metadata, deviceChange.Change.DeviceID) {
+			continue
+		}
 		if deviceChange.Status.Incarnation != networkChange.Status.Incarnation ||
 			deviceChange.Status.Phase != changetypes.Phase_ROLLBACK ||
 			deviceChange.Status.State == changetypes.State_FAILED {
@@ -344,6 +773,71 @@ func (r *Reconciler) ensureDeviceChangeRollbacks(networkChange *networkchange.Ne
 	return controller.Result{}, nil
 }
 
+// isDeferredDevice indicates whether the change with the given metadata has been deferred on the given device
+// because it was not connected, and has not been applied to it since
+func isDeferredDevice(metadata *metadatastore.Metadata, deviceID devicetype.ID) bool {
+	if metadata == nil {
+		return false
+	}
+	for _, deferredID := range metadata.Deferred {
+		if deferredID == deviceID {
+			return true
+		}
+	}
+	return false
+}
+
+// removeDevice returns the given devices without the given device
+func removeDevice(devices []devicetype.ID, deviceID devicetype.ID) []devicetype.ID {
+	remaining := make([]devicetype.ID, 0, len(devices))
+	for _, id := range devices {
+		if id != deviceID {
+			remaining = append(remaining, id)
+		}
+	}
+	return remaining
+}
+
+// hasPendingDevices indicates whether the complete change with the given metadata has still to be applied to
+// some of its devices, or has still to record its status on them
+func hasPendingDevices(metadata *metadatastore.Metadata) bool {
+	if metadata == nil {
+		return false
+	} else if len(metadata.Deferred) > 0 {
+		return true
+	}
+	for _, status := range metadata.Devices {
+		if status.State == changetypes.State_PENDING {
+			return true
+		}
+	}
+	return false
+}
+
+// getDeferredDevicesMessage returns a message giving the devices that a change with the given number of devices
+// has been deferred on because they were not connected, or an empty message if there are none
+func getDeferredDevicesMessage(metadata *metadatastore.Metadata, devices int) string {
+	if metadata == nil || len(metadata.Deferred) == 0 {
+		return ""
+	}
+	deferred := make([]string, len(metadata.Deferred))
+	for i, deviceID := range metadata.Deferred {
+		deferred[i] = string(deviceID)
+	}
+	return fmt.Sprintf("Applied to %d of %d devices, deferred on %s until connected",
+		devices-len(deferred), devices, strings.Join(deferred, ", "))
+}
+
+// getDeviceResults returns the status of each of the given device changes by device
+func getDeviceResults(deviceChanges []*devicechange.DeviceChange) map[devicetype.ID]*changetypes.Status {
+	results := make(map[devicetype.ID]*changetypes.Status)
+	for _, deviceChange := range deviceChanges {
+		status := deviceChange.Status
+		results[deviceChange.Change.DeviceID] = &status
+	}
+	return results
+}
+
 // reconcileRollback reconciles a change in the ROLLBACK phase
 func (r *Reconciler) reconcileRollback(change *networkchange.NetworkChange) (controller.Result, error) {
 	switch change.Status.State {
@@ -357,8 +851,14 @@ func (r *Reconciler) reconcileRollback(change *networkchange.NetworkChange) (con
 
 // reconcilePendingRollback reconciles a change in the ROLLBACK phase
 func (r *Reconciler) reconcilePendingRollback(change *networkchange.NetworkChange) (controller.Result, error) {
+	// The devices that the change was deferred on have nothing to roll back
+	metadata, err := r.networkMetadata.Get(change.ID)
+	if err != nil {
+		return controller.Result{}, err
+	}
+
 	// Ensure the device changes are in the ROLLBACK phase
-	updated, err := r.ensureDeviceRollbacks(change)
+	updated, err := r.ensureDeviceRollbacks(change, metadata)
 	if updated || err != nil {
 		return controller.Result{}, err
 	}
@@ -375,7 +875,7 @@ func (r *Reconciler) reconcilePendingRollback(change *networkchange.NetworkChang
 	}
 
 	// If the network rollback can be applied, apply it by incrementing the incarnation number
-	apply, err := r.canTryRollback(change, deviceChanges)
+	apply, err := r.canTryRollback(change, metadata, deviceChanges)
 	if err != nil {
 		return controller.Result{}, err
 	} else if apply {
@@ -391,7 +891,7 @@ func (r *Reconciler) reconcilePendingRollback(change *networkchange.NetworkChang
 	}
 
 	// If all device rollbacks are complete, complete the network change
-	if r.isDeviceRollbacksComplete(change, deviceChanges) {
+	if r.isDeviceRollbacksComplete(change, metadata, deviceChanges) {
 		change.Status.State = changetypes.State_COMPLETE
 		log.Infof("Completing NetworkChange %v", change)
 		if err := r.networkChanges.Update(change); err != nil {
@@ -401,14 +901,32 @@ func (r *Reconciler) reconcilePendingRollback(change *networkchange.NetworkChang
 	}
 
 	// If any device change has failed, roll back all device changes
-	if r.isDeviceChangesFailed(change, deviceChanges) {
-		return r.ensureDeviceChangeRollbacks(change, deviceChanges)
+	if r.isDeviceChangesFailed(change, metadata, deviceChanges) {
+		return r.ensureDeviceChangeRollbacks(change, metadata, deviceChanges)
 	}
 	return controller.Result{}, nil
 }
 
 // reconcileCompleteRollback reconciles a change in the COMPLETE state during the CHANGE phase
 func (r *Reconciler) reconcileCompleteRollback(change *networkchange.NetworkChange) (controller.Result, error) {
+	// A later change to the same devices may have been held while this change was awaiting confirmation
+	nextChange, err := r.networkChanges.GetNext(change.Index)
+	if err != nil {
+		return controller.Result{}, err
+	}
+	for nextChange != nil {
+		if isIntersectingChange(change, nextChange) {
+			if nextChange.Status.Phase == changetypes.Phase_CHANGE && nextChange.Status.State == changetypes.State_PENDING {
+				return controller.Result{Requeue: types.ID(nextChange.ID)}, nil
+			}
+			break
+		}
+		nextChange, err = r.networkChanges.GetNext(nextChange.Index)
+		if err != nil {
+			return controller.Result{}, err
+		}
+	}
+
 	prevChange, err := r.networkChanges.GetPrev(change.Index)
 	if err != nil {
 		return controller.Result{}, err
@@ -431,7 +949,7 @@ func (r *Reconciler) reconcileCompleteRollback(change *networkchange.NetworkChan
 }
 
 // ensureDeviceRollbacks ensures device rollbacks are pending
-func (r *Reconciler) ensureDeviceRollbacks(networkChange *networkchange.NetworkChange) (bool, error) {
+func (r *Reconciler) ensureDeviceRollbacks(networkChange *networkchange.NetworkChange, metadata *metadatastore.Metadata) (bool, error) {
 	// Ensure all device changes are being rolled back
 	updated := false
 	for _, changeRef := range networkChange.Refs {
@@ -440,6 +958,10 @@ func (r *Reconciler) ensureDeviceRollbacks(networkChange *networkchange.NetworkC
 			return false, err
 		}
 
+		// A device change that was deferred while its device was offline has nothing to roll back
+		if isDeferredDevice(metadata, deviceChange.Change.DeviceID) {
+			continue
+		}
 		if deviceChange.Status.Incarnation < networkChange.Status.Incarnation ||
 			deviceChange.Status.Phase != changetypes.Phase_ROLLBACK {
 			deviceChange.Status.Incarnation = networkChange.Status.Incarnation
@@ -456,8 +978,11 @@ func (r *Reconciler) ensureDeviceRollbacks(networkChange *networkchange.NetworkC
 }
 
 // isDeviceRollbacksComplete checks whether the device rollbacks are complete
-func (r *Reconciler) isDeviceRollbacksComplete(networkChange *networkchange.NetworkChange, changes []*devicechange.DeviceChange) bool {
+func (r *Reconciler) isDeviceRollbacksComplete(networkChange *networkchange.NetworkChange, metadata *metadatastore.Metadata, changes []*devicechange.DeviceChange) bool {
 	for _, change := range changes {
+		if isDeferredDevice(metadata, change.Change.DeviceID) {
+			continue
+		}
 		if change.Status.Incarnation != networkChange.Status.Incarnation ||
 			change.Status.Phase != changetypes.Phase_ROLLBACK ||
 			change.Status.State != changetypes.State_COMPLETE {
@@ -468,10 +993,13 @@ func (r *Reconciler) isDeviceRollbacksComplete(networkChange *networkchange.Netw
 }
 
 // canTryRollback returns a bool indicating whether the rollback can be attempted
-func (r *Reconciler) canTryRollback(change *networkchange.NetworkChange, deviceChanges []*devicechange.DeviceChange) (bool, error) {
+func (r *Reconciler) canTryRollback(change *networkchange.NetworkChange, metadata *metadatastore.Metadata, deviceChanges []*devicechange.DeviceChange) (bool, error) {
 	// Verify all device changes are being rolled back
 	if change.Status.Incarnation > 0 {
 		for _, deviceChange := range deviceChanges {
+			if isDeferredDevice(metadata, deviceChange.Change.DeviceID) {
+				continue
+			}
 			if deviceChange.Status.Incarnation != change.Status.Incarnation ||
 				deviceChange.Status.Phase != changetypes.Phase_ROLLBACK ||
 				deviceChange.Status.State != changetypes.State_FAILED {
@@ -486,8 +1014,9 @@ func (r *Reconciler) canTryRollback(change *networkchange.NetworkChange, deviceC
 	}
 
 	for nextChange != nil {
-		// If the change intersects this change, verify it has been rolled back
-		if isIntersectingChange(change, nextChange) {
+		// If the change intersects the paths of this change, verify it has been rolled back
+		// Later changes that only touch other paths do not prevent the rollback
+		if len(networkchangeutils.GetIntersectingPaths(change, nextChange)) > 0 {
 			return nextChange.Status.Phase == changetypes.Phase_ROLLBACK &&
 				(nextChange.Status.State == changetypes.State_COMPLETE ||
 					nextChange.Status.State == changetypes.State_FAILED), nil
@@ -501,6 +1030,29 @@ func (r *Reconciler) canTryRollback(change *networkchange.NetworkChange, deviceC
 	return true, nil
 }
 
+// getConflictingChanges returns the changes made between the given base index and the change that have not been
+// rolled back and that touch the same device paths as the change, each with the intersecting paths
+func (r *Reconciler) getConflictingChanges(change *networkchange.NetworkChange, baseIndex networkchange.Index) ([]string, error) {
+	conflicts := make([]string, 0)
+	prevChange, err := r.networkChanges.GetPrev(change.Index)
+	if err != nil {
+		return nil, err
+	}
+	for prevChange != nil && prevChange.Index > baseIndex {
+		if prevChange.Status.Phase != changetypes.Phase_ROLLBACK || prevChange.Status.State != changetypes.State_COMPLETE {
+			paths := networkchangeutils.GetIntersectingPaths(change, prevChange)
+			if len(paths) > 0 {
+				conflicts = append(conflicts, fmt.Sprintf("%s (%s)", prevChange.ID, strings.Join(paths, ", ")))
+			}
+		}
+		prevChange, err = r.networkChanges.GetPrev(prevChange.Index)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return conflicts, nil
+}
+
 // isIntersectingChange indicates whether the changes from the two given NetworkChanges intersect
 func isIntersectingChange(config *networkchange.NetworkChange, history *networkchange.NetworkChange) bool {
 	for _, configChange := range config.Changes {
@@ -24,36 +24,55 @@ import (
 	networkchange "github.com/onosproject/onos-api/go/onos/config/change/network"
 	"github.com/onosproject/onos-api/go/onos/config/device"
 	"github.com/onosproject/onos-api/go/onos/topo"
+	devicecontroller "github.com/onosproject/onos-config/pkg/controller/change/device"
 	devicetopo "github.com/onosproject/onos-config/pkg/device"
+	"github.com/onosproject/onos-config/pkg/southbound"
 	devicechanges "github.com/onosproject/onos-config/pkg/store/change/device"
+	metadatastore "github.com/onosproject/onos-config/pkg/store/change/metadata"
 	networkchanges "github.com/onosproject/onos-config/pkg/store/change/network"
 	devicestore "github.com/onosproject/onos-config/pkg/store/device"
+	"github.com/onosproject/onos-config/pkg/store/device/cache"
+	lockstore "github.com/onosproject/onos-config/pkg/store/lock"
+	mastershipstore "github.com/onosproject/onos-config/pkg/store/mastership"
+	"github.com/onosproject/onos-config/pkg/store/stream"
 	"github.com/onosproject/onos-config/pkg/test/mocks"
+	southboundmock "github.com/onosproject/onos-config/pkg/test/mocks/southbound"
+	mockcache "github.com/onosproject/onos-config/pkg/test/mocks/store/cache"
+	"github.com/onosproject/onos-lib-go/pkg/cluster"
+	"github.com/openconfig/gnmi/proto/gnmi"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 const (
 	device1     = device.ID("device-1")
 	device2     = device.ID("device-2")
+	device3     = device.ID("device-3")
 	v1          = "1.0.0"
 	stratumType = "Stratum"
 )
 
 const (
 	change1 = networkchange.ID("change-1")
+	change2 = networkchange.ID("change-2")
+	change3 = networkchange.ID("change-3")
 )
 
 // TestReconcilerChangeRollback tests applying and then rolling back a change
 func TestReconcilerChangeRollback(t *testing.T) {
-	networkChanges, deviceChanges, devices := newStores(t)
+	networkChanges, networkMetadata, deviceChanges, devices, locks := newStores(t)
 	defer networkChanges.Close()
 	defer deviceChanges.Close()
+	defer locks.Close()
+	defer networkMetadata.Close()
 
 	reconciler := &Reconciler{
-		networkChanges: networkChanges,
-		deviceChanges:  deviceChanges,
-		devices:        devices,
+		networkChanges:  networkChanges,
+		networkMetadata: networkMetadata,
+		deviceChanges:   deviceChanges,
+		devices:         devices,
+		locks:           locks,
 	}
 
 	// Create a network change
@@ -192,14 +211,18 @@ func TestReconcilerChangeRollback(t *testing.T) {
 
 // TestReconcilerError tests an error reverting a change to PENDING
 func TestReconcilerError(t *testing.T) {
-	networkChanges, deviceChanges, devices := newStores(t)
+	networkChanges, networkMetadata, deviceChanges, devices, locks := newStores(t)
 	defer networkChanges.Close()
 	defer deviceChanges.Close()
+	defer locks.Close()
+	defer networkMetadata.Close()
 
 	reconciler := &Reconciler{
-		networkChanges: networkChanges,
-		deviceChanges:  deviceChanges,
-		devices:        devices,
+		networkChanges:  networkChanges,
+		networkMetadata: networkMetadata,
+		deviceChanges:   deviceChanges,
+		devices:         devices,
+		locks:           locks,
 	}
 
 	// Create a network change
@@ -314,14 +337,629 @@ func TestReconcilerError(t *testing.T) {
 	assert.Equal(t, change.State_PENDING, networkChange.Status.State)
 }
 
-func newStores(t *testing.T) (networkchanges.Store, devicechanges.Store, devicestore.Store) {
+// TestReconcilerScheduledChange tests holding a change in PENDING until its scheduled time
+func TestReconcilerScheduledChange(t *testing.T) {
+	networkChanges, networkMetadata, deviceChanges, devices, locks := newStores(t)
+	defer networkChanges.Close()
+	defer deviceChanges.Close()
+	defer locks.Close()
+	defer networkMetadata.Close()
+
+	reconciler := &Reconciler{
+		networkChanges:  networkChanges,
+		networkMetadata: networkMetadata,
+		deviceChanges:   deviceChanges,
+		devices:         devices,
+		locks:           locks,
+	}
+
+	// Create a network change scheduled in the future
+	networkChange := newChange(change1, device1, device2)
+	notBefore := time.Now().Add(time.Hour)
+	metadata := &metadatastore.Metadata{
+		ID:        change1,
+		NotBefore: &notBefore,
+	}
+	err := networkMetadata.Create(metadata)
+	assert.NoError(t, err)
+	err = networkChanges.Create(networkChange)
+	assert.NoError(t, err)
+
+	// Reconcile the network change to create the device changes
+	_, err = reconciler.Reconcile(types.ID(networkChange.ID))
+	assert.NoError(t, err)
+
+	// Reconcile the network change again
+	result, err := reconciler.Reconcile(types.ID(networkChange.ID))
+	assert.NoError(t, err)
+
+	// Verify the change was not applied and was requeued for its scheduled time
+	assert.Equal(t, types.ID(change1), result.Requeue)
+	assert.True(t, result.RequeueAfter > 59*time.Minute)
+	networkChange, err = networkChanges.Get(change1)
+	assert.NoError(t, err)
+	assert.Equal(t, change.Phase_CHANGE, networkChange.Status.Phase)
+	assert.Equal(t, change.State_PENDING, networkChange.Status.State)
+	assert.Equal(t, uint64(0), networkChange.Status.Incarnation)
+
+	// Move the scheduled time into the past
+	notBefore = time.Now().Add(-time.Second)
+	metadata.NotBefore = &notBefore
+	err = networkMetadata.Update(metadata)
+	assert.NoError(t, err)
+
+	// Reconcile the network change again
+	result, err = reconciler.Reconcile(types.ID(networkChange.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, time.Duration(0), result.RequeueAfter)
+
+	// Verify the change is now being applied
+	networkChange, err = networkChanges.Get(change1)
+	assert.NoError(t, err)
+	assert.Equal(t, change.State_PENDING, networkChange.Status.State)
+	assert.Equal(t, uint64(1), networkChange.Status.Incarnation)
+}
+
+// TestReconcilerCompareAndSetChange tests failing a change that is based on an index when a change made after that
+// index touches the same device paths
+func TestReconcilerCompareAndSetChange(t *testing.T) {
+	networkChanges, networkMetadata, deviceChanges, devices, locks := newStores(t)
+	defer networkChanges.Close()
+	defer deviceChanges.Close()
+	defer locks.Close()
+	defer networkMetadata.Close()
+
+	reconciler := &Reconciler{
+		networkChanges:  networkChanges,
+		networkMetadata: networkMetadata,
+		deviceChanges:   deviceChanges,
+		devices:         devices,
+		locks:           locks,
+	}
+
+	// Create a network change to device 1, then two network changes based on the index before it
+	err := networkChanges.Create(newChange(change1, device1))
+	assert.NoError(t, err)
+	baseIndex := networkchange.Index(0)
+	for _, networkChange := range []*networkchange.NetworkChange{newChange(change2, device1), newChange(change3, device2)} {
+		err = networkMetadata.Create(&metadatastore.Metadata{
+			ID:        networkChange.ID,
+			BaseIndex: &baseIndex,
+		})
+		assert.NoError(t, err)
+		err = networkChanges.Create(networkChange)
+		assert.NoError(t, err)
+	}
+
+	// Verify the change to the same path of device 1 fails without creating device changes
+	_, err = reconciler.Reconcile(types.ID(change2))
+	assert.NoError(t, err)
+	networkChange, err := networkChanges.Get(change2)
+	assert.NoError(t, err)
+	assert.Equal(t, change.Phase_CHANGE, networkChange.Status.Phase)
+	assert.Equal(t, change.State_FAILED, networkChange.Status.State)
+	assert.Equal(t, change.Reason_ERROR, networkChange.Status.Reason)
+	assert.Contains(t, networkChange.Status.Message, string(change1))
+	assert.Len(t, networkChange.Refs, 0)
+
+	// Verify the change to device 2 goes ahead
+	_, err = reconciler.Reconcile(types.ID(change3))
+	assert.NoError(t, err)
+	networkChange, err = networkChanges.Get(change3)
+	assert.NoError(t, err)
+	assert.Equal(t, change.State_PENDING, networkChange.Status.State)
+	assert.Len(t, networkChange.Refs, 1)
+}
+
+// TestReconcilerLockedChange tests holding a change while another client has locked one of its devices
+func TestReconcilerLockedChange(t *testing.T) {
+	networkChanges, networkMetadata, deviceChanges, devices, locks := newStores(t)
+	defer networkChanges.Close()
+	defer deviceChanges.Close()
+	defer locks.Close()
+	defer networkMetadata.Close()
+
+	reconciler := &Reconciler{
+		networkChanges:  networkChanges,
+		networkMetadata: networkMetadata,
+		deviceChanges:   deviceChanges,
+		devices:         devices,
+		locks:           locks,
+	}
+
+	// Lock a device for another client than the one making the change
+	_, err := locks.Lock([]device.ID{device2}, "client-2", time.Minute)
+	assert.NoError(t, err)
+
+	// Create a network change
+	networkChange := newChange(change1, device1, device2)
+	err = networkMetadata.Create(&metadatastore.Metadata{ID: change1, Username: "client-1"})
+	assert.NoError(t, err)
+	err = networkChanges.Create(networkChange)
+	assert.NoError(t, err)
+
+	// Reconcile the network change to create the device changes
+	_, err = reconciler.Reconcile(types.ID(networkChange.ID))
+	assert.NoError(t, err)
+
+	// Reconcile the network change again
+	result, err := reconciler.Reconcile(types.ID(networkChange.ID))
+	assert.NoError(t, err)
+
+	// Verify the change was not applied and was requeued to check the lock again
+	assert.Equal(t, types.ID(change1), result.Requeue)
+	assert.Equal(t, lockRetryInterval, result.RequeueAfter)
+	networkChange, err = networkChanges.Get(change1)
+	assert.NoError(t, err)
+	assert.Equal(t, change.State_PENDING, networkChange.Status.State)
+	assert.Equal(t, uint64(0), networkChange.Status.Incarnation)
+
+	// Release the lock
+	err = locks.Unlock([]device.ID{device2}, "client-2")
+	assert.NoError(t, err)
+
+	// Reconcile the network change again
+	_, err = reconciler.Reconcile(types.ID(networkChange.ID))
+	assert.NoError(t, err)
+
+	// Verify the change is now being applied
+	networkChange, err = networkChanges.Get(change1)
+	assert.NoError(t, err)
+	assert.Equal(t, change.State_PENDING, networkChange.Status.State)
+	assert.Equal(t, uint64(1), networkChange.Status.Incarnation)
+
+	// A lock held by the client that made the change does not hold it
+	_, err = locks.Lock([]device.ID{device1}, "client-1", time.Minute)
+	assert.NoError(t, err)
+	networkChange2 := newChange(change2, device1)
+	metadata2 := &metadatastore.Metadata{ID: change2, Username: "client-1"}
+	err = networkMetadata.Create(metadata2)
+	assert.NoError(t, err)
+	err = networkChanges.Create(networkChange2)
+	assert.NoError(t, err)
+	lock, err := reconciler.getConflictingLock(networkChange2, metadata2)
+	assert.NoError(t, err)
+	assert.Nil(t, lock)
+
+	// But it holds a change whose author is not known
+	lock, err = reconciler.getConflictingLock(networkChange2, nil)
+	assert.NoError(t, err)
+	assert.NotNil(t, lock)
+}
+
+// TestReconcilerConfirmedChange tests rolling back a change that is not confirmed in time
+func TestReconcilerConfirmedChange(t *testing.T) {
+	networkChanges, networkMetadata, deviceChanges, devices, locks := newStores(t)
+	defer networkChanges.Close()
+	defer deviceChanges.Close()
+	defer locks.Close()
+	defer networkMetadata.Close()
+
+	reconciler := &Reconciler{
+		networkChanges:  networkChanges,
+		networkMetadata: networkMetadata,
+		deviceChanges:   deviceChanges,
+		devices:         devices,
+		locks:           locks,
+	}
+
+	// Create a network change that must be confirmed
+	confirmTimeout := time.Minute
+	err := networkMetadata.Create(&metadatastore.Metadata{
+		ID:             change1,
+		ConfirmTimeout: &confirmTimeout,
+	})
+	assert.NoError(t, err)
+	networkChange := newChange(change1, device1)
+	err = networkChanges.Create(networkChange)
+	assert.NoError(t, err)
+
+	// Reconcile the network change to create the device change and apply it
+	_, err = reconciler.Reconcile(types.ID(networkChange.ID))
+	assert.NoError(t, err)
+	_, err = reconciler.Reconcile(types.ID(networkChange.ID))
+	assert.NoError(t, err)
+	_, err = reconciler.Reconcile(types.ID(networkChange.ID))
+	assert.NoError(t, err)
+
+	// Complete the device change
+	deviceChange1, err := deviceChanges.Get("change-1:device-1:1.0.0")
+	assert.NoError(t, err)
+	deviceChange1.Status.State = change.State_COMPLETE
+	err = deviceChanges.Update(deviceChange1)
+	assert.NoError(t, err)
+	_, err = reconciler.Reconcile(types.ID(networkChange.ID))
+	assert.NoError(t, err)
+
+	// Verify the network change is complete and its confirmation deadline is set
+	networkChange, err = networkChanges.Get(change1)
+	assert.NoError(t, err)
+	assert.Equal(t, change.State_COMPLETE, networkChange.Status.State)
+	metadata, err := networkMetadata.Get(change1)
+	assert.NoError(t, err)
+	assert.NotNil(t, metadata.ConfirmDeadline)
+
+	// Reconcile the complete change and verify it is requeued for its deadline
+	result, err := reconciler.Reconcile(types.ID(networkChange.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, types.ID(change1), result.Requeue)
+	assert.True(t, result.RequeueAfter > 59*time.Second)
+
+	// Move the deadline into the past
+	deadline := time.Now().Add(-time.Second)
+	metadata.ConfirmDeadline = &deadline
+	err = networkMetadata.Update(metadata)
+	assert.NoError(t, err)
+
+	// Reconcile the network change and verify it is being rolled back
+	_, err = reconciler.Reconcile(types.ID(networkChange.ID))
+	assert.NoError(t, err)
+	networkChange, err = networkChanges.Get(change1)
+	assert.NoError(t, err)
+	assert.Equal(t, change.Phase_ROLLBACK, networkChange.Status.Phase)
+	assert.Equal(t, change.State_PENDING, networkChange.Status.State)
+	assert.Equal(t, "Not confirmed within 1m0s", networkChange.Status.Message)
+	metadata, err = networkMetadata.Get(change1)
+	assert.NoError(t, err)
+	assert.NotNil(t, metadata.RolledBack)
+}
+
+// TestReconcilerUnconfirmedChangeHoldsLaterChanges tests that a later change to the same device is held until
+// a change awaiting confirmation is confirmed, rather than confirming it
+func TestReconcilerUnconfirmedChangeHoldsLaterChanges(t *testing.T) {
+	networkChanges, networkMetadata, deviceChanges, devices, locks := newStores(t)
+	defer networkChanges.Close()
+	defer deviceChanges.Close()
+	defer locks.Close()
+	defer networkMetadata.Close()
+
+	reconciler := &Reconciler{
+		networkChanges:  networkChanges,
+		networkMetadata: networkMetadata,
+		deviceChanges:   deviceChanges,
+		devices:         devices,
+		locks:           locks,
+	}
+
+	// Create a complete network change awaiting confirmation, and a later change to the same device
+	confirmTimeout := time.Minute
+	deadline := time.Now().Add(time.Minute)
+	metadata := &metadatastore.Metadata{
+		ID:              change1,
+		ConfirmTimeout:  &confirmTimeout,
+		ConfirmDeadline: &deadline,
+	}
+	err := networkMetadata.Create(metadata)
+	assert.NoError(t, err)
+	networkChange := newChange(change1, device1)
+	networkChange.Status.State = change.State_COMPLETE
+	networkChange.Refs = []*networkchange.DeviceChangeRef{{DeviceChangeID: "change-1:device-1:1.0.0"}}
+	err = networkChanges.Create(networkChange)
+	assert.NoError(t, err)
+	networkChange2 := newChange(change2, device1)
+	err = networkChanges.Create(networkChange2)
+	assert.NoError(t, err)
+
+	// Reconcile the first change and verify it is still awaiting confirmation, and the later change is requeued
+	result, err := reconciler.Reconcile(types.ID(change1))
+	assert.NoError(t, err)
+	assert.Equal(t, types.ID(change2), result.Requeue)
+	metadata, err = networkMetadata.Get(change1)
+	assert.NoError(t, err)
+	assert.False(t, metadata.Confirmed)
+
+	// Reconcile the later change to create its device change, and verify it is held
+	_, err = reconciler.Reconcile(types.ID(change2))
+	assert.NoError(t, err)
+	_, err = reconciler.Reconcile(types.ID(change2))
+	assert.NoError(t, err)
+	_, err = reconciler.Reconcile(types.ID(change2))
+	assert.NoError(t, err)
+	networkChange2, err = networkChanges.Get(change2)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), networkChange2.Status.Incarnation)
+	assert.Equal(t, "Waiting for NetworkChange change-1 to be confirmed", networkChange2.Status.Message)
+
+	// The held change has the first change reconciled when its deadline is due
+	result, err = reconciler.Reconcile(types.ID(change2))
+	assert.NoError(t, err)
+	assert.Equal(t, types.ID(change1), result.Requeue)
+	assert.True(t, result.RequeueAfter > 59*time.Second)
+
+	// Once the first change is confirmed, the later change is requeued and applied
+	metadata.Confirmed = true
+	err = networkMetadata.Update(metadata)
+	assert.NoError(t, err)
+	result, err = reconciler.Reconcile(types.ID(change1))
+	assert.NoError(t, err)
+	assert.Equal(t, types.ID(change2), result.Requeue)
+	_, err = reconciler.Reconcile(types.ID(change2))
+	assert.NoError(t, err)
+	networkChange2, err = networkChanges.Get(change2)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), networkChange2.Status.Incarnation)
+	assert.Equal(t, "", networkChange2.Status.Message)
+}
+
+// TestReconcilerBestEffortChange tests applying a change to the connected devices, deferring the others until
+// they connect
+func TestReconcilerBestEffortChange(t *testing.T) {
+	networkChanges, networkMetadata, deviceChanges, devices, locks := newStores(t)
+	defer networkChanges.Close()
+	defer deviceChanges.Close()
+	defer locks.Close()
+	defer networkMetadata.Close()
+	offlineDevices[device3] = true
+	defer delete(offlineDevices, device3)
+
+	reconciler := &Reconciler{
+		networkChanges:  networkChanges,
+		networkMetadata: networkMetadata,
+		deviceChanges:   deviceChanges,
+		devices:         devices,
+		locks:           locks,
+	}
+
+	// An atomic change is not applied while one of its devices is offline
+	atomicChange := newChange(change1, device1, device3)
+	err := networkChanges.Create(atomicChange)
+	assert.NoError(t, err)
+	_, err = reconciler.Reconcile(types.ID(atomicChange.ID))
+	assert.NoError(t, err)
+	_, err = reconciler.Reconcile(types.ID(atomicChange.ID))
+	assert.NoError(t, err)
+	atomicChange, err = networkChanges.Get(change1)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), atomicChange.Status.Incarnation)
+	err = networkChanges.Delete(atomicChange)
+	assert.NoError(t, err)
+
+	// A quorum change needs enough of its devices to be connected
+	quorumMetadata := &metadatastore.Metadata{ID: change2, ApplyPolicy: metadatastore.ApplyPolicyQuorum, Quorum: 90}
+	assert.False(t, hasEnoughDevices(quorumMetadata, 2, 3))
+	quorumMetadata.Quorum = 60
+	assert.True(t, hasEnoughDevices(quorumMetadata, 2, 3))
+	assert.False(t, hasEnoughDevices(nil, 2, 3))
+
+	// A best-effort change is applied to the devices that are connected
+	networkChange := newChange("change-3", device1, device3)
+	err = networkMetadata.Create(&metadatastore.Metadata{ID: networkChange.ID, ApplyPolicy: metadatastore.ApplyPolicyBestEffort})
+	assert.NoError(t, err)
+	err = networkChanges.Create(networkChange)
+	assert.NoError(t, err)
+
+	// Reconcile the network change to create the device changes, and again to apply it
+	_, err = reconciler.Reconcile(types.ID(networkChange.ID))
+	assert.NoError(t, err)
+	_, err = reconciler.Reconcile(types.ID(networkChange.ID))
+	assert.NoError(t, err)
+	networkChange, err = networkChanges.Get("change-3")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), networkChange.Status.Incarnation)
+
+	// The change to the connected device is made pending, and the change to the other device is deferred
+	_, err = reconciler.Reconcile(types.ID(networkChange.ID))
+	assert.NoError(t, err)
+	deviceChange1, err := deviceChanges.Get("change-3:device-1:1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), deviceChange1.Status.Incarnation)
+	assert.Equal(t, change.State_PENDING, deviceChange1.Status.State)
+	deviceChange3, err := deviceChanges.Get("change-3:device-3:1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), deviceChange3.Status.Incarnation)
+	assert.Equal(t, change.State_PENDING, deviceChange3.Status.State)
+	metadata, err := networkMetadata.Get(networkChange.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []device.ID{device3}, metadata.Deferred)
+
+	// Complete the change on the connected device
+	deviceChange1.Status.State = change.State_COMPLETE
+	err = deviceChanges.Update(deviceChange1)
+	assert.NoError(t, err)
+
+	// The network change completes without being rolled back
+	_, err = reconciler.Reconcile(types.ID(networkChange.ID))
+	assert.NoError(t, err)
+	networkChange, err = networkChanges.Get("change-3")
+	assert.NoError(t, err)
+	assert.Equal(t, change.Phase_CHANGE, networkChange.Status.Phase)
+	assert.Equal(t, change.State_COMPLETE, networkChange.Status.State)
+	assert.Equal(t, "Applied to 1 of 2 devices, deferred on device-3 until connected", networkChange.Status.Message)
+	deviceChange1, err = deviceChanges.Get("change-3:device-1:1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, change.State_COMPLETE, deviceChange1.Status.State)
+
+	// The status of the change on each device is kept in its metadata
+	metadata, err = networkMetadata.Get(networkChange.ID)
+	assert.NoError(t, err)
+	assert.Len(t, metadata.Devices, 2)
+	assert.Equal(t, change.State_COMPLETE, metadata.Devices[device1].State)
+	assert.Equal(t, change.State_PENDING, metadata.Devices[device3].State)
+
+	// A later change to the device the change was deferred on is held until the change has been applied to it
+	laterChange := newChange("change-4", device1, device3)
+	err = networkMetadata.Create(&metadatastore.Metadata{ID: laterChange.ID, ApplyPolicy: metadatastore.ApplyPolicyBestEffort})
+	assert.NoError(t, err)
+	err = networkChanges.Create(laterChange)
+	assert.NoError(t, err)
+	_, err = reconciler.Reconcile(types.ID(laterChange.ID))
+	assert.NoError(t, err)
+	_, err = reconciler.Reconcile(types.ID(laterChange.ID))
+	assert.NoError(t, err)
+	laterChange, err = networkChanges.Get("change-4")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), laterChange.Status.Incarnation)
+
+	// Once the device is connected, the change is applied to it
+	delete(offlineDevices, device3)
+	_, err = reconciler.Reconcile(types.ID(networkChange.ID))
+	assert.NoError(t, err)
+	deviceChange3, err = deviceChanges.Get("change-3:device-3:1.0.0")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), deviceChange3.Status.Incarnation)
+	assert.Equal(t, change.Phase_CHANGE, deviceChange3.Status.Phase)
+	assert.Equal(t, change.State_PENDING, deviceChange3.Status.State)
+	metadata, err = networkMetadata.Get(networkChange.ID)
+	assert.NoError(t, err)
+	assert.Len(t, metadata.Deferred, 0)
+
+	// Complete the change on the device, and its status is recorded
+	deviceChange3.Status.State = change.State_COMPLETE
+	err = deviceChanges.Update(deviceChange3)
+	assert.NoError(t, err)
+	_, err = reconciler.Reconcile(types.ID(networkChange.ID))
+	assert.NoError(t, err)
+	networkChange, err = networkChanges.Get("change-3")
+	assert.NoError(t, err)
+	assert.Equal(t, "", networkChange.Status.Message)
+	metadata, err = networkMetadata.Get(networkChange.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, change.State_COMPLETE, metadata.Devices[device3].State)
+
+	// The later change is then requeued and applied
+	result, err := reconciler.Reconcile(types.ID(networkChange.ID))
+	assert.NoError(t, err)
+	assert.Equal(t, types.ID("change-4"), result.Requeue)
+	_, err = reconciler.Reconcile(types.ID(laterChange.ID))
+	assert.NoError(t, err)
+	laterChange, err = networkChanges.Get("change-4")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(1), laterChange.Status.Incarnation)
+
+	// A best-effort change is not applied when none of its devices is connected
+	assert.False(t, hasEnoughDevices(metadata, 0, 2))
+}
+
+// TestReconcilerCoalescedChanges tests that changes to other paths of a device are applied while an earlier change
+// to the device is being applied, so that the device change controller pushes them to the device together
+func TestReconcilerCoalescedChanges(t *testing.T) {
+	networkChanges, networkMetadata, deviceChanges, devices, locks := newStores(t)
+	defer networkChanges.Close()
+	defer deviceChanges.Close()
+	defer locks.Close()
+	defer networkMetadata.Close()
+
+	reconciler := &Reconciler{
+		networkChanges:  networkChanges,
+		networkMetadata: networkMetadata,
+		deviceChanges:   deviceChanges,
+		devices:         devices,
+		locks:           locks,
+	}
+
+	// The device holds its answer to the first set request until it is released
+	ctrl := gomock.NewController(t)
+	targetCtx := context.Background()
+	requests := make(chan *gnmi.SetRequest, 3)
+	release := make(chan struct{})
+	target := southboundmock.NewMockTargetIf(ctrl)
+	target.EXPECT().Context().Return(&targetCtx).AnyTimes()
+	target.EXPECT().Set(gomock.Any(), gomock.Any()).DoAndReturn(
+		func(ctx context.Context, request *gnmi.SetRequest) (*gnmi.SetResponse, error) {
+			requests <- request
+			<-release
+			return &gnmi.SetResponse{}, nil
+		}).AnyTimes()
+	southbound.Targets[devicetopo.ID(device1)] = target
+	defer delete(southbound.Targets, devicetopo.ID(device1))
+
+	// Run the device change controller
+	mastership, err := mastershipstore.NewLocalStore("TestReconcilerCoalescedChanges", cluster.NodeID("node-1"))
+	assert.NoError(t, err)
+	defer mastership.Close()
+	deviceCache := mockcache.NewMockCache(ctrl)
+	deviceCache.EXPECT().Watch(gomock.Any(), true).DoAndReturn(
+		func(ch chan<- stream.Event, replay bool) (stream.Context, error) {
+			go func() {
+				ch <- stream.Event{Type: stream.None, Object: &cache.Info{DeviceID: device1, Version: v1}}
+			}()
+			return stream.NewContext(func() {}), nil
+		})
+	deviceController := devicecontroller.NewController(mastership, devices, deviceCache, deviceChanges, networkMetadata,
+		devicecontroller.NewApplyTimeouts())
+	assert.NoError(t, deviceController.Start())
+	defer deviceController.Stop()
+
+	// Apply a first change to the device, which holds the set request
+	networkChange1 := newPathChange(change1, device1, "/cont1a/leaf1")
+	err = networkChanges.Create(networkChange1)
+	assert.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		_, err = reconciler.Reconcile(types.ID(change1))
+		assert.NoError(t, err)
+	}
+	request := getSetRequest(t, requests)
+	assert.Len(t, request.Update, 1)
+
+	// Later changes to other paths of the device are applied meanwhile
+	for _, networkChange := range []*networkchange.NetworkChange{
+		newPathChange(change2, device1, "/cont1a/leaf2"),
+		newPathChange(change3, device1, "/cont1a/leaf3"),
+	} {
+		err = networkChanges.Create(networkChange)
+		assert.NoError(t, err)
+		for i := 0; i < 3; i++ {
+			_, err = reconciler.Reconcile(types.ID(networkChange.ID))
+			assert.NoError(t, err)
+		}
+		deviceChange, err := deviceChanges.Get(devicechange.NewID(types.ID(networkChange.ID), device1, v1))
+		assert.NoError(t, err)
+		assert.Equal(t, uint64(1), deviceChange.Status.Incarnation)
+		assert.Equal(t, change.State_PENDING, deviceChange.Status.State)
+	}
+
+	// A later change to the same path as the first change waits for it to complete
+	networkChange4 := newPathChange("change-4", device1, "/cont1a/leaf1")
+	err = networkChanges.Create(networkChange4)
+	assert.NoError(t, err)
+	for i := 0; i < 3; i++ {
+		_, err = reconciler.Reconcile(types.ID(networkChange4.ID))
+		assert.NoError(t, err)
+	}
+	networkChange4, err = networkChanges.Get(networkChange4.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), networkChange4.Status.Incarnation)
+
+	// Once the device answers, the two later changes are pushed to it in a single set request
+	close(release)
+	request = getSetRequest(t, requests)
+	assert.Len(t, request.Update, 2)
+	for _, id := range []networkchange.ID{change1, change2, change3} {
+		assert.Eventually(t, func() bool {
+			deviceChange, err := deviceChanges.Get(devicechange.NewID(types.ID(id), device1, v1))
+			return err == nil && deviceChange.Status.State == change.State_COMPLETE
+		}, 5*time.Second, 10*time.Millisecond)
+	}
+}
+
+// getSetRequest returns the next set request made to the device
+func getSetRequest(t *testing.T, requests <-chan *gnmi.SetRequest) *gnmi.SetRequest {
+	select {
+	case request := <-requests:
+		return request
+	case <-time.After(5 * time.Second):
+		assert.FailNow(t, "no set request was made to the device")
+		return nil
+	}
+}
+
+// offlineDevices are the devices that the device store of the tests gives as not connected
+var offlineDevices = make(map[device.ID]bool)
+
+func newStores(t *testing.T) (networkchanges.Store, metadatastore.Store, devicechanges.Store, devicestore.Store, lockstore.Store) {
 	networkChanges, err := networkchanges.NewLocalStore()
 	assert.NoError(t, err)
+	networkMetadata, err := metadatastore.NewLocalStore()
+	assert.NoError(t, err)
 	deviceChanges, err := devicechanges.NewLocalStore()
 	assert.NoError(t, err)
 	ctrl := gomock.NewController(t)
 	client := mocks.NewMockTopoClient(ctrl)
 	client.EXPECT().Get(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, request *topo.GetRequest) (*topo.GetResponse, error) {
+		channelState := topo.ChannelState_CONNECTED
+		if offlineDevices[device.ID(request.ID)] {
+			channelState = topo.ChannelState_DISCONNECTED
+		}
 		return &topo.GetResponse{
 			Object: devicetopo.ToObject(&devicetopo.Device{
 				ID:      devicetopo.ID(request.ID),
@@ -331,7 +969,7 @@ func newStores(t *testing.T) (networkchanges.Store, devicechanges.Store, devices
 				Protocols: []*topo.ProtocolState{
 					{
 						Protocol:          topo.Protocol_GNMI,
-						ChannelState:      topo.ChannelState_CONNECTED,
+						ChannelState:      channelState,
 						ConnectivityState: topo.ConnectivityState_REACHABLE,
 					},
 				},
@@ -340,7 +978,9 @@ func newStores(t *testing.T) (networkchanges.Store, devicechanges.Store, devices
 	}).AnyTimes()
 	devices, err := devicestore.NewStore(client)
 	assert.NoError(t, err)
-	return networkChanges, deviceChanges, devices
+	locks, err := lockstore.NewLocalStore()
+	assert.NoError(t, err)
+	return networkChanges, networkMetadata, deviceChanges, devices, locks
 }
 
 func newChange(id networkchange.ID, devices ...device.ID) *networkchange.NetworkChange {
@@ -366,3 +1006,9 @@ func newChange(id networkchange.ID, devices ...device.ID) *networkchange.Network
 		Changes: changes,
 	}
 }
+
+func newPathChange(id networkchange.ID, device device.ID, path string) *networkchange.NetworkChange {
+	networkChange := newChange(id, device)
+	networkChange.Changes[0].Values[0].Path = path
+	return networkChange
+}
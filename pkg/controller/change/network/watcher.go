@@ -165,7 +165,8 @@ func (w *DeviceWatcher) updateWatch(topodevice *devicetopo.Device, ch chan<- typ
 		return
 	}
 
-	// Open a new device event stream
+	// Open a new device event stream. Replaying the changes to the device requeues the network changes that
+	// were deferred on it while it was not connected.
 	deviceCh := make(chan stream.Event, queueSize)
 	ctx, err := w.ChangeStore.Watch(deviceID, deviceCh, devicechangestore.WithReplay())
 	if err != nil {
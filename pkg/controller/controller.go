@@ -18,9 +18,8 @@ import (
 	"sync"
 	"time"
 
-	"github.com/cenkalti/backoff"
-
 	types "github.com/onosproject/onos-api/go/onos/config"
+	deadletterstore "github.com/onosproject/onos-config/pkg/store/deadletter"
 	"github.com/onosproject/onos-lib-go/pkg/logging"
 )
 
@@ -31,6 +30,10 @@ const (
 	maxRetryInterval     = 5 * time.Second
 )
 
+// DefaultMaxAttempts is the number of times in a row a request may fail to reconcile before the controller
+// gives up on it. With the retry interval capped at 5 seconds, this is about 4 minutes of retries.
+const DefaultMaxAttempts = 50
+
 // Watcher is implemented by controllers to implement watching for specific events
 // Type identifiers that are written to the watcher channel will eventually be processed by
 // the controller.
@@ -56,6 +59,8 @@ type Reconciler interface {
 type Result struct {
 	// Requeue is the identifier of an event to requeue
 	Requeue types.ID
+	// RequeueAfter is the delay after which the event is requeued; if zero the event is requeued immediately
+	RequeueAfter time.Duration
 }
 
 // NewController creates a new controller
@@ -65,7 +70,8 @@ func NewController(name string) *Controller {
 		activator:   &UnconditionalActivator{},
 		partitioner: &UnaryPartitioner{},
 		watchers:    make([]Watcher, 0),
-		partitions:  make(map[PartitionKey]chan types.ID),
+		partitions:  make(map[PartitionKey]*workQueue),
+		maxAttempts: DefaultMaxAttempts,
 	}
 }
 
@@ -79,12 +85,20 @@ func NewController(name string) *Controller {
 // request should be passed to the Reconciler.
 // Once the Reconciler receives a request, it should process the request using the current state of the cluster
 // Reconcilers should not cache state themselves and should instead rely on stores for consistency.
-// If a Reconciler returns false, the request will be requeued to be retried after all pending requests.
-// If a Reconciler returns an error, the request will be retried after a backoff period.
-// Once a Reconciler successfully processes a request by returning true, the request will be discarded.
+// If a Reconciler returns a Result to requeue, the request will be requeued to be retried after all pending
+// requests, or after the given delay.
+// If a Reconciler returns an error, the request will be retried after a backoff period that grows with each
+// failure of the request. The partition is held up until the request succeeds, so that the requests of a
+// partition are reconciled in the order in which they were received.
+// If the controller has a dead letter store, once a request has failed MaxAttempts times in a row the controller
+// gives up on it and stores it as a dead letter. The events of a dead letter are ignored until it is deleted
+// from the store, at which point the request is requeued. The dead letters of the controller are kept in memory
+// while it is active, and updated from the store.
+// Once a Reconciler successfully processes a request, the request will be discarded.
 // Requests can be partitioned among concurrent goroutines by configuring a WorkPartitioner. The controller
 // will create a goroutine per PartitionKey provided by the WorkPartitioner, and requests to different
-// partitions may be handled concurrently.
+// partitions may be handled concurrently. Each partition has a work queue, in which a request is queued at
+// most once at a time.
 type Controller struct {
 	name        string
 	mu          sync.RWMutex
@@ -93,7 +107,14 @@ type Controller struct {
 	filter      Filter
 	watchers    []Watcher
 	reconciler  Reconciler
-	partitions  map[PartitionKey]chan types.ID
+	partitions  map[PartitionKey]*workQueue
+	maxAttempts int
+	deadLetters *deadLetterWatcher
+}
+
+// Name returns the name of the controller
+func (c *Controller) Name() string {
+	return c.name
 }
 
 // Activate sets an activator for the controller
@@ -136,6 +157,28 @@ func (c *Controller) Reconcile(reconciler Reconciler) *Controller {
 	return c
 }
 
+// MaxAttempts sets the number of times in a row a request may fail to reconcile before the controller gives
+// up on it. Requests are retried for ever if it is zero, or if the controller has no dead letter store.
+func (c *Controller) MaxAttempts(attempts int) *Controller {
+	c.mu.Lock()
+	c.maxAttempts = attempts
+	c.mu.Unlock()
+	return c
+}
+
+// DeadLetters sets the store of the requests that the controller gives up on. The requests whose dead letters
+// are deleted from the store are requeued.
+func (c *Controller) DeadLetters(store deadletterstore.Store) *Controller {
+	deadLetters := &deadLetterWatcher{
+		controller: c.name,
+		store:      store,
+	}
+	c.mu.Lock()
+	c.deadLetters = deadLetters
+	c.mu.Unlock()
+	return c.Watch(deadLetters)
+}
+
 // Start starts the request controller
 func (c *Controller) Start() error {
 	ch := make(chan bool)
@@ -230,7 +273,7 @@ func (c *Controller) partition(id types.ID, partitioner WorkPartitioner) {
 		if err != nil {
 			time.Sleep(time.Duration(iteration*2) * time.Millisecond)
 		} else {
-			// Get or create a partition channel for the partition key
+			// Get or create a partition queue for the partition key
 			c.mu.RLock()
 			partition, ok := c.partitions[key]
 			c.mu.RUnlock()
@@ -238,68 +281,86 @@ func (c *Controller) partition(id types.ID, partitioner WorkPartitioner) {
 				c.mu.Lock()
 				partition, ok = c.partitions[key]
 				if !ok {
-					partition = make(chan types.ID)
+					partition = newWorkQueue()
 					c.partitions[key] = partition
 					go c.processRequests(partition)
 				}
 				c.mu.Unlock()
 			}
-			partition <- id
+			partition.add(id)
 			return
 		}
 		iteration++
 	}
 }
 
-// processRequests processes requests from the given channel
-func (c *Controller) processRequests(ch chan types.ID) {
+// processRequests processes requests from the given queue
+func (c *Controller) processRequests(queue *workQueue) {
 	c.mu.RLock()
 	reconciler := c.reconciler
 	c.mu.RUnlock()
 
-	for id := range ch {
-		// Reconcile the request. If the reconciliation is not successful, requeue the request to be processed
-		// after the remaining enqueued events.
-		result := c.reconcile(id, reconciler)
-		if result.Requeue != "" {
-			go c.requeueRequest(ch, result.Requeue)
+	for {
+		id := queue.get()
+		// Ignore the requests that have been given up on until they are requeued
+		if !c.isDeadLetter(id) {
+			c.reconcile(id, reconciler, queue)
 		}
+		queue.done(id)
 	}
 }
 
-// requeueRequest requeues the given request
-func (c *Controller) requeueRequest(ch chan types.ID, id types.ID) {
-	ch <- id
+// isDeadLetter indicates whether the controller has given up on the given request
+func (c *Controller) isDeadLetter(id types.ID) bool {
+	c.mu.RLock()
+	deadLetters := c.deadLetters
+	c.mu.RUnlock()
+	if deadLetters != nil && deadLetters.contains(id) {
+		log.Debugf("Ignoring dead letter %s in controller %s", id, c.name)
+		return true
+	}
+	return false
 }
 
-// reconcile reconciles the given request ID until complete
-func (c *Controller) reconcile(id types.ID, reconciler Reconciler) Result {
-
-	iteration := 0
-	var result Result
-	var err error
-	b := backoff.NewExponentialBackOff()
-	b.InitialInterval = initialRetryInterval
-	// MaxInterval caps the RetryInterval
-	b.MaxInterval = maxRetryInterval
-	// Never stops retrying
-	b.MaxElapsedTime = 0
-
-	notify := func(err error, t time.Duration) {
-		log.Infof("An error occurred during reconciliation of %s in iteration %v: %v", id, err, iteration)
-		iteration++
-
-	}
+// reconcile reconciles the given request. A request that fails is retried after a backoff period, before the
+// next requests of its partition, unless it has failed too many times. A request whose result asks for it is
+// requeued.
+func (c *Controller) reconcile(id types.ID, reconciler Reconciler, queue *workQueue) {
+	c.mu.RLock()
+	maxAttempts := c.maxAttempts
+	deadLetters := c.deadLetters
+	c.mu.RUnlock()
 
-	err = backoff.RetryNotify(func() error {
-		result, err = reconciler.Reconcile(id)
-		if err != nil {
-			return err
+	for {
+		result, err := reconciler.Reconcile(id)
+		if err == nil {
+			queue.forget(id)
+			if result.Requeue != "" {
+				queue.addAfter(result.Requeue, result.RequeueAfter)
+			}
+			return
 		}
 
-		return nil
-
-	}, b, notify)
+		attempts, delay := queue.failed(id)
+		if deadLetters != nil && maxAttempts > 0 && attempts >= maxAttempts {
+			log.Errorf("Giving up on %s in controller %s after %d attempts: %v", id, c.name, attempts, err)
+			letter := &deadletterstore.DeadLetter{
+				Controller: c.name,
+				ID:         id,
+				Attempts:   attempts,
+				Error:      err.Error(),
+				Time:       time.Now(),
+			}
+			errStore := deadLetters.put(letter)
+			if errStore == nil {
+				queue.forget(id)
+				return
+			}
+			log.Errorf("Failed to store the dead letter of %s in controller %s: %v", id, c.name, errStore)
+		} else {
+			log.Infof("An error occurred during reconciliation of %s in attempt %d: %v", id, attempts, err)
+		}
 
-	return result
+		time.Sleep(delay)
+	}
 }
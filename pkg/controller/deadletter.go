@@ -0,0 +1,121 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"sync"
+
+	types "github.com/onosproject/onos-api/go/onos/config"
+	deadletterstore "github.com/onosproject/onos-config/pkg/store/deadletter"
+	"github.com/onosproject/onos-config/pkg/store/stream"
+)
+
+// deadLetterWatcher is a Watcher that keeps the dead letters of a controller in memory while the controller is
+// active, and requeues the requests whose dead letters are deleted, whichever instance deleted them
+type deadLetterWatcher struct {
+	controller string
+	store      deadletterstore.Store
+	ctx        stream.Context
+	letters    map[types.ID]bool
+	mu         sync.RWMutex
+}
+
+// Start starts watching for dead letters, once the dead letters already in the store are known
+func (w *deadLetterWatcher) Start(ch chan<- types.ID) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.ctx != nil {
+		return nil
+	}
+
+	// The letters are listed once the watch has started so that no update is missed, and the events are only
+	// handled once the listing is done
+	letterCh := make(chan stream.Event)
+	ctx, err := w.store.Watch(letterCh)
+	if err != nil {
+		return err
+	}
+	listCh := make(chan *deadletterstore.DeadLetter)
+	if _, err := w.store.List(listCh); err != nil {
+		ctx.Close()
+		return err
+	}
+	w.letters = make(map[types.ID]bool)
+	for letter := range listCh {
+		if letter.Controller == w.controller {
+			w.letters[letter.ID] = true
+		}
+	}
+	w.ctx = ctx
+
+	go func() {
+		for event := range letterCh {
+			letter := event.Object.(*deadletterstore.DeadLetter)
+			if letter.Controller != w.controller {
+				continue
+			}
+			if event.Type == stream.Deleted {
+				w.remove(letter.ID)
+				ch <- letter.ID
+			} else {
+				w.add(letter.ID)
+			}
+		}
+		close(ch)
+	}()
+	return nil
+}
+
+// Stop stops watching for dead letters
+func (w *deadLetterWatcher) Stop() {
+	w.mu.Lock()
+	if w.ctx != nil {
+		w.ctx.Close()
+		w.ctx = nil
+	}
+	w.mu.Unlock()
+}
+
+// put stores the dead letter of a request that the controller gives up on
+func (w *deadLetterWatcher) put(letter *deadletterstore.DeadLetter) error {
+	if err := w.store.Put(letter); err != nil {
+		return err
+	}
+	w.add(letter.ID)
+	return nil
+}
+
+// contains indicates whether the controller has given up on a request
+func (w *deadLetterWatcher) contains(id types.ID) bool {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	return w.letters[id]
+}
+
+func (w *deadLetterWatcher) add(id types.ID) {
+	w.mu.Lock()
+	if w.letters != nil {
+		w.letters[id] = true
+	}
+	w.mu.Unlock()
+}
+
+func (w *deadLetterWatcher) remove(id types.ID) {
+	w.mu.Lock()
+	delete(w.letters, id)
+	w.mu.Unlock()
+}
+
+var _ Watcher = &deadLetterWatcher{}
@@ -0,0 +1,184 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff"
+	types "github.com/onosproject/onos-api/go/onos/config"
+)
+
+// newWorkQueue returns a new, empty work queue
+func newWorkQueue() *workQueue {
+	q := &workQueue{
+		queued:     make(map[types.ID]bool),
+		processing: make(map[types.ID]bool),
+		dirty:      make(map[types.ID]bool),
+		retries:    make(map[types.ID]*retry),
+		timers:     make(map[types.ID]*timer),
+	}
+	q.cond = sync.NewCond(&q.mu)
+	return q
+}
+
+// workQueue is the queue of the requests of a partition of a controller.
+// A request is queued at most once at a time: a request that is added while it is queued is dropped, and a
+// request that is added while it is being processed is queued again once it is done. A request that fails is
+// retried after a delay that grows with each failure, without holding up the other requests. A request has at
+// most one delayed add pending at a time.
+type workQueue struct {
+	mu         sync.Mutex
+	cond       *sync.Cond
+	queue      []types.ID
+	queued     map[types.ID]bool
+	processing map[types.ID]bool
+	dirty      map[types.ID]bool
+	retries    map[types.ID]*retry
+	timers     map[types.ID]*timer
+}
+
+// timer is a delayed add of a request
+type timer struct {
+	timer    *time.Timer
+	deadline time.Time
+}
+
+// retry is the failures of a request since it last succeeded
+type retry struct {
+	attempts int
+	backoff  *backoff.ExponentialBackOff
+}
+
+// add adds a request to the queue, unless it is already queued
+func (q *workQueue) add(id types.ID) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.enqueue(id)
+}
+
+// enqueue adds a request to the queue, unless it is already queued. The caller must hold the lock.
+func (q *workQueue) enqueue(id types.ID) {
+	if q.queued[id] {
+		return
+	}
+	if q.processing[id] {
+		q.dirty[id] = true
+		return
+	}
+	q.queued[id] = true
+	q.queue = append(q.queue, id)
+	q.cond.Signal()
+}
+
+// addAfter adds a request to the queue once the given delay has elapsed. If the request is already due to be
+// added, only the earlier of the two adds is kept.
+func (q *workQueue) addAfter(id types.ID, delay time.Duration) {
+	if delay <= 0 {
+		q.add(id)
+		return
+	}
+
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	deadline := time.Now().Add(delay)
+	if t, ok := q.timers[id]; ok {
+		if !deadline.Before(t.deadline) {
+			return
+		}
+		t.timer.Stop()
+	}
+	t := &timer{
+		deadline: deadline,
+	}
+	t.timer = time.AfterFunc(delay, func() {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		// The timer may have been replaced by an earlier one after it fired
+		if q.timers[id] == t {
+			delete(q.timers, id)
+		}
+		q.enqueue(id)
+	})
+	q.timers[id] = t
+}
+
+// get waits for a request and marks it as being processed. done must be called once it is processed.
+func (q *workQueue) get() types.ID {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	for len(q.queue) == 0 {
+		q.cond.Wait()
+	}
+	id := q.queue[0]
+	q.queue = q.queue[1:]
+	delete(q.queued, id)
+	q.processing[id] = true
+	return id
+}
+
+// done marks a request as processed, and queues it again if it was added in the meantime
+func (q *workQueue) done(id types.ID) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.processing, id)
+	if q.dirty[id] {
+		delete(q.dirty, id)
+		q.queued[id] = true
+		q.queue = append(q.queue, id)
+		q.cond.Signal()
+	}
+}
+
+// failed records a failure of a request, and returns the number of times it has failed in a row along with
+// how long to wait before retrying it
+func (q *workQueue) failed(id types.ID) (int, time.Duration) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	r, ok := q.retries[id]
+	if !ok {
+		b := backoff.NewExponentialBackOff()
+		b.InitialInterval = initialRetryInterval
+		// MaxInterval caps the RetryInterval
+		b.MaxInterval = maxRetryInterval
+		// The number of attempts, rather than the elapsed time, limits the retries
+		b.MaxElapsedTime = 0
+		b.Reset()
+		r = &retry{backoff: b}
+		q.retries[id] = r
+	}
+	r.attempts++
+	// The randomization may take the delay beyond the maximum interval
+	delay := r.backoff.NextBackOff()
+	if delay > maxRetryInterval {
+		delay = maxRetryInterval
+	}
+	return r.attempts, delay
+}
+
+// forget clears the failures of a request, once it has succeeded or has been given up on
+func (q *workQueue) forget(id types.ID) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	delete(q.retries, id)
+}
+
+// len returns the number of queued requests
+func (q *workQueue) len() int {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	return len(q.queue)
+}
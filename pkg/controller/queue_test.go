@@ -0,0 +1,184 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package controller
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	types "github.com/onosproject/onos-api/go/onos/config"
+	deadletterstore "github.com/onosproject/onos-config/pkg/store/deadletter"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWorkQueue(t *testing.T) {
+	queue := newWorkQueue()
+
+	// A request that is already queued is not queued again
+	queue.add("1")
+	queue.add("2")
+	queue.add("1")
+	assert.Equal(t, 2, queue.len())
+
+	// A request that is added while it is processed is queued again once it is done
+	id := queue.get()
+	assert.Equal(t, types.ID("1"), id)
+	queue.add("1")
+	assert.Equal(t, 1, queue.len())
+	queue.done("1")
+	assert.Equal(t, 2, queue.len())
+	assert.Equal(t, types.ID("2"), queue.get())
+	queue.done("2")
+	assert.Equal(t, types.ID("1"), queue.get())
+	queue.done("1")
+	assert.Equal(t, 0, queue.len())
+
+	// The delay before a retry grows with each failure, up to the maximum retry interval
+	attempts, delay := queue.failed("1")
+	assert.Equal(t, 1, attempts)
+	assert.True(t, delay > 0 && delay <= maxRetryInterval)
+	for i := 0; i < 20; i++ {
+		attempts, delay = queue.failed("1")
+	}
+	assert.Equal(t, 21, attempts)
+	assert.True(t, delay > initialRetryInterval && delay <= maxRetryInterval)
+	queue.forget("1")
+	attempts, _ = queue.failed("1")
+	assert.Equal(t, 1, attempts)
+
+	queue.addAfter("3", 10*time.Millisecond)
+	assert.Equal(t, types.ID("3"), queue.get())
+	queue.done("3")
+
+	// A request has a single delayed add pending, the earliest one
+	queue.addAfter("4", time.Hour)
+	queue.addAfter("4", 10*time.Millisecond)
+	queue.addAfter("4", time.Hour)
+	assert.Equal(t, 1, len(queue.timers))
+	assert.Equal(t, types.ID("4"), queue.get())
+	queue.done("4")
+	assert.Equal(t, 0, len(queue.timers))
+}
+
+func TestControllerOrdered(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	reconciled := make(chan string, 10)
+	reconcile := func(result string, err error) func(types.ID) (Result, error) {
+		return func(id types.ID) (Result, error) {
+			reconciled <- string(id) + ":" + result
+			return Result{}, err
+		}
+	}
+	reconciler := NewMockReconciler(ctrl)
+	gomock.InOrder(
+		reconciler.EXPECT().Reconcile(gomock.Eq(types.ID("1"))).DoAndReturn(reconcile("error", errors.New("some error"))),
+		reconciler.EXPECT().Reconcile(gomock.Eq(types.ID("1"))).DoAndReturn(reconcile("error", errors.New("some error"))),
+		reconciler.EXPECT().Reconcile(gomock.Eq(types.ID("1"))).DoAndReturn(reconcile("ok", nil)),
+		reconciler.EXPECT().Reconcile(gomock.Eq(types.ID("2"))).DoAndReturn(reconcile("ok", nil)),
+	)
+
+	controller := NewController("Test").
+		Reconcile(reconciler)
+
+	ch := make(chan types.ID)
+	go controller.processEvents(ch)
+	ch <- types.ID("1")
+	ch <- types.ID("2")
+
+	// The request that fails is retried before the next request of the partition
+	for _, expected := range []string{"1:error", "1:error", "1:ok", "2:ok"} {
+		select {
+		case result := <-reconciled:
+			assert.Equal(t, expected, result)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("Timed out waiting for %s", expected)
+		}
+	}
+	close(ch)
+}
+
+func TestControllerDeadLetters(t *testing.T) {
+	ctrl := gomock.NewController(t)
+
+	store, err := deadletterstore.NewLocalStore()
+	assert.NoError(t, err)
+	defer store.Close()
+
+	watcherCh := make(chan chan<- types.ID, 1)
+	watcher := NewMockWatcher(ctrl)
+	watcher.EXPECT().
+		Start(gomock.Any()).
+		DoAndReturn(func(ch chan<- types.ID) error {
+			watcherCh <- ch
+			return nil
+		})
+	watcher.EXPECT().Stop()
+
+	reconciled := make(chan types.ID, 10)
+	reconciler := NewMockReconciler(ctrl)
+	reconciler.EXPECT().
+		Reconcile(gomock.Eq(types.ID("1"))).
+		Return(Result{}, errors.New("some error")).
+		Times(3)
+	reconciler.EXPECT().
+		Reconcile(gomock.Eq(types.ID("1"))).
+		DoAndReturn(func(id types.ID) (Result, error) {
+			reconciled <- id
+			return Result{}, nil
+		})
+
+	// A request that was given up on before the controller was activated is ignored
+	err = store.Put(&deadletterstore.DeadLetter{Controller: "Test", ID: types.ID("2"), Attempts: 3, Time: time.Now()})
+	assert.NoError(t, err)
+
+	controller := NewController("Test").
+		Watch(watcher).
+		MaxAttempts(3).
+		DeadLetters(store).
+		Reconcile(reconciler)
+	controller.activate()
+	defer controller.deactivate()
+
+	ch := <-watcherCh
+	ch <- types.ID("2")
+	ch <- types.ID("1")
+
+	// The request is given up on after three failed attempts
+	var letter *deadletterstore.DeadLetter
+	for i := 0; i < 500 && letter == nil; i++ {
+		time.Sleep(10 * time.Millisecond)
+		letter, err = store.Get("Test", types.ID("1"))
+		assert.NoError(t, err)
+	}
+	assert.NotNil(t, letter)
+	assert.Equal(t, 3, letter.Attempts)
+	assert.Equal(t, "some error", letter.Error)
+
+	// Its events are ignored until its dead letter is deleted, which requeues it
+	ch <- types.ID("1")
+	time.Sleep(100 * time.Millisecond)
+	assert.Equal(t, 0, len(reconciled))
+	err = store.Delete("Test", types.ID("1"))
+	assert.NoError(t, err)
+	select {
+	case id := <-reconciled:
+		assert.Equal(t, types.ID("1"), id)
+	case <-time.After(5 * time.Second):
+		t.Fatal("Timed out waiting for the requeued request")
+	}
+}
@@ -29,6 +29,7 @@ import (
 	networksnapshot "github.com/onosproject/onos-api/go/onos/config/snapshot/network"
 	"github.com/onosproject/onos-config/pkg/controller"
 	devicechangestore "github.com/onosproject/onos-config/pkg/store/change/device"
+	metadatastore "github.com/onosproject/onos-config/pkg/store/change/metadata"
 	networkchangestore "github.com/onosproject/onos-config/pkg/store/change/network"
 	leadershipstore "github.com/onosproject/onos-config/pkg/store/leadership"
 	devicesnapstore "github.com/onosproject/onos-config/pkg/store/snapshot/device"
@@ -40,7 +41,7 @@ var log = logging.GetLogger("controller", "snapshot", "network")
 
 // NewController returns a new network snapshot controller
 func NewController(leadership leadershipstore.Store, networkChanges networkchangestore.Store,
-	networkSnapshots networksnapstore.Store, deviceSnapshots devicesnapstore.Store,
+	networkMetadata metadatastore.Store, networkSnapshots networksnapstore.Store, deviceSnapshots devicesnapstore.Store,
 	deviceChanges devicechangestore.Store) *controller.Controller {
 
 	c := controller.NewController("NetworkSnapshot")
@@ -55,6 +56,7 @@ func NewController(leadership leadershipstore.Store, networkChanges networkchang
 	})
 	c.Reconcile(&Reconciler{
 		networkChanges:   networkChanges,
+		networkMetadata:  networkMetadata,
 		deviceChanges:    deviceChanges,
 		networkSnapshots: networkSnapshots,
 		deviceSnapshots:  deviceSnapshots,
@@ -65,6 +67,7 @@ func NewController(leadership leadershipstore.Store, networkChanges networkchang
 // Reconciler is a network snapshot reconciler
 type Reconciler struct {
 	networkChanges   networkchangestore.Store
+	networkMetadata  metadatastore.Store
 	deviceChanges    devicechangestore.Store
 	networkSnapshots networksnapstore.Store
 	deviceSnapshots  devicesnapstore.Store
@@ -369,6 +372,9 @@ func (r *Reconciler) deleteNetworkChanges(snapshot *networksnapshot.NetworkSnaps
 			if err := r.networkChanges.Delete(change); err != nil {
 				return false, err
 			}
+			if err := r.networkMetadata.Delete(change.ID); err != nil {
+				return false, err
+			}
 		}
 	}
 	return true, nil
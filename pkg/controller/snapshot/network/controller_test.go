@@ -25,6 +25,7 @@ import (
 	devicesnapshot "github.com/onosproject/onos-api/go/onos/config/snapshot/device"
 	networksnapshot "github.com/onosproject/onos-api/go/onos/config/snapshot/network"
 	devicechangestore "github.com/onosproject/onos-config/pkg/store/change/device"
+	metadatastore "github.com/onosproject/onos-config/pkg/store/change/metadata"
 	networkchangestore "github.com/onosproject/onos-config/pkg/store/change/network"
 	devicesnapstore "github.com/onosproject/onos-config/pkg/store/snapshot/device"
 	networksnapstore "github.com/onosproject/onos-config/pkg/store/snapshot/network"
@@ -42,14 +43,16 @@ const (
 )
 
 func TestReconcileNetworkSnapshotPhaseState(t *testing.T) {
-	networkChanges, networkSnapshots, deviceSnapshots, deviceChanges := newStores(t)
+	networkChanges, networkMetadata, networkSnapshots, deviceSnapshots, deviceChanges := newStores(t)
 	defer networkChanges.Close()
+	defer networkMetadata.Close()
 	defer networkSnapshots.Close()
 	defer deviceSnapshots.Close()
 	defer deviceChanges.Close()
 
 	reconciler := &Reconciler{
 		networkChanges:   networkChanges,
+		networkMetadata:  networkMetadata,
 		networkSnapshots: networkSnapshots,
 		deviceSnapshots:  deviceSnapshots,
 		deviceChanges:    deviceChanges,
@@ -317,16 +320,18 @@ func TestReconcileNetworkSnapshotPhaseState(t *testing.T) {
 	assert.Nil(t, networkChange4)
 }
 
-func newStores(t *testing.T) (networkchangestore.Store, networksnapstore.Store, devicesnapstore.Store, devicechangestore.Store) {
+func newStores(t *testing.T) (networkchangestore.Store, metadatastore.Store, networksnapstore.Store, devicesnapstore.Store, devicechangestore.Store) {
 	networkChanges, err := networkchangestore.NewLocalStore()
 	assert.NoError(t, err)
+	networkMetadata, err := metadatastore.NewLocalStore()
+	assert.NoError(t, err)
 	networkSnapshots, err := networksnapstore.NewLocalStore()
 	assert.NoError(t, err)
 	deviceSnapshots, err := devicesnapstore.NewLocalStore()
 	assert.NoError(t, err)
 	deviceChanges, err := devicechangestore.NewLocalStore()
 	assert.NoError(t, err)
-	return networkChanges, networkSnapshots, deviceSnapshots, deviceChanges
+	return networkChanges, networkMetadata, networkSnapshots, deviceSnapshots, deviceChanges
 }
 
 func newNetworkChange(id networkchange.ID, phase changetypes.Phase, state changetypes.State, devices ...devicebase.ID) *networkchange.NetworkChange {
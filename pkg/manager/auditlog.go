@@ -0,0 +1,86 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"fmt"
+
+	changetypes "github.com/onosproject/onos-api/go/onos/config/change"
+	networkchange "github.com/onosproject/onos-api/go/onos/config/change/network"
+	"github.com/onosproject/onos-config/pkg/audit"
+	"github.com/onosproject/onos-config/pkg/store/stream"
+)
+
+// Audit appends a record of a northbound operation to the audit log, if there is one. A record that can not
+// be appended is logged, but does not fail the operation.
+func (m *Manager) Audit(record *audit.Record) {
+	if m.AuditLog == nil {
+		return
+	}
+	if err := m.AuditLog.Append(record); err != nil {
+		log.Errorf("Failed to append audit record %v: %s", record, err)
+	}
+}
+
+// auditNetworkChanges records the final outcome of each network change, as it is completed or fails on the devices
+func (m *Manager) auditNetworkChanges() error {
+	ch := make(chan stream.Event)
+	if _, err := m.NetworkChangesStore.Watch(ch); err != nil {
+		return err
+	}
+	go func() {
+		// The last outcome recorded for each network change, so that an update that does not change the
+		// state of a change is not recorded again
+		recorded := make(map[networkchange.ID]string)
+		for event := range ch {
+			change := event.Object.(*networkchange.NetworkChange)
+			if event.Type == stream.Deleted {
+				delete(recorded, change.ID)
+				continue
+			}
+			if change.Status.State != changetypes.State_COMPLETE && change.Status.State != changetypes.State_FAILED {
+				continue
+			}
+			outcome := fmt.Sprintf("%s %s", change.Status.Phase, change.Status.State)
+			if recorded[change.ID] == outcome {
+				continue
+			}
+			recorded[change.ID] = outcome
+
+			var client string
+			if changeMetadata, err := m.NetworkMetadataStore.Get(change.ID); err != nil {
+				log.Warnf("Failed to get the metadata of network change %s: %s", change.ID, err)
+			} else if changeMetadata != nil {
+				client = changeMetadata.Username
+			}
+			record := &audit.Record{
+				Operation:       audit.OperationApply,
+				Client:          client,
+				NetworkChangeID: string(change.ID),
+				Details:         outcome,
+				Outcome:         audit.OutcomeSuccess,
+			}
+			for _, deviceChange := range change.Changes {
+				record.Paths = append(record.Paths, string(deviceChange.DeviceID))
+			}
+			if change.Status.State == changetypes.State_FAILED {
+				record.Outcome = audit.OutcomeFailure
+				record.Error = change.Status.Message
+			}
+			m.Audit(record)
+		}
+	}()
+	return nil
+}
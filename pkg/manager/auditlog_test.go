@@ -0,0 +1,99 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	changetypes "github.com/onosproject/onos-api/go/onos/config/change"
+	devicechange "github.com/onosproject/onos-api/go/onos/config/change/device"
+	networkchange "github.com/onosproject/onos-api/go/onos/config/change/network"
+	"github.com/onosproject/onos-config/pkg/audit"
+	"github.com/onosproject/onos-config/pkg/store/change/metadata"
+	networkstore "github.com/onosproject/onos-config/pkg/store/change/network"
+	"gotest.tools/assert"
+)
+
+func listAuditRecords(t *testing.T, auditLog audit.Log) []*audit.Record {
+	records := make([]*audit.Record, 0)
+	err := auditLog.Scan(time.Time{}, func(record *audit.Record) error {
+		records = append(records, record)
+		return nil
+	})
+	assert.NilError(t, err)
+	return records
+}
+
+// Test_AuditNetworkChanges shows that the outcome of a network change is recorded once it is complete
+func Test_AuditNetworkChanges(t *testing.T) {
+	dir, err := ioutil.TempDir("", "audit")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	auditLog, err := audit.NewFileLog(filepath.Join(dir, "audit.log"), []byte("audit-key"), false)
+	assert.NilError(t, err)
+	defer auditLog.Close()
+
+	networkChangesStore, err := networkstore.NewLocalStore()
+	assert.NilError(t, err)
+	defer networkChangesStore.Close()
+	networkMetadataStore, err := metadata.NewLocalStore()
+	assert.NilError(t, err)
+	defer networkMetadataStore.Close()
+
+	m := &Manager{
+		NetworkChangesStore:  networkChangesStore,
+		NetworkMetadataStore: networkMetadataStore,
+		AuditLog:             auditLog,
+	}
+	assert.NilError(t, m.auditNetworkChanges())
+
+	assert.NilError(t, networkMetadataStore.Create(&metadata.Metadata{ID: "audited-change", Username: "alice"}))
+	change := &networkchange.NetworkChange{
+		ID: "audited-change",
+		Changes: []*devicechange.Change{
+			{DeviceID: "device-1", DeviceVersion: "1.0.0"},
+		},
+	}
+	assert.NilError(t, networkChangesStore.Create(change))
+	change.Status.State = changetypes.State_COMPLETE
+	assert.NilError(t, networkChangesStore.Update(change))
+	// An update that does not change the outcome is not recorded again
+	change.Status.Message = "Confirmed"
+	assert.NilError(t, networkChangesStore.Update(change))
+	change.Status.Phase = changetypes.Phase_ROLLBACK
+	change.Status.State = changetypes.State_FAILED
+	change.Status.Message = "device-1 is unavailable"
+	assert.NilError(t, networkChangesStore.Update(change))
+
+	var records []*audit.Record
+	for i := 0; i < 50 && len(records) < 2; i++ {
+		time.Sleep(100 * time.Millisecond)
+		records = listAuditRecords(t, auditLog)
+	}
+	assert.Equal(t, len(records), 2)
+	assert.Equal(t, records[0].Operation, audit.OperationApply)
+	assert.Equal(t, records[0].Client, "alice")
+	assert.Equal(t, records[0].NetworkChangeID, "audited-change")
+	assert.DeepEqual(t, records[0].Paths, []string{"device-1"})
+	assert.Equal(t, records[0].Details, "CHANGE COMPLETE")
+	assert.Equal(t, records[0].Outcome, audit.OutcomeSuccess)
+	assert.Equal(t, records[1].Details, "ROLLBACK FAILED")
+	assert.Equal(t, records[1].Outcome, audit.OutcomeFailure)
+	assert.Equal(t, records[1].Error, "device-1 is unavailable")
+}
@@ -0,0 +1,71 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"fmt"
+	"time"
+
+	changetypes "github.com/onosproject/onos-api/go/onos/config/change"
+	networkchange "github.com/onosproject/onos-api/go/onos/config/change/network"
+)
+
+// confirmedMessage is the status message of a network change once it has been confirmed
+const confirmedMessage = "Confirmed"
+
+// ConfirmNetworkChange confirms a network change that was made with a confirmation timeout, so that it is not
+// rolled back when the timeout expires. The change must be complete, and the deadline must not have passed.
+// Later changes to the same devices are held until the change is confirmed, so the change is touched once
+// confirmed for the controller to move on to them.
+func (m *Manager) ConfirmNetworkChange(networkChangeID networkchange.ID) error {
+	change, errGet := m.NetworkChangesStore.Get(networkChangeID)
+	if errGet != nil {
+		log.Errorf("Error on get change %s for confirmation: %s", networkChangeID, errGet)
+		return errGet
+	} else if change == nil {
+		return fmt.Errorf("change %s not found", networkChangeID)
+	}
+	changeMetadata, errGet := m.NetworkMetadataStore.Get(networkChangeID)
+	if errGet != nil {
+		log.Errorf("Error on get metadata of change %s for confirmation: %s", networkChangeID, errGet)
+		return errGet
+	}
+
+	if changeMetadata == nil || changeMetadata.ConfirmTimeout == nil {
+		return fmt.Errorf("change %s does not require confirmation", networkChangeID)
+	} else if changeMetadata.Confirmed {
+		return nil
+	} else if change.Status.Phase != changetypes.Phase_CHANGE {
+		return fmt.Errorf("change %s can not be confirmed as it is being rolled back", networkChangeID)
+	} else if change.Status.State != changetypes.State_COMPLETE || changeMetadata.ConfirmDeadline == nil {
+		return fmt.Errorf("change %s can not be confirmed until it is complete: %s", networkChangeID, change.Status.State)
+	} else if time.Now().After(*changeMetadata.ConfirmDeadline) {
+		return fmt.Errorf("change %s can not be confirmed as its deadline %s has passed",
+			networkChangeID, changeMetadata.ConfirmDeadline.Format(time.RFC3339))
+	}
+
+	changeMetadata.Confirmed = true
+	if errUpdate := m.NetworkMetadataStore.Update(changeMetadata); errUpdate != nil {
+		log.Errorf("Error on confirming change %s: %s", networkChangeID, errUpdate)
+		return errUpdate
+	}
+	change.Status.Message = confirmedMessage
+	if errUpdate := m.NetworkChangesStore.Update(change); errUpdate != nil {
+		log.Errorf("Error on confirming change %s: %s", networkChangeID, errUpdate)
+		return errUpdate
+	}
+	log.Infof("Confirmed change %s", networkChangeID)
+	return nil
+}
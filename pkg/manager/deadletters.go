@@ -0,0 +1,102 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"fmt"
+	"sort"
+
+	types "github.com/onosproject/onos-api/go/onos/config"
+	"github.com/onosproject/onos-config/pkg/controller"
+	"github.com/onosproject/onos-config/pkg/store/deadletter"
+)
+
+// controllers returns the controllers of the manager
+func (m *Manager) controllers() []*controller.Controller {
+	return []*controller.Controller{
+		m.networkChangeController,
+		m.deviceChangeController,
+		m.networkSnapshotController,
+		m.deviceSnapshotController,
+	}
+}
+
+// checkController checks that the named controller, if a name is given, is one of the controllers of the manager
+func (m *Manager) checkController(name string) error {
+	if name == "" {
+		return nil
+	}
+	for _, c := range m.controllers() {
+		if c.Name() == name {
+			return nil
+		}
+	}
+	return fmt.Errorf("unknown controller %s", name)
+}
+
+// ListDeadLetters returns the requests that the named controller, or all the controllers if no name is given,
+// have given up on, oldest first
+func (m *Manager) ListDeadLetters(controllerName string) ([]*deadletter.DeadLetter, error) {
+	if err := m.checkController(controllerName); err != nil {
+		return nil, err
+	}
+	ch := make(chan *deadletter.DeadLetter)
+	ctx, err := m.DeadLetterStore.List(ch)
+	if err != nil {
+		return nil, err
+	}
+	defer ctx.Close()
+
+	letters := make([]*deadletter.DeadLetter, 0)
+	for letter := range ch {
+		if controllerName == "" || letter.Controller == controllerName {
+			letters = append(letters, letter)
+		}
+	}
+	sort.Slice(letters, func(i, j int) bool {
+		if letters[i].Time.Equal(letters[j].Time) {
+			return letters[i].ID < letters[j].ID
+		}
+		return letters[i].Time.Before(letters[j].Time)
+	})
+	return letters, nil
+}
+
+// RequeueDeadLetters gives the requests with the given IDs, or all of them if no ID is given, that the named
+// controller or all the controllers have given up on a new set of attempts. The requests are requeued by the
+// instance whose controller is in charge of them, once their dead letters are deleted. It returns the requests
+// that were requeued.
+func (m *Manager) RequeueDeadLetters(controllerName string, ids []types.ID) ([]*deadletter.DeadLetter, error) {
+	letters, err := m.ListDeadLetters(controllerName)
+	if err != nil {
+		return nil, err
+	}
+	requested := make(map[types.ID]bool)
+	for _, id := range ids {
+		requested[id] = true
+	}
+
+	requeued := make([]*deadletter.DeadLetter, 0)
+	for _, letter := range letters {
+		if len(ids) > 0 && !requested[letter.ID] {
+			continue
+		}
+		if err := m.DeadLetterStore.Delete(letter.Controller, letter.ID); err != nil {
+			return nil, err
+		}
+		requeued = append(requeued, letter)
+	}
+	return requeued, nil
+}
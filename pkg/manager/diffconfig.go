@@ -0,0 +1,155 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"sort"
+
+	devicechange "github.com/onosproject/onos-api/go/onos/config/change/device"
+	networkchange "github.com/onosproject/onos-api/go/onos/config/change/network"
+	devicetype "github.com/onosproject/onos-api/go/onos/config/device"
+	"github.com/onosproject/onos-config/pkg/utils"
+)
+
+// ConfigDiff is a path where the config of a device differs between two points in the history of network changes
+type ConfigDiff struct {
+	DeviceID      devicetype.ID
+	DeviceVersion devicetype.Version
+	Path          string
+	// Old is the value at the first point, nil if the path has been added
+	Old *devicechange.TypedValue
+	// New is the value at the second point, nil if the path has been removed
+	New *devicechange.TypedValue
+}
+
+// DiffNetworkChanges returns the differences in the config of the devices between the point just after the
+// first network change and the point just after the second one, as the config at a past network change is given
+// by a Get. Only the devices changed in between are compared, and if a device ID (which may contain wildcards) is
+// given only the matching devices.
+func (m *Manager) DiffNetworkChanges(fromChangeID networkchange.ID, toChangeID networkchange.ID,
+	deviceID devicetype.ID) ([]*ConfigDiff, error) {
+	log.Infof("Diff of config between %s and %s for %s", fromChangeID, toChangeID, deviceID)
+	fromIndex, fromTime, err := m.resolveConfigPoint(ConfigPoint{ChangeID: fromChangeID})
+	if err != nil {
+		return nil, err
+	}
+	toIndex, toTime, err := m.resolveConfigPoint(ConfigPoint{ChangeID: toChangeID})
+	if err != nil {
+		return nil, err
+	}
+
+	firstIndex, lastIndex := fromIndex, toIndex
+	if firstIndex > lastIndex {
+		firstIndex, lastIndex = lastIndex, firstIndex
+	}
+	devices, err := m.getChangedDevices(firstIndex, lastIndex, deviceID)
+	if err != nil {
+		return nil, err
+	}
+
+	diffs := make([]*ConfigDiff, 0)
+	for _, device := range devices {
+		versionedID := devicetype.NewVersionedID(device.DeviceID, device.DeviceVersion)
+		fromValues, err := m.getConfigAt(versionedID, fromIndex, fromTime)
+		if err != nil {
+			return nil, err
+		}
+		toValues, err := m.getConfigAt(versionedID, toIndex, toTime)
+		if err != nil {
+			return nil, err
+		}
+		diffs = append(diffs, diffConfigValues(device.DeviceID, device.DeviceVersion, fromValues, toValues)...)
+	}
+	return diffs, nil
+}
+
+// getChangedDevices returns the devices changed by the network changes after the first index up to the last
+// index, sorted by ID and version
+func (m *Manager) getChangedDevices(firstIndex networkchange.Index, lastIndex networkchange.Index,
+	deviceID devicetype.ID) ([]*devicechange.Change, error) {
+	matcher := utils.MatchWildcardChNameRegexp(string(deviceID))
+	changeCh := make(chan *networkchange.NetworkChange)
+	ctx, err := m.NetworkChangesStore.List(changeCh)
+	if err != nil {
+		return nil, err
+	}
+	defer ctx.Close()
+
+	devices := make(map[devicetype.VersionedID]*devicechange.Change)
+	for change := range changeCh {
+		if change.Index <= firstIndex || change.Index > lastIndex {
+			continue
+		}
+		for _, deviceChange := range change.Changes {
+			if deviceID != "" && !matcher.MatchString(string(deviceChange.DeviceID)) {
+				continue
+			}
+			devices[deviceChange.GetVersionedDeviceID()] = deviceChange
+		}
+	}
+
+	changedDevices := make([]*devicechange.Change, 0, len(devices))
+	for _, device := range devices {
+		changedDevices = append(changedDevices, device)
+	}
+	sort.Slice(changedDevices, func(i, j int) bool {
+		return changedDevices[i].GetVersionedDeviceID() < changedDevices[j].GetVersionedDeviceID()
+	})
+	return changedDevices, nil
+}
+
+// diffConfigValues compares two sorted sets of config values of a device
+func diffConfigValues(deviceID devicetype.ID, version devicetype.Version,
+	fromValues []*devicechange.PathValue, toValues []*devicechange.PathValue) []*ConfigDiff {
+	toMap := make(map[string]*devicechange.TypedValue)
+	for _, value := range toValues {
+		toMap[value.Path] = value.Value
+	}
+	fromMap := make(map[string]*devicechange.TypedValue)
+	for _, value := range fromValues {
+		fromMap[value.Path] = value.Value
+	}
+
+	diffs := make([]*ConfigDiff, 0)
+	for _, value := range fromValues {
+		newValue, ok := toMap[value.Path]
+		if ok && newValue.GetType() == value.GetValue().GetType() &&
+			newValue.ValueToString() == value.GetValue().ValueToString() {
+			continue
+		}
+		diffs = append(diffs, &ConfigDiff{
+			DeviceID:      deviceID,
+			DeviceVersion: version,
+			Path:          value.Path,
+			Old:           value.Value,
+			New:           newValue,
+		})
+	}
+	for _, value := range toValues {
+		if _, ok := fromMap[value.Path]; ok {
+			continue
+		}
+		diffs = append(diffs, &ConfigDiff{
+			DeviceID:      deviceID,
+			DeviceVersion: version,
+			Path:          value.Path,
+			New:           value.Value,
+		})
+	}
+	sort.Slice(diffs, func(i, j int) bool {
+		return diffs[i].Path < diffs[j].Path
+	})
+	return diffs
+}
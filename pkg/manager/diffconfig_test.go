@@ -0,0 +1,54 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"testing"
+
+	devicechange "github.com/onosproject/onos-api/go/onos/config/change/device"
+	devicetype "github.com/onosproject/onos-api/go/onos/config/device"
+	"gotest.tools/assert"
+)
+
+func Test_diffConfigValues(t *testing.T) {
+	from := []*devicechange.PathValue{
+		{Path: "/cont1a/leaf1a", Value: devicechange.NewTypedValueString("unchanged")},
+		{Path: "/cont1a/leaf1b", Value: devicechange.NewTypedValueString("removed")},
+		{Path: "/cont1a/leaf1c", Value: devicechange.NewTypedValueUint(1, 8)},
+	}
+	to := []*devicechange.PathValue{
+		{Path: "/cont1a/leaf1a", Value: devicechange.NewTypedValueString("unchanged")},
+		{Path: "/cont1a/leaf1c", Value: devicechange.NewTypedValueUint(2, 8)},
+		{Path: "/cont1a/leaf1d", Value: devicechange.NewTypedValueBool(true)},
+	}
+
+	diffs := diffConfigValues(device1, deviceVersion1, from, to)
+	assert.Equal(t, len(diffs), 3)
+
+	assert.Equal(t, diffs[0].Path, "/cont1a/leaf1b")
+	assert.Equal(t, diffs[0].Old.ValueToString(), "removed")
+	assert.Assert(t, diffs[0].New == nil)
+
+	assert.Equal(t, diffs[1].Path, "/cont1a/leaf1c")
+	assert.Equal(t, diffs[1].Old.ValueToString(), "1")
+	assert.Equal(t, diffs[1].New.ValueToString(), "2")
+
+	assert.Equal(t, diffs[2].Path, "/cont1a/leaf1d")
+	assert.Assert(t, diffs[2].Old == nil)
+	assert.Equal(t, diffs[2].New.ValueToString(), "true")
+	assert.Equal(t, diffs[2].DeviceID, devicetype.ID(device1))
+
+	assert.Equal(t, len(diffConfigValues(device1, deviceVersion1, from, from)), 0)
+}
@@ -15,12 +15,28 @@
 package manager
 
 import (
+	"fmt"
+	"sort"
+	"time"
+
+	changetypes "github.com/onosproject/onos-api/go/onos/config/change"
 	devicechange "github.com/onosproject/onos-api/go/onos/config/change/device"
 	networkchange "github.com/onosproject/onos-api/go/onos/config/change/network"
 	devicetype "github.com/onosproject/onos-api/go/onos/config/device"
 	"github.com/onosproject/onos-config/pkg/utils"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
 )
 
+// ConfigPoint is a point in the history of network changes. Only one of its fields is expected to be set.
+type ConfigPoint struct {
+	// ChangeID is the network change just after which the config is wanted
+	ChangeID networkchange.ID
+	// Index is the index of the network change just after which the config is wanted
+	Index networkchange.Index
+	// Time is the time at which the config is wanted
+	Time *time.Time
+}
+
 // GetTargetConfig returns a set of change values given a target, a configuration name, a path and a layer.
 // The layer is the numbers of config changes we want to go back in time for. 0 is the latest (Atomix based)
 func (m *Manager) GetTargetConfig(deviceID devicetype.ID, version devicetype.Version, path string, revision networkchange.Revision) ([]*devicechange.PathValue, error) {
@@ -30,8 +46,154 @@ func (m *Manager) GetTargetConfig(deviceID devicetype.ID, version devicetype.Ver
 		log.Error("Error while extracting config", errGetTargetCfg)
 		return nil, errGetTargetCfg
 	}
+	return filterConfigValues(configValues, path), nil
+}
+
+// GetTargetConfigAt returns a set of change values given a target, a path and a point in the history of network
+// changes. The config is rebuilt from the snapshot of the device and the network changes made after it, so
+// points that are older than the snapshot can not be given.
+func (m *Manager) GetTargetConfigAt(deviceID devicetype.ID, version devicetype.Version, path string, point ConfigPoint) ([]*devicechange.PathValue, error) {
+	log.Infof("Getting config for %s at %s as of %v", deviceID, path, point)
+	index, at, err := m.resolveConfigPoint(point)
+	if err != nil {
+		return nil, err
+	}
+	configValues, err := m.getConfigAt(devicetype.NewVersionedID(deviceID, version), index, at)
+	if err != nil {
+		return nil, err
+	}
+	return filterConfigValues(configValues, path), nil
+}
+
+// getConfigAt rebuilds the config of the device just after the network change with the given index, at the given
+// time, from the snapshot of the device and the network changes made after it
+func (m *Manager) getConfigAt(versionedID devicetype.VersionedID, index networkchange.Index, at time.Time) ([]*devicechange.PathValue, error) {
+	state := make(map[string]*devicechange.TypedValue)
+	var snapshotIndex networkchange.Index
+	snapshot, err := m.DeviceSnapshotStore.Load(versionedID)
+	if err != nil && !errors.IsNotFound(err) {
+		return nil, err
+	} else if err == nil && snapshot != nil {
+		snapshotIndex = networkchange.Index(snapshot.ChangeIndex)
+		if index < snapshotIndex {
+			return nil, fmt.Errorf("config of %s at change index %d has been compacted in a snapshot at index %d",
+				versionedID, index, snapshotIndex)
+		}
+		for _, value := range snapshot.Values {
+			state[value.Path] = value.Value
+		}
+	}
+
+	changeCh := make(chan *networkchange.NetworkChange)
+	ctx, err := m.NetworkChangesStore.List(changeCh)
+	if err != nil {
+		return nil, err
+	}
+	defer ctx.Close()
+
+	for change := range changeCh {
+		if change.Index <= snapshotIndex || change.Index > index {
+			continue
+		}
+		if applied, err := m.wasAppliedAt(change, at); err != nil {
+			return nil, err
+		} else if !applied {
+			continue
+		}
+		for _, deviceChange := range change.Changes {
+			if deviceChange.GetVersionedDeviceID() != versionedID {
+				continue
+			}
+			for _, value := range deviceChange.Values {
+				if value.Removed {
+					for statePath := range state {
+						if utils.IsPathUnder(statePath, value.Path) {
+							delete(state, statePath)
+						}
+					}
+				} else {
+					state[value.Path] = value.Value
+				}
+			}
+		}
+	}
+
+	configValues := make([]*devicechange.PathValue, 0, len(state))
+	for statePath, value := range state {
+		configValues = append(configValues, &devicechange.PathValue{
+			Path:  statePath,
+			Value: value,
+		})
+	}
+	sort.Slice(configValues, func(i, j int) bool {
+		return configValues[i].Path < configValues[j].Path
+	})
+	return configValues, nil
+}
+
+// wasAppliedAt indicates whether the values of the given network change were in place at the given time. A change
+// counts once it is complete, and a change that has since been rolled back still counts if the rollback happened
+// after the given time or has not completed.
+func (m *Manager) wasAppliedAt(change *networkchange.NetworkChange, at time.Time) (bool, error) {
+	switch change.Status.Phase {
+	case changetypes.Phase_CHANGE:
+		return change.Status.State == changetypes.State_COMPLETE, nil
+	case changetypes.Phase_ROLLBACK:
+		changeMetadata, err := m.NetworkMetadataStore.Get(change.ID)
+		if err != nil {
+			return false, err
+		} else if changeMetadata == nil || changeMetadata.RolledBack == nil {
+			return false, nil
+		}
+		return changeMetadata.RolledBack.After(at) || change.Status.State != changetypes.State_COMPLETE, nil
+	}
+	return false, nil
+}
+
+// resolveConfigPoint returns the index of the last network change made at the given point, and the time of the point
+func (m *Manager) resolveConfigPoint(point ConfigPoint) (networkchange.Index, time.Time, error) {
+	if point.ChangeID != "" {
+		change, err := m.NetworkChangesStore.Get(point.ChangeID)
+		if err != nil {
+			return 0, time.Time{}, err
+		} else if change == nil {
+			return 0, time.Time{}, fmt.Errorf("network change %s not found", point.ChangeID)
+		}
+		return change.Index, change.Created, nil
+	}
+
+	if point.Index > 0 {
+		change, err := m.NetworkChangesStore.GetByIndex(point.Index)
+		if err != nil {
+			return 0, time.Time{}, err
+		} else if change == nil {
+			return 0, time.Time{}, fmt.Errorf("network change at index %d not found", point.Index)
+		}
+		return change.Index, change.Created, nil
+	}
+
+	if point.Time != nil {
+		var index networkchange.Index
+		changeCh := make(chan *networkchange.NetworkChange)
+		ctx, err := m.NetworkChangesStore.List(changeCh)
+		if err != nil {
+			return 0, time.Time{}, err
+		}
+		defer ctx.Close()
+		for change := range changeCh {
+			if !change.Created.After(*point.Time) && change.Index > index {
+				index = change.Index
+			}
+		}
+		return index, *point.Time, nil
+	}
+	return 0, time.Time{}, fmt.Errorf("no network change, index or time given")
+}
+
+// filterConfigValues returns the config values that match the given path, which may contain wildcards
+func filterConfigValues(configValues []*devicechange.PathValue, path string) []*devicechange.PathValue {
 	if len(configValues) == 0 {
-		return configValues, nil
+		return configValues
 	}
 	filteredValues := make([]*devicechange.PathValue, 0)
 	pathRegexp := utils.MatchWildcardRegexp(path)
@@ -41,7 +203,7 @@ func (m *Manager) GetTargetConfig(deviceID devicetype.ID, version devicetype.Ver
 		}
 	}
 	//TODO if filteredValue is empty return error
-	return filteredValues, nil
+	return filteredValues
 }
 
 // GetAllDeviceIds returns a list of just DeviceIDs from the device cache
@@ -0,0 +1,65 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"fmt"
+	"time"
+
+	devicetype "github.com/onosproject/onos-api/go/onos/config/device"
+	"github.com/onosproject/onos-config/pkg/store/lock"
+)
+
+// LockDevices locks the configuration of the given devices for a client until the ttl expires, so that
+// changes to them from other clients are refused. Locks the client already holds are renewed.
+func (m *Manager) LockDevices(deviceIDs []devicetype.ID, owner string, ttl time.Duration) ([]*lock.DeviceLock, error) {
+	if len(deviceIDs) == 0 {
+		return nil, fmt.Errorf("no device to lock")
+	}
+	locks, err := m.DeviceLockStore.Lock(deviceIDs, owner, ttl)
+	if err != nil {
+		log.Errorf("Error on locking devices %v for %s: %s", deviceIDs, owner, err)
+		return nil, err
+	}
+	log.Infof("Locked devices %v for %s for %v", deviceIDs, owner, ttl)
+	return locks, nil
+}
+
+// UnlockDevices releases the locks held by a client on the given devices
+func (m *Manager) UnlockDevices(deviceIDs []devicetype.ID, owner string) error {
+	if len(deviceIDs) == 0 {
+		return fmt.Errorf("no device to unlock")
+	}
+	if err := m.DeviceLockStore.Unlock(deviceIDs, owner); err != nil {
+		log.Errorf("Error on unlocking devices %v for %s: %s", deviceIDs, owner, err)
+		return err
+	}
+	log.Infof("Unlocked devices %v for %s", deviceIDs, owner)
+	return nil
+}
+
+// GetDeviceLockConflicts gives the locks held by clients other than the given one on any of the devices
+func (m *Manager) GetDeviceLockConflicts(deviceIDs []devicetype.ID, owner string) ([]*lock.DeviceLock, error) {
+	conflicts := make([]*lock.DeviceLock, 0)
+	for _, deviceID := range deviceIDs {
+		deviceLock, err := m.DeviceLockStore.Get(deviceID)
+		if err != nil {
+			return nil, err
+		} else if deviceLock != nil && deviceLock.Owner != owner {
+			conflicts = append(conflicts, deviceLock)
+		}
+	}
+	return conflicts, nil
+}
@@ -21,6 +21,7 @@ import (
 
 	devicechange "github.com/onosproject/onos-api/go/onos/config/change/device"
 	devicetype "github.com/onosproject/onos-api/go/onos/config/device"
+	"github.com/onosproject/onos-config/pkg/audit"
 	"github.com/onosproject/onos-config/pkg/controller"
 	devicechangectl "github.com/onosproject/onos-config/pkg/controller/change/device"
 	networkchangectl "github.com/onosproject/onos-config/pkg/controller/change/network"
@@ -34,14 +35,18 @@ import (
 	"github.com/onosproject/onos-config/pkg/southbound/synchronizer"
 	"github.com/onosproject/onos-config/pkg/store/change/device"
 	"github.com/onosproject/onos-config/pkg/store/change/device/state"
+	"github.com/onosproject/onos-config/pkg/store/change/metadata"
 	"github.com/onosproject/onos-config/pkg/store/change/network"
+	"github.com/onosproject/onos-config/pkg/store/deadletter"
 	devicestore "github.com/onosproject/onos-config/pkg/store/device"
 	"github.com/onosproject/onos-config/pkg/store/device/cache"
 	"github.com/onosproject/onos-config/pkg/store/leadership"
+	"github.com/onosproject/onos-config/pkg/store/lock"
 	"github.com/onosproject/onos-config/pkg/store/mastership"
 	devicesnap "github.com/onosproject/onos-config/pkg/store/snapshot/device"
 	networksnap "github.com/onosproject/onos-config/pkg/store/snapshot/network"
 	"github.com/onosproject/onos-lib-go/pkg/logging"
+	"github.com/openconfig/goyang/pkg/yang"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 )
@@ -59,8 +64,11 @@ type Manager struct {
 	DeviceStore               devicestore.Store
 	DeviceCache               cache.Cache
 	NetworkChangesStore       network.Store
+	NetworkMetadataStore      metadata.Store
 	NetworkSnapshotStore      networksnap.Store
 	DeviceSnapshotStore       devicesnap.Store
+	DeviceLockStore           lock.Store
+	DeadLetterStore           deadletter.Store
 	networkChangeController   *controller.Controller
 	deviceChangeController    *controller.Controller
 	networkSnapshotController *controller.Controller
@@ -72,23 +80,33 @@ type Manager struct {
 	Dispatcher                *dispatcher.Dispatcher
 	OperationalStateCache     map[topodevice.ID]devicechange.TypedValueMap
 	OperationalStateCacheLock *sync.RWMutex
+	ConfigDriftCache          map[topodevice.ID][]*synchronizer.ConfigDrift
+	ConfigDriftCacheLock      *sync.RWMutex
+	ConfigDriftPolicy         synchronizer.DriftPolicy
+	AuditLog                  audit.Log
+	DeviceWorkers             int
+	ApplyTimeouts             *devicechangectl.ApplyTimeouts
+	MaxReconcileAttempts      int
 	allowUnvalidatedConfig    bool
 }
 
 // NewManager initializes the network config manager subsystem.
 func NewManager(leadershipStore leadership.Store, mastershipStore mastership.Store, deviceChangesStore device.Store,
 	deviceStateStore state.Store, deviceStore devicestore.Store, deviceCache cache.Cache,
-	networkChangesStore network.Store, networkSnapshotStore networksnap.Store,
-	deviceSnapshotStore devicesnap.Store, allowUnvalidatedConfig bool) *Manager {
+	networkChangesStore network.Store, networkMetadataStore metadata.Store, networkSnapshotStore networksnap.Store,
+	deviceSnapshotStore devicesnap.Store, deviceLockStore lock.Store, deadLetterStore deadletter.Store,
+	allowUnvalidatedConfig bool) *Manager {
 	log.Info("Creating Manager")
 
 	modelReg := &modelregistry.ModelRegistry{
 		ModelPlugins:        make(map[string]modelregistry.ModelPlugin),
 		ModelReadOnlyPaths:  make(map[string]modelregistry.ReadOnlyPathMap),
 		ModelReadWritePaths: make(map[string]modelregistry.ReadWritePathMap),
+		ModelSchemas:        make(map[string]map[string]*yang.Entry),
 		LocationStore:       make(map[string]string),
 	}
 
+	applyTimeouts := devicechangectl.NewApplyTimeouts()
 	mgr = Manager{
 		DeviceChangesStore:        deviceChangesStore,
 		DeviceStateStore:          deviceStateStore,
@@ -96,11 +114,14 @@ func NewManager(leadershipStore leadership.Store, mastershipStore mastership.Sto
 		DeviceCache:               deviceCache,
 		MastershipStore:           mastershipStore,
 		NetworkChangesStore:       networkChangesStore,
+		NetworkMetadataStore:      networkMetadataStore,
 		NetworkSnapshotStore:      networkSnapshotStore,
 		DeviceSnapshotStore:       deviceSnapshotStore,
-		networkChangeController:   networkchangectl.NewController(leadershipStore, deviceCache, deviceStore, networkChangesStore, deviceChangesStore),
-		deviceChangeController:    devicechangectl.NewController(mastershipStore, deviceStore, deviceCache, deviceChangesStore),
-		networkSnapshotController: networksnapshotctl.NewController(leadershipStore, networkChangesStore, networkSnapshotStore, deviceSnapshotStore, deviceChangesStore),
+		DeviceLockStore:           deviceLockStore,
+		DeadLetterStore:           deadLetterStore,
+		networkChangeController:   networkchangectl.NewController(leadershipStore, deviceCache, deviceStore, networkChangesStore, networkMetadataStore, deviceChangesStore, deviceLockStore),
+		deviceChangeController:    devicechangectl.NewController(mastershipStore, deviceStore, deviceCache, deviceChangesStore, networkMetadataStore, applyTimeouts),
+		networkSnapshotController: networksnapshotctl.NewController(leadershipStore, networkChangesStore, networkMetadataStore, networkSnapshotStore, deviceSnapshotStore, deviceChangesStore),
 		deviceSnapshotController:  devicesnapshotctl.NewController(mastershipStore, deviceChangesStore, deviceSnapshotStore),
 		TopoChannel:               make(chan *topodevice.ListResponse, 10),
 		ModelRegistry:             modelReg,
@@ -109,8 +130,17 @@ func NewManager(leadershipStore leadership.Store, mastershipStore mastership.Sto
 		Dispatcher:                dispatcher.NewDispatcher(),
 		OperationalStateCache:     make(map[topodevice.ID]devicechange.TypedValueMap),
 		OperationalStateCacheLock: &sync.RWMutex{},
+		ConfigDriftCache:          make(map[topodevice.ID][]*synchronizer.ConfigDrift),
+		ConfigDriftCacheLock:      &sync.RWMutex{},
+		ConfigDriftPolicy:         synchronizer.DriftPolicyReport,
+		DeviceWorkers:             DefaultDeviceWorkers,
+		ApplyTimeouts:             applyTimeouts,
+		MaxReconcileAttempts:      controller.DefaultMaxAttempts,
 		allowUnvalidatedConfig:    allowUnvalidatedConfig,
 	}
+	for _, c := range mgr.controllers() {
+		c.DeadLetters(deadLetterStore)
+	}
 	return &mgr
 }
 
@@ -123,6 +153,10 @@ func (m *Manager) setTargetGenerator(targetGen func() southbound.TargetIf) {
 func (m *Manager) Run() {
 	log.Info("Starting Manager")
 
+	for _, c := range m.controllers() {
+		c.MaxAttempts(m.MaxReconcileAttempts)
+	}
+
 	// Start the NetworkChange controller
 	errNetworkCtrl := m.networkChangeController.Start()
 	if errNetworkCtrl != nil {
@@ -144,6 +178,13 @@ func (m *Manager) Run() {
 		log.Error("Can't start controller ", errDeviceSnapshotCtrl)
 	}
 
+	// Record the outcome of the network changes in the audit log
+	if m.AuditLog != nil {
+		if err := m.auditNetworkChanges(); err != nil {
+			log.Error("Can't audit network changes ", err)
+		}
+	}
+
 	// Start the main dispatcher system
 	go m.Dispatcher.ListenOperationalState(m.OperationalStateChannel)
 
@@ -156,6 +197,11 @@ func (m *Manager) Run() {
 		synchronizer.WithNewTargetFn(southbound.TargetGenerator),
 		synchronizer.WithOperationalStateCacheLock(m.OperationalStateCacheLock),
 		synchronizer.WithDeviceChangeStore(m.DeviceChangesStore),
+		synchronizer.WithDeviceStateStore(m.DeviceStateStore),
+		synchronizer.WithNetworkChangeStore(m.NetworkChangesStore),
+		synchronizer.WithConfigDriftCache(m.ConfigDriftCache),
+		synchronizer.WithConfigDriftCacheLock(m.ConfigDriftCacheLock),
+		synchronizer.WithDriftPolicy(m.ConfigDriftPolicy),
 		synchronizer.WithMastershipStore(m.MastershipStore),
 		synchronizer.WithDeviceStore(m.DeviceStore),
 		synchronizer.WithSessions(make(map[topodevice.ID]*synchronizer.Session)),
@@ -190,7 +236,7 @@ func GetManager() *Manager {
 // on the configuration for the specified target
 func (m *Manager) ComputeDeviceChange(deviceName devicetype.ID, version devicetype.Version,
 	deviceType devicetype.Type, updates devicechange.TypedValueMap,
-	deletes []string, description string) (*devicechange.Change, error) {
+	deletes []string) (*devicechange.Change, error) {
 
 	var newChanges = make([]*devicechange.ChangeValue, 0)
 	//updates
@@ -207,11 +253,6 @@ func (m *Manager) ComputeDeviceChange(deviceName devicetype.ID, version devicety
 		deleteValue, _ := devicechange.NewChangeValue(path, devicechange.NewTypedValueEmpty(), true)
 		newChanges = append(newChanges, deleteValue)
 	}
-	//description := fmt.Sprintf("Originally created as part of %s", description)
-	//if description == "" {
-	//	description = fmt.Sprintf("Created at %s", time.Now().Format(time.RFC3339))
-	//}
-	//TODO lost description of Change
 	changeElement := &devicechange.Change{
 		DeviceID:      deviceName,
 		DeviceVersion: version,
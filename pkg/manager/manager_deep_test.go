@@ -30,9 +30,12 @@ import (
 	"github.com/onosproject/onos-config/pkg/southbound"
 	devicechanges "github.com/onosproject/onos-config/pkg/store/change/device"
 	"github.com/onosproject/onos-config/pkg/store/change/device/state"
+	"github.com/onosproject/onos-config/pkg/store/change/metadata"
 	networkstore "github.com/onosproject/onos-config/pkg/store/change/network"
+	"github.com/onosproject/onos-config/pkg/store/deadletter"
 	"github.com/onosproject/onos-config/pkg/store/device/cache"
 	"github.com/onosproject/onos-config/pkg/store/leadership"
+	"github.com/onosproject/onos-config/pkg/store/lock"
 	"github.com/onosproject/onos-config/pkg/store/mastership"
 	devicesnapstore "github.com/onosproject/onos-config/pkg/store/snapshot/device"
 	networksnapstore "github.com/onosproject/onos-config/pkg/store/snapshot/network"
@@ -120,6 +123,8 @@ func setUpDeepTest(t *testing.T) (*Manager, *AllMocks) {
 
 	networkChangesStore, err := networkstore.NewLocalStore()
 	assert.NilError(t, err)
+	networkMetadataStore, err := metadata.NewLocalStore()
+	assert.NilError(t, err)
 	deviceChangesStore, err := devicechanges.NewLocalStore()
 	assert.NilError(t, err)
 	networkSnapshotStore, err := networksnapstore.NewLocalStore()
@@ -136,9 +141,14 @@ func setUpDeepTest(t *testing.T) (*Manager, *AllMocks) {
 	assert.NilError(t, err)
 	mastershipStore, err := mastership.NewLocalStore("test", cluster.NodeID("node1"))
 	assert.NilError(t, err)
+	deviceLockStore, err := lock.NewLocalStore()
+	assert.NilError(t, err)
+	deadLetterStore, err := deadletter.NewLocalStore()
+	assert.NilError(t, err)
 
 	mgrTest = NewManager(leadershipStore, mastershipStore, deviceChangesStore, deviceStateStore,
-		mockDeviceStore, deviceCache, networkChangesStore, networkSnapshotStore, deviceSnapshotStore, true)
+		mockDeviceStore, deviceCache, networkChangesStore, networkMetadataStore, networkSnapshotStore, deviceSnapshotStore,
+		deviceLockStore, deadLetterStore, true)
 
 	modelData1 := gnmi.ModelData{
 		Name:         "test1",
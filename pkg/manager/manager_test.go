@@ -24,8 +24,11 @@ import (
 	devicetype "github.com/onosproject/onos-api/go/onos/config/device"
 	topodevice "github.com/onosproject/onos-config/pkg/device"
 	"github.com/onosproject/onos-config/pkg/modelregistry"
+	"github.com/onosproject/onos-config/pkg/store/change/metadata"
 	networkstore "github.com/onosproject/onos-config/pkg/store/change/network"
+	"github.com/onosproject/onos-config/pkg/store/deadletter"
 	"github.com/onosproject/onos-config/pkg/store/device/cache"
+	"github.com/onosproject/onos-config/pkg/store/lock"
 	"github.com/onosproject/onos-config/pkg/store/stream"
 	mockstore "github.com/onosproject/onos-config/pkg/test/mocks/store"
 	mockcache "github.com/onosproject/onos-config/pkg/test/mocks/store/cache"
@@ -162,14 +165,14 @@ func setUp(t *testing.T) (*Manager, *AllMocks) {
 	_ = mockNetworkChangesStore.Create(networkChange1)
 
 	mockNetworkChangesStore.EXPECT().List(gomock.Any()).DoAndReturn(
-		func(c chan<- *networkchange.NetworkChange) error {
+		func(c chan<- *networkchange.NetworkChange) (stream.Context, error) {
 			go func() {
 				for _, networkChange := range networkChangesList {
 					c <- networkChange
 				}
 				close(c)
 			}()
-			return nil
+			return stream.NewContext(func() {}), nil
 		}).AnyTimes()
 	mockNetworkChangesStore.EXPECT().Watch(gomock.Any(), gomock.Any()).DoAndReturn(
 		func(c chan<- stream.Event, o ...networkstore.WatchOption) (stream.Context, error) {
@@ -252,6 +255,22 @@ func setUp(t *testing.T) (*Manager, *AllMocks) {
 	mockDeviceStore := mockstore.NewMockDeviceStore(ctrl)
 	mockDeviceStore.EXPECT().Watch(gomock.Any()).AnyTimes()
 
+	// Mock Device Lock Store
+	mockDeviceLockStore := mockstore.NewMockDeviceLockStore(ctrl)
+	mockDeviceLockStore.EXPECT().Get(gomock.Any()).Return(nil, nil).AnyTimes()
+
+	// Mock Network Change Metadata Store
+	mockMetadataStore := mockstore.NewMockNetworkChangeMetadataStore(ctrl)
+	mockstore.SetUpMapBackedNetworkChangeMetadataStore(mockMetadataStore)
+
+	// Mock Dead Letter Store
+	mockDeadLetterStore := mockstore.NewMockDeadLetterStore(ctrl)
+	mockDeadLetterStore.EXPECT().List(gomock.Any()).DoAndReturn(func(ch chan<- *deadletter.DeadLetter) (stream.Context, error) {
+		close(ch)
+		return stream.NewContext(func() {}), nil
+	}).AnyTimes()
+	mockDeadLetterStore.EXPECT().Watch(gomock.Any()).Return(stream.NewContext(func() {}), nil).AnyTimes()
+
 	mgrTest = NewManager(
 		mockLeadershipStore,
 		mockMastershipStore,
@@ -260,8 +279,11 @@ func setUp(t *testing.T) (*Manager, *AllMocks) {
 		mockDeviceStore,
 		mockDeviceCache,
 		mockNetworkChangesStore,
+		mockMetadataStore,
 		mockNetworkSnapshotStore,
 		mockDeviceSnapshotStore,
+		mockDeviceLockStore,
+		mockDeadLetterStore,
 		true)
 
 	mgrTest.Run()
@@ -275,6 +297,9 @@ func setUp(t *testing.T) (*Manager, *AllMocks) {
 		DeviceSnapshotStore:  mockDeviceSnapshotStore,
 		LeadershipStore:      mockLeadershipStore,
 		MastershipStore:      mockMastershipStore,
+		DeviceLockStore:      mockDeviceLockStore,
+		MetadataStore:        mockMetadataStore,
+		DeadLetterStore:      mockDeadLetterStore,
 	}
 
 	allMocks.MockStores = mockStores
@@ -385,6 +410,26 @@ func Test_SetNetworkConfig_NewConfig(t *testing.T) {
 	assert.Equal(t, value2C.Removed, true)
 }
 
+func Test_SetNetworkConfig_NotBefore(t *testing.T) {
+	mgrTest, _ := setUp(t)
+
+	updates := make(devicechange.TypedValueMap)
+	updates[test1Cont1ACont2ALeaf2A] = devicechange.NewTypedValueUint(valueLeaf2A789, 16)
+	updatesForDevice1, deletesForDevice1, deviceInfo := makeDeviceChanges(device1, updates, nil)
+
+	const testNetworkChange networkchange.ID = "Test_SetNetworkConfig_NotBefore"
+	notBefore := time.Now().Add(time.Hour).Truncate(time.Second)
+	_, err := mgrTest.SetNetworkConfig(updatesForDevice1, deletesForDevice1, deviceInfo, string(testNetworkChange),
+		WithNotBefore(notBefore))
+	assert.NilError(t, err, "SetTargetConfig error")
+	testUpdate, _ := mgrTest.NetworkChangesStore.Get(testNetworkChange)
+	assert.Assert(t, testUpdate != nil)
+	testMetadata, _ := mgrTest.NetworkMetadataStore.Get(testNetworkChange)
+	assert.Assert(t, testMetadata != nil)
+	assert.Assert(t, testMetadata.NotBefore != nil)
+	assert.Assert(t, testMetadata.NotBefore.Equal(notBefore))
+}
+
 func Test_SetMultipleSimilarNetworkConfig(t *testing.T) {
 
 	mgrTest, _ := setUp(t)
@@ -533,9 +578,157 @@ func TestManager_ComputeRollbackFailure(t *testing.T) {
 	assert.NilError(t, err, "Cant' retrieve Config")
 
 	mocks.MockStores.NetworkChangesStore.EXPECT().GetNext(testingRollback.Index).Return(testingRollback2, nil)
+	mocks.MockStores.NetworkChangesStore.EXPECT().GetNext(testingRollback2.Index).Return(nil, nil)
+
+	err = mgrTest.RollbackTargetConfig("TestingRollback")
+	assert.Error(t, err, "change TestingRollback can not be rolled back as later changes intersect with it: "+
+		"TestingRollback2 ("+device1+":"+test1Cont1ACont2ALeaf2B+")")
+}
+
+// TestManager_ComputeRollbackNotLast shows that a change can be rolled back when the later changes do not
+// touch the same paths
+func TestManager_ComputeRollbackNotLast(t *testing.T) {
+	mgrTest, mocks := setUp(t)
+
+	updates := make(devicechange.TypedValueMap)
+	updates[test1Cont1ACont2ALeaf2B] = devicechange.NewTypedValueFloat(valueLeaf2B159)
+	updatesForDevice1, deletesForDevice1, deviceInfo := makeDeviceChanges(device1, updates, nil)
+	_, err := mgrTest.SetNetworkConfig(updatesForDevice1, deletesForDevice1, deviceInfo, "TestingRollback")
+	assert.NilError(t, err, "Can't create change", err)
+
+	updates = make(devicechange.TypedValueMap)
+	updates[test1Cont1ACont2ALeaf2D] = devicechange.NewTypedValueFloat(valueLeaf2D123)
+	updatesForDevice1, deletesForDevice1, deviceInfo = makeDeviceChanges(device1, updates, nil)
+	_, err = mgrTest.SetNetworkConfig(updatesForDevice1, deletesForDevice1, deviceInfo, "TestingRollback2")
+	assert.NilError(t, err, "Can't create change")
+
+	testingRollback, err := mocks.MockStores.NetworkChangesStore.Get("TestingRollback")
+	assert.NilError(t, err, "Cant' retrieve Config")
+
+	testingRollback2, err := mocks.MockStores.NetworkChangesStore.Get("TestingRollback2")
+	assert.NilError(t, err, "Cant' retrieve Config")
+
+	mocks.MockStores.NetworkChangesStore.EXPECT().GetNext(testingRollback.Index).Return(testingRollback2, nil)
+	mocks.MockStores.NetworkChangesStore.EXPECT().GetNext(testingRollback2.Index).Return(nil, nil)
 
 	err = mgrTest.RollbackTargetConfig("TestingRollback")
-	assert.Error(t, err, "change TestingRollback is not the last active on the stack of changes")
+	assert.NilError(t, err, "Can't roll back change")
+	rbChange, _ := mgrTest.NetworkChangesStore.Get("TestingRollback")
+	assert.Assert(t, rbChange != nil)
+	assert.Equal(t, rbChange.Status.Phase, changetypes.Phase_ROLLBACK)
+}
+
+// TestManager_RollbackToNetworkChange shows that the changes made after a given change are rolled back
+func TestManager_RollbackToNetworkChange(t *testing.T) {
+	mgrTest, mocks := setUp(t)
+
+	updates := make(devicechange.TypedValueMap)
+	updates[test1Cont1ACont2ALeaf2B] = devicechange.NewTypedValueFloat(valueLeaf2B159)
+	updatesForDevice1, deletesForDevice1, deviceInfo := makeDeviceChanges(device1, updates, nil)
+	_, err := mgrTest.SetNetworkConfig(updatesForDevice1, deletesForDevice1, deviceInfo, "TestingRollback")
+	assert.NilError(t, err, "Can't create change", err)
+
+	updates[test1Cont1ACont2ALeaf2B] = devicechange.NewTypedValueFloat(valueLeaf2B314)
+	updatesForDevice1, deletesForDevice1, deviceInfo = makeDeviceChanges(device1, updates, nil)
+	_, err = mgrTest.SetNetworkConfig(updatesForDevice1, deletesForDevice1, deviceInfo, "TestingRollback2")
+	assert.NilError(t, err, "Can't create change")
+
+	testingRollback, err := mocks.MockStores.NetworkChangesStore.Get("TestingRollback")
+	assert.NilError(t, err, "Cant' retrieve Config")
+
+	testingRollback2, err := mocks.MockStores.NetworkChangesStore.Get("TestingRollback2")
+	assert.NilError(t, err, "Cant' retrieve Config")
+
+	// Once to find the changes to roll back, then to check the rollback of TestingRollback2 has no conflicts
+	mocks.MockStores.NetworkChangesStore.EXPECT().GetNext(testingRollback.Index).Return(testingRollback2, nil)
+	mocks.MockStores.NetworkChangesStore.EXPECT().GetNext(testingRollback2.Index).Return(nil, nil).Times(2)
+
+	rolledBack, err := mgrTest.RollbackToNetworkChange("TestingRollback")
+	assert.NilError(t, err, "Can't roll back to change")
+	assert.DeepEqual(t, rolledBack, []networkchange.ID{"TestingRollback2"})
+
+	rbChange, _ := mgrTest.NetworkChangesStore.Get("TestingRollback2")
+	assert.Equal(t, rbChange.Status.Phase, changetypes.Phase_ROLLBACK)
+	change, _ := mgrTest.NetworkChangesStore.Get("TestingRollback")
+	assert.Equal(t, change.Status.Phase, changetypes.Phase_CHANGE)
+}
+
+func TestManager_ConfirmNetworkChange(t *testing.T) {
+	mgrTest, _ := setUp(t)
+
+	updates := make(devicechange.TypedValueMap)
+	updates[test1Cont1ACont2ALeaf2B] = devicechange.NewTypedValueFloat(valueLeaf2B159)
+	updatesForDevice1, deletesForDevice1, deviceInfo := makeDeviceChanges(device1, updates, nil)
+	change, err := mgrTest.SetNetworkConfig(updatesForDevice1, deletesForDevice1, deviceInfo, "TestingConfirm",
+		WithConfirmTimeout(time.Minute))
+	assert.NilError(t, err, "Can't create change")
+	changeMetadata, err := mgrTest.NetworkMetadataStore.Get(change.ID)
+	assert.NilError(t, err)
+	assert.Equal(t, *changeMetadata.ConfirmTimeout, time.Minute)
+
+	// The change can not be confirmed before it has been completed by the controller
+	err = mgrTest.ConfirmNetworkChange("TestingConfirm")
+	assert.ErrorContains(t, err, "can not be confirmed until it is complete")
+
+	// Once the change is complete it can be confirmed until its deadline
+	change.Status.State = changetypes.State_COMPLETE
+	deadline := time.Now().Add(time.Minute)
+	changeMetadata.ConfirmDeadline = &deadline
+	err = mgrTest.ConfirmNetworkChange("TestingConfirm")
+	assert.NilError(t, err, "Can't confirm change")
+	changeMetadata, _ = mgrTest.NetworkMetadataStore.Get("TestingConfirm")
+	assert.Assert(t, changeMetadata.Confirmed)
+	change, _ = mgrTest.NetworkChangesStore.Get("TestingConfirm")
+	assert.Equal(t, change.Status.Message, confirmedMessage)
+
+	// After the deadline the change can not be confirmed any more
+	changeMetadata.Confirmed = false
+	deadline = time.Now().Add(-time.Second)
+	changeMetadata.ConfirmDeadline = &deadline
+	err = mgrTest.ConfirmNetworkChange("TestingConfirm")
+	assert.ErrorContains(t, err, "has passed")
+
+	updates[test1Cont1ACont2ALeaf2B] = devicechange.NewTypedValueFloat(valueLeaf2B314)
+	updatesForDevice1, deletesForDevice1, deviceInfo = makeDeviceChanges(device1, updates, nil)
+	_, err = mgrTest.SetNetworkConfig(updatesForDevice1, deletesForDevice1, deviceInfo, "TestingNoConfirm")
+	assert.NilError(t, err, "Can't create change")
+	err = mgrTest.ConfirmNetworkChange("TestingNoConfirm")
+	assert.ErrorContains(t, err, "does not require confirmation")
+}
+
+func TestManager_DeviceLocks(t *testing.T) {
+	mgrTest, _ := setUp(t)
+	deviceLockStore, err := lock.NewLocalStore()
+	assert.NilError(t, err)
+	mgrTest.DeviceLockStore = deviceLockStore
+
+	_, err = mgrTest.LockDevices(nil, "client1", time.Minute)
+	assert.ErrorContains(t, err, "no device to lock")
+
+	locks, err := mgrTest.LockDevices([]devicetype.ID{device1}, "client1", time.Minute)
+	assert.NilError(t, err, "Can't lock device")
+	assert.Equal(t, len(locks), 1)
+	assert.Equal(t, locks[0].Owner, "client1")
+
+	// The lock only conflicts with changes from other clients
+	conflicts, err := mgrTest.GetDeviceLockConflicts([]devicetype.ID{device1}, "client1")
+	assert.NilError(t, err)
+	assert.Equal(t, len(conflicts), 0)
+	conflicts, err = mgrTest.GetDeviceLockConflicts([]devicetype.ID{device1}, "client2")
+	assert.NilError(t, err)
+	assert.Equal(t, len(conflicts), 1)
+	assert.Equal(t, conflicts[0].DeviceID, devicetype.ID(device1))
+
+	_, err = mgrTest.LockDevices([]devicetype.ID{device1}, "client2", time.Minute)
+	assert.Assert(t, err != nil, "Device locked by another client")
+	err = mgrTest.UnlockDevices([]devicetype.ID{device1}, "client2")
+	assert.Assert(t, err != nil, "Device unlocked by another client")
+
+	err = mgrTest.UnlockDevices([]devicetype.ID{device1}, "client1")
+	assert.NilError(t, err, "Can't unlock device")
+	conflicts, err = mgrTest.GetDeviceLockConflicts([]devicetype.ID{device1}, "client2")
+	assert.NilError(t, err)
+	assert.Equal(t, len(conflicts), 0)
 }
 
 func TestManager_ComputeRollbackDelete(t *testing.T) {
@@ -593,6 +786,132 @@ func TestManager_ComputeRollbackDelete(t *testing.T) {
 	}
 }
 
+// TestManager_GetTargetConfigAt shows that the config of a device can be rebuilt as it was at a point in the past
+func TestManager_GetTargetConfigAt(t *testing.T) {
+	mgrTest, mocks := setUp(t)
+	mocks.MockStores.DeviceSnapshotStore.EXPECT().Load(gomock.Any()).Return(nil, nil).AnyTimes()
+
+	now := time.Now()
+	newChange := func(id networkchange.ID, index networkchange.Index, created time.Time, values ...*devicechange.ChangeValue) *networkchange.NetworkChange {
+		change := &networkchange.NetworkChange{
+			ID:    id,
+			Index: index,
+			Changes: []*devicechange.Change{{
+				DeviceID:      device1,
+				DeviceVersion: deviceVersion1,
+				Values:        values,
+			}},
+			Created: created,
+			Updated: created,
+			Status: changetypes.Status{
+				Phase: changetypes.Phase_CHANGE,
+				State: changetypes.State_COMPLETE,
+			},
+		}
+		assert.NilError(t, mgrTest.NetworkChangesStore.Create(change))
+		return change
+	}
+	newChange("ChangeAt1", 1, now.Add(-3*time.Hour),
+		&devicechange.ChangeValue{Path: test1Cont1ACont2ALeaf2A, Value: devicechange.NewTypedValueString("first")})
+	change2 := newChange("ChangeAt2", 2, now.Add(-2*time.Hour),
+		&devicechange.ChangeValue{Path: test1Cont1ACont2ALeaf2A, Value: devicechange.NewTypedValueString("second")},
+		&devicechange.ChangeValue{Path: test1Cont1ACont2ALeaf2B, Value: devicechange.NewTypedValueString("second")})
+	newChange("ChangeAt3", 3, now.Add(-time.Hour),
+		&devicechange.ChangeValue{Path: "/cont1a/cont2a", Removed: true})
+	failed := newChange("ChangeAt4", 4, now.Add(-45*time.Minute),
+		&devicechange.ChangeValue{Path: test1Cont1ACont2ALeaf2A, Value: devicechange.NewTypedValueString("failed")})
+	failed.Status.State = changetypes.State_FAILED
+
+	// At a network change ID
+	result, err := mgrTest.GetTargetConfigAt(device1, deviceVersion1, "/cont1a/*/*", ConfigPoint{ChangeID: "ChangeAt1"})
+	assert.NilError(t, err)
+	assert.Equal(t, len(result), 1)
+	assert.Equal(t, result[0].Path, test1Cont1ACont2ALeaf2A)
+	assert.Equal(t, result[0].Value.ValueToString(), "first")
+
+	// At a network change index
+	mocks.MockStores.NetworkChangesStore.EXPECT().GetByIndex(networkchange.Index(2)).Return(change2, nil)
+	result, err = mgrTest.GetTargetConfigAt(device1, deviceVersion1, "/cont1a/*/*", ConfigPoint{Index: 2})
+	assert.NilError(t, err)
+	assert.Equal(t, len(result), 2)
+	assert.Equal(t, result[0].Value.ValueToString(), "second")
+	assert.Equal(t, result[1].Path, test1Cont1ACont2ALeaf2B)
+
+	// At a time after the container was removed, a change that failed since is left out
+	at := now.Add(-30 * time.Minute)
+	result, err = mgrTest.GetTargetConfigAt(device1, deviceVersion1, "/cont1a/*/*", ConfigPoint{Time: &at})
+	assert.NilError(t, err)
+	assert.Equal(t, len(result), 0)
+
+	// At a time before the container was removed, even though the change has since been rolled back
+	rolledBack := now.Add(-80 * time.Minute)
+	assert.NilError(t, mgrTest.NetworkMetadataStore.Create(&metadata.Metadata{ID: change2.ID, RolledBack: &rolledBack}))
+	change2.Status.Phase = changetypes.Phase_ROLLBACK
+	change2.Updated = now
+	at = now.Add(-90 * time.Minute)
+	result, err = mgrTest.GetTargetConfigAt(device1, deviceVersion1, "/cont1a/*/*", ConfigPoint{Time: &at})
+	assert.NilError(t, err)
+	assert.Equal(t, len(result), 2)
+
+	// Not at a time after the rollback, even though the change has been updated since
+	at = now.Add(-70 * time.Minute)
+	result, err = mgrTest.GetTargetConfigAt(device1, deviceVersion1, "/cont1a/*/*", ConfigPoint{Time: &at})
+	assert.NilError(t, err)
+	assert.Equal(t, len(result), 1)
+	assert.Equal(t, result[0].Value.ValueToString(), "first")
+
+	// Unknown network change
+	_, err = mgrTest.GetTargetConfigAt(device1, deviceVersion1, "/cont1a/*/*", ConfigPoint{ChangeID: "NoSuchChange"})
+	assert.ErrorContains(t, err, "not found")
+}
+
+// TestManager_DiffNetworkChanges shows that the config compared by a diff is the config given by a Get at the
+// same network changes, which leaves out the changes that failed
+func TestManager_DiffNetworkChanges(t *testing.T) {
+	mgrTest, mocks := setUp(t)
+	mocks.MockStores.DeviceSnapshotStore.EXPECT().Load(gomock.Any()).Return(nil, nil).AnyTimes()
+
+	now := time.Now()
+	newChange := func(id networkchange.ID, index networkchange.Index, state changetypes.State, values ...*devicechange.ChangeValue) {
+		change := &networkchange.NetworkChange{
+			ID:    id,
+			Index: index,
+			Changes: []*devicechange.Change{{
+				DeviceID:      device1,
+				DeviceVersion: deviceVersion1,
+				Values:        values,
+			}},
+			Created: now.Add(time.Duration(index-4) * time.Hour),
+		}
+		assert.NilError(t, mgrTest.NetworkChangesStore.Create(change))
+		change.Status.State = state
+	}
+	newChange("ChangeAt1", 1, changetypes.State_COMPLETE,
+		&devicechange.ChangeValue{Path: test1Cont1ACont2ALeaf2A, Value: devicechange.NewTypedValueString("first")})
+	newChange("ChangeAt2", 2, changetypes.State_FAILED,
+		&devicechange.ChangeValue{Path: test1Cont1ACont2ALeaf2A, Value: devicechange.NewTypedValueString("failed")},
+		&devicechange.ChangeValue{Path: test1Cont1ACont2ALeaf2B, Value: devicechange.NewTypedValueString("failed")})
+	newChange("ChangeAt3", 3, changetypes.State_COMPLETE,
+		&devicechange.ChangeValue{Path: test1Cont1ACont2ALeaf2B, Value: devicechange.NewTypedValueString("third")})
+
+	diffs, err := mgrTest.DiffNetworkChanges("ChangeAt1", "ChangeAt3", "")
+	assert.NilError(t, err)
+	assert.Equal(t, len(diffs), 1)
+	assert.Equal(t, diffs[0].Path, test1Cont1ACont2ALeaf2B)
+	assert.Assert(t, diffs[0].Old == nil)
+	assert.Equal(t, diffs[0].New.ValueToString(), "third")
+
+	toValues, err := mgrTest.GetTargetConfigAt(device1, deviceVersion1, "/cont1a/*/*", ConfigPoint{ChangeID: "ChangeAt3"})
+	assert.NilError(t, err)
+	assert.Equal(t, len(toValues), 2)
+	assert.Equal(t, toValues[0].Value.ValueToString(), "first")
+
+	// The failed change alone makes no difference
+	diffs, err = mgrTest.DiffNetworkChanges("ChangeAt1", "ChangeAt2", "")
+	assert.NilError(t, err)
+	assert.Equal(t, len(diffs), 0)
+}
+
 func TestManager_GetTargetState(t *testing.T) {
 	const (
 		device1 = "device1"
@@ -0,0 +1,83 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	devicetype "github.com/onosproject/onos-api/go/onos/config/device"
+)
+
+// DefaultDeviceWorkers is the default number of devices whose changes are validated or computed at the same time
+const DefaultDeviceWorkers = 16
+
+// DeviceErrors are the errors of an operation on several devices, by device
+type DeviceErrors map[devicetype.ID]error
+
+func (e DeviceErrors) Error() string {
+	devices := make([]string, 0, len(e))
+	for device := range e {
+		devices = append(devices, string(device))
+	}
+	sort.Strings(devices)
+	errs := make([]string, 0, len(devices))
+	for _, device := range devices {
+		errs = append(errs, fmt.Sprintf("%s: %s", device, e[devicetype.ID(device)]))
+	}
+	return strings.Join(errs, "; ")
+}
+
+// ForEachDevice calls fn for each of the given devices on a pool of at most DeviceWorkers workers, and waits for
+// all the calls to return. The errors of the calls are aggregated by device in a DeviceErrors.
+func (m *Manager) ForEachDevice(devices []devicetype.ID, fn func(devicetype.ID) error) error {
+	workers := m.DeviceWorkers
+	if workers <= 0 {
+		workers = 1
+	}
+	if workers > len(devices) {
+		workers = len(devices)
+	}
+
+	ch := make(chan devicetype.ID)
+	errs := make(DeviceErrors)
+	mu := &sync.Mutex{}
+	wg := &sync.WaitGroup{}
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for device := range ch {
+				if err := fn(device); err != nil {
+					mu.Lock()
+					errs[device] = err
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for _, device := range devices {
+		ch <- device
+	}
+	close(ch)
+	wg.Wait()
+
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
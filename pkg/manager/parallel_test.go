@@ -0,0 +1,113 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	devicechange "github.com/onosproject/onos-api/go/onos/config/change/device"
+	devicetype "github.com/onosproject/onos-api/go/onos/config/device"
+	"github.com/onosproject/onos-config/pkg/store/device/cache"
+	"gotest.tools/assert"
+)
+
+func Test_ForEachDevice(t *testing.T) {
+	m := &Manager{DeviceWorkers: 3}
+	devices := make([]devicetype.ID, 0)
+	for i := 0; i < 10; i++ {
+		devices = append(devices, devicetype.ID(fmt.Sprintf("device-%d", i)))
+	}
+
+	mu := &sync.Mutex{}
+	running, maxRunning := 0, 0
+	done := make(map[devicetype.ID]bool)
+	err := m.ForEachDevice(devices, func(device devicetype.ID) error {
+		mu.Lock()
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		done[device] = true
+		mu.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		mu.Lock()
+		running--
+		mu.Unlock()
+		if device == "device-7" || device == "device-2" {
+			return fmt.Errorf("invalid change")
+		}
+		return nil
+	})
+
+	// Every device is handled, by no more than the number of workers at a time
+	assert.Equal(t, len(done), 10)
+	assert.Assert(t, maxRunning <= 3)
+
+	// The errors are given for each device that failed
+	deviceErrors, ok := err.(DeviceErrors)
+	assert.Assert(t, ok)
+	assert.Equal(t, len(deviceErrors), 2)
+	assert.Error(t, err, "device-2: invalid change; device-7: invalid change")
+
+	err = m.ForEachDevice(devices, func(device devicetype.ID) error {
+		return nil
+	})
+	assert.NilError(t, err)
+}
+
+func Test_computeNetworkConfigParallel(t *testing.T) {
+	m := &Manager{DeviceWorkers: 4}
+	targetUpdates := make(map[devicetype.ID]devicechange.TypedValueMap)
+	targetRemoves := make(map[devicetype.ID][]string)
+	deviceInfo := make(map[devicetype.ID]cache.Info)
+	for i := 0; i < 20; i++ {
+		target := devicetype.ID(fmt.Sprintf("device-%02d", i))
+		deviceInfo[target] = cache.Info{DeviceID: target, Type: deviceTypeTd, Version: deviceVersion1}
+		if i%2 == 0 {
+			targetUpdates[target] = devicechange.TypedValueMap{
+				test1Cont1ACont2ALeaf2A: devicechange.NewTypedValueUint(uint(i), 8),
+			}
+		}
+		if i%3 == 0 {
+			targetRemoves[target] = []string{test1Cont1ACont2ALeaf2C}
+		}
+	}
+
+	deviceChanges, err := m.computeNetworkConfig(targetUpdates, targetRemoves, deviceInfo)
+	assert.NilError(t, err)
+
+	// Devices with updates, removes or both are given one change each, in the order of the devices
+	assert.Equal(t, len(deviceChanges), 13)
+	for i, deviceChange := range deviceChanges {
+		if i > 0 {
+			assert.Assert(t, deviceChanges[i-1].DeviceID < deviceChange.DeviceID)
+		}
+		_, hasUpdates := targetUpdates[deviceChange.DeviceID]
+		_, hasRemoves := targetRemoves[deviceChange.DeviceID]
+		expected := 0
+		if hasUpdates {
+			expected++
+		}
+		if hasRemoves {
+			expected++
+		}
+		assert.Equal(t, len(deviceChange.Values), expected, string(deviceChange.DeviceID))
+	}
+}
@@ -0,0 +1,95 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"sort"
+	"time"
+
+	changetypes "github.com/onosproject/onos-api/go/onos/config/change"
+	devicechange "github.com/onosproject/onos-api/go/onos/config/change/device"
+	networkchange "github.com/onosproject/onos-api/go/onos/config/change/network"
+	"github.com/onosproject/onos-config/pkg/store/change/metadata"
+)
+
+// DefaultPendingThreshold is how long a network change has to be PENDING to be listed, if no threshold is given
+const DefaultPendingThreshold = 5 * time.Minute
+
+// PendingChange is a network change that has been PENDING for a while, along with those of its device changes
+// that are still PENDING
+type PendingChange struct {
+	NetworkChange *networkchange.NetworkChange
+	DeviceChanges []*devicechange.DeviceChange
+	PendingSince  time.Time
+}
+
+// ListPendingChanges returns the network changes that have been PENDING for longer than the given threshold, the
+// longest pending first. These are usually held by a device that is offline or that does not answer.
+func (m *Manager) ListPendingChanges(threshold time.Duration) ([]*PendingChange, error) {
+	ch := make(chan *networkchange.NetworkChange)
+	ctx, err := m.NetworkChangesStore.List(ch)
+	if err != nil {
+		return nil, err
+	}
+	defer ctx.Close()
+
+	now := time.Now()
+	pendingChanges := make([]*PendingChange, 0)
+	for change := range ch {
+		if change.Status.State != changetypes.State_PENDING {
+			continue
+		}
+		changeMetadata, err := m.NetworkMetadataStore.Get(change.ID)
+		if err != nil {
+			return nil, err
+		}
+		since := getPendingSince(change, changeMetadata)
+		if now.Sub(since) < threshold {
+			continue
+		}
+		pendingChanges = append(pendingChanges, &PendingChange{
+			NetworkChange: change,
+			PendingSince:  since,
+		})
+	}
+
+	for _, pendingChange := range pendingChanges {
+		for _, ref := range pendingChange.NetworkChange.Refs {
+			deviceChange, err := m.DeviceChangesStore.Get(ref.DeviceChangeID)
+			if err != nil {
+				return nil, err
+			} else if deviceChange != nil && deviceChange.Status.State == changetypes.State_PENDING {
+				pendingChange.DeviceChanges = append(pendingChange.DeviceChanges, deviceChange)
+			}
+		}
+	}
+	sort.SliceStable(pendingChanges, func(i, j int) bool {
+		return pendingChanges[i].PendingSince.Before(pendingChanges[j].PendingSince)
+	})
+	return pendingChanges, nil
+}
+
+// getPendingSince returns the time from which a network change has been PENDING. A change that is being applied
+// is pending from when it was made, or from when it was scheduled for if that is later. A change that is being
+// rolled back is pending from when it was last updated.
+func getPendingSince(change *networkchange.NetworkChange, changeMetadata *metadata.Metadata) time.Time {
+	if change.Status.Phase == changetypes.Phase_ROLLBACK {
+		return change.Updated
+	}
+	if changeMetadata != nil && changeMetadata.NotBefore != nil && changeMetadata.NotBefore.After(change.Created) {
+		return *changeMetadata.NotBefore
+	}
+	return change.Created
+}
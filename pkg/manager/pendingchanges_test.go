@@ -0,0 +1,46 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package manager
+
+import (
+	"testing"
+	"time"
+
+	changetypes "github.com/onosproject/onos-api/go/onos/config/change"
+	networkchange "github.com/onosproject/onos-api/go/onos/config/change/network"
+	"github.com/onosproject/onos-config/pkg/store/change/metadata"
+	"gotest.tools/assert"
+)
+
+func Test_getPendingSince(t *testing.T) {
+	created := time.Date(2020, 11, 2, 10, 0, 0, 0, time.UTC)
+	updated := created.Add(time.Hour)
+	change := &networkchange.NetworkChange{
+		Created: created,
+		Updated: updated,
+	}
+	assert.Equal(t, getPendingSince(change, nil), created)
+
+	// A scheduled change is only pending once it is due
+	notBefore := created.Add(30 * time.Minute)
+	changeMetadata := &metadata.Metadata{NotBefore: &notBefore}
+	assert.Equal(t, getPendingSince(change, changeMetadata), notBefore)
+	notBefore = created.Add(-30 * time.Minute)
+	assert.Equal(t, getPendingSince(change, changeMetadata), created)
+
+	// A rollback is pending from when it was asked for
+	change.Status.Phase = changetypes.Phase_ROLLBACK
+	assert.Equal(t, getPendingSince(change, changeMetadata), updated)
+}
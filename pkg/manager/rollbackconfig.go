@@ -15,36 +15,55 @@
 package manager
 
 import (
+	"context"
 	"fmt"
+	"math"
+	"strings"
+	"time"
+
 	changetypes "github.com/onosproject/onos-api/go/onos/config/change"
 	networkchange "github.com/onosproject/onos-api/go/onos/config/change/network"
+	"github.com/onosproject/onos-config/pkg/store/change/metadata"
 	networkchangestore "github.com/onosproject/onos-config/pkg/store/change/network"
+	networkchangeutils "github.com/onosproject/onos-config/pkg/store/change/network/utils"
 	"github.com/onosproject/onos-config/pkg/store/stream"
 )
 
-// RollbackTargetConfig rollbacks the last change for a given configuration on the target, by setting phase to
-// rollback and state to pending.
+// RollbackTargetConfig rollbacks a change for a given configuration on the target, by setting phase to
+// rollback and state to pending. The change does not have to be the last one, as long as none of the later
+// changes that are still active touch the same device paths.
 func (m *Manager) RollbackTargetConfig(networkChangeID networkchange.ID) error {
 
 	changeRollback, errGet := m.NetworkChangesStore.Get(networkChangeID)
 	if errGet != nil {
 		log.Errorf("Error on get change %s for rollback: %s", networkChangeID, errGet)
 		return errGet
+	} else if changeRollback == nil {
+		return fmt.Errorf("change %s not found", networkChangeID)
 	}
 
-	//Making sure that the change is the last one
-	next, errGetNext := m.NetworkChangesStore.GetNext(changeRollback.Index)
-	if errGetNext != nil {
-		log.Errorf("Error on get next change during rollback: %s", errGetNext)
-		return errGet
+	//Making sure that no later change depends on the paths of the change
+	conflicts, errConflicts := m.getRollbackConflicts(changeRollback)
+	if errConflicts != nil {
+		log.Errorf("Error on get next change during rollback: %s", errConflicts)
+		return errConflicts
+	}
+	if len(conflicts) > 0 {
+		errConflict := fmt.Errorf("change %s can not be rolled back as later changes intersect with it: %s",
+			networkChangeID, strings.Join(conflicts, "; "))
+		return errConflict
 	}
-	// if the error is nil and the change is nil the requested one is the last one thus we proceed.
-	// if there is a next change but the phase is different from ROLLBACK and the status is different from COMPLETE we
-	// fail the operation because there is a need to rollback the previous one.
-	if next != nil && (next.Status.Phase != changetypes.Phase_ROLLBACK ||
-		(next.Status.Phase == changetypes.Phase_ROLLBACK && next.Status.State != changetypes.State_COMPLETE)) {
-		errLast := fmt.Errorf("change %s is not the last active on the stack of changes", networkChangeID)
-		return errLast
+
+	// The time of the rollback is kept so that the config can still be rebuilt as it was before it
+	if changeRollback.Status.Phase == changetypes.Phase_CHANGE && changeRollback.Status.State == changetypes.State_COMPLETE {
+		rolledBack := time.Now()
+		errMetadata := m.updateNetworkChangeMetadata(networkChangeID, func(changeMetadata *metadata.Metadata) {
+			changeMetadata.RolledBack = &rolledBack
+		})
+		if errMetadata != nil {
+			log.Errorf("Error on recording the rollback of change %s: %s", networkChangeID, errMetadata)
+			return errMetadata
+		}
 	}
 
 	changeRollback.Status.Incarnation++
@@ -61,30 +80,144 @@ func (m *Manager) RollbackTargetConfig(networkChangeID networkchange.ID) error {
 	return listenForChangeNotification(m, networkChangeID)
 }
 
+// RollbackToNetworkChange rolls back, one at a time and in reverse order, every change that was made after the
+// given change and that has not been rolled back yet. Each rollback must complete before the next one is started,
+// and the first one that fails stops the sequence. The IDs of the changes rolled back are returned.
+func (m *Manager) RollbackToNetworkChange(networkChangeID networkchange.ID) ([]networkchange.ID, error) {
+	changeTo, errGet := m.NetworkChangesStore.Get(networkChangeID)
+	if errGet != nil {
+		log.Errorf("Error on get change %s for rollback: %s", networkChangeID, errGet)
+		return nil, errGet
+	} else if changeTo == nil {
+		return nil, fmt.Errorf("change %s not found", networkChangeID)
+	}
+
+	laterChanges := make([]networkchange.ID, 0)
+	next, errGetNext := m.NetworkChangesStore.GetNext(changeTo.Index)
+	for next != nil && errGetNext == nil {
+		if next.Status.Phase == changetypes.Phase_CHANGE {
+			laterChanges = append(laterChanges, next.ID)
+		}
+		next, errGetNext = m.NetworkChangesStore.GetNext(next.Index)
+	}
+	if errGetNext != nil {
+		log.Errorf("Error on get next change during rollback: %s", errGetNext)
+		return nil, errGetNext
+	}
+
+	rolledBack := make([]networkchange.ID, 0, len(laterChanges))
+	for i := len(laterChanges) - 1; i >= 0; i-- {
+		log.Infof("Rolling back change %s (%d of %d) to get back to change %s",
+			laterChanges[i], len(rolledBack)+1, len(laterChanges), networkChangeID)
+		if err := m.RollbackTargetConfig(laterChanges[i]); err != nil {
+			return rolledBack, fmt.Errorf("rollback to change %s stopped at change %s after rolling back %d of %d changes %v: %s",
+				networkChangeID, laterChanges[i], len(rolledBack), len(laterChanges), rolledBack, err)
+		}
+		rolledBack = append(rolledBack, laterChanges[i])
+	}
+	return rolledBack, nil
+}
+
+// getRollbackConflicts returns the later changes that have not been rolled back and that touch the same device
+// paths as the given change, each with the intersecting paths. The change can only be rolled back if there are none.
+func (m *Manager) getRollbackConflicts(changeRollback *networkchange.NetworkChange) ([]string, error) {
+	return m.GetConflictingChanges(changeRollback, changeRollback.Index)
+}
+
+// GetConflictingChanges returns the changes made after the given index that have not been rolled back and that
+// touch the same device paths as the given change, each with the intersecting paths
+func (m *Manager) GetConflictingChanges(change *networkchange.NetworkChange, index networkchange.Index) ([]string, error) {
+	conflicts := make([]string, 0)
+	next, err := m.NetworkChangesStore.GetNext(index)
+	if err != nil {
+		return nil, err
+	}
+	for next != nil {
+		// if there is a next change but the phase is different from ROLLBACK and the status is different from
+		// COMPLETE it still has an effect on the paths it intersects with.
+		if next.Status.Phase != changetypes.Phase_ROLLBACK || next.Status.State != changetypes.State_COMPLETE {
+			paths := networkchangeutils.GetIntersectingPaths(change, next)
+			if len(paths) > 0 {
+				conflicts = append(conflicts, fmt.Sprintf("%s (%s)", next.ID, strings.Join(paths, ", ")))
+			}
+		}
+		next, err = m.NetworkChangesStore.GetNext(next.Index)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return conflicts, nil
+}
+
+// GetLastNetworkChangeIndex returns the index of the last network change, or 0 if there is none
+func (m *Manager) GetLastNetworkChangeIndex() (networkchange.Index, error) {
+	last, err := m.NetworkChangesStore.GetPrev(networkchange.Index(math.MaxUint64))
+	if err != nil {
+		return 0, err
+	} else if last == nil {
+		return 0, nil
+	}
+	return last.Index, nil
+}
+
 func listenForChangeNotification(mgr *Manager, changeID networkchange.ID) error {
-	networkChan := make(chan stream.Event)
-	ctx, errWatch := mgr.NetworkChangesStore.Watch(networkChan, networkchangestore.WithChangeID(changeID))
+	change, errWatch := watchNetworkChange(context.Background(), mgr, changeID, changetypes.Phase_ROLLBACK, 0)
 	if errWatch != nil {
 		return fmt.Errorf("can't complete rollback operation on target due to %s", errWatch)
 	}
-	defer ctx.Close()
-	for changeEvent := range networkChan {
-		change := changeEvent.Object.(*networkchange.NetworkChange)
-		log.Infof("Received notification for change ID %s, phase %s, state %s", change.ID,
-			change.Status.Phase, change.Status.State)
-		if change.Status.Phase == changetypes.Phase_ROLLBACK {
-			switch changeStatus := change.Status.State; changeStatus {
-			case changetypes.State_COMPLETE:
-				log.Infof("Rollback succeeded for change %s ", changeID)
-				return nil
-			case changetypes.State_FAILED:
-				log.Infof("Received Change Status %s", changeStatus)
-				return fmt.Errorf("issue in setting config reson %s, error %s, rolling back change %s",
-					change.Status.Reason, change.Status.Message, changeID)
-			default:
-				continue
-			}
+	if change != nil && change.Status.Phase == changetypes.Phase_ROLLBACK {
+		switch changeStatus := change.Status.State; changeStatus {
+		case changetypes.State_COMPLETE:
+			log.Infof("Rollback succeeded for change %s ", changeID)
+		case changetypes.State_FAILED:
+			log.Infof("Received Change Status %s", changeStatus)
+			return fmt.Errorf("issue in setting config reson %s, error %s, rolling back change %s",
+				change.Status.Reason, change.Status.Message, changeID)
 		}
 	}
 	return nil
 }
+
+// watchNetworkChange watches the given network change until it is COMPLETE or FAILED in the given phase, and
+// returns it. If the timeout is not zero and expires first, or if the watch ends, the change is returned as it
+// was last seen, which may be nil. If the context is done first, its error is returned.
+func watchNetworkChange(ctx context.Context, mgr *Manager, changeID networkchange.ID, phase changetypes.Phase,
+	timeout time.Duration) (*networkchange.NetworkChange, error) {
+	networkChan := make(chan stream.Event)
+	watchCtx, errWatch := mgr.NetworkChangesStore.Watch(networkChan, networkchangestore.WithReplay(),
+		networkchangestore.WithChangeID(changeID))
+	if errWatch != nil {
+		return nil, errWatch
+	}
+	defer watchCtx.Close()
+
+	var timeoutCh <-chan time.Time
+	if timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		timeoutCh = timer.C
+	}
+
+	var change *networkchange.NetworkChange
+	for {
+		select {
+		case changeEvent, ok := <-networkChan:
+			if !ok {
+				return change, nil
+			}
+			change = changeEvent.Object.(*networkchange.NetworkChange)
+			log.Infof("Received notification for change ID %s, phase %s, state %s", change.ID,
+				change.Status.Phase, change.Status.State)
+			if change.Status.Phase == phase && (change.Status.State == changetypes.State_COMPLETE ||
+				change.Status.State == changetypes.State_FAILED) {
+				return change, nil
+			}
+		case <-timeoutCh:
+			log.Infof("Stopped waiting for change ID %s after %v", changeID, timeout)
+			return change, nil
+		case <-ctx.Done():
+			log.Infof("Stopped waiting for change ID %s: %s", changeID, ctx.Err())
+			return nil, ctx.Err()
+		}
+	}
+}
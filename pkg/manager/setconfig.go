@@ -15,13 +15,18 @@
 package manager
 
 import (
+	"context"
 	"fmt"
 	"sort"
+	"time"
 
+	types "github.com/onosproject/onos-api/go/onos/config"
+	changetypes "github.com/onosproject/onos-api/go/onos/config/change"
 	devicechange "github.com/onosproject/onos-api/go/onos/config/change/device"
 	networkchange "github.com/onosproject/onos-api/go/onos/config/change/network"
 	devicetype "github.com/onosproject/onos-api/go/onos/config/device"
 	"github.com/onosproject/onos-config/pkg/store"
+	"github.com/onosproject/onos-config/pkg/store/change/metadata"
 	"github.com/onosproject/onos-config/pkg/store/device/cache"
 	"github.com/onosproject/onos-config/pkg/utils"
 )
@@ -34,7 +39,7 @@ const SetConfigAlreadyApplied = "Already applied:"
 func (m *Manager) ValidateNetworkConfig(deviceName devicetype.ID, version devicetype.Version,
 	deviceType devicetype.Type, updates devicechange.TypedValueMap, deletes []string, lastWrite networkchange.Revision) error {
 
-	chg, err := m.ComputeDeviceChange(deviceName, version, deviceType, updates, deletes, "Generated for validation")
+	chg, err := m.ComputeDeviceChange(deviceName, version, deviceType, updates, deletes)
 	if err != nil {
 		return err
 	}
@@ -76,7 +81,8 @@ func (m *Manager) ValidateNetworkConfig(deviceName devicetype.ID, version device
 		return configValues[i].Path < configValues[j].Path
 	})
 
-	jsonTree, err := store.BuildTree(configValues, true)
+	jsonTree, err := store.BuildTree(configValues, true, store.WithPathMaps(
+		m.ModelRegistry.ModelReadWritePaths[modelName], m.ModelRegistry.ModelReadOnlyPaths[modelName]))
 	if err != nil {
 		log.Error("Error building JSON tree from Config Values ", err, jsonTree)
 		return err
@@ -97,60 +103,226 @@ func (m *Manager) ValidateNetworkConfig(deviceName devicetype.ID, version device
 	return nil
 }
 
+// NetworkChangeOption sets an optional attribute of a new network change, kept in the metadata of the change
+type NetworkChangeOption func(*metadata.Metadata)
+
+// WithNotBefore schedules the network change so that it is not applied before the given time
+func WithNotBefore(notBefore time.Time) NetworkChangeOption {
+	return func(changeMetadata *metadata.Metadata) {
+		changeMetadata.NotBefore = &notBefore
+	}
+}
+
+// WithConfirmTimeout requires the network change to be confirmed within the given timeout once it is complete,
+// failing which it is rolled back
+func WithConfirmTimeout(timeout time.Duration) NetworkChangeOption {
+	return func(changeMetadata *metadata.Metadata) {
+		changeMetadata.ConfirmTimeout = &timeout
+	}
+}
+
+// WithBaseIndex bases the network change on the network change with the given index, so that it fails if a
+// change made after that index touches the same device paths
+func WithBaseIndex(index networkchange.Index) NetworkChangeOption {
+	return func(changeMetadata *metadata.Metadata) {
+		changeMetadata.BaseIndex = &index
+	}
+}
+
+// WithUsername records the identity of the client that made the network change as its author
+func WithUsername(username string) NetworkChangeOption {
+	return func(changeMetadata *metadata.Metadata) {
+		changeMetadata.Username = username
+	}
+}
+
+// WithDescription describes what the network change is for
+func WithDescription(description string) NetworkChangeOption {
+	return func(changeMetadata *metadata.Metadata) {
+		changeMetadata.Description = description
+	}
+}
+
+// WithLabels attaches free-form labels to the network change, e.g. a ticket number
+func WithLabels(labels map[string]string) NetworkChangeOption {
+	return func(changeMetadata *metadata.Metadata) {
+		changeMetadata.Labels = labels
+	}
+}
+
+// WithApplyPolicy sets how the network change is applied when some of its devices are not connected. The quorum
+// is the percentage of the devices that must be connected for a change with the quorum policy.
+func WithApplyPolicy(policy metadata.ApplyPolicy, quorum uint32) NetworkChangeOption {
+	return func(changeMetadata *metadata.Metadata) {
+		changeMetadata.ApplyPolicy = policy
+		changeMetadata.Quorum = quorum
+	}
+}
+
 // SetNetworkConfig creates and stores a new netork config for the given updates and deletes and targets
 func (m *Manager) SetNetworkConfig(targetUpdates map[devicetype.ID]devicechange.TypedValueMap,
-	targetRemoves map[devicetype.ID][]string, deviceInfo map[devicetype.ID]cache.Info, netChangeID string) (*networkchange.NetworkChange, error) {
+	targetRemoves map[devicetype.ID][]string, deviceInfo map[devicetype.ID]cache.Info, netChangeID string,
+	opts ...NetworkChangeOption) (*networkchange.NetworkChange, error) {
 	//TODO evaluate need of user and add it back if need be.
 
-	allDeviceChanges, errChanges := m.computeNetworkConfig(targetUpdates, targetRemoves, deviceInfo, "")
-	if errChanges != nil {
-		return nil, errChanges
-	}
-	newNetworkConfig, errNetChange := networkchange.NewNetworkChange(netChangeID, allDeviceChanges)
+	newNetworkConfig, errNetChange := m.ComputeNetworkConfig(targetUpdates, targetRemoves, deviceInfo, netChangeID)
 	if errNetChange != nil {
 		return nil, errNetChange
 	}
 	//Writing to the atomix backed store too
-	errStoreChange := m.NetworkChangesStore.Create(newNetworkConfig)
+	errStoreChange := m.CreateNetworkChange(newNetworkConfig, opts...)
 	if errStoreChange != nil {
 		return nil, errStoreChange
 	}
 	return newNetworkConfig, nil
 }
 
-//computeNetworkConfig computes each device change
-func (m *Manager) computeNetworkConfig(targetUpdates map[devicetype.ID]devicechange.TypedValueMap,
-	targetRemoves map[devicetype.ID][]string, deviceInfo map[devicetype.ID]cache.Info,
-	description string) ([]*devicechange.Change, error) {
+// CreateNetworkChange stores a computed network change along with its metadata. The metadata is stored first so
+// that it is there by the time the network change controller sees the change.
+func (m *Manager) CreateNetworkChange(change *networkchange.NetworkChange, opts ...NetworkChangeOption) error {
+	if change.ID == "" {
+		change.ID = networkchange.ID(types.NewUUID().String())
+	}
+	changeMetadata := &metadata.Metadata{
+		ID: change.ID,
+	}
+	for _, opt := range opts {
+		opt(changeMetadata)
+	}
+	if err := m.NetworkMetadataStore.Create(changeMetadata); err != nil {
+		return err
+	}
+	if err := m.NetworkChangesStore.Create(change); err != nil {
+		if errDelete := m.NetworkMetadataStore.Delete(change.ID); errDelete != nil {
+			log.Warnf("Failed to delete the metadata of network change %s: %s", change.ID, errDelete)
+		}
+		return err
+	}
+	return nil
+}
 
-	deviceChanges := make([]*devicechange.Change, 0)
-	for target, updates := range targetUpdates {
-		//FIXME this is a sequential job, not parallelized
-		version := deviceInfo[target].Version
-		deviceType := deviceInfo[target].Type
-		newChange, err := m.ComputeDeviceChange(
-			target, version, deviceType, updates, targetRemoves[target], description)
+// updateNetworkChangeMetadata applies the given update to the metadata of an existing network change, creating the
+// metadata if the change has none
+func (m *Manager) updateNetworkChangeMetadata(networkChangeID networkchange.ID, update NetworkChangeOption) error {
+	changeMetadata, err := m.NetworkMetadataStore.Get(networkChangeID)
+	if err != nil {
+		return err
+	} else if changeMetadata == nil {
+		changeMetadata = &metadata.Metadata{
+			ID: networkChangeID,
+		}
+		update(changeMetadata)
+		return m.NetworkMetadataStore.Create(changeMetadata)
+	}
+	update(changeMetadata)
+	return m.NetworkMetadataStore.Update(changeMetadata)
+}
+
+// WaitForNetworkChange waits for at most the given timeout for a network change to be COMPLETE or FAILED, and
+// returns it along with the device change for each of its devices. A device that has no device change yet is
+// given one with the status of the network change. If the context is done first, its error is returned.
+func (m *Manager) WaitForNetworkChange(ctx context.Context, networkChangeID networkchange.ID,
+	timeout time.Duration) (*networkchange.NetworkChange, []*devicechange.DeviceChange, error) {
+	change, err := watchNetworkChange(ctx, m, networkChangeID, changetypes.Phase_CHANGE, timeout)
+	if err != nil {
+		return nil, nil, err
+	} else if change == nil {
+		change, err = m.NetworkChangesStore.Get(networkChangeID)
 		if err != nil {
-			log.Error("Error in setting config: ", newChange, " for target ", err)
-			continue
+			return nil, nil, err
+		} else if change == nil {
+			return nil, nil, fmt.Errorf("change %s not found", networkChangeID)
 		}
-		log.Infof("Appending device change %v", newChange)
-		deviceChanges = append(deviceChanges, newChange)
-		delete(targetRemoves, target)
 	}
 
+	deviceChanges := make([]*devicechange.DeviceChange, len(change.Changes))
+	for i, deviceChange := range change.Changes {
+		deviceChanges[i] = &devicechange.DeviceChange{
+			NetworkChange: devicechange.NetworkChangeRef{
+				ID:    types.ID(change.ID),
+				Index: types.Index(change.Index),
+			},
+			Change: deviceChange,
+			Status: change.Status,
+		}
+	}
+	for i, ref := range change.Refs {
+		if i >= len(deviceChanges) {
+			break
+		}
+		deviceChange, err := m.DeviceChangesStore.Get(ref.DeviceChangeID)
+		if err != nil {
+			return nil, nil, err
+		} else if deviceChange != nil {
+			deviceChanges[i] = deviceChange
+		}
+	}
+	return change, deviceChanges, nil
+}
+
+// ComputeNetworkConfig creates a new network config for the given updates and deletes and targets
+// without storing it
+func (m *Manager) ComputeNetworkConfig(targetUpdates map[devicetype.ID]devicechange.TypedValueMap,
+	targetRemoves map[devicetype.ID][]string, deviceInfo map[devicetype.ID]cache.Info,
+	netChangeID string) (*networkchange.NetworkChange, error) {
+	allDeviceChanges, errChanges := m.computeNetworkConfig(targetUpdates, targetRemoves, deviceInfo)
+	if errChanges != nil {
+		return nil, errChanges
+	}
+	return networkchange.NewNetworkChange(netChangeID, allDeviceChanges)
+}
+
+//computeNetworkConfig computes each device change, for several devices at the same time
+func (m *Manager) computeNetworkConfig(targetUpdates map[devicetype.ID]devicechange.TypedValueMap,
+	targetRemoves map[devicetype.ID][]string, deviceInfo map[devicetype.ID]cache.Info) ([]*devicechange.Change, error) {
+
 	// Some targets might only have removes
-	for target, removes := range targetRemoves {
+	targets := make([]devicetype.ID, 0, len(targetUpdates)+len(targetRemoves))
+	for target := range targetUpdates {
+		targets = append(targets, target)
+	}
+	for target := range targetRemoves {
+		if _, ok := targetUpdates[target]; !ok {
+			targets = append(targets, target)
+		}
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		return targets[i] < targets[j]
+	})
+	positions := make(map[devicetype.ID]int)
+	for i, target := range targets {
+		positions[target] = i
+	}
+
+	// Each worker writes the change of its device to the position of the device. A device whose change can not be
+	// computed is skipped, as when the changes were computed one after the other.
+	computedChanges := make([]*devicechange.Change, len(targets))
+	err := m.ForEachDevice(targets, func(target devicetype.ID) error {
 		version := deviceInfo[target].Version
 		deviceType := deviceInfo[target].Type
+		updates, ok := targetUpdates[target]
+		if !ok {
+			updates = make(devicechange.TypedValueMap)
+		}
 		newChange, err := m.ComputeDeviceChange(
-			target, version, deviceType, make(devicechange.TypedValueMap), removes, description)
+			target, version, deviceType, updates, targetRemoves[target])
 		if err != nil {
 			log.Error("Error in setting config: ", newChange, " for target ", err)
-			continue
+			return nil
 		}
 		log.Infof("Appending device change %v", newChange)
-		deviceChanges = append(deviceChanges, newChange)
+		computedChanges[positions[target]] = newChange
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	deviceChanges := make([]*devicechange.Change, 0, len(computedChanges))
+	for _, deviceChange := range computedChanges {
+		if deviceChange != nil {
+			deviceChanges = append(deviceChanges, deviceChange)
+		}
 	}
 	return deviceChanges, nil
 }
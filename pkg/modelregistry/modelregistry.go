@@ -149,6 +149,7 @@ type ModelRegistry struct {
 	ModelPlugins        map[string]ModelPlugin
 	ModelReadOnlyPaths  map[string]ReadOnlyPathMap
 	ModelReadWritePaths map[string]ReadWritePathMap
+	ModelSchemas        map[string]map[string]*yang.Entry
 	LocationStore       map[string]string
 }
 
@@ -191,6 +192,9 @@ func (registry *ModelRegistry) RegisterModelPlugin(moduleName string) (string, s
 		log.Warn("Error loading schema from model plugin", modelName, err)
 		return "", "", err
 	}
+	if registry.ModelSchemas != nil {
+		registry.ModelSchemas[modelName] = modelschema
+	}
 	readOnlyPaths, readWritePaths := ExtractPaths(modelschema["Device"], yang.TSUnset, "", "")
 
 	/////////////////////////////////////////////////////////////////////
@@ -265,7 +269,7 @@ func ExtractPaths(deviceEntry *yang.Entry, parentState yang.TriState, parentPath
 		itemPath := formatName(dirEntry, false, parentPath, subpathPrefix)
 		if dirEntry.IsLeaf() || dirEntry.IsLeafList() {
 			// No need to recurse
-			t, typeOpts, err := toValueType(dirEntry.Type, dirEntry.IsLeafList())
+			t, typeOpts, err := ToValueType(dirEntry.Type, dirEntry.IsLeafList())
 			tObj := ReadOnlyAttrib{ValueType: t, TypeOpts: typeOpts, Description: dirEntry.Description, Units: dirEntry.Units}
 			if err != nil {
 				log.Errorf(err.Error())
@@ -455,7 +459,8 @@ func PathsRW(rwPathMap ReadWritePathMap) []string {
 	return keys
 }
 
-func toValueType(entry *yang.YangType, isLeafList bool) (devicechange.ValueType, []uint8, error) {
+// ToValueType gives the type of the values of a leaf or leaf-list of the given YANG type, with its type options
+func ToValueType(entry *yang.YangType, isLeafList bool) (devicechange.ValueType, []uint8, error) {
 	//TODO evaluate better devicechange and error return
 	switch entry.Name {
 	case "int8", "int16", "int32", "int64":
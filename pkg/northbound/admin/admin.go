@@ -21,24 +21,35 @@ import (
 	"io"
 	"os"
 	"strings"
+	"time"
 
+	types "github.com/onosproject/onos-api/go/onos/config"
 	"github.com/onosproject/onos-api/go/onos/config/admin"
 	networkchange "github.com/onosproject/onos-api/go/onos/config/change/network"
 	devicetype "github.com/onosproject/onos-api/go/onos/config/device"
 	"github.com/onosproject/onos-api/go/onos/config/snapshot"
 	devicesnapshot "github.com/onosproject/onos-api/go/onos/config/snapshot/device"
 	networksnapshot "github.com/onosproject/onos-api/go/onos/config/snapshot/network"
+	adminapi "github.com/onosproject/onos-config/pkg/api/admin"
+	"github.com/onosproject/onos-config/pkg/audit"
 	"github.com/onosproject/onos-config/pkg/manager"
+	"github.com/onosproject/onos-config/pkg/store/deadletter"
+	lockstore "github.com/onosproject/onos-config/pkg/store/lock"
 	streams "github.com/onosproject/onos-config/pkg/store/stream"
 	"github.com/onosproject/onos-config/pkg/utils"
 	"github.com/onosproject/onos-lib-go/pkg/logging"
 	"github.com/onosproject/onos-lib-go/pkg/northbound"
 	"github.com/pkg/errors"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 var log = logging.GetLogger("northbound", "admin")
 
+// defaultLockTTL is how long devices are locked for when no TTL is given
+const defaultLockTTL = 10 * time.Minute
+
 // Service is a Service implementation for administration.
 type Service struct {
 	northbound.Service
@@ -48,6 +59,7 @@ type Service struct {
 func (s Service) Register(r *grpc.Server) {
 	server := Server{}
 	admin.RegisterConfigAdminServiceServer(r, server)
+	adminapi.RegisterConfigAdminExtServiceServer(r, server)
 }
 
 // Server implements the gRPC service for administrative facilities.
@@ -55,9 +67,14 @@ type Server struct {
 }
 
 // UploadRegisterModel uploads and registers a new model plugin.
-func (s Server) UploadRegisterModel(stream admin.ConfigAdminService_UploadRegisterModelServer) error {
+func (s Server) UploadRegisterModel(stream admin.ConfigAdminService_UploadRegisterModelServer) (err error) {
 	response := admin.RegisterResponse{Name: "WidthUnknown"}
 	soFileName := ""
+	defer func() {
+		record := audit.NewRecord(stream.Context(), audit.OperationUploadModel, err)
+		record.Details = fmt.Sprintf("model plugin %s", soFileName)
+		manager.GetManager().Audit(record)
+	}()
 
 	const TEMPFILE = "/tmp/uploaded_model_plugin.tmp"
 	f, err := os.Create(TEMPFILE)
@@ -192,6 +209,7 @@ func (s Server) ListRegisteredModels(req *admin.ListModelsRequest, stream admin.
 // RollbackNetworkChange rolls back a named atomix-based network change.
 func (s Server) RollbackNetworkChange(ctx context.Context, req *admin.RollbackRequest) (*admin.RollbackResponse, error) {
 	errRollback := manager.GetManager().RollbackTargetConfig(networkchange.ID(req.Name))
+	auditNetworkChange(ctx, audit.OperationRollback, req.Name, errRollback)
 	if errRollback != nil {
 		return nil, errRollback
 	}
@@ -200,6 +218,96 @@ func (s Server) RollbackNetworkChange(ctx context.Context, req *admin.RollbackRe
 	}, nil
 }
 
+// RollbackToNetworkChange rolls back every network change made after the named network change, latest first.
+func (s Server) RollbackToNetworkChange(ctx context.Context, req *adminapi.RollbackToNetworkChangeRequest) (*adminapi.RollbackToNetworkChangeResponse, error) {
+	rolledBack, errRollback := manager.GetManager().RollbackToNetworkChange(req.Name)
+	record := audit.NewRecord(ctx, audit.OperationRollback, errRollback)
+	record.NetworkChangeID = string(req.Name)
+	record.Details = fmt.Sprintf("rollback to change, rolled back %v", rolledBack)
+	manager.GetManager().Audit(record)
+	if errRollback != nil {
+		return nil, errRollback
+	}
+	return &adminapi.RollbackToNetworkChangeResponse{
+		Message:    fmt.Sprintf("Rolled back %d changes to change '%s'", len(rolledBack), req.Name),
+		RolledBack: rolledBack,
+	}, nil
+}
+
+// ConfirmNetworkChange confirms a named network change that was made with a confirmation timeout, so that it
+// is not rolled back when the timeout expires.
+func (s Server) ConfirmNetworkChange(ctx context.Context, req *adminapi.ConfirmNetworkChangeRequest) (*adminapi.ConfirmNetworkChangeResponse, error) {
+	errConfirm := manager.GetManager().ConfirmNetworkChange(req.Name)
+	auditNetworkChange(ctx, audit.OperationConfirm, string(req.Name), errConfirm)
+	if errConfirm != nil {
+		return nil, errConfirm
+	}
+	return &adminapi.ConfirmNetworkChangeResponse{
+		Message: fmt.Sprintf("Confirmed change '%s'", req.Name),
+	}, nil
+}
+
+// LockDevices locks the configuration of the named devices for the calling client until the TTL expires,
+// so that changes to them from other clients are refused. The client must be authenticated.
+func (s Server) LockDevices(ctx context.Context, req *adminapi.LockDevicesRequest) (*adminapi.LockDevicesResponse, error) {
+	ttl := defaultLockTTL
+	if req.TTL != nil {
+		ttl = *req.TTL
+	}
+	client := utils.GetClientIdentity(ctx)
+	var locks []*lockstore.DeviceLock
+	errLock := checkAuthenticated(client)
+	if errLock == nil {
+		locks, errLock = manager.GetManager().LockDevices(req.DeviceIDs, client, ttl)
+	}
+	record := audit.NewRecord(ctx, audit.OperationLock, errLock)
+	record.Paths = deviceIDStrings(req.DeviceIDs)
+	record.Details = fmt.Sprintf("ttl %s", ttl)
+	manager.GetManager().Audit(record)
+	if errLock != nil {
+		return nil, errLock
+	}
+	response := &adminapi.LockDevicesResponse{
+		Locks: make([]*adminapi.DeviceLock, 0, len(locks)),
+	}
+	for _, lock := range locks {
+		response.Locks = append(response.Locks, &adminapi.DeviceLock{
+			DeviceID: lock.DeviceID,
+			Owner:    lock.Owner,
+			Expires:  lock.Expires,
+		})
+	}
+	return response, nil
+}
+
+// UnlockDevices releases the locks held by the calling client on the named devices. The client must be
+// authenticated.
+func (s Server) UnlockDevices(ctx context.Context, req *adminapi.UnlockDevicesRequest) (*adminapi.UnlockDevicesResponse, error) {
+	client := utils.GetClientIdentity(ctx)
+	errUnlock := checkAuthenticated(client)
+	if errUnlock == nil {
+		errUnlock = manager.GetManager().UnlockDevices(req.DeviceIDs, client)
+	}
+	record := audit.NewRecord(ctx, audit.OperationUnlock, errUnlock)
+	record.Paths = deviceIDStrings(req.DeviceIDs)
+	manager.GetManager().Audit(record)
+	if errUnlock != nil {
+		return nil, errUnlock
+	}
+	return &adminapi.UnlockDevicesResponse{
+		Message: fmt.Sprintf("Unlocked devices %v", req.DeviceIDs),
+	}, nil
+}
+
+// checkAuthenticated refuses the locking operations of a client whose identity is not known, since the locks
+// of all such clients would have the same owner
+func checkAuthenticated(client string) error {
+	if client == "" {
+		return status.Error(codes.Unauthenticated, "device locks require an authenticated client")
+	}
+	return nil
+}
+
 // ListSnapshots lists snapshots for all devices
 func (s Server) ListSnapshots(r *admin.ListSnapshotsRequest, stream admin.ConfigAdminService_ListSnapshotsServer) error {
 	log.Infof("ListSnapshots called with %s. Subscribe %v", r.ID, r.Subscribe)
@@ -286,7 +394,15 @@ func (s Server) ListSnapshots(r *admin.ListSnapshotsRequest, stream admin.Config
 }
 
 // CompactChanges takes a snapshot of all devices
-func (s Server) CompactChanges(ctx context.Context, request *admin.CompactChangesRequest) (*admin.CompactChangesResponse, error) {
+func (s Server) CompactChanges(ctx context.Context, request *admin.CompactChangesRequest) (response *admin.CompactChangesResponse, err error) {
+	defer func() {
+		record := audit.NewRecord(ctx, audit.OperationCompact, err)
+		if request.RetentionPeriod != nil {
+			record.Details = fmt.Sprintf("retention period %s", *request.RetentionPeriod)
+		}
+		manager.GetManager().Audit(record)
+	}()
+
 	snap := &networksnapshot.NetworkSnapshot{
 		Retention: snapshot.RetentionOptions{
 			RetainWindow: request.RetentionPeriod,
@@ -311,3 +427,118 @@ func (s Server) CompactChanges(ctx context.Context, request *admin.CompactChange
 	}
 	return nil, errors.New("snapshot state unknown")
 }
+
+// ListAuditRecords streams the records of the audit log of this instance that match the request, oldest
+// first. The stream fails at the first record that has been tampered with.
+func (s Server) ListAuditRecords(req *adminapi.ListAuditRecordsRequest, stream adminapi.ListAuditRecordsServer) error {
+	log.Infof("ListAuditRecords called with client %s, operation %s, change %s", req.Client, req.Operation, req.NetworkChangeID)
+	auditLog := manager.GetManager().AuditLog
+	if auditLog == nil {
+		return errors.New("audit log is not enabled")
+	}
+	var since time.Time
+	if req.Since != nil {
+		since = *req.Since
+	}
+	err := auditLog.Scan(since, func(record *audit.Record) error {
+		if !matchesAuditRecord(req, record) {
+			return nil
+		}
+		return stream.Send(&adminapi.AuditRecord{
+			Index:           record.Index,
+			Timestamp:       record.Timestamp,
+			Operation:       string(record.Operation),
+			Client:          record.Client,
+			Paths:           record.Paths,
+			NetworkChangeID: record.NetworkChangeID,
+			Details:         record.Details,
+			Outcome:         string(record.Outcome),
+			Error:           record.Error,
+			PrevHash:        record.PrevHash,
+			Hash:            record.Hash,
+		})
+	})
+	if err != nil {
+		log.Errorf("Error listing audit records %s", err)
+		return err
+	}
+	return nil
+}
+
+// matchesAuditRecord indicates whether an audit record matches all the filters of the request
+func matchesAuditRecord(req *adminapi.ListAuditRecordsRequest, record *audit.Record) bool {
+	if req.Client != "" && req.Client != record.Client {
+		return false
+	}
+	if req.Operation != "" && req.Operation != string(record.Operation) {
+		return false
+	}
+	if req.NetworkChangeID != "" && req.NetworkChangeID != record.NetworkChangeID {
+		return false
+	}
+	return true
+}
+
+// ListDeadLetters streams the requests that the controllers have given up on, after failing to reconcile them
+// too many times in a row
+func (s Server) ListDeadLetters(req *adminapi.ListDeadLettersRequest, stream adminapi.ListDeadLettersServer) error {
+	log.Infof("ListDeadLetters called with controller %s", req.Controller)
+	letters, err := manager.GetManager().ListDeadLetters(req.Controller)
+	if err != nil {
+		return err
+	}
+	for _, letter := range letters {
+		if err := stream.Send(newDeadLetter(letter)); err != nil {
+			log.Errorf("Error sending dead letters %s", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// RequeueDeadLetters gives the requests that the controllers have given up on a new set of attempts. All the
+// dead letters of the controller are requeued if no ID is given, and those of all the controllers if no
+// controller is given either.
+func (s Server) RequeueDeadLetters(ctx context.Context, req *adminapi.RequeueDeadLettersRequest) (*adminapi.RequeueDeadLettersResponse, error) {
+	log.Infof("RequeueDeadLetters called with controller %s, ids %v", req.Controller, req.IDs)
+	ids := make([]types.ID, 0, len(req.IDs))
+	for _, id := range req.IDs {
+		ids = append(ids, types.ID(id))
+	}
+	letters, err := manager.GetManager().RequeueDeadLetters(req.Controller, ids)
+	if err != nil {
+		return nil, err
+	}
+	response := &adminapi.RequeueDeadLettersResponse{
+		DeadLetters: make([]*adminapi.DeadLetter, 0, len(letters)),
+	}
+	for _, letter := range letters {
+		response.DeadLetters = append(response.DeadLetters, newDeadLetter(letter))
+	}
+	return response, nil
+}
+
+func newDeadLetter(letter *deadletter.DeadLetter) *adminapi.DeadLetter {
+	return &adminapi.DeadLetter{
+		Controller: letter.Controller,
+		ID:         string(letter.ID),
+		Attempts:   uint32(letter.Attempts),
+		Error:      letter.Error,
+		Time:       letter.Time,
+	}
+}
+
+// auditNetworkChange records an operation on a network change in the audit log
+func auditNetworkChange(ctx context.Context, op audit.Operation, name string, err error) {
+	record := audit.NewRecord(ctx, op, err)
+	record.NetworkChangeID = name
+	manager.GetManager().Audit(record)
+}
+
+func deviceIDStrings(deviceIDs []devicetype.ID) []string {
+	ids := make([]string, 0, len(deviceIDs))
+	for _, id := range deviceIDs {
+		ids = append(ids, string(id))
+	}
+	return ids
+}
@@ -16,23 +16,37 @@ package admin
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
 	"errors"
 	"fmt"
 	"github.com/golang/mock/gomock"
+	"github.com/onosproject/onos-api/go/onos/config"
 	"github.com/onosproject/onos-api/go/onos/config/admin"
 	device2 "github.com/onosproject/onos-api/go/onos/config/change/device"
 	"github.com/onosproject/onos-api/go/onos/config/device"
 	devicesnapshot "github.com/onosproject/onos-api/go/onos/config/snapshot/device"
+	adminapi "github.com/onosproject/onos-config/pkg/api/admin"
+	"github.com/onosproject/onos-config/pkg/audit"
 	"github.com/onosproject/onos-config/pkg/manager"
+	"github.com/onosproject/onos-config/pkg/store/deadletter"
+	"github.com/onosproject/onos-config/pkg/store/lock"
 	"github.com/onosproject/onos-config/pkg/store/stream"
 	mockstore "github.com/onosproject/onos-config/pkg/test/mocks/store"
 	"github.com/onosproject/onos-config/pkg/test/mocks/store/cache"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
 	"google.golang.org/grpc/test/bufconn"
 	"gotest.tools/assert"
 	"io"
+	"io/ioutil"
 	"net"
 	"os"
+	"path/filepath"
 	"testing"
 	"time"
 )
@@ -47,9 +61,10 @@ func setUpServer(t *testing.T) (*manager.Manager, *grpc.ClientConn, admin.Config
 	s := grpc.NewServer()
 
 	admin.RegisterConfigAdminServiceServer(s, &Server{})
+	adminapi.RegisterConfigAdminExtServiceServer(s, &Server{})
 
 	go func() {
-		if err := s.Serve(lis); err != nil {
+		if err := s.Serve(lis); err != nil && err != grpc.ErrServerStopped {
 			t.Error("Server exited with error")
 		}
 	}()
@@ -74,8 +89,11 @@ func setUpServer(t *testing.T) (*manager.Manager, *grpc.ClientConn, admin.Config
 		mockstore.NewMockDeviceStore(ctrl),
 		cache.NewMockCache(ctrl),
 		mockstore.NewMockNetworkChangesStore(ctrl),
+		mockstore.NewMockNetworkChangeMetadataStore(ctrl),
 		mockstore.NewMockNetworkSnapshotStore(ctrl),
 		mockstore.NewMockDeviceSnapshotStore(ctrl),
+		mockstore.NewMockDeviceLockStore(ctrl),
+		mockstore.NewMockDeadLetterStore(ctrl),
 		true)
 
 	return mgrTest, conn, client, s
@@ -107,6 +125,194 @@ func Test_RollbackNetworkChange_NoChange(t *testing.T) {
 	assert.ErrorContains(t, err, "is not")
 }
 
+// newClientContext returns the context of a call from a client authenticated by a verified TLS certificate
+func newClientContext(name string) context.Context {
+	tlsInfo := credentials.TLSInfo{
+		State: tls.ConnectionState{
+			VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: name}}}},
+		},
+	}
+	return peer.NewContext(context.Background(), &peer.Peer{AuthInfo: tlsInfo})
+}
+
+func Test_LockDevices(t *testing.T) {
+	mgrTest, conn, _, server := setUpServer(t)
+	defer server.Stop()
+	defer conn.Close()
+
+	mockLockStore, ok := mgrTest.DeviceLockStore.(*mockstore.MockDeviceLockStore)
+	assert.Assert(t, ok, "casting mock store")
+
+	expires := time.Now().Add(5 * time.Minute)
+	mockLockStore.EXPECT().Lock([]device.ID{"device-1"}, "client-1", 5*time.Minute).DoAndReturn(
+		func(ids []device.ID, owner string, ttl time.Duration) ([]*lock.DeviceLock, error) {
+			return []*lock.DeviceLock{{DeviceID: ids[0], Owner: owner, Expires: expires}}, nil
+		})
+	ttl := 5 * time.Minute
+	resp, err := Server{}.LockDevices(newClientContext("client-1"), &adminapi.LockDevicesRequest{
+		DeviceIDs: []device.ID{"device-1"},
+		TTL:       &ttl,
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, 1, len(resp.Locks))
+	assert.Equal(t, device.ID("device-1"), resp.Locks[0].DeviceID)
+	assert.Equal(t, "client-1", resp.Locks[0].Owner)
+	assert.Assert(t, resp.Locks[0].Expires.Equal(expires))
+
+	mockLockStore.EXPECT().Unlock([]device.ID{"device-1"}, "client-1").Return(nil)
+	unlockResp, err := Server{}.UnlockDevices(newClientContext("client-1"), &adminapi.UnlockDevicesRequest{
+		DeviceIDs: []device.ID{"device-1"},
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, "Unlocked devices [device-1]", unlockResp.Message)
+}
+
+func Test_LockDevices_Unauthenticated(t *testing.T) {
+	_, conn, _, server := setUpServer(t)
+	defer server.Stop()
+	defer conn.Close()
+
+	client := adminapi.CreateConfigAdminExtServiceClient(conn)
+	_, err := client.LockDevices(context.Background(), &adminapi.LockDevicesRequest{DeviceIDs: []device.ID{"device-1"}})
+	assert.Equal(t, status.Code(err), codes.Unauthenticated)
+	_, err = client.UnlockDevices(context.Background(), &adminapi.UnlockDevicesRequest{DeviceIDs: []device.ID{"device-1"}})
+	assert.Equal(t, status.Code(err), codes.Unauthenticated)
+}
+
+func Test_UnlockDevices_NoDevice(t *testing.T) {
+	_, conn, _, server := setUpServer(t)
+	defer server.Stop()
+	defer conn.Close()
+
+	_, err := Server{}.UnlockDevices(newClientContext("client-1"), &adminapi.UnlockDevicesRequest{})
+	assert.ErrorContains(t, err, "no device to unlock")
+}
+
+func Test_ListAuditRecords(t *testing.T) {
+	mgrTest, conn, client, server := setUpServer(t)
+	defer server.Stop()
+	defer conn.Close()
+
+	dir, err := ioutil.TempDir("", "audit")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	mgrTest.AuditLog, err = audit.NewFileLog(filepath.Join(dir, "audit.log"), []byte("audit-key"), false)
+	assert.NilError(t, err)
+	defer mgrTest.AuditLog.Close()
+
+	mockLockStore, ok := mgrTest.DeviceLockStore.(*mockstore.MockDeviceLockStore)
+	assert.Assert(t, ok, "casting mock store")
+	mockLockStore.EXPECT().Lock([]device.ID{"device-1"}, gomock.Any(), gomock.Any()).Return([]*lock.DeviceLock{}, nil)
+	_, err = Server{}.LockDevices(newClientContext("client-1"), &adminapi.LockDevicesRequest{DeviceIDs: []device.ID{"device-1"}})
+	assert.NilError(t, err)
+
+	mockNwChStore, ok := mgrTest.NetworkChangesStore.(*mockstore.MockNetworkChangesStore)
+	assert.Assert(t, ok, "casting mock store")
+	mockNwChStore.EXPECT().Get(gomock.Any()).Return(nil, errors.New("change not found"))
+	_, err = client.RollbackNetworkChange(context.Background(), &admin.RollbackRequest{Name: "BAD CHANGE"})
+	assert.ErrorContains(t, err, "change not found")
+
+	extClient := adminapi.CreateConfigAdminExtServiceClient(conn)
+	listAuditRecords := func(req *adminapi.ListAuditRecordsRequest) []*adminapi.AuditRecord {
+		stream, err := extClient.ListAuditRecords(context.Background(), req)
+		assert.NilError(t, err)
+		records := make([]*adminapi.AuditRecord, 0)
+		for {
+			record, err := stream.Recv()
+			if err == io.EOF {
+				break
+			}
+			assert.NilError(t, err)
+			records = append(records, record)
+		}
+		return records
+	}
+
+	records := listAuditRecords(&adminapi.ListAuditRecordsRequest{})
+	assert.Equal(t, len(records), 2)
+	assert.Equal(t, records[0].Operation, "lock")
+	assert.DeepEqual(t, records[0].Paths, []string{"device-1"})
+	assert.Equal(t, records[0].Outcome, "SUCCESS")
+	assert.Equal(t, records[1].Operation, "rollback")
+	assert.Equal(t, records[1].NetworkChangeID, "BAD CHANGE")
+	assert.Equal(t, records[1].Outcome, "FAILURE")
+	assert.Equal(t, records[1].PrevHash, records[0].Hash)
+
+	records = listAuditRecords(&adminapi.ListAuditRecordsRequest{NetworkChangeID: "BAD CHANGE"})
+	assert.Equal(t, len(records), 1)
+	assert.Equal(t, records[0].Index, uint64(2))
+
+	since := time.Now()
+	records = listAuditRecords(&adminapi.ListAuditRecordsRequest{Since: &since})
+	assert.Equal(t, len(records), 0)
+}
+
+func Test_DeadLetters(t *testing.T) {
+	mgrTest, conn, _, server := setUpServer(t)
+	defer server.Stop()
+	defer conn.Close()
+
+	now := time.Now()
+	letters := []*deadletter.DeadLetter{
+		{Controller: "NetworkChange", ID: "change-2", Attempts: 50, Error: "some error", Time: now},
+		{Controller: "DeviceChange", ID: "change-1:device-1:1.0.0", Attempts: 50, Error: "another error", Time: now.Add(-time.Minute)},
+	}
+	mockDeadLetterStore, ok := mgrTest.DeadLetterStore.(*mockstore.MockDeadLetterStore)
+	assert.Assert(t, ok, "casting mock store")
+	mockDeadLetterStore.EXPECT().List(gomock.Any()).DoAndReturn(
+		func(ch chan<- *deadletter.DeadLetter) (stream.Context, error) {
+			go func() {
+				for _, letter := range letters {
+					ch <- letter
+				}
+				close(ch)
+			}()
+			return stream.NewContext(func() {}), nil
+		}).AnyTimes()
+
+	extClient := adminapi.CreateConfigAdminExtServiceClient(conn)
+	listDeadLetters := func(controller string) ([]*adminapi.DeadLetter, error) {
+		stream, err := extClient.ListDeadLetters(context.Background(), &adminapi.ListDeadLettersRequest{Controller: controller})
+		assert.NilError(t, err)
+		letters := make([]*adminapi.DeadLetter, 0)
+		for {
+			letter, err := stream.Recv()
+			if err == io.EOF {
+				return letters, nil
+			} else if err != nil {
+				return nil, err
+			}
+			letters = append(letters, letter)
+		}
+	}
+
+	// The dead letters of all the controllers are listed oldest first
+	list, err := listDeadLetters("")
+	assert.NilError(t, err)
+	assert.Equal(t, len(list), 2)
+	assert.Equal(t, list[0].Controller, "DeviceChange")
+	assert.Equal(t, list[0].ID, "change-1:device-1:1.0.0")
+	assert.Equal(t, list[1].Controller, "NetworkChange")
+	assert.Equal(t, list[1].Attempts, uint32(50))
+	assert.Equal(t, list[1].Error, "some error")
+
+	list, err = listDeadLetters("NetworkChange")
+	assert.NilError(t, err)
+	assert.Equal(t, len(list), 1)
+	assert.Equal(t, list[0].ID, "change-2")
+
+	_, err = listDeadLetters("BadController")
+	assert.ErrorContains(t, err, "unknown controller BadController")
+
+	// Requeuing a dead letter deletes it, so that its controller requeues it
+	mockDeadLetterStore.EXPECT().Delete("NetworkChange", config.ID("change-2")).Return(nil)
+	resp, err := extClient.RequeueDeadLetters(context.Background(), &adminapi.RequeueDeadLettersRequest{IDs: []string{"change-2", "change-3"}})
+	assert.NilError(t, err)
+	assert.Equal(t, len(resp.DeadLetters), 1)
+	assert.Equal(t, resp.DeadLetters[0].Controller, "NetworkChange")
+	assert.Equal(t, resp.DeadLetters[0].ID, "change-2")
+}
+
 func Test_ListSnapshots(t *testing.T) {
 	const numSnapshots = 2
 	mgrTest, conn, client, server := setUpServer(t)
@@ -16,16 +16,20 @@
 package diags
 
 import (
+	"context"
 	"fmt"
+	"strings"
 
 	"github.com/onosproject/onos-api/go/onos/config/admin"
 	devicechange "github.com/onosproject/onos-api/go/onos/config/change/device"
 	networkchange "github.com/onosproject/onos-api/go/onos/config/change/network"
 	devicetype "github.com/onosproject/onos-api/go/onos/config/device"
 	"github.com/onosproject/onos-api/go/onos/config/diags"
+	diagsapi "github.com/onosproject/onos-config/pkg/api/diags"
 	topodevice "github.com/onosproject/onos-config/pkg/device"
 	"github.com/onosproject/onos-config/pkg/manager"
 	"github.com/onosproject/onos-config/pkg/store/change/device"
+	"github.com/onosproject/onos-config/pkg/store/change/metadata"
 	"github.com/onosproject/onos-config/pkg/store/change/network"
 	streams "github.com/onosproject/onos-config/pkg/store/stream"
 	"github.com/onosproject/onos-config/pkg/utils"
@@ -45,6 +49,7 @@ type Service struct {
 func (s Service) Register(r *grpc.Server) {
 	diags.RegisterOpStateDiagsServer(r, Server{})
 	diags.RegisterChangeServiceServer(r, Server{})
+	diagsapi.RegisterChangeExtServiceServer(r, Server{})
 }
 
 // Server implements the gRPC service for diagnostic facilities.
@@ -122,22 +127,75 @@ func (s Server) GetOpState(r *diags.OpStateRequest, stream diags.OpStateDiags_Ge
 // further updates until the client hangs up
 func (s Server) ListNetworkChanges(r *diags.ListNetworkChangeRequest, stream diags.ChangeService_ListNetworkChangesServer) error {
 	log.Infof("ListNetworkChanges called with %s. Subscribe %v", r.ChangeID, r.Subscribe)
+	err := listNetworkChanges(stream.Context(), r.ChangeID, r.Subscribe, r.WithoutReplay,
+		func(change *networkchange.NetworkChange, eventType diags.Type) error {
+			return stream.Send(&diags.ListNetworkChangeResponse{
+				Change: change,
+				Type:   eventType,
+			})
+		})
+	if err != nil {
+		return err
+	}
+	log.Infof("Closing ListNetworkChanges for %s", r.ChangeID)
+	return nil
+}
 
+// ListNetworkChangesWithMetadata provides a stream of Network Changes along with their author, description
+// and labels, which are kept in the metadata of the changes. The changes can be filtered on them.
+func (s Server) ListNetworkChangesWithMetadata(r *diagsapi.ListNetworkChangesWithMetadataRequest, stream diagsapi.ListNetworkChangesWithMetadataServer) error {
+	log.Infof("ListNetworkChangesWithMetadata called with %s. Subscribe %v. Author '%s', description '%s', labels %v",
+		r.ChangeID, r.Subscribe, r.Author, r.Description, r.Labels)
+	err := listNetworkChanges(stream.Context(), r.ChangeID, r.Subscribe, r.WithoutReplay,
+		func(change *networkchange.NetworkChange, eventType diags.Type) error {
+			changeMetadata, err := manager.GetManager().NetworkMetadataStore.Get(change.ID)
+			if err != nil {
+				return err
+			}
+			if changeMetadata == nil {
+				changeMetadata = &metadata.Metadata{ID: change.ID}
+			}
+			if !matchesChangeMetadata(r, changeMetadata) {
+				return nil
+			}
+			return stream.Send(&diagsapi.ListNetworkChangesWithMetadataResponse{
+				Change:        change,
+				Type:          eventType,
+				Author:        changeMetadata.Username,
+				Description:   changeMetadata.Description,
+				Labels:        changeMetadata.Labels,
+				ApplyPolicy:   string(changeMetadata.ApplyPolicy),
+				Quorum:        changeMetadata.Quorum,
+				Devices:       changeMetadata.Devices,
+				ApplyTimeouts: changeMetadata.ApplyTimeouts,
+			})
+		})
+	if err != nil {
+		return err
+	}
+	log.Infof("Closing ListNetworkChangesWithMetadata for %s", r.ChangeID)
+	return nil
+}
+
+// listNetworkChanges calls send for each network change that matches the changeID, which may contain a wildcard.
+// When subscribing it then keeps calling it on each update of a matching change, until the context is done.
+func listNetworkChanges(ctx context.Context, changeID networkchange.ID, subscribe bool, withoutReplay bool,
+	send func(*networkchange.NetworkChange, diags.Type) error) error {
 	// There may be a wildcard given - we only want to reply with changes that match
-	matcher := utils.MatchWildcardChNameRegexp(string(r.ChangeID))
+	matcher := utils.MatchWildcardChNameRegexp(string(changeID))
 	var watchOpts []network.WatchOption
-	if !r.WithoutReplay {
+	if !withoutReplay {
 		watchOpts = append(watchOpts, network.WithReplay())
 	}
 
-	if r.Subscribe {
+	if subscribe {
 		eventCh := make(chan streams.Event)
-		ctx, err := manager.GetManager().NetworkChangesStore.Watch(eventCh, watchOpts...)
+		watchCtx, err := manager.GetManager().NetworkChangesStore.Watch(eventCh, watchOpts...)
 		if err != nil {
 			log.Errorf("Error watching Network Changes %s", err)
 			return err
 		}
-		defer ctx.Close()
+		defer watchCtx.Close()
 
 		for {
 			breakout := false
@@ -151,18 +209,14 @@ func (s Server) ListNetworkChanges(r *diags.ListNetworkChangeRequest, stream dia
 				change := event.Object.(*networkchange.NetworkChange)
 
 				if matcher.MatchString(string(change.ID)) {
-					msg := &diags.ListNetworkChangeResponse{
-						Change: change,
-						Type:   streamTypeToResponseType(event.Type),
-					}
 					log.Infof("Sending matching change %v", change.ID)
-					err := stream.Send(msg)
+					err := send(change, streamTypeToResponseType(event.Type))
 					if err != nil {
 						log.Errorf("Error sending NetworkChanges %v %v", change.ID, err)
 						return err
 					}
 				}
-			case <-stream.Context().Done():
+			case <-ctx.Done():
 				log.Infof("ListNetworkChanges remote client closed connection")
 				return nil
 			}
@@ -172,12 +226,12 @@ func (s Server) ListNetworkChanges(r *diags.ListNetworkChangeRequest, stream dia
 		}
 	} else {
 		changeCh := make(chan *networkchange.NetworkChange)
-		ctx, err := manager.GetManager().NetworkChangesStore.List(changeCh)
+		listCtx, err := manager.GetManager().NetworkChangesStore.List(changeCh)
 		if err != nil {
 			log.Errorf("Error listing Network Changes %s", err)
 			return err
 		}
-		defer ctx.Close()
+		defer listCtx.Close()
 
 		for {
 			breakout := false
@@ -189,18 +243,14 @@ func (s Server) ListNetworkChanges(r *diags.ListNetworkChangeRequest, stream dia
 				}
 
 				if matcher.MatchString(string(change.ID)) {
-					msg := &diags.ListNetworkChangeResponse{
-						Change: change,
-						Type:   diags.Type_NONE,
-					}
 					log.Infof("Sending matching change %v", change.ID)
-					err := stream.Send(msg)
+					err := send(change, diags.Type_NONE)
 					if err != nil {
 						log.Errorf("Error sending NetworkChanges %v %v", change.ID, err)
 						return err
 					}
 				}
-			case <-stream.Context().Done():
+			case <-ctx.Done():
 				log.Infof("ListNetworkChanges remote client closed connection")
 				return nil
 			}
@@ -209,10 +259,26 @@ func (s Server) ListNetworkChanges(r *diags.ListNetworkChangeRequest, stream dia
 			}
 		}
 	}
-	log.Infof("Closing ListNetworkChanges for %s", r.ChangeID)
 	return nil
 }
 
+// matchesChangeMetadata indicates whether the metadata of a network change has the author and labels asked for,
+// and a description that contains the one asked for, ignoring case
+func matchesChangeMetadata(r *diagsapi.ListNetworkChangesWithMetadataRequest, changeMetadata *metadata.Metadata) bool {
+	if r.Author != "" && r.Author != changeMetadata.Username {
+		return false
+	}
+	if r.Description != "" && !strings.Contains(strings.ToLower(changeMetadata.Description), strings.ToLower(r.Description)) {
+		return false
+	}
+	for key, value := range r.Labels {
+		if labelValue, ok := changeMetadata.Labels[key]; !ok || labelValue != value {
+			return false
+		}
+	}
+	return true
+}
+
 // ListDeviceChanges provides a stream of Device Changes
 func (s Server) ListDeviceChanges(r *diags.ListDeviceChangeRequest, stream diags.ChangeService_ListDeviceChangesServer) error {
 	log.Infof("ListDeviceChanges called with %s %s. Subscribe %v", r.DeviceID, r.DeviceVersion, r.Subscribe)
@@ -307,6 +373,104 @@ func (s Server) ListDeviceChanges(r *diags.ListDeviceChangeRequest, stream diags
 	return nil
 }
 
+// ListConfigDrift provides a stream of the paths where the running config of a device was found to differ
+// from its intended config, when onos-config last connected to the device
+// There may be a wildcard in the DeviceID given, and if it is empty then all devices are listed
+func (s Server) ListConfigDrift(r *diagsapi.ListConfigDriftRequest, stream diagsapi.ListConfigDriftServer) error {
+	log.Infof("ListConfigDrift called with %s", r.DeviceID)
+	matcher := utils.MatchWildcardChNameRegexp(string(r.DeviceID))
+
+	responses := make([]*diagsapi.ListConfigDriftResponse, 0)
+	manager.GetManager().ConfigDriftCacheLock.RLock()
+	for deviceID, drift := range manager.GetManager().ConfigDriftCache {
+		if r.DeviceID != "" && !matcher.MatchString(string(deviceID)) {
+			continue
+		}
+		for _, d := range drift {
+			responses = append(responses, &diagsapi.ListConfigDriftResponse{
+				DeviceID:      devicetype.ID(deviceID),
+				Path:          d.Path,
+				IntendedValue: d.Intended,
+				ActualValue:   d.Actual,
+				ChangeID:      d.ChangeID,
+			})
+		}
+	}
+	manager.GetManager().ConfigDriftCacheLock.RUnlock()
+
+	for _, msg := range responses {
+		if err := stream.Send(msg); err != nil {
+			log.Errorf("Error sending config drift %s %s %v", msg.DeviceID, msg.Path, err)
+			return err
+		}
+	}
+	log.Infof("Closing ListConfigDrift for %s", r.DeviceID)
+	return nil
+}
+
+// ListConfigDiff provides a stream of the paths where the config of the devices differs between the point just
+// after the first network change and the point just after the second one
+// There may be a wildcard in the DeviceID given, and if it is empty then all devices are compared
+func (s Server) ListConfigDiff(r *diagsapi.ListConfigDiffRequest, stream diagsapi.ListConfigDiffServer) error {
+	log.Infof("ListConfigDiff called with %s %s %s", r.FromChangeID, r.ToChangeID, r.DeviceID)
+	diffs, err := manager.GetManager().DiffNetworkChanges(r.FromChangeID, r.ToChangeID, r.DeviceID)
+	if err != nil {
+		log.Errorf("Error computing config diff %s", err)
+		return err
+	}
+
+	for _, diff := range diffs {
+		msg := &diagsapi.ListConfigDiffResponse{
+			DeviceID:      diff.DeviceID,
+			DeviceVersion: diff.DeviceVersion,
+			Path:          diff.Path,
+			Type:          diagsapi.DiffUpdated,
+			OldValue:      diff.Old,
+			NewValue:      diff.New,
+		}
+		if diff.Old == nil {
+			msg.Type = diagsapi.DiffAdded
+		} else if diff.New == nil {
+			msg.Type = diagsapi.DiffRemoved
+		}
+		if err := stream.Send(msg); err != nil {
+			log.Errorf("Error sending config diff %s %s %v", msg.DeviceID, msg.Path, err)
+			return err
+		}
+	}
+	log.Infof("Closing ListConfigDiff for %s %s", r.FromChangeID, r.ToChangeID)
+	return nil
+}
+
+// ListPendingChanges provides a stream of the network changes that have been PENDING for longer than the given
+// threshold, along with their device changes that are still PENDING
+func (s Server) ListPendingChanges(r *diagsapi.ListPendingChangesRequest, stream diagsapi.ListPendingChangesServer) error {
+	threshold := manager.DefaultPendingThreshold
+	if r.Threshold != nil {
+		threshold = *r.Threshold
+	}
+	log.Infof("ListPendingChanges called with %v", threshold)
+	pendingChanges, err := manager.GetManager().ListPendingChanges(threshold)
+	if err != nil {
+		log.Errorf("Error listing pending changes %s", err)
+		return err
+	}
+
+	for _, pendingChange := range pendingChanges {
+		msg := &diagsapi.ListPendingChangesResponse{
+			NetworkChange: pendingChange.NetworkChange,
+			DeviceChanges: pendingChange.DeviceChanges,
+			PendingSince:  pendingChange.PendingSince,
+		}
+		if err := stream.Send(msg); err != nil {
+			log.Errorf("Error sending pending change %s %v", pendingChange.NetworkChange.ID, err)
+			return err
+		}
+	}
+	log.Infof("Closing ListPendingChanges for %v", threshold)
+	return nil
+}
+
 func streamTypeToResponseType(eventType streams.EventType) diags.Type {
 	switch eventType {
 	case streams.Created:
@@ -23,8 +23,11 @@ import (
 	networkchange "github.com/onosproject/onos-api/go/onos/config/change/network"
 	devicetypes "github.com/onosproject/onos-api/go/onos/config/device"
 	"github.com/onosproject/onos-api/go/onos/config/diags"
+	diagsapi "github.com/onosproject/onos-config/pkg/api/diags"
 	topodevice "github.com/onosproject/onos-config/pkg/device"
 	"github.com/onosproject/onos-config/pkg/manager"
+	"github.com/onosproject/onos-config/pkg/southbound/synchronizer"
+	"github.com/onosproject/onos-config/pkg/store/change/metadata"
 	"github.com/onosproject/onos-config/pkg/store/device/cache"
 	"github.com/onosproject/onos-config/pkg/store/stream"
 	mockstore "github.com/onosproject/onos-config/pkg/test/mocks/store"
@@ -46,6 +49,7 @@ func setUpServer(t *testing.T) (*manager.Manager, *grpc.ClientConn, diags.Change
 	s := grpc.NewServer()
 
 	diags.RegisterChangeServiceServer(s, &Server{})
+	diagsapi.RegisterChangeExtServiceServer(s, &Server{})
 
 	go func() {
 		if err := s.Serve(lis); err != nil {
@@ -73,8 +77,11 @@ func setUpServer(t *testing.T) (*manager.Manager, *grpc.ClientConn, diags.Change
 		mockstore.NewMockDeviceStore(ctrl),
 		mockcache.NewMockCache(ctrl),
 		mockstore.NewMockNetworkChangesStore(ctrl),
+		mockstore.NewMockNetworkChangeMetadataStore(ctrl),
 		mockstore.NewMockNetworkSnapshotStore(ctrl),
 		mockstore.NewMockDeviceSnapshotStore(ctrl),
+		mockstore.NewMockDeviceLockStore(ctrl),
+		mockstore.NewMockDeadLetterStore(ctrl),
 		true)
 
 	mgrTest.DeviceStore = mockstore.NewMockDeviceStore(ctrl)
@@ -131,6 +138,75 @@ func Test_ListNetworkChanges(t *testing.T) {
 	time.Sleep(time.Millisecond * numevents * 2)
 }
 
+func Test_ListNetworkChangesByMetadata(t *testing.T) {
+	const numevents = 40
+	mgrTest, conn, _, server := setUpServer(t)
+	defer server.Stop()
+	defer conn.Close()
+
+	networkChanges := generateNetworkChangeData(numevents)
+
+	// Changes 1, 5, 9... are by user-1 with ticket OPS-1
+	mockMetadataStore, ok := mgrTest.NetworkMetadataStore.(*mockstore.MockNetworkChangeMetadataStore)
+	assert.Assert(t, ok, "casting mock store")
+	mockstore.SetUpMapBackedNetworkChangeMetadataStore(mockMetadataStore)
+	for i, nwch := range networkChanges {
+		err := mockMetadataStore.Create(&metadata.Metadata{
+			ID:          nwch.ID,
+			Username:    fmt.Sprintf("user-%d", i%2),
+			Description: fmt.Sprintf("Change number %d", i),
+			Labels:      map[string]string{"ticket": fmt.Sprintf("OPS-%d", i%4)},
+		})
+		assert.NilError(t, err)
+	}
+
+	mockNwChStore, ok := mgrTest.NetworkChangesStore.(*mockstore.MockNetworkChangesStore)
+	assert.Assert(t, ok, "casting mock store")
+	mockNwChStore.EXPECT().List(gomock.Any()).DoAndReturn(func(ch chan<- *networkchange.NetworkChange) (stream.Context, error) {
+		go func() {
+			for _, nwch := range networkChanges {
+				ch <- nwch
+			}
+			close(ch)
+		}()
+		return stream.NewContext(func() {
+
+		}), nil
+	}).Times(2)
+
+	client := diagsapi.CreateChangeExtServiceClient(conn)
+	listChanges := func(req *diagsapi.ListNetworkChangesWithMetadataRequest) []*diagsapi.ListNetworkChangesWithMetadataResponse {
+		stream, err := client.ListNetworkChangesWithMetadata(context.Background(), req)
+		assert.NilError(t, err)
+		changes := make([]*diagsapi.ListNetworkChangesWithMetadataResponse, 0)
+		for {
+			in, err := stream.Recv()
+			if err == io.EOF {
+				return changes
+			}
+			assert.NilError(t, err, "unable to receive message")
+			changes = append(changes, in)
+		}
+	}
+
+	changes := listChanges(&diagsapi.ListNetworkChangesWithMetadataRequest{
+		Author: "user-1",
+		Labels: map[string]string{"ticket": "OPS-1"},
+	})
+	assert.Equal(t, len(changes), numevents/4)
+	for _, change := range changes {
+		assert.Equal(t, change.Author, "user-1")
+		assert.Equal(t, change.Labels["ticket"], "OPS-1")
+	}
+
+	// Only change 13 has a description containing "number 13"
+	changes = listChanges(&diagsapi.ListNetworkChangesWithMetadataRequest{
+		Description: "NUMBER 13",
+	})
+	assert.Equal(t, len(changes), 1)
+	assert.Equal(t, changes[0].Change.ID, networkchange.ID("change-13"))
+}
+
 func Test_ListDeviceChanges(t *testing.T) {
 	const numevents = 40
 	mgrTest, conn, client, server := setUpServer(t)
@@ -251,6 +327,58 @@ func Test_ListDeviceChangesNoVersionManyPresent(t *testing.T) {
 
 }
 
+func Test_ListConfigDrift(t *testing.T) {
+	mgrTest, conn, _, server := setUpServer(t)
+	defer server.Stop()
+	defer conn.Close()
+	client := diagsapi.CreateChangeExtServiceClient(conn)
+
+	mgrTest.ConfigDriftCache["device-1"] = []*synchronizer.ConfigDrift{
+		{
+			Path:     "/cont1a/cont2a/leaf2a",
+			Intended: devicechange.NewTypedValueUint(13, 8),
+			Actual:   devicechange.NewTypedValueUint(1, 8),
+		},
+		{
+			Path:     "/cont1a/leaf1a",
+			Intended: devicechange.NewTypedValueString("leaf1aval"),
+			ChangeID: "drift-change",
+		},
+	}
+	mgrTest.ConfigDriftCache["device-2"] = []*synchronizer.ConfigDrift{
+		{
+			Path:     "/cont1a/leaf1a",
+			Intended: devicechange.NewTypedValueString("leaf1aval"),
+		},
+	}
+
+	stream, err := client.ListConfigDrift(context.Background(), &diagsapi.ListConfigDriftRequest{DeviceID: "device-1"})
+	assert.NilError(t, err)
+
+	count := 0
+	for {
+		in, err := stream.Recv()
+		if err == io.EOF || in == nil {
+			break
+		}
+		assert.NilError(t, err, "unable to receive message")
+		assert.Equal(t, in.DeviceID, devicetypes.ID("device-1"))
+		switch in.Path {
+		case "/cont1a/cont2a/leaf2a":
+			assert.Equal(t, in.IntendedValue.ValueToString(), "13")
+			assert.Equal(t, in.ActualValue.ValueToString(), "1")
+		case "/cont1a/leaf1a":
+			assert.Equal(t, in.IntendedValue.ValueToString(), "leaf1aval")
+			assert.Assert(t, in.ActualValue == nil)
+			assert.Equal(t, in.ChangeID, networkchange.ID("drift-change"))
+		default:
+			t.Fatal("Unexpected path in config drift", in.Path)
+		}
+		count++
+	}
+	assert.Equal(t, count, 2)
+}
+
 func generateNetworkChangeData(count int) []*networkchange.NetworkChange {
 	networkChanges := make([]*networkchange.NetworkChange, count)
 	now := time.Now()
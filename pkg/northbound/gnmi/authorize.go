@@ -0,0 +1,123 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gnmi
+
+import (
+	"context"
+
+	devicetype "github.com/onosproject/onos-api/go/onos/config/device"
+	topodevice "github.com/onosproject/onos-config/pkg/device"
+	"github.com/onosproject/onos-config/pkg/manager"
+	"github.com/onosproject/onos-config/pkg/rbac"
+	"github.com/onosproject/onos-config/pkg/store/device/cache"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// authorizePath checks that the client is allowed the operation on a path of a request, whose target is
+// given either by the path or by the prefix
+func (s *Server) authorizePath(ctx context.Context, op rbac.Operation, prefix *gnmi.Path, path *gnmi.Path) error {
+	if s.authorizer == nil {
+		return nil
+	}
+	target := path.GetTarget()
+	if target == "" {
+		target = prefix.GetTarget()
+	}
+	return s.authorize(ctx, op, getAuthzDevice(devicetype.ID(target), ""), fullPath(prefix, path))
+}
+
+// authorizeSet checks that the client is allowed to set each of the paths that are updated and removed. The device
+// type is the one the device is known as, and the one given in the request only for a new device.
+func (s *Server) authorizeSet(ctx context.Context, targetUpdates mapTargetUpdates, targetRemoves mapTargetRemoves,
+	deviceInfo map[devicetype.ID]cache.Info) error {
+	if s.authorizer == nil {
+		return nil
+	}
+	devices := make(map[devicetype.ID]rbac.Device)
+	getDevice := func(target devicetype.ID) rbac.Device {
+		device, ok := devices[target]
+		if !ok {
+			device = getAuthzDevice(target, deviceInfo[target].Type)
+			devices[target] = device
+		}
+		return device
+	}
+	for target, updates := range targetUpdates {
+		for path := range updates {
+			if err := s.authorize(ctx, rbac.OperationSet, getDevice(target), path); err != nil {
+				return err
+			}
+		}
+	}
+	for target, removes := range targetRemoves {
+		for _, path := range removes {
+			if err := s.authorize(ctx, rbac.OperationSet, getDevice(target), path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// authorizeSubscription checks that the client is allowed to subscribe to each of the paths of a subscription list.
+// As changes are notified by the path of a subscription alone, it is checked both with and without the prefix.
+func (s *Server) authorizeSubscription(ctx context.Context, subscribe *gnmi.SubscriptionList) error {
+	if s.authorizer == nil {
+		return nil
+	}
+	for _, sub := range subscribe.Subscription {
+		if err := s.authorizePath(ctx, rbac.OperationSubscribe, subscribe.Prefix, sub.Path); err != nil {
+			return err
+		}
+		if len(subscribe.Prefix.GetElem()) > 0 || len(subscribe.Prefix.GetElement()) > 0 {
+			if err := s.authorizePath(ctx, rbac.OperationSubscribe, &gnmi.Path{Target: subscribe.Prefix.Target}, sub.Path); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func (s *Server) authorize(ctx context.Context, op rbac.Operation, device rbac.Device, path string) error {
+	err := s.authorizer.Authorize(ctx, op, rbac.Resource{Device: device, Path: path})
+	if err != nil {
+		return status.Error(codes.PermissionDenied, err.Error())
+	}
+	return nil
+}
+
+// getAuthzDevice gives the type and the topology role of a device, as far as they are known, for authorization.
+// The "*" target of a Get for the names of all the devices is not a device.
+func getAuthzDevice(target devicetype.ID, deviceType devicetype.Type) rbac.Device {
+	device := rbac.Device{
+		ID:   string(target),
+		Type: string(deviceType),
+	}
+	if target == "*" {
+		return device
+	}
+	mgr := manager.GetManager()
+	if device.Type == "" {
+		if infos := mgr.DeviceCache.GetDevicesByID(target); len(infos) > 0 {
+			device.Type = string(infos[0].Type)
+		}
+	}
+	if topoDevice, err := mgr.DeviceStore.Get(topodevice.ID(target)); err == nil && topoDevice != nil {
+		device.Role = string(topoDevice.Role)
+	}
+	return device
+}
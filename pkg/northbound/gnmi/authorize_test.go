@@ -0,0 +1,199 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gnmi
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	devicechange "github.com/onosproject/onos-api/go/onos/config/change/device"
+	topodevice "github.com/onosproject/onos-config/pkg/device"
+	"github.com/onosproject/onos-config/pkg/rbac"
+	"github.com/onosproject/onos-config/pkg/store/device/cache"
+	"github.com/onosproject/onos-config/pkg/utils"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmi/proto/gnmi_ext"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"gotest.tools/assert"
+)
+
+func testPolicy() *rbac.Policy {
+	return &rbac.Policy{
+		Roles: map[string][]rbac.Rule{
+			"noc-readonly": {
+				{Operations: []rbac.Operation{rbac.OperationGet, rbac.OperationSubscribe}},
+			},
+			"access-team": {
+				{Operations: []rbac.Operation{rbac.OperationGet}, DeviceRoles: []string{"leaf"}, Paths: []string{"/cont1a"}},
+				{Operations: []rbac.Operation{rbac.OperationSet}, DeviceTypes: []string{"TestDevice"}, Paths: []string{"/cont1a/cont2a/leaf2a"}},
+				{Operations: []rbac.Operation{rbac.OperationSubscribe}, Paths: []string{"/cont1a"}},
+			},
+			"lab-team": {
+				{Operations: []rbac.Operation{rbac.OperationSet}, DeviceTypes: []string{"Devicesim"}},
+			},
+		},
+	}
+}
+
+func contextWithGroups(groups ...string) context.Context {
+	tlsInfo := credentials.TLSInfo{
+		State: tls.ConnectionState{
+			VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{OrganizationalUnit: groups}}}},
+		},
+	}
+	return peer.NewContext(context.Background(), &peer.Peer{AuthInfo: tlsInfo})
+}
+
+// Test_getAuthorized shows that each path of a Get must be allowed for the roles of the client
+func Test_getAuthorized(t *testing.T) {
+	server, _, mocks := setUp(t)
+	server.authorizer = rbac.NewAuthorizer(testPolicy())
+	mocks.MockDeviceCache.EXPECT().GetDevicesByID(gomock.Any()).Return([]*cache.Info{
+		{
+			DeviceID: "Device1",
+			Version:  "1.0.0",
+			Type:     "TestDevice",
+		},
+	}).AnyTimes()
+	mocks.MockStores.DeviceStore.EXPECT().Get(topodevice.ID("Device1")).Return(&topodevice.Device{
+		ID:   "Device1",
+		Type: "TestDevice",
+		Role: "leaf",
+	}, nil).AnyTimes()
+	mocks.MockStores.DeviceStateStore.EXPECT().Get(gomock.Any(), gomock.Any()).Return([]*devicechange.PathValue{}, nil).AnyTimes()
+	setUpListMock(mocks)
+
+	cont1aPath, err := utils.ParseGNMIElements([]string{"cont1a"})
+	assert.NilError(t, err)
+	cont1aPath.Target = "Device1"
+	request := gnmi.GetRequest{
+		Path: []*gnmi.Path{cont1aPath, {Target: "Device1"}},
+	}
+
+	// Any path may be read by noc-readonly
+	result, err := server.Get(contextWithGroups("noc-readonly"), &request)
+	assert.NilError(t, err)
+	assert.Equal(t, len(result.Notification), 2)
+
+	// Only /cont1a may be read by access-team, not the whole device
+	_, err = server.Get(contextWithGroups("access-team"), &request)
+	assert.Equal(t, status.Code(err), codes.PermissionDenied)
+	assert.ErrorContains(t, err, "get of / on Device1 is not allowed")
+
+	request.Path = request.Path[:1]
+	_, err = server.Get(contextWithGroups("access-team"), &request)
+	assert.NilError(t, err)
+
+	// A client without any role can not read anything
+	_, err = server.Get(context.Background(), &request)
+	assert.Equal(t, status.Code(err), codes.PermissionDenied)
+}
+
+// Test_doSetAuthorized shows that each path of a Set must be allowed for the roles of the client
+func Test_doSetAuthorized(t *testing.T) {
+	server, mocks, _ := setUpForGetSetTests(t)
+	server.authorizer = rbac.NewAuthorizer(testPolicy())
+	setUpChangesMock(mocks)
+
+	leaf2aPath, _ := utils.ParseGNMIElements([]string{"cont1a", "cont2a", "leaf2a"})
+	leaf2aPath.Target = "Device1"
+	leaf2bPath, _ := utils.ParseGNMIElements([]string{"cont1a", "cont2a", "leaf2b"})
+	leaf2bPath.Target = "Device1"
+	value := &gnmi.TypedValue{Value: &gnmi.TypedValue_UintVal{UintVal: 11}}
+
+	// Setting another path is denied, and nothing is set
+	setRequest := gnmi.SetRequest{
+		Update: []*gnmi.Update{{Path: leaf2aPath, Val: value}},
+		Delete: []*gnmi.Path{leaf2bPath},
+	}
+	_, err := server.Set(contextWithGroups("access-team"), &setRequest)
+	assert.Equal(t, status.Code(err), codes.PermissionDenied)
+	assert.ErrorContains(t, err, "set of /cont1a/cont2a/leaf2b on Device1 is not allowed")
+
+	_, err = server.Set(contextWithGroups("noc-readonly"), &setRequest)
+	assert.Equal(t, status.Code(err), codes.PermissionDenied)
+
+	setRequest.Delete = nil
+	setResponse, err := server.Set(contextWithGroups("access-team"), &setRequest)
+	assert.NilError(t, err)
+	assert.Equal(t, len(setResponse.Response), 1)
+}
+
+// Test_doSetAuthorizedDeviceType shows that a Set to a known device is checked against the type of the device,
+// whatever type the client gives in the request
+func Test_doSetAuthorizedDeviceType(t *testing.T) {
+	server, mocks, _ := setUpForGetSetTests(t)
+	server.authorizer = rbac.NewAuthorizer(testPolicy())
+	setUpChangesMock(mocks)
+
+	leaf2aPath, _ := utils.ParseGNMIElements([]string{"cont1a", "cont2a", "leaf2a"})
+	leaf2aPath.Target = "Device1"
+	value := &gnmi.TypedValue{Value: &gnmi.TypedValue_UintVal{UintVal: 11}}
+
+	// Device1 is a TestDevice, so claiming it is a Devicesim does not let lab-team set it
+	setRequest := gnmi.SetRequest{
+		Update: []*gnmi.Update{{Path: leaf2aPath, Val: value}},
+		Extension: []*gnmi_ext.Extension{{
+			Ext: &gnmi_ext.Extension_RegisteredExt{
+				RegisteredExt: &gnmi_ext.RegisteredExtension{
+					Id:  GnmiExtensionDeviceType,
+					Msg: []byte("Devicesim"),
+				},
+			},
+		}},
+	}
+	_, err := server.Set(contextWithGroups("lab-team"), &setRequest)
+	assert.Equal(t, status.Code(err), codes.PermissionDenied)
+	assert.ErrorContains(t, err, "set of /cont1a/cont2a/leaf2a on Device1 is not allowed")
+
+	// The type that the client gives does not matter to a client allowed for the type of the device
+	setResponse, err := server.Set(contextWithGroups("access-team"), &setRequest)
+	assert.NilError(t, err)
+	assert.Equal(t, len(setResponse.Response), 1)
+}
+
+// Test_authorizeSubscription shows that the paths of a subscription are checked with and without the prefix
+func Test_authorizeSubscription(t *testing.T) {
+	server, _, mocks := setUp(t)
+	server.authorizer = rbac.NewAuthorizer(testPolicy())
+	mocks.MockDeviceCache.EXPECT().GetDevicesByID(gomock.Any()).Return([]*cache.Info{}).AnyTimes()
+	mocks.MockStores.DeviceStore.EXPECT().Get(gomock.Any()).Return(nil, status.Error(codes.NotFound, "device not found")).AnyTimes()
+
+	cont1aPath, _ := utils.ParseGNMIElements([]string{"cont1a"})
+	cont1aPath.Target = "Device1"
+	cont2aPath, _ := utils.ParseGNMIElements([]string{"cont2a"})
+	subscribe := &gnmi.SubscriptionList{
+		Subscription: []*gnmi.Subscription{{Path: cont2aPath}},
+	}
+	err := server.authorizeSubscription(contextWithGroups("access-team"), subscribe)
+	assert.Equal(t, status.Code(err), codes.PermissionDenied)
+
+	// Changes to /cont2a would be notified too, so a prefix of /cont1a is not enough
+	subscribe.Prefix = cont1aPath
+	err = server.authorizeSubscription(contextWithGroups("access-team"), subscribe)
+	assert.Equal(t, status.Code(err), codes.PermissionDenied)
+
+	subscribe.Prefix = &gnmi.Path{Target: "Device1"}
+	subscribe.Subscription[0].Path = cont1aPath
+	assert.NilError(t, server.authorizeSubscription(contextWithGroups("access-team"), subscribe))
+	assert.NilError(t, server.authorizeSubscription(contextWithGroups("noc-readonly"), subscribe))
+}
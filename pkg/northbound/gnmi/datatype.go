@@ -0,0 +1,114 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gnmi
+
+import (
+	"strings"
+
+	devicechange "github.com/onosproject/onos-api/go/onos/config/change/device"
+	"github.com/onosproject/onos-config/pkg/modelregistry"
+	"github.com/openconfig/gnmi/proto/gnmi"
+)
+
+const (
+	configContainer = "/config/"
+	stateContainer  = "/state/"
+)
+
+// dataTypeFilter selects the values of a Get by the data type asked for. Each path is classified as read write
+// or read only by the model of the device. A path that is not in the model, for a device without a model
+// plugin, is taken to be config if it comes from the network changes and state if it comes from the device.
+type dataTypeFilter struct {
+	dataType gnmi.GetRequest_DataType
+	rwPaths  map[string]bool
+	roPaths  map[string]bool
+}
+
+func newDataTypeFilter(dataType gnmi.GetRequest_DataType, rwPaths modelregistry.ReadWritePathMap,
+	roPaths modelregistry.ReadOnlyPathMap) *dataTypeFilter {
+	f := &dataTypeFilter{
+		dataType: dataType,
+		rwPaths:  make(map[string]bool),
+		roPaths:  make(map[string]bool),
+	}
+	if dataType == gnmi.GetRequest_ALL {
+		return f
+	}
+	for path := range rwPaths {
+		f.rwPaths[modelregistry.RemovePathIndices(path)] = true
+	}
+	for path, subPaths := range roPaths {
+		for subPath := range subPaths {
+			fullPath := path
+			if subPath != "/" {
+				fullPath = path + subPath
+			}
+			f.roPaths[modelregistry.RemovePathIndices(fullPath)] = true
+		}
+	}
+	return f
+}
+
+// filter gives the config and state values that are of the data type asked for
+func (f *dataTypeFilter) filter(configValues []*devicechange.PathValue, stateValues []*devicechange.PathValue) []*devicechange.PathValue {
+	if f.dataType == gnmi.GetRequest_ALL {
+		return append(configValues, stateValues...)
+	}
+	filtered := make([]*devicechange.PathValue, 0, len(configValues)+len(stateValues))
+	for _, configValue := range configValues {
+		if f.matches(configValue.Path, true) {
+			filtered = append(filtered, configValue)
+		}
+	}
+	for _, stateValue := range stateValues {
+		if f.matches(stateValue.Path, false) {
+			filtered = append(filtered, stateValue)
+		}
+	}
+	return filtered
+}
+
+// matches indicates whether a path is of the data type asked for. The key of a list of config is both read
+// write and read only, so that the list entries can be told apart in the state too.
+func (f *dataTypeFilter) matches(path string, isConfig bool) bool {
+	pathNoIndices := modelregistry.RemovePathIndices(path)
+	isRW, isRO := f.rwPaths[pathNoIndices], f.roPaths[pathNoIndices]
+	if !isRW && !isRO {
+		isRW, isRO = isConfig, !isConfig
+	}
+	switch f.dataType {
+	case gnmi.GetRequest_CONFIG:
+		return isRW
+	case gnmi.GetRequest_STATE:
+		return isRO
+	case gnmi.GetRequest_OPERATIONAL:
+		// Operational data is the state that is not the applied value of some config, as the leaves of
+		// a state container that mirror the leaves of its config container are
+		return isRO && !isRW && !f.isAppliedConfig(pathNoIndices)
+	default:
+		return true
+	}
+}
+
+// isAppliedConfig indicates whether a read only path is the applied value of a read write path, as in the
+// config and state containers of the OpenConfig models
+func (f *dataTypeFilter) isAppliedConfig(pathNoIndices string) bool {
+	stateIdx := strings.LastIndex(pathNoIndices, stateContainer)
+	if stateIdx < 0 {
+		return false
+	}
+	configPath := pathNoIndices[:stateIdx] + configContainer + pathNoIndices[stateIdx+len(stateContainer):]
+	return f.rwPaths[configPath]
+}
@@ -0,0 +1,85 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gnmi
+
+import (
+	"testing"
+
+	devicechange "github.com/onosproject/onos-api/go/onos/config/change/device"
+	"github.com/onosproject/onos-config/pkg/modelregistry"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"gotest.tools/assert"
+)
+
+func filteredPaths(f *dataTypeFilter, configValues []*devicechange.PathValue, stateValues []*devicechange.PathValue) []string {
+	paths := make([]string, 0)
+	for _, value := range f.filter(configValues, stateValues) {
+		paths = append(paths, value.Path)
+	}
+	return paths
+}
+
+func Test_dataTypeFilter(t *testing.T) {
+	rwPaths := modelregistry.ReadWritePathMap{
+		"/interfaces/interface[name=*]/name":       {},
+		"/interfaces/interface[name=*]/config/mtu": {},
+	}
+	roPaths := modelregistry.ReadOnlyPathMap{
+		"/interfaces/interface[name=*]": {
+			"/name": {},
+		},
+		"/interfaces/interface[name=*]/state": {
+			"/mtu":              {},
+			"/counters/in-pkts": {},
+		},
+	}
+	value := devicechange.NewTypedValueUint(1500, 16)
+	configValues := []*devicechange.PathValue{
+		{Path: "/interfaces/interface[name=eth1]/name", Value: value},
+		{Path: "/interfaces/interface[name=eth1]/config/mtu", Value: value},
+		// Not in the model
+		{Path: "/system/config/hostname", Value: value},
+	}
+	stateValues := []*devicechange.PathValue{
+		{Path: "/interfaces/interface[name=eth1]/state/mtu", Value: value},
+		{Path: "/interfaces/interface[name=eth1]/state/counters/in-pkts", Value: value},
+		// Not in the model
+		{Path: "/system/state/uptime", Value: value},
+	}
+
+	all := newDataTypeFilter(gnmi.GetRequest_ALL, rwPaths, roPaths)
+	assert.Equal(t, len(filteredPaths(all, configValues, stateValues)), 6)
+
+	config := newDataTypeFilter(gnmi.GetRequest_CONFIG, rwPaths, roPaths)
+	assert.DeepEqual(t, filteredPaths(config, configValues, stateValues), []string{
+		"/interfaces/interface[name=eth1]/name",
+		"/interfaces/interface[name=eth1]/config/mtu",
+		"/system/config/hostname",
+	})
+
+	state := newDataTypeFilter(gnmi.GetRequest_STATE, rwPaths, roPaths)
+	assert.DeepEqual(t, filteredPaths(state, configValues, stateValues), []string{
+		"/interfaces/interface[name=eth1]/name",
+		"/interfaces/interface[name=eth1]/state/mtu",
+		"/interfaces/interface[name=eth1]/state/counters/in-pkts",
+		"/system/state/uptime",
+	})
+
+	operational := newDataTypeFilter(gnmi.GetRequest_OPERATIONAL, rwPaths, roPaths)
+	assert.DeepEqual(t, filteredPaths(operational, configValues, stateValues), []string{
+		"/interfaces/interface[name=eth1]/state/counters/in-pkts",
+		"/system/state/uptime",
+	})
+}
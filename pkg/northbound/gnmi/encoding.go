@@ -0,0 +1,167 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gnmi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	devicechange "github.com/onosproject/onos-api/go/onos/config/change/device"
+	"github.com/onosproject/onos-config/pkg/modelregistry"
+	"github.com/onosproject/onos-config/pkg/store"
+	"github.com/onosproject/onos-config/pkg/utils"
+	"github.com/onosproject/onos-config/pkg/utils/values"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/goyang/pkg/yang"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// encodings are the encodings in which Get and Subscribe can give values. JSON_IETF can only be given for the
+// devices whose model qualifies names by module.
+var encodings = []gnmi.Encoding{
+	gnmi.Encoding_JSON,
+	gnmi.Encoding_JSON_IETF,
+	gnmi.Encoding_PROTO,
+	gnmi.Encoding_ASCII,
+}
+
+// supportedEncodings gives the encodings that are advertised. JSON_IETF is only advertised when it can be
+// given for the models of all the devices.
+func supportedEncodings(registry *modelregistry.ModelRegistry) []gnmi.Encoding {
+	jsonIETF := len(registry.ModelPlugins) > 0
+	for modelName := range registry.ModelPlugins {
+		if !store.QualifiesNames(registry.ModelSchemas[modelName]) {
+			jsonIETF = false
+		}
+	}
+	supported := make([]gnmi.Encoding, 0, len(encodings))
+	for _, encoding := range encodings {
+		if encoding != gnmi.Encoding_JSON_IETF || jsonIETF {
+			supported = append(supported, encoding)
+		}
+	}
+	return supported
+}
+
+// checkEncoding checks that the encoding asked for by a request is supported
+func checkEncoding(encoding gnmi.Encoding) error {
+	for _, supported := range encodings {
+		if encoding == supported {
+			return nil
+		}
+	}
+	return status.Errorf(codes.InvalidArgument, "unsupported encoding %s", encoding)
+}
+
+// checkModelEncoding checks that values of a device can be given in the encoding asked for, from the schema
+// of its model. JSON_IETF needs the schema to give the modules that the names are qualified with.
+func checkModelEncoding(encoding gnmi.Encoding, target string, schema map[string]*yang.Entry) error {
+	if encoding == gnmi.Encoding_JSON_IETF && !store.QualifiesNames(schema) {
+		return status.Errorf(codes.InvalidArgument,
+			"encoding JSON_IETF is not available for %s, as its model does not give the modules of its nodes", target)
+	}
+	return nil
+}
+
+// encodeValue gives a single value in the encoding asked for. Apart from ASCII, a single value is
+// given as a scalar in all encodings.
+func encodeValue(value *devicechange.TypedValue, encoding gnmi.Encoding) (*gnmi.TypedValue, error) {
+	if encoding == gnmi.Encoding_ASCII {
+		return &gnmi.TypedValue{
+			Value: &gnmi.TypedValue_AsciiVal{AsciiVal: value.ValueToString()},
+		}, nil
+	}
+	return values.NativeTypeToGnmiTypedValue(value)
+}
+
+// encodeTree gives the values under a path as a single tree in the encoding asked for
+func encodeTree(configValues []*devicechange.PathValue, encoding gnmi.Encoding, treeOpts ...store.TreeOption) (*gnmi.TypedValue, error) {
+	if encoding == gnmi.Encoding_ASCII {
+		return &gnmi.TypedValue{
+			Value: &gnmi.TypedValue_AsciiVal{AsciiVal: asciiTree(configValues)},
+		}, nil
+	}
+	json, err := store.BuildTree(configValues, true, treeOpts...)
+	if err != nil {
+		return nil, err
+	}
+	if encoding == gnmi.Encoding_JSON_IETF {
+		return &gnmi.TypedValue{
+			Value: &gnmi.TypedValue_JsonIetfVal{JsonIetfVal: json},
+		}, nil
+	}
+	return &gnmi.TypedValue{
+		Value: &gnmi.TypedValue_JsonVal{JsonVal: json},
+	}, nil
+}
+
+// asciiTree gives the values as text, one "path value" line per leaf, sorted by path
+func asciiTree(configValues []*devicechange.PathValue) string {
+	lines := make([]string, 0, len(configValues))
+	for _, configValue := range configValues {
+		lines = append(lines, fmt.Sprintf("%s %s", configValue.Path, configValue.GetValue().ValueToString()))
+	}
+	sort.Strings(lines)
+	return strings.Join(lines, "\n") + "\n"
+}
+
+// encodeLeaves gives an update of a scalar value for each leaf, as PROTO does. The paths of the updates are
+// relative to the prefix, and have the target of the path that was asked for. A leaf must be under the prefix
+// itself, so a prefix with wildcards can not be used, as the leaves would lose the keys the wildcards stand for.
+func encodeLeaves(prefix *gnmi.Path, path *gnmi.Path, configValues []*devicechange.PathValue) ([]*gnmi.Update, error) {
+	updates := make([]*gnmi.Update, 0, len(configValues))
+	for _, configValue := range configValues {
+		leafPath, err := utils.ParseGNMIElements(utils.SplitPath(configValue.Path))
+		if err != nil {
+			return nil, err
+		}
+		if !hasPrefix(leafPath, prefix) {
+			return nil, status.Errorf(codes.InvalidArgument, "%s is not under the prefix %s",
+				configValue.Path, utils.StrPath(prefix))
+		}
+		leafPath.Elem = leafPath.Elem[len(prefix.GetElem()):]
+		leafPath.Target = path.GetTarget()
+		value, err := values.NativeTypeToGnmiTypedValue(configValue.GetValue())
+		if err != nil {
+			return nil, err
+		}
+		updates = append(updates, &gnmi.Update{
+			Path: leafPath,
+			Val:  value,
+		})
+	}
+	return updates, nil
+}
+
+// hasPrefix indicates whether the elements of a path start with the elements of the prefix, keys included
+func hasPrefix(path *gnmi.Path, prefix *gnmi.Path) bool {
+	if len(prefix.GetElem()) > len(path.GetElem()) {
+		return false
+	}
+	for i, elem := range prefix.GetElem() {
+		pathElem := path.Elem[i]
+		if elem.Name != pathElem.Name || len(elem.Key) != len(pathElem.Key) {
+			return false
+		}
+		for key, value := range elem.Key {
+			if pathElem.Key[key] != value {
+				return false
+			}
+		}
+	}
+	return true
+}
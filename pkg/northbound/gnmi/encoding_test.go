@@ -0,0 +1,149 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gnmi
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	devicechange "github.com/onosproject/onos-api/go/onos/config/change/device"
+	devicetype "github.com/onosproject/onos-api/go/onos/config/device"
+	"github.com/onosproject/onos-config/pkg/store/device/cache"
+	"github.com/onosproject/onos-config/pkg/utils"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gotest.tools/assert"
+)
+
+func Test_getUnsupportedEncoding(t *testing.T) {
+	server, _, _ := setUp(t)
+
+	request := gnmi.GetRequest{
+		Prefix:   &gnmi.Path{Target: "Device1"},
+		Encoding: gnmi.Encoding_BYTES,
+	}
+	_, err := server.Get(context.TODO(), &request)
+	assert.Equal(t, status.Code(err), codes.InvalidArgument)
+	assert.ErrorContains(t, err, "unsupported encoding BYTES")
+}
+
+func Test_getEncodingASCII(t *testing.T) {
+	server, _, mocks := setUp(t)
+	setUpChangesMock(mocks)
+	mocks.MockDeviceCache.EXPECT().GetDevicesByID(devicetype.ID("Device1")).Return([]*cache.Info{
+		{
+			DeviceID: "Device1",
+			Type:     "Devicesim",
+			Version:  "1.0.0",
+		},
+	}).AnyTimes()
+	mocks.MockStores.DeviceStore.EXPECT().Get(gomock.Any()).Return(nil, status.Error(codes.NotFound, "device not found")).AnyTimes()
+
+	leafAPath, err := utils.ParseGNMIElements([]string{"cont1a", "cont2a", "leaf2a"})
+	assert.NilError(t, err)
+	leafAPath.Target = "Device1"
+
+	request := gnmi.GetRequest{
+		Path:     []*gnmi.Path{leafAPath},
+		Encoding: gnmi.Encoding_ASCII,
+	}
+	result, err := server.Get(context.TODO(), &request)
+	assert.NilError(t, err)
+	assert.Equal(t, len(result.Notification), 1)
+	assert.Equal(t, len(result.Notification[0].Update), 1)
+	assert.Equal(t, result.Notification[0].Update[0].GetVal().GetAsciiVal(), "13")
+}
+
+// Test_getEncodingJSONIETF shows that JSON_IETF is refused for a device whose model does not qualify names
+func Test_getEncodingJSONIETF(t *testing.T) {
+	server, _, mocks := setUp(t)
+	setUpChangesMock(mocks)
+	mocks.MockDeviceCache.EXPECT().GetDevicesByID(devicetype.ID("Device1")).Return([]*cache.Info{
+		{
+			DeviceID: "Device1",
+			Type:     "Devicesim",
+			Version:  "1.0.0",
+		},
+	}).AnyTimes()
+	mocks.MockStores.DeviceStore.EXPECT().Get(gomock.Any()).Return(nil, status.Error(codes.NotFound, "device not found")).AnyTimes()
+
+	request := gnmi.GetRequest{
+		Path:     []*gnmi.Path{{Target: "Device1"}},
+		Encoding: gnmi.Encoding_JSON_IETF,
+	}
+	_, err := server.Get(context.TODO(), &request)
+	assert.Equal(t, status.Code(err), codes.InvalidArgument)
+	assert.ErrorContains(t, err, "encoding JSON_IETF is not available for Device1")
+}
+
+func Test_buildUpdatesEncodings(t *testing.T) {
+	prefix, err := utils.ParseGNMIElements([]string{"cont1a", "cont2a"})
+	assert.NilError(t, err)
+	path := &gnmi.Path{Target: "Device1"}
+	configValues := []*devicechange.PathValue{
+		{Path: "/cont1a/cont2a/leaf2c", Value: devicechange.NewTypedValueString("abc")},
+		{Path: "/cont1a/cont2a/leaf2a", Value: devicechange.NewTypedValueUint(13, 8)},
+	}
+
+	// JSON and JSON_IETF give a single tree
+	updates, err := buildUpdates(prefix, path, configValues, gnmi.Encoding_JSON)
+	assert.NilError(t, err)
+	assert.Equal(t, len(updates), 1)
+	assert.Assert(t, strings.Contains(string(updates[0].GetVal().GetJsonVal()), `"leaf2a": 13`))
+
+	updates, err = buildUpdates(prefix, path, configValues, gnmi.Encoding_JSON_IETF)
+	assert.NilError(t, err)
+	assert.Equal(t, len(updates), 1)
+	assert.Assert(t, strings.Contains(string(updates[0].GetVal().GetJsonIetfVal()), `"leaf2c": "abc"`))
+
+	// PROTO gives a scalar for each leaf, relative to the prefix
+	updates, err = buildUpdates(prefix, path, configValues, gnmi.Encoding_PROTO)
+	assert.NilError(t, err)
+	assert.Equal(t, len(updates), 2)
+	assert.Equal(t, utils.StrPath(updates[0].Path), "/leaf2c")
+	assert.Equal(t, updates[0].Path.Target, "Device1")
+	assert.Equal(t, updates[0].GetVal().GetStringVal(), "abc")
+	assert.Equal(t, utils.StrPath(updates[1].Path), "/leaf2a")
+	assert.Equal(t, updates[1].GetVal().GetUintVal(), uint64(13))
+
+	// ASCII gives a line for each leaf
+	updates, err = buildUpdates(prefix, path, configValues, gnmi.Encoding_ASCII)
+	assert.NilError(t, err)
+	assert.Equal(t, len(updates), 1)
+	assert.Equal(t, updates[0].GetVal().GetAsciiVal(), "/cont1a/cont2a/leaf2a 13\n/cont1a/cont2a/leaf2c abc\n")
+}
+
+// Test_encodeLeavesPrefix shows that the leaves must be under the prefix, keys included
+func Test_encodeLeavesPrefix(t *testing.T) {
+	path := &gnmi.Path{Target: "Device1"}
+	configValues := []*devicechange.PathValue{
+		{Path: "/cont1a/list2a[name=first]/tx-power", Value: devicechange.NewTypedValueUint(5, 16)},
+	}
+
+	prefix, err := utils.ParseGNMIElements([]string{"cont1a", "list2a[name=first]"})
+	assert.NilError(t, err)
+	updates, err := encodeLeaves(prefix, path, configValues)
+	assert.NilError(t, err)
+	assert.Equal(t, utils.StrPath(updates[0].Path), "/tx-power")
+
+	prefix, err = utils.ParseGNMIElements([]string{"cont1a", "list2a[name=*]"})
+	assert.NilError(t, err)
+	_, err = encodeLeaves(prefix, path, configValues)
+	assert.Equal(t, status.Code(err), codes.InvalidArgument)
+	assert.ErrorContains(t, err, "/cont1a/list2a[name=first]/tx-power is not under the prefix")
+}
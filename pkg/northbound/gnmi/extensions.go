@@ -33,4 +33,43 @@ const (
 	// was requested for one or more device which is currently not connected.
 	// Not Connected devices are included in the message.
 	GnmiExtensionDevicesNotConnected = 103
+
+	// GnmiExtensionValidateOnly is used in Set to validate a change against the device models without
+	// storing it. In the SetResponse it carries the computed network change, protobuf encoded.
+	GnmiExtensionValidateOnly = 104
+
+	// GnmiExtensionNotBefore is used in Set to schedule a change. The message is an RFC 3339 timestamp, and
+	// the change is held in PENDING until that time before being applied to the devices.
+	GnmiExtensionNotBefore = 105
+
+	// GnmiExtensionConfigAt is used in Get to get the config as it was at a point in the past. The message is
+	// either a network change ID, a network change index or an RFC 3339 timestamp.
+	GnmiExtensionConfigAt = 106
+
+	// GnmiExtensionConfirmTimeout is used in Set to require the change to be confirmed with the
+	// ConfirmNetworkChange admin RPC within the given number of seconds of being complete. If it is not
+	// confirmed in time the change is rolled back.
+	GnmiExtensionConfirmTimeout = 107
+
+	// GnmiExtensionWaitForApply is used in Set to wait for the change to be COMPLETE or FAILED before returning.
+	// The message is the maximum number of seconds to wait, or empty for the default. In the SetResponse it is
+	// given once for each device, carrying the device change protobuf encoded, including its status.
+	GnmiExtensionWaitForApply = 108
+
+	// GnmiExtensionBaseIndex is used for compare-and-set. In a GetRequest it asks for the index of the last network
+	// change to be given in the GetResponse. In a SetRequest it gives the network change index the edit is based on,
+	// and the Set is aborted if a later change has touched the same paths. The SetResponse gives the index of the
+	// new change, on which the next edit can be based.
+	GnmiExtensionBaseIndex = 109
+
+	// GnmiExtensionDescription is used in Set to describe what the change is for
+	GnmiExtensionDescription = 110
+
+	// GnmiExtensionLabels is used in Set to attach free-form labels to the change. The message is a comma separated
+	// list of key=value pairs, and the extension may be repeated.
+	GnmiExtensionLabels = 111
+
+	// GnmiExtensionApplyPolicy is used in Set to choose how the change is applied when some of its devices are
+	// not connected. The message is "atomic" (the default), "best-effort" or "quorum:<percent>".
+	GnmiExtensionApplyPolicy = 112
 )
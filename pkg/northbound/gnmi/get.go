@@ -18,14 +18,18 @@ import (
 	"context"
 	"fmt"
 	devicechange "github.com/onosproject/onos-api/go/onos/config/change/device"
+	networkchange "github.com/onosproject/onos-api/go/onos/config/change/network"
 	devicetype "github.com/onosproject/onos-api/go/onos/config/device"
 	"github.com/onosproject/onos-config/pkg/manager"
+	"github.com/onosproject/onos-config/pkg/rbac"
 	"github.com/onosproject/onos-config/pkg/store"
 	"github.com/onosproject/onos-config/pkg/utils"
-	"github.com/onosproject/onos-config/pkg/utils/values"
 	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmi/proto/gnmi_ext"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"strconv"
+	"strings"
 	"time"
 )
 
@@ -35,19 +39,45 @@ func (s *Server) Get(ctx context.Context, req *gnmi.GetRequest) (*gnmi.GetRespon
 
 	prefix := req.GetPrefix()
 
-	version, err := extractGetVersion(req)
+	if err := checkEncoding(req.GetEncoding()); err != nil {
+		return nil, err
+	}
+
+	getExts, err := extractGetExtensions(req)
 	if err != nil {
 		return nil, err
 	}
+	version, configAt := getExts.version, getExts.configAt
+
+	// The index of the last network change is taken before reading the config, so that a change made in between
+	// is seen as a conflict when the index is used for compare-and-set
+	var extensions []*gnmi_ext.Extension
+	if getExts.baseIndex {
+		lastIndex, errIndex := manager.GetManager().GetLastNetworkChangeIndex()
+		if errIndex != nil {
+			return nil, status.Error(codes.Internal, errIndex.Error())
+		}
+		extensions = append(extensions, &gnmi_ext.Extension{
+			Ext: &gnmi_ext.Extension_RegisteredExt{
+				RegisteredExt: &gnmi_ext.RegisteredExtension{
+					Id:  GnmiExtensionBaseIndex,
+					Msg: []byte(strconv.FormatUint(uint64(lastIndex), 10)),
+				},
+			},
+		})
+	}
 
 	for _, path := range req.GetPath() {
-		update, err := s.getUpdate(version, prefix, path)
+		if err := s.authorizePath(ctx, rbac.OperationGet, prefix, path); err != nil {
+			return nil, err
+		}
+		updates, err := s.getUpdatesAt(version, prefix, path, req.GetEncoding(), req.GetType(), configAt)
 		if err != nil {
-			return nil, status.Error(codes.Internal, err.Error())
+			return nil, toStatus(err)
 		}
 		notification := &gnmi.Notification{
 			Timestamp: time.Now().Unix(),
-			Update:    []*gnmi.Update{update},
+			Update:    updates,
 			Prefix:    prefix,
 		}
 
@@ -55,13 +85,16 @@ func (s *Server) Get(ctx context.Context, req *gnmi.GetRequest) (*gnmi.GetRespon
 	}
 	// Alternatively - if there's only the prefix
 	if len(req.GetPath()) == 0 {
-		update, err := s.getUpdate(version, prefix, nil)
+		if err := s.authorizePath(ctx, rbac.OperationGet, prefix, nil); err != nil {
+			return nil, err
+		}
+		updates, err := s.getUpdatesAt(version, prefix, nil, req.GetEncoding(), req.GetType(), configAt)
 		if err != nil {
-			return nil, status.Error(codes.Internal, err.Error())
+			return nil, toStatus(err)
 		}
 		notification := &gnmi.Notification{
 			Timestamp: time.Now().Unix(),
-			Update:    []*gnmi.Update{update},
+			Update:    updates,
 			Prefix:    prefix,
 		}
 
@@ -70,12 +103,30 @@ func (s *Server) Get(ctx context.Context, req *gnmi.GetRequest) (*gnmi.GetRespon
 
 	response := gnmi.GetResponse{
 		Notification: notifications,
+		Extension:    extensions,
 	}
 	return &response, nil
 }
 
-// getUpdate utility method for getting an Update for a given path
-func (s *Server) getUpdate(version devicetype.Version, prefix *gnmi.Path, path *gnmi.Path) (*gnmi.Update, error) {
+// toStatus gives an error as a gRPC status, keeping the code of an error that already has one
+func toStatus(err error) error {
+	if _, ok := status.FromError(err); ok {
+		return err
+	}
+	return status.Error(codes.Internal, err.Error())
+}
+
+// getUpdates utility method for getting the Updates for a given path in the given encoding
+func (s *Server) getUpdates(version devicetype.Version, prefix *gnmi.Path, path *gnmi.Path,
+	encoding gnmi.Encoding) ([]*gnmi.Update, error) {
+	return s.getUpdatesAt(version, prefix, path, encoding, gnmi.GetRequest_ALL, nil)
+}
+
+// getUpdatesAt utility method for getting the Updates for a given path in the given encoding, with only the
+// values of the given data type, as it was at the given point in the history of network changes if one is given.
+// There is a single update, unless the values are encoded in PROTO, which has an update for each leaf.
+func (s *Server) getUpdatesAt(version devicetype.Version, prefix *gnmi.Path, path *gnmi.Path,
+	encoding gnmi.Encoding, dataType gnmi.GetRequest_DataType, configAt *manager.ConfigPoint) ([]*gnmi.Update, error) {
 	if (path == nil || path.Target == "") && (prefix == nil || prefix.Target == "") {
 		return nil, fmt.Errorf("Invalid request - Path %s has no target", utils.StrPath(path))
 	}
@@ -104,10 +155,10 @@ func (s *Server) getUpdate(version devicetype.Version, prefix *gnmi.Path, path *
 			Path: &allDevicesPath,
 			Val:  &gnmi.TypedValue{Value: &typedVal},
 		}
-		return update, nil
+		return []*gnmi.Update{update}, nil
 	}
 
-	_, version, errTypeVersion := manager.GetManager().CheckCacheForDevice(devicetype.ID(target), "", version)
+	deviceType, version, errTypeVersion := manager.GetManager().CheckCacheForDevice(devicetype.ID(target), "", version)
 	if errTypeVersion != nil {
 		log.Errorf("Error while extracting type and version for target %s with err %v", target, errTypeVersion)
 		return nil, status.Error(codes.InvalidArgument, errTypeVersion.Error())
@@ -118,6 +169,33 @@ func (s *Server) getUpdate(version devicetype.Version, prefix *gnmi.Path, path *
 		pathAsString = utils.StrPath(prefix) + pathAsString
 	}
 
+	// The JSON tree is typed from the model of the device, where there is one. The schema of the
+	// model qualifies the names by module, as JSON_IETF requires.
+	modelName := utils.ToModelName(deviceType, version)
+	modelRegistry := manager.GetManager().ModelRegistry
+	if err := checkModelEncoding(encoding, target, modelRegistry.ModelSchemas[modelName]); err != nil {
+		return nil, err
+	}
+	treeOpts := []store.TreeOption{
+		store.WithPathMaps(modelRegistry.ModelReadWritePaths[modelName], modelRegistry.ModelReadOnlyPaths[modelName]),
+	}
+	if encoding == gnmi.Encoding_JSON_IETF {
+		treeOpts = append(treeOpts, store.WithSchema(modelRegistry.ModelSchemas[modelName]))
+	}
+	typeFilter := newDataTypeFilter(dataType,
+		modelRegistry.ModelReadWritePaths[modelName], modelRegistry.ModelReadOnlyPaths[modelName])
+
+	// The operational state is not kept in the history, so only config is given for a point in the past
+	if configAt != nil {
+		configValues, errGetTargetCfg := manager.GetManager().GetTargetConfigAt(
+			devicetype.ID(target), version, pathAsString, *configAt)
+		if errGetTargetCfg != nil {
+			log.Error("Error while extracting config", errGetTargetCfg)
+			return nil, errGetTargetCfg
+		}
+		return buildUpdates(prefix, path, typeFilter.filter(configValues, nil), encoding, treeOpts...)
+	}
+
 	s.mu.RLock()
 	revision := s.lastWrite
 	s.mu.RUnlock()
@@ -130,51 +208,91 @@ func (s *Server) getUpdate(version devicetype.Version, prefix *gnmi.Path, path *
 	}
 
 	stateValues := manager.GetManager().GetTargetState(target, pathAsString)
-	//Merging the two results
-	configValues = append(configValues, stateValues...)
+	//Merging the two results, with only the values of the data type asked for
+	configValues = typeFilter.filter(configValues, stateValues)
 
-	return buildUpdate(prefix, path, configValues)
+	return buildUpdates(prefix, path, configValues, encoding, treeOpts...)
 }
 
-func buildUpdate(prefix *gnmi.Path, path *gnmi.Path, configValues []*devicechange.PathValue) (*gnmi.Update, error) {
+func buildUpdates(prefix *gnmi.Path, path *gnmi.Path, configValues []*devicechange.PathValue,
+	encoding gnmi.Encoding, treeOpts ...store.TreeOption) ([]*gnmi.Update, error) {
+	if encoding == gnmi.Encoding_PROTO && len(configValues) > 0 {
+		return encodeLeaves(prefix, path, configValues)
+	}
 	var value *gnmi.TypedValue
 	var err error
 	if len(configValues) == 0 {
 		value = nil
 	} else if len(configValues) == 1 {
-		value, err = values.NativeTypeToGnmiTypedValue(configValues[0].GetValue())
+		value, err = encodeValue(configValues[0].GetValue(), encoding)
 		if err != nil {
 			log.Warn("Unable to convert native value to gnmi", err)
 			return nil, err
 		}
 		// These should match the assignments made in changevalue.go
 	} else {
-		json, err := store.BuildTree(configValues, true)
+		value, err = encodeTree(configValues, encoding, treeOpts...)
 		if err != nil {
 			return nil, err
 		}
-		typedValue := &gnmi.TypedValue_JsonVal{
-			JsonVal: json,
-		}
-		value = &gnmi.TypedValue{
-			Value: typedValue,
-		}
 	}
-	return &gnmi.Update{
+	return []*gnmi.Update{{
 		Path: path,
 		Val:  value,
-	}, nil
+	}}, nil
+}
+
+const (
+	configAtIndexPrefix  = "index:"
+	configAtChangePrefix = "change:"
+)
+
+// getExtensions holds the extensions that may be given in a GetRequest
+type getExtensions struct {
+	version   devicetype.Version   // May be specified as 101 in extension
+	configAt  *manager.ConfigPoint // May be specified as 106 in extension
+	baseIndex bool                 // May be specified as 109 in extension
 }
 
-func extractGetVersion(req *gnmi.GetRequest) (devicetype.Version, error) {
-	var version devicetype.Version
+func extractGetExtensions(req *gnmi.GetRequest) (*getExtensions, error) {
+	getExts := &getExtensions{}
 	for _, ext := range req.GetExtension() {
 		if ext.GetRegisteredExt().GetId() == GnmiExtensionVersion {
-			version = devicetype.Version(ext.GetRegisteredExt().GetMsg())
+			getExts.version = devicetype.Version(ext.GetRegisteredExt().GetMsg())
+		} else if ext.GetRegisteredExt().GetId() == GnmiExtensionConfigAt {
+			getExts.configAt = parseConfigPoint(string(ext.GetRegisteredExt().GetMsg()))
+			if getExts.configAt == nil {
+				return nil, status.Error(codes.InvalidArgument, fmt.Errorf("invalid extension %d = '%s' in Get()",
+					ext.GetRegisteredExt().GetId(), ext.GetRegisteredExt().GetMsg()).Error())
+			}
+		} else if ext.GetRegisteredExt().GetId() == GnmiExtensionBaseIndex {
+			getExts.baseIndex = true
 		} else {
-			return "", status.Error(codes.InvalidArgument, fmt.Errorf("unexpected extension %d = '%s' in Set()",
+			return nil, status.Error(codes.InvalidArgument, fmt.Errorf("unexpected extension %d = '%s' in Get()",
 				ext.GetRegisteredExt().GetId(), ext.GetRegisteredExt().GetMsg()).Error())
 		}
 	}
-	return version, nil
+	return getExts, nil
+}
+
+// parseConfigPoint parses the message of the config at extension. An index has to be given with the "index:" prefix
+// and a network change ID may be given with the "change:" prefix. Without a prefix a timestamp is tried first, and
+// anything else is taken to be a network change ID.
+func parseConfigPoint(msg string) *manager.ConfigPoint {
+	if strings.HasPrefix(msg, configAtIndexPrefix) {
+		index, err := strconv.ParseUint(strings.TrimPrefix(msg, configAtIndexPrefix), 10, 64)
+		if err != nil || index == 0 {
+			return nil
+		}
+		return &manager.ConfigPoint{Index: networkchange.Index(index)}
+	}
+	if strings.HasPrefix(msg, configAtChangePrefix) {
+		msg = strings.TrimPrefix(msg, configAtChangePrefix)
+	} else if at, err := time.Parse(time.RFC3339, msg); err == nil {
+		return &manager.ConfigPoint{Time: &at}
+	}
+	if msg == "" {
+		return nil
+	}
+	return &manager.ConfigPoint{ChangeID: networkchange.ID(msg)}
 }
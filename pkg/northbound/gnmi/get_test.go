@@ -18,15 +18,19 @@ import (
 	"context"
 	"github.com/golang/mock/gomock"
 	devicechange "github.com/onosproject/onos-api/go/onos/config/change/device"
+	networkchange "github.com/onosproject/onos-api/go/onos/config/change/network"
 	devicetype "github.com/onosproject/onos-api/go/onos/config/device"
 	"github.com/onosproject/onos-config/pkg/store/device/cache"
 	"github.com/onosproject/onos-config/pkg/utils"
 	"github.com/openconfig/gnmi/proto/gnmi"
+	"github.com/openconfig/gnmi/proto/gnmi_ext"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"gotest.tools/assert"
+	"math"
 	"strings"
 	"testing"
+	"time"
 )
 
 // See also the Test_getWithPrefixNoOtherPathsNoTarget below where the Target
@@ -115,6 +119,33 @@ func Test_getAllDevices(t *testing.T) {
 	assert.Assert(t, strings.Contains(deviceListStr, "Device1, Device2, Device3"))
 }
 
+// Test_getBaseIndex shows that the index of the last network change is given when asked for
+func Test_getBaseIndex(t *testing.T) {
+	server, mocks, _ := setUpForGetSetTests(t)
+	mocks.MockStores.NetworkChangesStore.EXPECT().GetPrev(networkchange.Index(math.MaxUint64)).
+		Return(&networkchange.NetworkChange{ID: "LastChange", Index: 7}, nil)
+
+	allDevicesPath := gnmi.Path{Elem: make([]*gnmi.PathElem, 0), Target: "*"}
+
+	request := gnmi.GetRequest{
+		Path: []*gnmi.Path{&allDevicesPath},
+		Extension: []*gnmi_ext.Extension{{
+			Ext: &gnmi_ext.Extension_RegisteredExt{
+				RegisteredExt: &gnmi_ext.RegisteredExtension{
+					Id: GnmiExtensionBaseIndex,
+				},
+			},
+		}},
+	}
+
+	result, err := server.Get(context.TODO(), &request)
+	assert.NilError(t, err)
+	assert.Equal(t, len(result.Notification), 1)
+	assert.Equal(t, len(result.Extension), 1)
+	assert.Equal(t, result.Extension[0].GetRegisteredExt().GetId(), gnmi_ext.ExtensionID(GnmiExtensionBaseIndex))
+	assert.Equal(t, string(result.Extension[0].GetRegisteredExt().GetMsg()), "7")
+}
+
 // Test_getalldevices is where a wildcard is used for target - path is ignored
 func Test_getAllDevicesInPrefix(t *testing.T) {
 	server, _, _ := setUpForGetSetTests(t)
@@ -282,3 +313,32 @@ func Test_pathDoesNotExist(t *testing.T) {
 		"/leaf2w")
 	assert.Assert(t, result.Notification[0].Update[0].Val == nil)
 }
+
+func Test_parseConfigPoint(t *testing.T) {
+	point := parseConfigPoint("2020-07-01T02:00:00Z")
+	assert.Assert(t, point != nil && point.Time != nil)
+	assert.Assert(t, point.Time.Equal(time.Date(2020, 7, 1, 2, 0, 0, 0, time.UTC)))
+
+	point = parseConfigPoint("index:42")
+	assert.Assert(t, point != nil)
+	assert.Equal(t, uint64(point.Index), uint64(42))
+
+	point = parseConfigPoint("Change-VgUAZI928B644v")
+	assert.Assert(t, point != nil)
+	assert.Equal(t, string(point.ChangeID), "Change-VgUAZI928B644v")
+
+	// A network change ID made of digits is not an index
+	point = parseConfigPoint("42")
+	assert.Assert(t, point != nil)
+	assert.Equal(t, string(point.ChangeID), "42")
+	assert.Equal(t, uint64(point.Index), uint64(0))
+
+	point = parseConfigPoint("change:2020-07-01T02:00:00Z")
+	assert.Assert(t, point != nil && point.Time == nil)
+	assert.Equal(t, string(point.ChangeID), "2020-07-01T02:00:00Z")
+
+	assert.Assert(t, parseConfigPoint("") == nil)
+	assert.Assert(t, parseConfigPoint("change:") == nil)
+	assert.Assert(t, parseConfigPoint("index:0") == nil)
+	assert.Assert(t, parseConfigPoint("index:latest") == nil)
+}
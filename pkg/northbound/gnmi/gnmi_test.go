@@ -238,6 +238,9 @@ func setUp(t *testing.T) (*Server, *manager.Manager, *AllMocks) {
 		DeviceSnapshotStore:  mockstore.NewMockDeviceSnapshotStore(ctrl),
 		LeadershipStore:      mockstore.NewMockLeadershipStore(ctrl),
 		MastershipStore:      mockstore.NewMockMastershipStore(ctrl),
+		DeviceLockStore:      mockstore.NewMockDeviceLockStore(ctrl),
+		MetadataStore:        mockstore.NewMockNetworkChangeMetadataStore(ctrl),
+		DeadLetterStore:      mockstore.NewMockDeadLetterStore(ctrl),
 	}
 	deviceCache := mockcache.NewMockCache(ctrl)
 	allMocks.MockStores = mockStores
@@ -251,13 +254,18 @@ func setUp(t *testing.T) (*Server, *manager.Manager, *AllMocks) {
 		mockStores.DeviceStore,
 		deviceCache,
 		mockStores.NetworkChangesStore,
+		mockStores.MetadataStore,
 		mockStores.NetworkSnapshotStore,
 		mockStores.DeviceSnapshotStore,
+		mockStores.DeviceLockStore,
+		mockStores.DeadLetterStore,
 		true)
 
 	mgr.DeviceStore = mockStores.DeviceStore
 	mgr.DeviceChangesStore = mockStores.DeviceChangesStore
 	mgr.NetworkChangesStore = mockStores.NetworkChangesStore
+	mockStores.DeviceLockStore.EXPECT().Get(gomock.Any()).Return(nil, nil).AnyTimes()
+	mockstore.SetUpMapBackedNetworkChangeMetadataStore(mockStores.MetadataStore)
 
 	log.Infof("Dispatcher pointer %p", &mgr.Dispatcher)
 	go listenToTopoLoading(mgr.TopoChannel)
@@ -22,6 +22,7 @@ import (
 	"fmt"
 	networkchange "github.com/onosproject/onos-api/go/onos/config/change/network"
 	"github.com/onosproject/onos-config/pkg/manager"
+	"github.com/onosproject/onos-config/pkg/rbac"
 	"io/ioutil"
 	"sync"
 
@@ -35,17 +36,23 @@ import (
 // Service implements Service for GNMI
 type Service struct {
 	northbound.Service
+	// Authorizer authorizes the paths of Get, Set and Subscribe requests. Everything is allowed if it is nil.
+	Authorizer *rbac.Authorizer
 }
 
 // Register registers the GNMI server with grpc
 func (s Service) Register(r *grpc.Server) {
-	gnmi.RegisterGNMIServer(r, &Server{})
+	gnmi.RegisterGNMIServer(r, &Server{authorizer: s.Authorizer})
 }
 
 // Server implements the grpc GNMI service
 type Server struct {
 	mu        sync.RWMutex
 	lastWrite networkchange.Revision
+	// casMu serializes the Set requests of this replica that are based on a network change index
+	casMu sync.Mutex
+	// authorizer authorizes the operations of the clients, if any
+	authorizer *rbac.Authorizer
 }
 
 // Capabilities implements gNMI Capabilities
@@ -53,7 +60,7 @@ func (s *Server) Capabilities(ctx context.Context, req *gnmi.CapabilityRequest)
 	v, _ := getGNMIServiceVersion()
 	return &gnmi.CapabilityResponse{
 		SupportedModels:    manager.GetManager().ModelRegistry.Capabilities(),
-		SupportedEncodings: []gnmi.Encoding{gnmi.Encoding_JSON},
+		SupportedEncodings: supportedEncodings(manager.GetManager().ModelRegistry),
 		GNMIVersion:        *v,
 	}, nil
 }
@@ -41,8 +41,11 @@ func TestService_Capabilities(t *testing.T) {
 	assert.NilError(t, err)
 	assert.Assert(t, response != nil)
 	assert.Equal(t, response.GNMIVersion, "0.7.0")
-	assert.Equal(t, len(response.SupportedEncodings), 1)
+	// JSON_IETF is not advertised without models that qualify names by module
+	assert.Equal(t, len(response.SupportedEncodings), 3)
 	assert.Equal(t, response.SupportedEncodings[0], gnmi.Encoding_JSON)
+	assert.Equal(t, response.SupportedEncodings[1], gnmi.Encoding_PROTO)
+	assert.Equal(t, response.SupportedEncodings[2], gnmi.Encoding_ASCII)
 }
 
 func TestService_Register(t *testing.T) {
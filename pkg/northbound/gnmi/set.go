@@ -17,14 +17,21 @@ package gnmi
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strconv"
+	"strings"
 	"time"
 
+	"github.com/gogo/protobuf/proto"
+	changetypes "github.com/onosproject/onos-api/go/onos/config/change"
 	devicechange "github.com/onosproject/onos-api/go/onos/config/change/device"
 	networkchange "github.com/onosproject/onos-api/go/onos/config/change/network"
 	devicetype "github.com/onosproject/onos-api/go/onos/config/device"
+	"github.com/onosproject/onos-config/pkg/audit"
 	"github.com/onosproject/onos-config/pkg/manager"
 	"github.com/onosproject/onos-config/pkg/modelregistry"
 	"github.com/onosproject/onos-config/pkg/modelregistry/jsonvalues"
+	"github.com/onosproject/onos-config/pkg/store/change/metadata"
 	"github.com/onosproject/onos-config/pkg/store/device/cache"
 	"github.com/onosproject/onos-config/pkg/utils"
 	"github.com/onosproject/onos-config/pkg/utils/values"
@@ -34,28 +41,61 @@ import (
 	"google.golang.org/grpc/status"
 )
 
+const (
+	// defaultWaitForApplyTimeout is how long a Set waits for a change to be applied when no timeout is given
+	defaultWaitForApplyTimeout = 30 * time.Second
+	// maxWaitForApplyTimeout is the longest a Set may wait for a change to be applied
+	maxWaitForApplyTimeout = 5 * time.Minute
+)
+
 type mapTargetUpdates map[devicetype.ID]devicechange.TypedValueMap
 type mapTargetRemoves map[devicetype.ID][]string
+type mapTargetReplaces map[devicetype.ID][]string
 type mapTargetModels map[devicetype.ID]modelregistry.ReadWritePathMap
 
+// setExtensions holds the extensions that may be given in a SetRequest
+// There is only one set of extensions in Set request, regardless of number of updates
+type setExtensions struct {
+	netCfgChangeName string               // May be specified as 100 in extension
+	version          devicetype.Version   // May be specified as 101 in extension
+	deviceType       devicetype.Type      // May be specified as 102 in extension
+	validateOnly     bool                 // May be specified as 104 in extension
+	notBefore        *time.Time           // May be specified as 105 in extension
+	confirmTimeout   *time.Duration       // May be specified as 107 in extension
+	waitTimeout      *time.Duration       // May be specified as 108 in extension
+	baseIndex        *networkchange.Index // May be specified as 109 in extension
+	description      string               // May be specified as 110 in extension
+	labels           map[string]string    // May be specified as 111 in extension
+	applyPolicy      *applyPolicy         // May be specified as 112 in extension
+}
+
+// applyPolicy is how a network change is applied when some of its devices are not connected
+type applyPolicy struct {
+	policy metadata.ApplyPolicy
+	quorum uint32
+}
+
 // Set implements gNMI Set
 func (s *Server) Set(ctx context.Context, req *gnmi.SetRequest) (*gnmi.SetResponse, error) {
-	// There is only one set of extensions in Set request, regardless of number of
-	// updates
-	var (
-		netCfgChangeName string             // May be specified as 100 in extension
-		version          devicetype.Version // May be specified as 101 in extension
-		deviceType       devicetype.Type    // May be specified as 102 in extension
-	)
+	response, err := s.set(ctx, req)
+	auditSet(ctx, req, response, err)
+	return response, err
+}
 
+// set makes the network change of a gNMI Set request
+func (s *Server) set(ctx context.Context, req *gnmi.SetRequest) (*gnmi.SetResponse, error) {
 	targetUpdates := make(mapTargetUpdates)
 	targetRemoves := make(mapTargetRemoves)
+	targetReplaces := make(mapTargetReplaces)
 	targetModels := make(mapTargetModels)
 
-	netCfgChangeName, version, deviceType, err := extractExtensions(req)
+	setExts, err := extractExtensions(req)
 	if err != nil {
 		return nil, status.Error(codes.InvalidArgument, err.Error())
 	}
+	version := setExts.version
+	deviceType := setExts.deviceType
+	client := utils.GetClientIdentity(ctx)
 
 	log.Infof("gNMI Set Request %v", req)
 	prefixTarget := devicetype.ID(req.GetPrefix().GetTarget())
@@ -91,6 +131,7 @@ func (s *Server) Set(ctx context.Context, req *gnmi.SetRequest) (*gnmi.SetRespon
 			log.Warn("Error in replace", err)
 			return nil, status.Error(codes.InvalidArgument, err.Error())
 		}
+		targetReplaces[target] = append(targetReplaces[target], fullPath(req.GetPrefix(), u.GetPath()))
 	}
 
 	//Delete
@@ -109,20 +150,29 @@ func (s *Server) Set(ctx context.Context, req *gnmi.SetRequest) (*gnmi.SetRespon
 		}
 	}
 
+	s.mu.RLock()
+	lastWrite := s.lastWrite
+	s.mu.RUnlock()
+
+	//Replace - anything configured under a replaced path that is not restated is implicitly deleted
+	for target, replacedPaths := range targetReplaces {
+		targetRemoves[target], err = s.doReplaceDeletes(target, version, deviceType, replacedPaths,
+			targetUpdates[target], targetRemoves[target], lastWrite)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	//Temporary map in order to not to modify the original removes but optimize calculations during validation
 	targetRemovesTmp := make(mapTargetRemoves)
 	for k, v := range targetRemoves {
 		targetRemovesTmp[k] = v
 	}
 
-	s.mu.RLock()
-	lastWrite := s.lastWrite
-	s.mu.RUnlock()
-
 	mgr := manager.GetManager()
 	deviceInfo := make(map[devicetype.ID]cache.Info)
-	//Checking for wrong configuration against the device models for updates
-	for target, updates := range targetUpdates {
+	targets := make([]devicetype.ID, 0, len(targetUpdates)+len(targetRemovesTmp))
+	for target := range targetUpdates {
 		deviceType, version, err = mgr.CheckCacheForDevice(target, deviceType, version)
 		if err != nil {
 			return nil, status.Error(codes.InvalidArgument, err.Error())
@@ -132,18 +182,11 @@ func (s *Server) Set(ctx context.Context, req *gnmi.SetRequest) (*gnmi.SetRespon
 			Type:     deviceType,
 			Version:  version,
 		}
-
-		// TODO: Since the change has not been stored yet, we cannot guarantee the change will be validated against
-		//       the same state as will be pushed to the device. Changes must be validated after they're stored
-		//       to achieve this level of consistency.
-		err := validateChange(target, deviceType, version, updates, targetRemoves[target], lastWrite)
-		if err != nil {
-			return nil, status.Error(codes.InvalidArgument, err.Error())
-		}
+		targets = append(targets, target)
 		delete(targetRemovesTmp, target)
 	}
-	//Checking for wrong configuration against the device models for deletes
-	for target, removes := range targetRemovesTmp {
+	// Some targets might only have removes
+	for target := range targetRemovesTmp {
 		deviceType, version, err = mgr.CheckCacheForDevice(target, deviceType, version)
 		if err != nil {
 			return nil, status.Error(codes.InvalidArgument, err.Error())
@@ -153,21 +196,60 @@ func (s *Server) Set(ctx context.Context, req *gnmi.SetRequest) (*gnmi.SetRespon
 			Type:     deviceType,
 			Version:  version,
 		}
+		targets = append(targets, target)
+	}
 
-		// TODO: Since the change has not been stored yet, we cannot guarantee the change will be validated against
-		//       the same state as will be pushed to the device. Changes must be validated after they're stored
-		//       to achieve this level of consistency.
-		err := validateChange(target, deviceType, version, make(devicechange.TypedValueMap), removes, lastWrite)
-		if err != nil {
-			return nil, status.Error(codes.InvalidArgument, err.Error())
+	// Every path that is updated or removed, including those removed by a replace, must be allowed for the client
+	if err := s.authorizeSet(ctx, targetUpdates, targetRemoves, deviceInfo); err != nil {
+		return nil, err
+	}
+
+	//Checking for wrong configuration against the device models, for several devices at the same time
+	// TODO: Since the change has not been stored yet, we cannot guarantee the change will be validated against
+	//       the same state as will be pushed to the device. Changes must be validated after they're stored
+	//       to achieve this level of consistency.
+	err = mgr.ForEachDevice(targets, func(target devicetype.ID) error {
+		updates, ok := targetUpdates[target]
+		if !ok {
+			updates = make(devicechange.TypedValueMap)
 		}
+		return validateChange(target, deviceInfo[target].Type, deviceInfo[target].Version,
+			updates, targetRemoves[target], lastWrite)
+	})
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	// When only validating, give back the computed change without storing it
+	if setExts.validateOnly {
+		change, errCompute := mgr.ComputeNetworkConfig(targetUpdates, targetRemoves, deviceInfo, setExts.netCfgChangeName)
+		if errCompute != nil {
+			log.Errorf("Error while computing config %s", errCompute.Error())
+			return nil, status.Error(codes.Internal, errCompute.Error())
+		}
+		return buildValidateOnlyResponse(change)
+	}
+
+	// Refuse the change if another client holds a lock on any of its devices
+	if err := checkDeviceLocks(deviceInfo, client); err != nil {
+		return nil, err
 	}
 
 	// Creating and setting the config on the atomix Store
-	change, errSet := mgr.SetNetworkConfig(targetUpdates, targetRemoves, deviceInfo, netCfgChangeName)
-	if errSet != nil {
-		log.Errorf("Error while setting config in atomix %s", errSet.Error())
-		return nil, status.Error(codes.Internal, errSet.Error())
+	var change *networkchange.NetworkChange
+	if setExts.baseIndex != nil {
+		change, err = s.setNetworkConfigIfNotModified(targetUpdates, targetRemoves, deviceInfo, setExts, client)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var errSet error
+		change, errSet = mgr.SetNetworkConfig(targetUpdates, targetRemoves, deviceInfo, setExts.netCfgChangeName,
+			setExts.networkChangeOptions(client)...)
+		if errSet != nil {
+			log.Errorf("Error while setting config in atomix %s", errSet.Error())
+			return nil, status.Error(codes.Internal, errSet.Error())
+		}
 	}
 
 	// Store the highest known change index
@@ -177,7 +259,221 @@ func (s *Server) Set(ctx context.Context, req *gnmi.SetRequest) (*gnmi.SetRespon
 	}
 	s.mu.Unlock()
 
-	// Build the responses
+	extensions := []*gnmi_ext.Extension{
+		{
+			Ext: &gnmi_ext.Extension_RegisteredExt{
+				RegisteredExt: &gnmi_ext.RegisteredExtension{
+					Id:  GnmiExtensionNetwkChangeID,
+					Msg: []byte(change.ID),
+				},
+			},
+		},
+	}
+
+	// When the edit was based on a network change, give the index of the new one to base the next edit on
+	if setExts.baseIndex != nil {
+		extensions = append(extensions, &gnmi_ext.Extension{
+			Ext: &gnmi_ext.Extension_RegisteredExt{
+				RegisteredExt: &gnmi_ext.RegisteredExtension{
+					Id:  GnmiExtensionBaseIndex,
+					Msg: []byte(strconv.FormatUint(uint64(change.Index), 10)),
+				},
+			},
+		})
+	}
+
+	// When asked to, wait for the change to be applied and give the status of each device
+	if setExts.waitTimeout != nil {
+		statusExtensions, errWait := buildWaitForApplyExtensions(ctx, change.ID, *setExts.waitTimeout)
+		if errWait != nil {
+			log.Errorf("Error while waiting for change %s %s", change.ID, errWait.Error())
+			return nil, errWait
+		}
+		extensions = append(extensions, statusExtensions...)
+	}
+
+	setResponse := &gnmi.SetResponse{
+		Response:  buildUpdateResults(change),
+		Timestamp: time.Now().Unix(),
+		Extension: extensions,
+	}
+
+	return setResponse, nil
+}
+
+// auditSet records a Set request in the audit log, along with the network change that resulted from it
+func auditSet(ctx context.Context, req *gnmi.SetRequest, response *gnmi.SetResponse, err error) {
+	record := audit.NewRecord(ctx, audit.OperationSet, err)
+	for _, u := range req.GetUpdate() {
+		record.Paths = append(record.Paths, targetPath(req.GetPrefix(), u.GetPath()))
+	}
+	for _, u := range req.GetReplace() {
+		record.Paths = append(record.Paths, targetPath(req.GetPrefix(), u.GetPath()))
+	}
+	for _, path := range req.GetDelete() {
+		record.Paths = append(record.Paths, targetPath(req.GetPrefix(), path))
+	}
+	for _, ext := range response.GetExtension() {
+		if regExt := ext.GetRegisteredExt(); regExt.GetId() == GnmiExtensionNetwkChangeID {
+			record.NetworkChangeID = string(regExt.GetMsg())
+		}
+	}
+	if setExts, errExt := extractExtensions(req); errExt == nil && setExts.validateOnly {
+		record.Details = "validate only"
+	}
+	manager.GetManager().Audit(record)
+}
+
+// targetPath gives a path of a request as target:path
+func targetPath(prefix *gnmi.Path, path *gnmi.Path) string {
+	target := path.GetTarget()
+	if target == "" {
+		target = prefix.GetTarget()
+	}
+	return fmt.Sprintf("%s:%s", target, fullPath(prefix, path))
+}
+
+// buildWaitForApplyExtensions waits for the network change to be applied to the devices, and builds an extension
+// 108 for each device with its device change, protobuf encoded, which has the status of the device. If the change
+// has failed, an Aborted status is returned instead with the reason of the failure.
+func buildWaitForApplyExtensions(ctx context.Context, changeID networkchange.ID, timeout time.Duration) ([]*gnmi_ext.Extension, error) {
+	change, deviceChanges, err := manager.GetManager().WaitForNetworkChange(ctx, changeID, timeout)
+	if err == context.Canceled {
+		return nil, status.Error(codes.Canceled, err.Error())
+	} else if err == context.DeadlineExceeded {
+		return nil, status.Error(codes.DeadlineExceeded, err.Error())
+	} else if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	log.Infof("Change %s is %s %s after waiting for at most %v", change.ID, change.Status.Phase,
+		change.Status.State, timeout)
+	if change.Status.State == changetypes.State_FAILED {
+		return nil, status.Error(codes.Aborted, getFailureMessage(change, deviceChanges))
+	}
+
+	extensions := make([]*gnmi_ext.Extension, 0, len(deviceChanges))
+	for _, deviceChange := range deviceChanges {
+		deviceChangeBytes, err := proto.Marshal(deviceChange)
+		if err != nil {
+			return nil, err
+		}
+		extensions = append(extensions, &gnmi_ext.Extension{
+			Ext: &gnmi_ext.Extension_RegisteredExt{
+				RegisteredExt: &gnmi_ext.RegisteredExtension{
+					Id:  GnmiExtensionWaitForApply,
+					Msg: deviceChangeBytes,
+				},
+			},
+		})
+	}
+	return extensions, nil
+}
+
+// getFailureMessage returns the reason why the network change failed, along with that of each of its devices
+// that failed
+func getFailureMessage(change *networkchange.NetworkChange, deviceChanges []*devicechange.DeviceChange) string {
+	message := fmt.Sprintf("change %s failed: %s", change.ID, change.Status.Message)
+	for _, deviceChange := range deviceChanges {
+		if deviceChange.Status.State == changetypes.State_FAILED && deviceChange.Status.Message != "" {
+			message = fmt.Sprintf("%s; %s: %s", message, deviceChange.Change.DeviceID, deviceChange.Status.Message)
+		}
+	}
+	return message
+}
+
+// setNetworkConfigIfNotModified stores the network change only if no change made after the network change index
+// the edit was based on touches the same device paths, otherwise the Set is aborted. A conflicting change may still
+// be stored between the check and the store, by another replica or by a Set without a base index. The network
+// change records the base index so that the controller, which sees every change made before it, fails it then.
+func (s *Server) setNetworkConfigIfNotModified(targetUpdates mapTargetUpdates, targetRemoves mapTargetRemoves,
+	deviceInfo map[devicetype.ID]cache.Info, setExts *setExtensions, client string) (*networkchange.NetworkChange, error) {
+	mgr := manager.GetManager()
+	change, errCompute := mgr.ComputeNetworkConfig(targetUpdates, targetRemoves, deviceInfo, setExts.netCfgChangeName)
+	if errCompute != nil {
+		log.Errorf("Error while computing config %s", errCompute.Error())
+		return nil, status.Error(codes.Internal, errCompute.Error())
+	}
+
+	// The check and the store are not interleaved with another compare-and-set on this replica, so that the
+	// later one is aborted here rather than failed by the controller
+	s.casMu.Lock()
+	defer s.casMu.Unlock()
+	conflicts, errConflicts := mgr.GetConflictingChanges(change, *setExts.baseIndex)
+	if errConflicts != nil {
+		log.Errorf("Error while checking for conflicting changes %s", errConflicts.Error())
+		return nil, status.Error(codes.Internal, errConflicts.Error())
+	}
+	if len(conflicts) > 0 {
+		return nil, status.Errorf(codes.Aborted, "changes made after index %d intersect with the Set: %s",
+			*setExts.baseIndex, strings.Join(conflicts, "; "))
+	}
+
+	if errSet := mgr.CreateNetworkChange(change, setExts.networkChangeOptions(client)...); errSet != nil {
+		log.Errorf("Error while setting config in atomix %s", errSet.Error())
+		return nil, status.Error(codes.Internal, errSet.Error())
+	}
+	return change, nil
+}
+
+// checkDeviceLocks refuses a change to devices that are locked by clients other than the one making the change
+func checkDeviceLocks(deviceInfo map[devicetype.ID]cache.Info, client string) error {
+	deviceIDs := make([]devicetype.ID, 0, len(deviceInfo))
+	for deviceID := range deviceInfo {
+		deviceIDs = append(deviceIDs, deviceID)
+	}
+	locks, err := manager.GetManager().GetDeviceLockConflicts(deviceIDs, client)
+	if err != nil {
+		log.Errorf("Error while checking device locks %s", err.Error())
+		return status.Error(codes.Internal, err.Error())
+	}
+	if len(locks) > 0 {
+		lockedBy := make([]string, 0, len(locks))
+		for _, lock := range locks {
+			lockedBy = append(lockedBy, fmt.Sprintf("%s by %s until %s", lock.DeviceID, lock.Owner,
+				lock.Expires.Format(time.RFC3339)))
+		}
+		sort.Strings(lockedBy)
+		return status.Errorf(codes.FailedPrecondition, "devices are locked: %s", strings.Join(lockedBy, "; "))
+	}
+	return nil
+}
+
+// buildValidateOnlyResponse builds the response to a Set that was only validated. The computed
+// network change is given in extension 104 and its name in extension 100
+func buildValidateOnlyResponse(change *networkchange.NetworkChange) (*gnmi.SetResponse, error) {
+	changeBytes, err := proto.Marshal(change)
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	extensions := []*gnmi_ext.Extension{
+		{
+			Ext: &gnmi_ext.Extension_RegisteredExt{
+				RegisteredExt: &gnmi_ext.RegisteredExtension{
+					Id:  GnmiExtensionNetwkChangeID,
+					Msg: []byte(change.ID),
+				},
+			},
+		},
+		{
+			Ext: &gnmi_ext.Extension_RegisteredExt{
+				RegisteredExt: &gnmi_ext.RegisteredExtension{
+					Id:  GnmiExtensionValidateOnly,
+					Msg: changeBytes,
+				},
+			},
+		},
+	}
+
+	return &gnmi.SetResponse{
+		Response:  buildUpdateResults(change),
+		Timestamp: time.Now().Unix(),
+		Extension: extensions,
+	}, nil
+}
+
+// buildUpdateResults builds an UpdateResult for each value of the network change
+func buildUpdateResults(change *networkchange.NetworkChange) []*gnmi.UpdateResult {
 	updateResults := make([]*gnmi.UpdateResult, 0)
 	for _, deviceChange := range change.Changes {
 		deviceID := deviceChange.DeviceID
@@ -198,46 +494,168 @@ func (s *Server) Set(ctx context.Context, req *gnmi.SetRequest) (*gnmi.SetRespon
 			updateResults = append(updateResults, updateResult)
 		}
 	}
-
-	extensions := []*gnmi_ext.Extension{
-		{
-			Ext: &gnmi_ext.Extension_RegisteredExt{
-				RegisteredExt: &gnmi_ext.RegisteredExtension{
-					Id:  GnmiExtensionNetwkChangeID,
-					Msg: []byte(change.ID),
-				},
-			},
-		},
-	}
-
-	setResponse := &gnmi.SetResponse{
-		Response:  updateResults,
-		Timestamp: time.Now().Unix(),
-		Extension: extensions,
-	}
-
-	return setResponse, nil
+	return updateResults
 }
 
-func extractExtensions(req *gnmi.SetRequest) (string, devicetype.Version, devicetype.Type, error) {
-	var netcfgchangename string
-	var version string
-	var deviceType string
+func extractExtensions(req *gnmi.SetRequest) (*setExtensions, error) {
+	setExts := &setExtensions{}
 	for _, ext := range req.GetExtension() {
 		if ext.GetRegisteredExt().GetId() == GnmiExtensionNetwkChangeID {
-			netcfgchangename = string(ext.GetRegisteredExt().GetMsg())
+			setExts.netCfgChangeName = string(ext.GetRegisteredExt().GetMsg())
 		} else if ext.GetRegisteredExt().GetId() == GnmiExtensionVersion {
-			version = string(ext.GetRegisteredExt().GetMsg())
+			setExts.version = devicetype.Version(ext.GetRegisteredExt().GetMsg())
 		} else if ext.GetRegisteredExt().GetId() == GnmiExtensionDeviceType {
-			deviceType = string(ext.GetRegisteredExt().GetMsg())
+			setExts.deviceType = devicetype.Type(ext.GetRegisteredExt().GetMsg())
+		} else if ext.GetRegisteredExt().GetId() == GnmiExtensionValidateOnly {
+			validateOnly, err := parseBoolExtension(ext.GetRegisteredExt().GetMsg())
+			if err != nil {
+				return nil, status.Error(codes.InvalidArgument, fmt.Errorf("invalid extension %d = '%s' in Set() %v",
+					ext.GetRegisteredExt().GetId(), ext.GetRegisteredExt().GetMsg(), err).Error())
+			}
+			setExts.validateOnly = validateOnly
+		} else if ext.GetRegisteredExt().GetId() == GnmiExtensionNotBefore {
+			notBefore, err := time.Parse(time.RFC3339, string(ext.GetRegisteredExt().GetMsg()))
+			if err != nil {
+				return nil, status.Error(codes.InvalidArgument, fmt.Errorf("invalid extension %d = '%s' in Set() %v",
+					ext.GetRegisteredExt().GetId(), ext.GetRegisteredExt().GetMsg(), err).Error())
+			}
+			setExts.notBefore = &notBefore
+		} else if ext.GetRegisteredExt().GetId() == GnmiExtensionConfirmTimeout {
+			seconds, err := strconv.ParseUint(string(ext.GetRegisteredExt().GetMsg()), 10, 32)
+			if err == nil && seconds == 0 {
+				err = fmt.Errorf("timeout must be at least 1 second")
+			}
+			if err != nil {
+				return nil, status.Error(codes.InvalidArgument, fmt.Errorf("invalid extension %d = '%s' in Set() %v",
+					ext.GetRegisteredExt().GetId(), ext.GetRegisteredExt().GetMsg(), err).Error())
+			}
+			confirmTimeout := time.Duration(seconds) * time.Second
+			setExts.confirmTimeout = &confirmTimeout
+		} else if ext.GetRegisteredExt().GetId() == GnmiExtensionWaitForApply {
+			waitTimeout, err := parseWaitTimeout(ext.GetRegisteredExt().GetMsg())
+			if err != nil {
+				return nil, status.Error(codes.InvalidArgument, fmt.Errorf("invalid extension %d = '%s' in Set() %v",
+					ext.GetRegisteredExt().GetId(), ext.GetRegisteredExt().GetMsg(), err).Error())
+			}
+			setExts.waitTimeout = &waitTimeout
+		} else if ext.GetRegisteredExt().GetId() == GnmiExtensionBaseIndex {
+			baseIndex, err := strconv.ParseUint(string(ext.GetRegisteredExt().GetMsg()), 10, 64)
+			if err != nil {
+				return nil, status.Error(codes.InvalidArgument, fmt.Errorf("invalid extension %d = '%s' in Set() %v",
+					ext.GetRegisteredExt().GetId(), ext.GetRegisteredExt().GetMsg(), err).Error())
+			}
+			index := networkchange.Index(baseIndex)
+			setExts.baseIndex = &index
+		} else if ext.GetRegisteredExt().GetId() == GnmiExtensionDescription {
+			setExts.description = string(ext.GetRegisteredExt().GetMsg())
+		} else if ext.GetRegisteredExt().GetId() == GnmiExtensionLabels {
+			if setExts.labels == nil {
+				setExts.labels = make(map[string]string)
+			}
+			if err := parseLabels(ext.GetRegisteredExt().GetMsg(), setExts.labels); err != nil {
+				return nil, status.Error(codes.InvalidArgument, fmt.Errorf("invalid extension %d = '%s' in Set() %v",
+					ext.GetRegisteredExt().GetId(), ext.GetRegisteredExt().GetMsg(), err).Error())
+			}
+		} else if ext.GetRegisteredExt().GetId() == GnmiExtensionApplyPolicy {
+			policy, err := parseApplyPolicy(ext.GetRegisteredExt().GetMsg())
+			if err != nil {
+				return nil, status.Error(codes.InvalidArgument, fmt.Errorf("invalid extension %d = '%s' in Set() %v",
+					ext.GetRegisteredExt().GetId(), ext.GetRegisteredExt().GetMsg(), err).Error())
+			}
+			setExts.applyPolicy = policy
 		} else {
-			return "", "", "", status.Error(codes.InvalidArgument, fmt.Errorf("unexpected extension %d = '%s' in Set()",
+			return nil, status.Error(codes.InvalidArgument, fmt.Errorf("unexpected extension %d = '%s' in Set()",
 				ext.GetRegisteredExt().GetId(), ext.GetRegisteredExt().GetMsg()).Error())
 		}
 	}
-	log.Infof("Set called with extensions; 100: %s, 101: %s, 102: %s",
-		netcfgchangename, version, deviceType)
-	return netcfgchangename, devicetype.Version(version), devicetype.Type(deviceType), nil
+	log.Infof("Set called with extensions; 100: %s, 101: %s, 102: %s, 104: %v, 105: %v, 107: %v, 108: %v, 109: %v, "+
+		"110: %s, 111: %v, 112: %v", setExts.netCfgChangeName, setExts.version, setExts.deviceType, setExts.validateOnly,
+		setExts.notBefore, setExts.confirmTimeout, setExts.waitTimeout, setExts.baseIndex, setExts.description,
+		setExts.labels, setExts.applyPolicy)
+	return setExts, nil
+}
+
+// networkChangeOptions returns the options to set on the network change for the given extensions and client
+func (setExts *setExtensions) networkChangeOptions(client string) []manager.NetworkChangeOption {
+	opts := []manager.NetworkChangeOption{manager.WithUsername(client)}
+	if setExts.notBefore != nil {
+		opts = append(opts, manager.WithNotBefore(*setExts.notBefore))
+	}
+	if setExts.confirmTimeout != nil {
+		opts = append(opts, manager.WithConfirmTimeout(*setExts.confirmTimeout))
+	}
+	if setExts.baseIndex != nil {
+		opts = append(opts, manager.WithBaseIndex(*setExts.baseIndex))
+	}
+	if setExts.description != "" {
+		opts = append(opts, manager.WithDescription(setExts.description))
+	}
+	if len(setExts.labels) > 0 {
+		opts = append(opts, manager.WithLabels(setExts.labels))
+	}
+	if setExts.applyPolicy != nil {
+		opts = append(opts, manager.WithApplyPolicy(setExts.applyPolicy.policy, setExts.applyPolicy.quorum))
+	}
+	return opts
+}
+
+// parseLabels parses the message of the labels extension - comma separated key=value pairs - into the labels
+func parseLabels(msg []byte, labels map[string]string) error {
+	for _, label := range strings.Split(string(msg), ",") {
+		keyValue := strings.SplitN(label, "=", 2)
+		key := strings.TrimSpace(keyValue[0])
+		if len(keyValue) != 2 || key == "" {
+			return fmt.Errorf("label '%s' is not of the form key=value", label)
+		}
+		labels[key] = strings.TrimSpace(keyValue[1])
+	}
+	return nil
+}
+
+// parseWaitTimeout parses the message of the wait for apply extension - a number of seconds, up to the maximum,
+// or an empty message for the default
+func parseWaitTimeout(msg []byte) (time.Duration, error) {
+	if len(msg) == 0 {
+		return defaultWaitForApplyTimeout, nil
+	}
+	seconds, err := strconv.ParseUint(string(msg), 10, 32)
+	if err != nil {
+		return 0, err
+	}
+	timeout := time.Duration(seconds) * time.Second
+	if timeout == 0 || timeout > maxWaitForApplyTimeout {
+		return 0, fmt.Errorf("timeout must be between 1 second and %v", maxWaitForApplyTimeout)
+	}
+	return timeout, nil
+}
+
+// parseApplyPolicy parses the message of the apply policy extension - "atomic", "best-effort" or "quorum:<percent>"
+func parseApplyPolicy(msg []byte) (*applyPolicy, error) {
+	policy := strings.TrimSpace(string(msg))
+	switch {
+	case policy == "atomic":
+		return &applyPolicy{policy: metadata.ApplyPolicyAtomic}, nil
+	case policy == "best-effort":
+		return &applyPolicy{policy: metadata.ApplyPolicyBestEffort}, nil
+	case strings.HasPrefix(policy, "quorum:"):
+		percent, err := strconv.ParseUint(strings.TrimPrefix(policy, "quorum:"), 10, 32)
+		if err != nil {
+			return nil, err
+		}
+		if percent == 0 || percent > 100 {
+			return nil, fmt.Errorf("quorum must be between 1 and 100 percent")
+		}
+		return &applyPolicy{policy: metadata.ApplyPolicyQuorum, quorum: uint32(percent)}, nil
+	}
+	return nil, fmt.Errorf("policy must be atomic, best-effort or quorum:<percent>")
+}
+
+// parseBoolExtension parses the message of a flag extension - an empty message means the flag is set
+func parseBoolExtension(msg []byte) (bool, error) {
+	if len(msg) == 0 {
+		return true, nil
+	}
+	return strconv.ParseBool(string(msg))
 }
 
 // This deals with either a path and a value (simple case) or a path with
@@ -248,11 +666,7 @@ func (s *Server) formatUpdateOrReplace(prefix *gnmi.Path, u *gnmi.Update,
 	if target == "" {
 		target = devicetype.ID(prefix.GetTarget())
 	}
-	prefixPath := utils.StrPath(prefix)
-	path := utils.StrPath(u.Path)
-	if prefixPath != "/" {
-		path = fmt.Sprintf("%s%s", prefixPath, path)
-	}
+	path := fullPath(prefix, u.Path)
 
 	updates, ok := targetUpdates[target]
 	if !ok {
@@ -302,11 +716,7 @@ func (s *Server) doDelete(prefix *gnmi.Path, u *gnmi.Path,
 	if !ok {
 		deletes = make([]string, 0)
 	}
-	prefixPath := utils.StrPath(prefix)
-	path := utils.StrPath(u)
-	if prefixPath != "/" {
-		path = fmt.Sprintf("%s%s", prefixPath, path)
-	}
+	path := fullPath(prefix, u)
 	// Checks for read only paths
 	_, err := findPathFromModel(path, rwPaths)
 	if err != nil {
@@ -317,6 +727,55 @@ func (s *Server) doDelete(prefix *gnmi.Path, u *gnmi.Path,
 
 }
 
+// doReplaceDeletes adds a delete for every leaf currently configured under one of the
+// replaced paths that is neither restated in the updates nor already deleted
+func (s *Server) doReplaceDeletes(target devicetype.ID, version devicetype.Version, deviceType devicetype.Type,
+	replacedPaths []string, updates devicechange.TypedValueMap, deletes []string,
+	lastWrite networkchange.Revision) ([]string, error) {
+
+	mgr := manager.GetManager()
+	_, version, err := mgr.CheckCacheForDevice(target, deviceType, version)
+	if err != nil {
+		return nil, status.Error(codes.InvalidArgument, err.Error())
+	}
+
+	deleted := make(map[string]struct{})
+	for _, path := range deletes {
+		deleted[path] = struct{}{}
+	}
+	for _, replacedPath := range replacedPaths {
+		configValues, err := mgr.GetTargetConfig(target, version, replacedPath, lastWrite)
+		if err != nil {
+			return nil, status.Error(codes.Internal, err.Error())
+		}
+		for _, cv := range configValues {
+			if !utils.IsPathUnder(cv.Path, replacedPath) {
+				continue
+			}
+			if _, restated := updates[cv.Path]; restated {
+				continue
+			}
+			if _, ok := deleted[cv.Path]; ok {
+				continue
+			}
+			log.Infof("Replace of %s on %s implicitly deletes %s", replacedPath, target, cv.Path)
+			deleted[cv.Path] = struct{}{}
+			deletes = append(deletes, cv.Path)
+		}
+	}
+	return deletes, nil
+}
+
+// fullPath gives the string path of a gNMI path relative to the request prefix
+func fullPath(prefix *gnmi.Path, path *gnmi.Path) string {
+	prefixPath := utils.StrPath(prefix)
+	strPath := utils.StrPath(path)
+	if prefixPath != "/" {
+		strPath = fmt.Sprintf("%s%s", prefixPath, strPath)
+	}
+	return strPath
+}
+
 func buildUpdateResult(pathStr string, target string, op gnmi.UpdateResult_Operation) (*gnmi.UpdateResult, error) {
 	path, errInPath := utils.ParseGNMIElements(utils.SplitPath(pathStr))
 	if errInPath != nil {
@@ -16,20 +16,36 @@ package gnmi
 
 import (
 	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"github.com/gogo/protobuf/proto"
 	"github.com/golang/mock/gomock"
+	changetypes "github.com/onosproject/onos-api/go/onos/config/change"
+	devicechange "github.com/onosproject/onos-api/go/onos/config/change/device"
 	networkchange "github.com/onosproject/onos-api/go/onos/config/change/network"
 	devicetype "github.com/onosproject/onos-api/go/onos/config/device"
+	"github.com/onosproject/onos-config/pkg/audit"
 	topodevice "github.com/onosproject/onos-config/pkg/device"
+	"github.com/onosproject/onos-config/pkg/store/change/metadata"
 	"github.com/onosproject/onos-config/pkg/store/device/cache"
+	"github.com/onosproject/onos-config/pkg/store/lock"
+	mockstore "github.com/onosproject/onos-config/pkg/test/mocks/store"
 	"github.com/onosproject/onos-config/pkg/utils"
 	"github.com/openconfig/gnmi/proto/gnmi"
 	"github.com/openconfig/gnmi/proto/gnmi_ext"
 	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
 	"google.golang.org/grpc/status"
 	"gotest.tools/assert"
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"regexp"
 	"strconv"
 	"testing"
+	"time"
 )
 
 const (
@@ -644,3 +660,600 @@ func TestSet_BadDeviceType(t *testing.T) {
 	assert.ErrorContains(t, setError, "target DeviceWithMultipleVersions type given NotTheSameType does not match expected TestDevice")
 	assert.Assert(t, setResponse == nil)
 }
+
+// Test_doReplace shows how a replace removes the leaves under the replaced path that are not restated
+func Test_doReplace(t *testing.T) {
+	server, mocks, _ := setUpForGetSetTests(t)
+	deletePaths, replacedPaths, updatedPaths := setUpPathsForGetSetTests()
+
+	leaf2a, _ := devicechange.NewChangeValue("/cont1a/cont2a/leaf2a", devicechange.NewTypedValueUint(13, 8), false)
+	leaf2c, _ := devicechange.NewChangeValue("/cont1a/cont2a/leaf2c", devicechange.NewTypedValueString("abc"), false)
+	leaf2g, _ := devicechange.NewChangeValue("/cont1a/cont2a/leaf2g", devicechange.NewTypedValueBool(true), false)
+	leaf1a, _ := devicechange.NewChangeValue("/cont1a/leaf1a", devicechange.NewTypedValueString("leaf1aval"), false)
+	mocks.MockStores.DeviceStateStore.EXPECT().Get(gomock.Any(), gomock.Any()).Return([]*devicechange.PathValue{
+		{Path: leaf2a.Path, Value: leaf2a.Value},
+		{Path: leaf2c.Path, Value: leaf2c.Value},
+		{Path: leaf2g.Path, Value: leaf2g.Value},
+		{Path: leaf1a.Path, Value: leaf1a.Value},
+	}, nil).AnyTimes()
+
+	pathElemsRefs, _ := utils.ParseGNMIElements([]string{"cont1a", "cont2a"})
+	typedValue := gnmi.TypedValue_JsonVal{JsonVal: []byte(`{"leaf2a": 12}`)}
+	value := gnmi.TypedValue{Value: &typedValue}
+	replacePath := gnmi.Path{Elem: pathElemsRefs.Elem, Target: "Device1"}
+	replacedPaths = append(replacedPaths, &gnmi.Update{Path: &replacePath, Val: &value})
+
+	var setRequest = gnmi.SetRequest{
+		Delete:  deletePaths,
+		Replace: replacedPaths,
+		Update:  updatedPaths,
+	}
+
+	setResponse, setError := server.Set(context.Background(), &setRequest)
+	assert.NilError(t, setError, "Unexpected error from gnmi Set")
+	assert.Assert(t, setResponse != nil, "Expected setResponse to have a value")
+
+	// leaf2a is restated, leaf2c and leaf2g are removed and leaf1a is outside of the replaced path
+	assert.Equal(t, len(setResponse.Response), 3)
+	deleted := make(map[string]bool)
+	for _, response := range setResponse.Response {
+		path := utils.StrPath(response.Path)
+		if response.Op == gnmi.UpdateResult_DELETE {
+			deleted[path] = true
+		} else {
+			assert.Equal(t, response.Op, gnmi.UpdateResult_UPDATE)
+			assert.Equal(t, path, "/cont1a/cont2a/leaf2a")
+		}
+	}
+	assert.Equal(t, len(deleted), 2)
+	assert.Assert(t, deleted["/cont1a/cont2a/leaf2c"])
+	assert.Assert(t, deleted["/cont1a/cont2a/leaf2g"])
+}
+
+// Test_doSingleSetValidateOnly shows how a change can be validated without being stored
+func Test_doSingleSetValidateOnly(t *testing.T) {
+	server, mocks, mgr := setUpForGetSetTests(t)
+	setUpChangesMock(mocks)
+	deletePaths, replacedPaths, updatedPaths := setUpPathsForGetSetTests()
+
+	pathElemsRefs, _ := utils.ParseGNMIElements([]string{"cont1a", "cont2a", "leaf2a"})
+	typedValue := gnmi.TypedValue_UintVal{UintVal: 11}
+	value := gnmi.TypedValue{Value: &typedValue}
+	updatePath := gnmi.Path{Elem: pathElemsRefs.Elem, Target: "Device1"}
+	updatedPaths = append(updatedPaths, &gnmi.Update{Path: &updatePath, Val: &value})
+
+	var setRequest = gnmi.SetRequest{
+		Delete:  deletePaths,
+		Replace: replacedPaths,
+		Update:  updatedPaths,
+		Extension: []*gnmi_ext.Extension{
+			{
+				Ext: &gnmi_ext.Extension_RegisteredExt{
+					RegisteredExt: &gnmi_ext.RegisteredExtension{
+						Id:  GnmiExtensionNetwkChangeID,
+						Msg: []byte("ValidateOnlyChange"),
+					},
+				},
+			},
+			{
+				Ext: &gnmi_ext.Extension_RegisteredExt{
+					RegisteredExt: &gnmi_ext.RegisteredExtension{
+						Id:  GnmiExtensionValidateOnly,
+						Msg: []byte("true"),
+					},
+				},
+			},
+		},
+	}
+
+	setResponse, setError := server.Set(context.Background(), &setRequest)
+	assert.NilError(t, setError, "Unexpected error from gnmi Set")
+	assert.Assert(t, setResponse != nil, "Expected setResponse to have a value")
+	assert.Equal(t, len(setResponse.Response), 1)
+	assert.Equal(t, setResponse.Response[0].Op.String(), gnmi.UpdateResult_UPDATE.String())
+
+	assert.Equal(t, len(setResponse.Extension), 2)
+	extensionChgID := setResponse.Extension[0].GetRegisteredExt()
+	assert.Equal(t, int(extensionChgID.Id), GnmiExtensionNetwkChangeID)
+	assert.Equal(t, string(extensionChgID.Msg), "ValidateOnlyChange")
+
+	extensionValidate := setResponse.Extension[1].GetRegisteredExt()
+	assert.Equal(t, int(extensionValidate.Id), GnmiExtensionValidateOnly)
+	validated := &networkchange.NetworkChange{}
+	assert.NilError(t, proto.Unmarshal(extensionValidate.Msg, validated))
+	assert.Equal(t, string(validated.ID), "ValidateOnlyChange")
+	assert.Equal(t, len(validated.Changes), 1)
+	assert.Equal(t, string(validated.Changes[0].DeviceID), "Device1")
+	assert.Equal(t, len(validated.Changes[0].Values), 1)
+	assert.Equal(t, validated.Changes[0].Values[0].Path, "/cont1a/cont2a/leaf2a")
+
+	// Check the network change was not stored
+	nwChange, err := mgr.NetworkChangesStore.Get("ValidateOnlyChange")
+	assert.NilError(t, err)
+	assert.Assert(t, nwChange == nil)
+}
+
+// Test_doSingleSetValidateOnlyBadFlag shows that the validate only extension must be a boolean
+func Test_doSingleSetValidateOnlyBadFlag(t *testing.T) {
+	server, mocks, _ := setUpForGetSetTests(t)
+	setUpChangesMock(mocks)
+	deletePaths, replacedPaths, updatedPaths := setUpPathsForGetSetTests()
+
+	pathElemsRefs, _ := utils.ParseGNMIElements([]string{"cont1a", "cont2a", "leaf2a"})
+	typedValue := gnmi.TypedValue_UintVal{UintVal: 11}
+	value := gnmi.TypedValue{Value: &typedValue}
+	updatePath := gnmi.Path{Elem: pathElemsRefs.Elem, Target: "Device1"}
+	updatedPaths = append(updatedPaths, &gnmi.Update{Path: &updatePath, Val: &value})
+
+	var setRequest = gnmi.SetRequest{
+		Delete:  deletePaths,
+		Replace: replacedPaths,
+		Update:  updatedPaths,
+		Extension: []*gnmi_ext.Extension{{
+			Ext: &gnmi_ext.Extension_RegisteredExt{
+				RegisteredExt: &gnmi_ext.RegisteredExtension{
+					Id:  GnmiExtensionValidateOnly,
+					Msg: []byte("maybe"),
+				},
+			},
+		}},
+	}
+
+	setResponse, setError := server.Set(context.Background(), &setRequest)
+	assert.Equal(t, status.Code(setError), codes.InvalidArgument)
+	assert.Assert(t, setResponse == nil)
+}
+
+// Test_doSingleSetNotBefore shows how a change can be scheduled to be applied later
+func Test_doSingleSetNotBefore(t *testing.T) {
+	server, mocks, mgr := setUpForGetSetTests(t)
+	setUpChangesMock(mocks)
+	deletePaths, replacedPaths, updatedPaths := setUpPathsForGetSetTests()
+
+	pathElemsRefs, _ := utils.ParseGNMIElements([]string{"cont1a", "cont2a", "leaf2a"})
+	typedValue := gnmi.TypedValue_UintVal{UintVal: 11}
+	value := gnmi.TypedValue{Value: &typedValue}
+	updatePath := gnmi.Path{Elem: pathElemsRefs.Elem, Target: "Device1"}
+	updatedPaths = append(updatedPaths, &gnmi.Update{Path: &updatePath, Val: &value})
+
+	notBefore := time.Now().Add(8 * time.Hour).Truncate(time.Second)
+	var setRequest = gnmi.SetRequest{
+		Delete:  deletePaths,
+		Replace: replacedPaths,
+		Update:  updatedPaths,
+		Extension: []*gnmi_ext.Extension{
+			{
+				Ext: &gnmi_ext.Extension_RegisteredExt{
+					RegisteredExt: &gnmi_ext.RegisteredExtension{
+						Id:  GnmiExtensionNetwkChangeID,
+						Msg: []byte("ScheduledChange"),
+					},
+				},
+			},
+			{
+				Ext: &gnmi_ext.Extension_RegisteredExt{
+					RegisteredExt: &gnmi_ext.RegisteredExtension{
+						Id:  GnmiExtensionNotBefore,
+						Msg: []byte(notBefore.Format(time.RFC3339)),
+					},
+				},
+			},
+		},
+	}
+
+	setResponse, setError := server.Set(context.Background(), &setRequest)
+	assert.NilError(t, setError, "Unexpected error from gnmi Set")
+	assert.Assert(t, setResponse != nil, "Expected setResponse to have a value")
+
+	// Check the network change was stored with its schedule
+	nwChange, err := mgr.NetworkChangesStore.Get("ScheduledChange")
+	assert.NilError(t, err)
+	assert.Assert(t, nwChange != nil)
+	metadata, err := mgr.NetworkMetadataStore.Get("ScheduledChange")
+	assert.NilError(t, err)
+	assert.Assert(t, metadata != nil)
+	assert.Assert(t, metadata.NotBefore != nil)
+	assert.Assert(t, metadata.NotBefore.Equal(notBefore))
+}
+
+// Test_doSingleSetNotBeforeBadTime shows that the not before extension must be an RFC 3339 timestamp
+func Test_doSingleSetNotBeforeBadTime(t *testing.T) {
+	server, mocks, _ := setUpForGetSetTests(t)
+	setUpChangesMock(mocks)
+	deletePaths, replacedPaths, updatedPaths := setUpPathsForGetSetTests()
+
+	var setRequest = gnmi.SetRequest{
+		Delete:  deletePaths,
+		Replace: replacedPaths,
+		Update:  updatedPaths,
+		Extension: []*gnmi_ext.Extension{{
+			Ext: &gnmi_ext.Extension_RegisteredExt{
+				RegisteredExt: &gnmi_ext.RegisteredExtension{
+					Id:  GnmiExtensionNotBefore,
+					Msg: []byte("tonight"),
+				},
+			},
+		}},
+	}
+
+	setResponse, setError := server.Set(context.Background(), &setRequest)
+	assert.Equal(t, status.Code(setError), codes.InvalidArgument)
+	assert.Assert(t, setResponse == nil)
+}
+
+// Test_extractExtensionsConfirmTimeout shows that the confirm timeout extension is a positive number of seconds
+func Test_extractExtensionsConfirmTimeout(t *testing.T) {
+	newRequest := func(msg string) *gnmi.SetRequest {
+		return &gnmi.SetRequest{
+			Extension: []*gnmi_ext.Extension{{
+				Ext: &gnmi_ext.Extension_RegisteredExt{
+					RegisteredExt: &gnmi_ext.RegisteredExtension{
+						Id:  GnmiExtensionConfirmTimeout,
+						Msg: []byte(msg),
+					},
+				},
+			}},
+		}
+	}
+
+	setExts, err := extractExtensions(newRequest("300"))
+	assert.NilError(t, err)
+	assert.Assert(t, setExts.confirmTimeout != nil)
+	assert.Equal(t, *setExts.confirmTimeout, 5*time.Minute)
+	assert.Equal(t, len(setExts.networkChangeOptions("")), 2)
+
+	for _, msg := range []string{"0", "-10", "5m", ""} {
+		_, err = extractExtensions(newRequest(msg))
+		assert.Equal(t, status.Code(err), codes.InvalidArgument, msg)
+	}
+}
+
+// Test_doSingleSetWaitForApply shows that a Set can wait for the change to be applied and give the device statuses
+func Test_doSingleSetWaitForApply(t *testing.T) {
+	server, mocks, _ := setUpForGetSetTests(t)
+	setUpChangesMock(mocks)
+	deletePaths, replacedPaths, updatedPaths := setUpPathsForGetSetTests()
+
+	pathElemsRefs, _ := utils.ParseGNMIElements([]string{"cont1a", "cont2a", "leaf2a"})
+	typedValue := gnmi.TypedValue_UintVal{UintVal: 11}
+	value := gnmi.TypedValue{Value: &typedValue}
+	updatePath := gnmi.Path{Elem: pathElemsRefs.Elem, Target: "Device1"}
+	updatedPaths = append(updatedPaths, &gnmi.Update{Path: &updatePath, Val: &value})
+
+	var setRequest = gnmi.SetRequest{
+		Delete:  deletePaths,
+		Replace: replacedPaths,
+		Update:  updatedPaths,
+		Extension: []*gnmi_ext.Extension{
+			{
+				Ext: &gnmi_ext.Extension_RegisteredExt{
+					RegisteredExt: &gnmi_ext.RegisteredExtension{
+						Id:  GnmiExtensionNetwkChangeID,
+						Msg: []byte("SyncChange"),
+					},
+				},
+			},
+			{
+				Ext: &gnmi_ext.Extension_RegisteredExt{
+					RegisteredExt: &gnmi_ext.RegisteredExtension{
+						Id:  GnmiExtensionWaitForApply,
+						Msg: []byte("10"),
+					},
+				},
+			},
+		},
+	}
+
+	setResponse, setError := server.Set(context.Background(), &setRequest)
+	assert.NilError(t, setError, "Unexpected error from gnmi Set")
+	assert.Assert(t, setResponse != nil, "Expected setResponse to have a value")
+
+	// Check the status of the device is given after the network change ID
+	assert.Equal(t, len(setResponse.Extension), 2)
+	extStatus := setResponse.Extension[1].GetRegisteredExt()
+	assert.Equal(t, extStatus.Id.String(), strconv.Itoa(GnmiExtensionWaitForApply))
+	deviceChange := &devicechange.DeviceChange{}
+	err := proto.Unmarshal(extStatus.Msg, deviceChange)
+	assert.NilError(t, err)
+	assert.Equal(t, deviceChange.Change.DeviceID, devicetype.ID(device1))
+	assert.Equal(t, string(deviceChange.NetworkChange.ID), "SyncChange")
+	assert.Equal(t, deviceChange.Status.State, changetypes.State_COMPLETE)
+}
+
+// Test_getFailureMessage shows that the reason a change failed is given along with that of each failed device
+func Test_getFailureMessage(t *testing.T) {
+	change := &networkchange.NetworkChange{
+		ID: "FailedChange",
+		Status: changetypes.Status{
+			State:   changetypes.State_FAILED,
+			Message: "not applied",
+		},
+	}
+	deviceChanges := []*devicechange.DeviceChange{
+		{
+			Change: &devicechange.Change{DeviceID: "device-1"},
+			Status: changetypes.Status{State: changetypes.State_FAILED, Message: "write failed"},
+		},
+		{
+			Change: &devicechange.Change{DeviceID: "device-2"},
+			Status: changetypes.Status{State: changetypes.State_COMPLETE},
+		},
+	}
+	assert.Equal(t, getFailureMessage(change, deviceChanges),
+		"change FailedChange failed: not applied; device-1: write failed")
+}
+
+// Test_parseWaitTimeout shows that the wait timeout is a number of seconds up to the maximum
+func Test_parseWaitTimeout(t *testing.T) {
+	timeout, err := parseWaitTimeout([]byte{})
+	assert.NilError(t, err)
+	assert.Equal(t, timeout, defaultWaitForApplyTimeout)
+
+	timeout, err = parseWaitTimeout([]byte("45"))
+	assert.NilError(t, err)
+	assert.Equal(t, timeout, 45*time.Second)
+
+	for _, msg := range []string{"0", "301", "-1", "1m"} {
+		_, err = parseWaitTimeout([]byte(msg))
+		assert.Assert(t, err != nil, msg)
+	}
+}
+
+// Test_parseApplyPolicy shows that the apply policy is atomic, best-effort or a quorum of the devices
+func Test_parseApplyPolicy(t *testing.T) {
+	policy, err := parseApplyPolicy([]byte("atomic"))
+	assert.NilError(t, err)
+	assert.Equal(t, policy.policy, metadata.ApplyPolicyAtomic)
+
+	policy, err = parseApplyPolicy([]byte("best-effort"))
+	assert.NilError(t, err)
+	assert.Equal(t, policy.policy, metadata.ApplyPolicyBestEffort)
+
+	policy, err = parseApplyPolicy([]byte("quorum:75"))
+	assert.NilError(t, err)
+	assert.Equal(t, policy.policy, metadata.ApplyPolicyQuorum)
+	assert.Equal(t, policy.quorum, uint32(75))
+
+	// The policy is kept in the metadata of the change
+	changeMetadata := &metadata.Metadata{}
+	for _, opt := range (&setExtensions{applyPolicy: policy}).networkChangeOptions("client") {
+		opt(changeMetadata)
+	}
+	assert.Equal(t, changeMetadata.ApplyPolicy, metadata.ApplyPolicyQuorum)
+	assert.Equal(t, changeMetadata.Quorum, uint32(75))
+
+	for _, msg := range []string{"", "all", "quorum", "quorum:0", "quorum:101", "quorum:half"} {
+		_, err = parseApplyPolicy([]byte(msg))
+		assert.Assert(t, err != nil, msg)
+	}
+}
+
+// Test_doSingleSetBaseIndex shows that a Set based on a network change index is aborted if a later change has
+// touched the same paths
+func Test_doSingleSetBaseIndex(t *testing.T) {
+	server, mocks, _ := setUpForGetSetTests(t)
+	setUpChangesMock(mocks)
+
+	configValue, _ := devicechange.NewChangeValue("/cont1a/cont2a/leaf2a", devicechange.NewTypedValueUint(12, 8), false)
+	laterChange := &networkchange.NetworkChange{
+		ID:    "LaterChange",
+		Index: 5,
+		Changes: []*devicechange.Change{{
+			DeviceID:      device1,
+			DeviceVersion: deviceVersion1,
+			Values:        []*devicechange.ChangeValue{configValue},
+		}},
+	}
+	mocks.MockStores.NetworkChangesStore.EXPECT().GetNext(networkchange.Index(3)).Return(laterChange, nil)
+	mocks.MockStores.NetworkChangesStore.EXPECT().GetNext(networkchange.Index(5)).Return(nil, nil).Times(2)
+
+	newRequest := func(baseIndex string) *gnmi.SetRequest {
+		deletePaths, replacedPaths, updatedPaths := setUpPathsForGetSetTests()
+		pathElemsRefs, _ := utils.ParseGNMIElements([]string{"cont1a", "cont2a", "leaf2a"})
+		value := gnmi.TypedValue{Value: &gnmi.TypedValue_UintVal{UintVal: 11}}
+		updatePath := gnmi.Path{Elem: pathElemsRefs.Elem, Target: "Device1"}
+		updatedPaths = append(updatedPaths, &gnmi.Update{Path: &updatePath, Val: &value})
+		return &gnmi.SetRequest{
+			Delete:  deletePaths,
+			Replace: replacedPaths,
+			Update:  updatedPaths,
+			Extension: []*gnmi_ext.Extension{{
+				Ext: &gnmi_ext.Extension_RegisteredExt{
+					RegisteredExt: &gnmi_ext.RegisteredExtension{
+						Id:  GnmiExtensionBaseIndex,
+						Msg: []byte(baseIndex),
+					},
+				},
+			}},
+		}
+	}
+
+	// The change at index 5 sets the same leaf, so a Set based on index 3 is aborted
+	setResponse, setError := server.Set(context.Background(), newRequest("3"))
+	assert.Equal(t, status.Code(setError), codes.Aborted)
+	assert.ErrorContains(t, setError, "LaterChange (Device1:/cont1a/cont2a/leaf2a)")
+	assert.Assert(t, setResponse == nil)
+
+	// Based on index 5 there is no conflict, and the index of the new change is given back
+	setResponse, setError = server.Set(context.Background(), newRequest("5"))
+	assert.NilError(t, setError, "Unexpected error from gnmi Set")
+	assert.Equal(t, len(setResponse.Extension), 2)
+	extBaseIndex := setResponse.Extension[1].GetRegisteredExt()
+	assert.Equal(t, extBaseIndex.Id.String(), strconv.Itoa(GnmiExtensionBaseIndex))
+
+	// The index must be a number
+	_, setError = server.Set(context.Background(), newRequest("latest"))
+	assert.Equal(t, status.Code(setError), codes.InvalidArgument)
+}
+
+// newClientContext returns the context of a call from a client authenticated by a verified TLS certificate
+func newClientContext(name string) context.Context {
+	tlsInfo := credentials.TLSInfo{
+		State: tls.ConnectionState{
+			VerifiedChains: [][]*x509.Certificate{{{Subject: pkix.Name{CommonName: name}}}},
+		},
+	}
+	return peer.NewContext(context.Background(), &peer.Peer{AuthInfo: tlsInfo})
+}
+
+// Test_doSingleSetLockedDevice shows that a Set to a device locked by another client is refused
+func Test_doSingleSetLockedDevice(t *testing.T) {
+	server, mocks, mgr := setUpForGetSetTests(t)
+	setUpChangesMock(mocks)
+	deletePaths, replacedPaths, updatedPaths := setUpPathsForGetSetTests()
+
+	deviceLockStore := mockstore.NewMockDeviceLockStore(gomock.NewController(t))
+	deviceLockStore.EXPECT().Get(devicetype.ID(device1)).Return(&lock.DeviceLock{
+		DeviceID: device1,
+		Owner:    "client-1",
+		Expires:  time.Now().Add(time.Minute),
+	}, nil).AnyTimes()
+	mgr.DeviceLockStore = deviceLockStore
+
+	pathElemsRefs, _ := utils.ParseGNMIElements([]string{"cont1a", "cont2a", "leaf2a"})
+	typedValue := gnmi.TypedValue_UintVal{UintVal: 11}
+	value := gnmi.TypedValue{Value: &typedValue}
+	updatePath := gnmi.Path{Elem: pathElemsRefs.Elem, Target: device1}
+	updatedPaths = append(updatedPaths, &gnmi.Update{Path: &updatePath, Val: &value})
+
+	var setRequest = gnmi.SetRequest{
+		Delete:  deletePaths,
+		Replace: replacedPaths,
+		Update:  updatedPaths,
+		Extension: []*gnmi_ext.Extension{{
+			Ext: &gnmi_ext.Extension_RegisteredExt{
+				RegisteredExt: &gnmi_ext.RegisteredExtension{
+					Id:  GnmiExtensionNetwkChangeID,
+					Msg: []byte("LockedChange"),
+				},
+			},
+		}},
+	}
+
+	// Another client's Set is refused
+	otherClient := newClientContext("client-2")
+	setResponse, setError := server.Set(otherClient, &setRequest)
+	assert.Equal(t, status.Code(setError), codes.FailedPrecondition)
+	assert.ErrorContains(t, setError, "Device1 by client-1")
+	assert.Assert(t, setResponse == nil)
+
+	// The Set of the client holding the lock is accepted, and the change records the client
+	lockOwner := newClientContext("client-1")
+	setResponse, setError = server.Set(lockOwner, &setRequest)
+	assert.NilError(t, setError, "Unexpected error from gnmi Set")
+	assert.Assert(t, setResponse != nil)
+	changeMetadata, err := mgr.NetworkMetadataStore.Get("LockedChange")
+	assert.NilError(t, err)
+	assert.Equal(t, changeMetadata.Username, "client-1")
+}
+
+// Test_doSingleSetMetadata shows that the description and labels extensions are stored with the change
+func Test_doSingleSetMetadata(t *testing.T) {
+	server, mocks, mgr := setUpForGetSetTests(t)
+	setUpChangesMock(mocks)
+	deletePaths, replacedPaths, updatedPaths := setUpPathsForGetSetTests()
+
+	pathElemsRefs, _ := utils.ParseGNMIElements([]string{"cont1a", "cont2a", "leaf2a"})
+	typedValue := gnmi.TypedValue_UintVal{UintVal: 11}
+	value := gnmi.TypedValue{Value: &typedValue}
+	updatePath := gnmi.Path{Elem: pathElemsRefs.Elem, Target: device1}
+	updatedPaths = append(updatedPaths, &gnmi.Update{Path: &updatePath, Val: &value})
+
+	newExtension := func(id gnmi_ext.ExtensionID, msg string) *gnmi_ext.Extension {
+		return &gnmi_ext.Extension{
+			Ext: &gnmi_ext.Extension_RegisteredExt{
+				RegisteredExt: &gnmi_ext.RegisteredExtension{
+					Id:  id,
+					Msg: []byte(msg),
+				},
+			},
+		}
+	}
+
+	var setRequest = gnmi.SetRequest{
+		Delete:  deletePaths,
+		Replace: replacedPaths,
+		Update:  updatedPaths,
+		Extension: []*gnmi_ext.Extension{
+			newExtension(GnmiExtensionNetwkChangeID, "DescribedChange"),
+			newExtension(GnmiExtensionDescription, "Raise leaf2a for the maintenance"),
+			newExtension(GnmiExtensionLabels, "ticket=OPS-123, team=netops"),
+			newExtension(GnmiExtensionLabels, "window=night"),
+		},
+	}
+
+	client := newClientContext("client-3")
+	setResponse, setError := server.Set(client, &setRequest)
+	assert.NilError(t, setError, "Unexpected error from gnmi Set")
+	assert.Assert(t, setResponse != nil)
+
+	changeMetadata, err := mgr.NetworkMetadataStore.Get("DescribedChange")
+	assert.NilError(t, err)
+	assert.Equal(t, changeMetadata.Username, "client-3")
+	assert.Equal(t, changeMetadata.Description, "Raise leaf2a for the maintenance")
+	assert.DeepEqual(t, changeMetadata.Labels, map[string]string{"ticket": "OPS-123", "team": "netops", "window": "night"})
+
+	setRequest.Extension = []*gnmi_ext.Extension{newExtension(GnmiExtensionLabels, "ticket")}
+	setResponse, setError = server.Set(client, &setRequest)
+	assert.Equal(t, status.Code(setError), codes.InvalidArgument)
+	assert.Assert(t, setResponse == nil)
+}
+
+// Test_doSingleSetAudited shows that each Set is recorded in the audit log, whether it succeeds or not
+func Test_doSingleSetAudited(t *testing.T) {
+	server, mocks, mgr := setUpForGetSetTests(t)
+	setUpChangesMock(mocks)
+	dir, err := ioutil.TempDir("", "audit")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+	mgr.AuditLog, err = audit.NewFileLog(filepath.Join(dir, "audit.log"), []byte("audit-key"), false)
+	assert.NilError(t, err)
+	defer mgr.AuditLog.Close()
+
+	pathElemsRefs, _ := utils.ParseGNMIElements([]string{"cont1a", "cont2a", "leaf2a"})
+	updatePath := gnmi.Path{Elem: pathElemsRefs.Elem, Target: "Device1"}
+	value := gnmi.TypedValue{Value: &gnmi.TypedValue_UintVal{UintVal: 11}}
+	var setRequest = gnmi.SetRequest{
+		Update: []*gnmi.Update{{Path: &updatePath, Val: &value}},
+		Extension: []*gnmi_ext.Extension{{
+			Ext: &gnmi_ext.Extension_RegisteredExt{
+				RegisteredExt: &gnmi_ext.RegisteredExtension{
+					Id:  GnmiExtensionNetwkChangeID,
+					Msg: []byte("AuditedChange"),
+				},
+			},
+		}},
+	}
+
+	client := newClientContext("client-4")
+	_, setError := server.Set(client, &setRequest)
+	assert.NilError(t, setError)
+
+	setRequest.Extension = append(setRequest.Extension, &gnmi_ext.Extension{
+		Ext: &gnmi_ext.Extension_RegisteredExt{
+			RegisteredExt: &gnmi_ext.RegisteredExtension{
+				Id:  GnmiExtensionLabels,
+				Msg: []byte("ticket"),
+			},
+		},
+	})
+	_, setError = server.Set(client, &setRequest)
+	assert.Equal(t, status.Code(setError), codes.InvalidArgument)
+
+	records := make([]*audit.Record, 0)
+	err = mgr.AuditLog.Scan(time.Time{}, func(record *audit.Record) error {
+		records = append(records, record)
+		return nil
+	})
+	assert.NilError(t, err)
+	assert.Equal(t, len(records), 2)
+	assert.Equal(t, records[0].Operation, audit.OperationSet)
+	assert.Equal(t, records[0].Client, "client-4")
+	assert.DeepEqual(t, records[0].Paths, []string{"Device1:/cont1a/cont2a/leaf2a"})
+	assert.Equal(t, records[0].NetworkChangeID, "AuditedChange")
+	assert.Equal(t, records[0].Outcome, audit.OutcomeSuccess)
+	assert.DeepEqual(t, records[1].Paths, []string{"Device1:/cont1a/cont2a/leaf2a"})
+	assert.Equal(t, records[1].NetworkChangeID, "")
+	assert.Equal(t, records[1].Outcome, audit.OutcomeFailure)
+	assert.Assert(t, records[1].Error != "")
+}
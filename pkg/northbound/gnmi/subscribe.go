@@ -15,6 +15,8 @@
 package gnmi
 
 import (
+	"bytes"
+	"context"
 	"crypto/sha1"
 	"fmt"
 	changetypes "github.com/onosproject/onos-api/go/onos/config/change"
@@ -26,16 +28,19 @@ import (
 	"github.com/onosproject/onos-config/pkg/store"
 	streams "github.com/onosproject/onos-config/pkg/store/stream"
 	"github.com/onosproject/onos-config/pkg/utils"
-	"github.com/onosproject/onos-config/pkg/utils/values"
 	"github.com/openconfig/gnmi/proto/gnmi"
 	"github.com/openconfig/gnmi/proto/gnmi_ext"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 	"io"
 	"regexp"
+	"sync"
 	"time"
 )
 
+// minSampleInterval is the lowest sample interval supported for SAMPLE subscriptions
+const minSampleInterval = time.Second
+
 //internal struct to handle return of methods
 type result struct {
 	success bool
@@ -60,27 +65,56 @@ func (s *Server) Subscribe(stream gnmi.GNMI_SubscribeServer) error {
 		log.Warn("Subscription present: ", err)
 		return status.Error(codes.AlreadyExists, err.Error())
 	}
-	resChan := make(chan result)
+	//The responses are sent from several goroutines, which gRPC does not allow on a single stream
+	stream = &subscribeStream{GNMI_SubscribeServer: stream}
+	//Only the first result ends the subscription, any later one is dropped once the stream is done
+	resChan := make(chan result, 1)
 	//Handles each subscribe request coming into the server, blocks until a new request or an error comes in
 	go s.listenOnChannel(stream, mgr, hash, resChan, subscribe, opStateChan)
 
 	res := <-resChan
 
 	if !res.success {
+		if code := status.Code(res.err); code == codes.PermissionDenied || code == codes.InvalidArgument {
+			return res.err
+		}
 		return status.Error(codes.Internal, res.err.Error())
 	}
 	return nil
 }
 
+// subscribeStream serializes the responses sent on a Subscribe stream
+type subscribeStream struct {
+	gnmi.GNMI_SubscribeServer
+	mu sync.Mutex
+}
+
+func (s *subscribeStream) Send(response *gnmi.SubscribeResponse) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.GNMI_SubscribeServer.Send(response)
+}
+
+// sendResult passes the result of a subscription on to Subscribe, unless the subscription has already ended
+func sendResult(ctx context.Context, resChan chan<- result, res result) {
+	select {
+	case resChan <- res:
+	case <-ctx.Done():
+	}
+}
+
 func (s *Server) listenOnChannel(stream gnmi.GNMI_SubscribeServer, mgr *manager.Manager, hash string,
 	resChan chan result, subscribe *gnmi.SubscriptionList, opStateChan chan events.OperationalStateEvent) {
+	//Closed when the subscription ends, to stop any samplers
+	done := make(chan struct{})
+	defer close(done)
 	for {
 		in, err := stream.Recv()
 		if err == io.EOF {
 			log.Info("Subscription Terminated EOF")
 			//Ignoring Errors during removal
 			mgr.Dispatcher.UnregisterOperationalState(hash)
-			resChan <- result{success: true, err: nil}
+			sendResult(stream.Context(), resChan, result{success: true, err: nil})
 			break
 		}
 
@@ -89,12 +123,12 @@ func (s *Server) listenOnChannel(stream gnmi.GNMI_SubscribeServer, mgr *manager.
 			if ok && code.Code() == codes.Canceled {
 				log.Info("Subscription Terminated, Canceled")
 				mgr.Dispatcher.UnregisterOperationalState(hash)
-				resChan <- result{success: true, err: nil}
+				sendResult(stream.Context(), resChan, result{success: true, err: nil})
 			} else {
 				log.Error("Error in subscription ", err)
 				//Ignoring Errors during removal
 				mgr.Dispatcher.UnregisterOperationalState(hash)
-				resChan <- result{success: false, err: err}
+				sendResult(stream.Context(), resChan, result{success: false, err: err})
 			}
 			break
 		}
@@ -111,7 +145,20 @@ func (s *Server) listenOnChannel(stream gnmi.GNMI_SubscribeServer, mgr *manager.
 		//If there are no paths in the request such request is ignored
 		if subscribe.Subscription == nil {
 			log.Error("No subscription paths, ignoring request ", in)
-			resChan <- result{success: false, err: fmt.Errorf("no subscription paths in request")}
+			sendResult(stream.Context(), resChan, result{success: false, err: fmt.Errorf("no subscription paths in request")})
+			break
+		}
+
+		//Each of the subscription paths must be allowed for the client
+		if err := s.authorizeSubscription(stream.Context(), subscribe); err != nil {
+			mgr.Dispatcher.UnregisterOperationalState(hash)
+			sendResult(stream.Context(), resChan, result{success: false, err: err})
+			break
+		}
+
+		if err := checkEncoding(subscribe.Encoding); err != nil {
+			mgr.Dispatcher.UnregisterOperationalState(hash)
+			sendResult(stream.Context(), resChan, result{success: false, err: err})
 			break
 		}
 
@@ -119,89 +166,334 @@ func (s *Server) listenOnChannel(stream gnmi.GNMI_SubscribeServer, mgr *manager.
 		version, err := extractSubscribeVersion(in)
 		if mode != gnmi.SubscriptionList_STREAM {
 			if err != nil {
-				resChan <- result{success: false, err: err}
+				sendResult(stream.Context(), resChan, result{success: false, err: err})
 			} else {
 				go s.collector(mgr, version, stream, subscribe, resChan, mode)
 			}
 		} else {
+			if err != nil {
+				sendResult(stream.Context(), resChan, result{success: false, err: err})
+				break
+			}
+			//SAMPLE subscriptions and ON_CHANGE subscriptions with a heartbeat are sent on a timer
+			samplers, onChangeSubs, err := s.buildSamplers(mgr, stream, subscribe, version)
+			if err != nil {
+				sendResult(stream.Context(), resChan, result{success: false, err: err})
+				break
+			}
 
-			subs := subscribe.Subscription
 			//FAST way to identify if target and subscription is present
 			subsStr := make([]*regexp.Regexp, 0)
 			targets := make(map[string]struct{})
-			for _, sub := range subs {
+			for _, sub := range onChangeSubs {
 				subscriptionPathStr := utils.StrPath(sub.Path)
 				subsStr = append(subsStr, utils.MatchWildcardRegexp(subscriptionPathStr))
 				targets[sub.Path.Target] = struct{}{}
 			}
-			//Each subscription request spawns a go routing listening for related events for the target and the paths
-			go listenForUpdates(stream, mgr, targets, version, subsStr, resChan)
-			go listenForOpStateUpdates(opStateChan, stream, targets, subsStr, resChan)
+			//The changes are watched before the current values are collected, so that no change
+			//made in between is lost. Each target is listened to by its own go routine
+			if err := listenForUpdates(stream, mgr, targets, version, subsStr, subscribe.Encoding, resChan); err != nil {
+				sendResult(stream.Context(), resChan, result{success: false, err: err})
+				break
+			}
+			go listenForOpStateUpdates(opStateChan, stream, targets, subsStr, subscribe.Encoding, resChan)
+
+			//Samplers that suppress redundant values start from the values in the initial updates
+			for _, smp := range samplers {
+				if smp.suppressRedundant {
+					if err := smp.seed(time.Now()); err != nil {
+						log.Warn("Error seeding sampler ", err)
+					}
+				}
+			}
+
+			//The current values are sent first, unless only updates are asked for, followed by a single sync
+			if !subscribe.UpdatesOnly {
+				if err := s.sendSubscriptionUpdates(mgr, version, stream, subscribe); err != nil {
+					sendResult(stream.Context(), resChan, result{success: false, err: err})
+					break
+				}
+			}
+			if err := sendResponse(buildSyncResponse(), stream); err != nil {
+				log.Error("Error sending sync response ", err)
+				sendResult(stream.Context(), resChan, result{success: false, err: err})
+				break
+			}
+
+			for _, smp := range samplers {
+				go smp.run(done, resChan)
+			}
 		}
 	}
 }
 
-func (s *Server) collector(mgr *manager.Manager, version devicetype.Version, stream gnmi.GNMI_SubscribeServer, request *gnmi.SubscriptionList, resChan chan result, mode gnmi.SubscriptionList_Mode) {
-	for _, sub := range request.Subscription {
-		_, version, err := mgr.CheckCacheForDevice(devicetype.ID(sub.GetPath().GetTarget()), devicetype.Type(""), version)
-		if err != nil {
-			log.Error("Error while collecting data from device cache ", err)
-			resChan <- result{success: false, err: err}
+// buildSamplers creates a sampler for each SAMPLE subscription and for each ON_CHANGE or TARGET_DEFINED
+// subscription that asks for a heartbeat. The subscriptions that are notified on change are returned too.
+// TARGET_DEFINED is handled as ON_CHANGE, since onos-config is notified of changes to both config and state.
+func (s *Server) buildSamplers(mgr *manager.Manager, stream gnmi.GNMI_SubscribeServer, subscribe *gnmi.SubscriptionList,
+	version devicetype.Version) ([]*sampler, []*gnmi.Subscription, error) {
+	samplers := make([]*sampler, 0)
+	onChangeSubs := make([]*gnmi.Subscription, 0)
+	for _, sub := range subscribe.Subscription {
+		switch sub.Mode {
+		case gnmi.SubscriptionMode_SAMPLE:
+			interval := time.Duration(sub.SampleInterval)
+			if interval == 0 {
+				interval = minSampleInterval
+			} else if interval < minSampleInterval {
+				return nil, nil, status.Errorf(codes.InvalidArgument,
+					"sample interval %v for %s is less than the minimum %v", interval, utils.StrPath(sub.Path), minSampleInterval)
+			}
+			samplers = append(samplers, &sampler{
+				server:            s,
+				mgr:               mgr,
+				stream:            stream,
+				prefix:            subscribe.Prefix,
+				sub:               sub,
+				encoding:          subscribe.Encoding,
+				version:           version,
+				interval:          interval,
+				heartbeatInterval: time.Duration(sub.HeartbeatInterval),
+				suppressRedundant: sub.SuppressRedundant,
+			})
+		case gnmi.SubscriptionMode_ON_CHANGE, gnmi.SubscriptionMode_TARGET_DEFINED:
+			onChangeSubs = append(onChangeSubs, sub)
+			if sub.HeartbeatInterval > 0 {
+				samplers = append(samplers, &sampler{
+					server:   s,
+					mgr:      mgr,
+					stream:   stream,
+					prefix:   subscribe.Prefix,
+					sub:      sub,
+					encoding: subscribe.Encoding,
+					version:  version,
+					interval: time.Duration(sub.HeartbeatInterval),
+				})
+			}
+		default:
+			return nil, nil, status.Errorf(codes.InvalidArgument, "unsupported subscription mode %v", sub.Mode)
+		}
+	}
+	return samplers, onChangeSubs, nil
+}
+
+// sampler periodically sends the config and state values of a single subscription
+type sampler struct {
+	server            *Server
+	mgr               *manager.Manager
+	stream            gnmi.GNMI_SubscribeServer
+	prefix            *gnmi.Path
+	sub               *gnmi.Subscription
+	encoding          gnmi.Encoding
+	version           devicetype.Version
+	interval          time.Duration
+	heartbeatInterval time.Duration
+	suppressRedundant bool
+	lastSent          map[string]*devicechange.TypedValue
+	lastHeartbeat     time.Time
+}
+
+// run samples the subscription on each interval until done is closed. The values at the start
+// of the subscription are sent with the initial updates, so the first sample is after one interval
+func (smp *sampler) run(done <-chan struct{}, resChan chan result) {
+	ticker := time.NewTicker(smp.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			if err := smp.sample(now); err != nil {
+				log.Error("Error sampling subscription ", err)
+				sendResult(smp.stream.Context(), resChan, result{success: false, err: err})
+				return
+			}
+		case <-done:
+			return
+		}
+	}
+}
+
+// values returns the current config and state values of the subscription
+func (smp *sampler) values() ([]*devicechange.PathValue, string, error) {
+	target := smp.sub.GetPath().GetTarget()
+	if target == "" {
+		target = smp.prefix.GetTarget()
+	}
+	_, version, err := smp.mgr.CheckCacheForDevice(devicetype.ID(target), devicetype.Type(""), smp.version)
+	if err != nil {
+		return nil, target, err
+	}
+
+	pathAsString := utils.StrPath(smp.sub.GetPath())
+	if smp.prefix != nil && smp.prefix.Elem != nil {
+		pathAsString = utils.StrPath(smp.prefix) + pathAsString
+	}
+
+	smp.server.mu.RLock()
+	revision := smp.server.lastWrite
+	smp.server.mu.RUnlock()
+
+	configValues, err := smp.mgr.GetTargetConfig(devicetype.ID(target), version, pathAsString, revision)
+	if err != nil {
+		return nil, target, err
+	}
+	return append(configValues, smp.mgr.GetTargetState(target, pathAsString)...), target, nil
+}
+
+// seed records the values at the start of the subscription as already sent, since they are
+// part of the initial updates. The first heartbeat is then one heartbeat interval later
+func (smp *sampler) seed(now time.Time) error {
+	pathValues, _, err := smp.values()
+	if err != nil {
+		return err
+	}
+	smp.lastSent = make(map[string]*devicechange.TypedValue)
+	for _, pathValue := range pathValues {
+		smp.lastSent[pathValue.Path] = pathValue.Value
+	}
+	smp.lastHeartbeat = now
+	return nil
+}
+
+// sample sends the current values of the subscription. When suppressing redundant values only the values
+// that changed since the last sample are sent, unless the heartbeat interval has elapsed.
+// Values that have disappeared since the last sample are sent as deletes
+func (smp *sampler) sample(now time.Time) error {
+	pathValues, target, err := smp.values()
+	if err != nil {
+		return err
+	}
+
+	heartbeat := smp.heartbeatInterval > 0 && now.Sub(smp.lastHeartbeat) >= smp.heartbeatInterval
+	current := make(map[string]*devicechange.TypedValue)
+	for _, pathValue := range pathValues {
+		current[pathValue.Path] = pathValue.Value
+		if last, ok := smp.lastSent[pathValue.Path]; ok && smp.suppressRedundant && !heartbeat &&
+			isSameValue(last, pathValue.Value) {
+			continue
 		}
-		//We get the stated of the device, for each path we build an update and send it out.
-		update, err := s.getUpdate(version, request.Prefix, sub.Path)
+		pathGnmi, err := utils.ParseGNMIElements(utils.SplitPath(pathValue.Path))
 		if err != nil {
-			log.Error("Error while collecting data for subscribe once or poll ", err)
-			resChan <- result{success: false, err: err}
+			log.Warn("Error in parsing path ", err)
+			continue
 		}
-		response, errGet := buildUpdateResponse(update)
-		if errGet != nil {
-			log.Error("Error Retrieving Device", err)
-			resChan <- result{success: false, err: err}
+		if err := sendValueUpdate(pathGnmi, target, pathValue.Value, false, smp.encoding, smp.stream); err != nil {
+			return err
 		}
-		err = sendResponse(response, stream)
+	}
+	for path := range smp.lastSent {
+		if _, ok := current[path]; ok {
+			continue
+		}
+		pathGnmi, err := utils.ParseGNMIElements(utils.SplitPath(path))
 		if err != nil {
-			log.Error("Error sending response ", err)
-			resChan <- result{success: false, err: err}
+			log.Warn("Error in parsing path ", err)
+			continue
+		}
+		if err := sendValueUpdate(pathGnmi, target, nil, true, smp.encoding, smp.stream); err != nil {
+			return err
 		}
 	}
+	smp.lastSent = current
+	if heartbeat {
+		smp.lastHeartbeat = now
+	}
+	return nil
+}
+
+func isSameValue(value1 *devicechange.TypedValue, value2 *devicechange.TypedValue) bool {
+	return value1.Type == value2.Type && bytes.Equal(value1.Bytes, value2.Bytes)
+}
+
+func (s *Server) collector(mgr *manager.Manager, version devicetype.Version, stream gnmi.GNMI_SubscribeServer, request *gnmi.SubscriptionList, resChan chan result, mode gnmi.SubscriptionList_Mode) {
+	if err := s.sendSubscriptionUpdates(mgr, version, stream, request); err != nil {
+		sendResult(stream.Context(), resChan, result{success: false, err: err})
+		return
+	}
 	responseSync := buildSyncResponse()
 	err := sendResponse(responseSync, stream)
 	if err != nil {
 		log.Error("Error sending sync response ", err)
-		resChan <- result{success: false, err: err}
+		sendResult(stream.Context(), resChan, result{success: false, err: err})
 	} else if mode != gnmi.SubscriptionList_POLL {
 		//Sending only if we need to finish listening because of ONCE
 		// if POLL we need to keep the channel open
-		resChan <- result{success: true, err: nil}
+		sendResult(stream.Context(), resChan, result{success: true, err: nil})
 	}
 }
 
-//For each update coming from the change channel we check if it's for a valid target and path then, if so, we send it NB
+// sendSubscriptionUpdates sends the current config and state of each subscription in the request,
+// without any sync response
+func (s *Server) sendSubscriptionUpdates(mgr *manager.Manager, version devicetype.Version, stream gnmi.GNMI_SubscribeServer,
+	request *gnmi.SubscriptionList) error {
+	for _, sub := range request.Subscription {
+		target := sub.GetPath().GetTarget()
+		if target == "" {
+			target = request.GetPrefix().GetTarget()
+		}
+		_, version, err := mgr.CheckCacheForDevice(devicetype.ID(target), devicetype.Type(""), version)
+		if err != nil {
+			log.Error("Error while collecting data from device cache ", err)
+			return err
+		}
+		//We get the stated of the device, for each path we build the updates and send them out.
+		updates, err := s.getUpdates(version, request.Prefix, sub.Path, request.Encoding)
+		if err != nil {
+			log.Error("Error while collecting data for subscribe ", err)
+			return err
+		}
+		for _, update := range updates {
+			response, err := buildUpdateResponse(update)
+			if err != nil {
+				log.Error("Error Retrieving Device ", err)
+				return err
+			}
+			err = sendResponse(response, stream)
+			if err != nil {
+				log.Error("Error sending response ", err)
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+//Watches the changes of each target, so that for each update coming from the change channel we check
+//if it's for a valid target and path then, if so, we send it NB
 func listenForUpdates(stream gnmi.GNMI_SubscribeServer, mgr *manager.Manager,
-	targets map[string]struct{}, version devicetype.Version, subs []*regexp.Regexp, resChan chan result) {
+	targets map[string]struct{}, version devicetype.Version, subs []*regexp.Regexp, encoding gnmi.Encoding,
+	resChan chan result) error {
 	for target := range targets {
 		_, version, err := mgr.CheckCacheForDevice(devicetype.ID(target), devicetype.Type(""), version)
 		if err != nil {
 			log.Errorf("unable to get version from cache %s", err)
-			return
+			return nil
+		}
+		eventCh := make(chan streams.Event)
+		ctx, errWatch := mgr.DeviceChangesStore.Watch(devicetype.NewVersionedID(devicetype.ID(target), version), eventCh)
+		if errWatch != nil {
+			log.Errorf("Cant watch for changes on device %s. error %s", target, errWatch.Error())
+			return errWatch
 		}
-		go listenForDeviceUpdates(stream, mgr, devicetype.ID(target), version, subs, resChan)
+		go listenForDeviceUpdates(stream, ctx, eventCh, devicetype.ID(target), subs, encoding, resChan)
 	}
+	return nil
 }
 
 //For each update coming from the change channel we check if it's for a valid target and path then, if so, we send it NB
-func listenForDeviceUpdates(stream gnmi.GNMI_SubscribeServer, mgr *manager.Manager,
-	target devicetype.ID, version devicetype.Version, subs []*regexp.Regexp, resChan chan result) {
-	eventCh := make(chan streams.Event)
-	ctx, errWatch := mgr.DeviceChangesStore.Watch(devicetype.NewVersionedID(target, version), eventCh)
-	if errWatch != nil {
-		log.Errorf("Cant watch for changes on device %s. error %s", target, errWatch.Error())
-		resChan <- result{success: false, err: errWatch}
-		return
-	}
+func listenForDeviceUpdates(stream gnmi.GNMI_SubscribeServer, ctx streams.Context, eventCh chan streams.Event,
+	target devicetype.ID, subs []*regexp.Regexp, encoding gnmi.Encoding, resChan chan result) {
 	defer ctx.Close()
-	for changeEvent := range eventCh {
+	for {
+		var changeEvent streams.Event
+		select {
+		case event, ok := <-eventCh:
+			if !ok {
+				return
+			}
+			changeEvent = event
+		case <-stream.Context().Done():
+			return
+		}
 		change, ok := changeEvent.Object.(*devicechange.DeviceChange)
 		if !ok {
 			log.Error("Could not convert event to DeviceChange")
@@ -216,10 +508,11 @@ func listenForDeviceUpdates(stream gnmi.GNMI_SubscribeServer, mgr *manager.Manag
 						continue
 					}
 					log.Infof("Subscribe notification for %s on %s with value %s", pathGnmi, target, value.Value)
-					err = buildAndSendUpdate(pathGnmi, string(target), value.Value, value.Removed, stream)
+					err = sendValueUpdate(pathGnmi, string(target), value.Value, value.Removed, encoding, stream)
 					if err != nil {
 						log.Error("Error in sending update path ", err)
-						resChan <- result{success: false, err: err}
+						sendResult(stream.Context(), resChan, result{success: false, err: err})
+						return
 					}
 				}
 			}
@@ -229,7 +522,7 @@ func listenForDeviceUpdates(stream gnmi.GNMI_SubscribeServer, mgr *manager.Manag
 
 //For each update coming from the state channel we check if it's for a valid target and path then, if so, we send it NB
 func listenForOpStateUpdates(opStateChan chan events.OperationalStateEvent, stream gnmi.GNMI_SubscribeServer,
-	targets map[string]struct{}, subs []*regexp.Regexp, resChan chan result) {
+	targets map[string]struct{}, subs []*regexp.Regexp, encoding gnmi.Encoding, resChan chan result) {
 	for opStateChange := range opStateChan {
 		target := opStateChange.Subject()
 		_, targetPresent := targets[target]
@@ -238,14 +531,14 @@ func listenForOpStateUpdates(opStateChan chan events.OperationalStateEvent, stre
 			pathGnmi, err := utils.ParseGNMIElements(pathArr)
 			if err != nil {
 				log.Warn("Error in parsing path", err)
-				resChan <- result{success: true, err: nil}
 				continue
 			}
 
-			err = buildAndSendUpdate(pathGnmi, target, opStateChange.Value(), len(opStateChange.Value().Bytes) == 0, stream)
+			err = sendValueUpdate(pathGnmi, target, opStateChange.Value(), len(opStateChange.Value().Bytes) == 0, encoding, stream)
 			if err != nil {
 				log.Error("Error in sending update path ", err)
-				resChan <- result{success: false, err: err}
+				sendResult(stream.Context(), resChan, result{success: false, err: err})
+				return
 			}
 		}
 	}
@@ -260,8 +553,9 @@ func matchRegex(path string, subs []*regexp.Regexp) bool {
 	return false
 }
 
-func buildAndSendUpdate(pathGnmi *gnmi.Path, target string, value *devicechange.TypedValue, removed bool,
-	stream gnmi.GNMI_SubscribeServer) error {
+// sendValueUpdate sends an update in the given encoding, or a delete if removed, for a single path
+func sendValueUpdate(pathGnmi *gnmi.Path, target string, value *devicechange.TypedValue, removed bool,
+	encoding gnmi.Encoding, stream gnmi.GNMI_SubscribeServer) error {
 	pathGnmi.Target = target
 	var response *gnmi.SubscribeResponse
 	var errGet error
@@ -269,7 +563,7 @@ func buildAndSendUpdate(pathGnmi *gnmi.Path, target string, value *devicechange.
 	if removed {
 		response, errGet = buildDeleteResponse(pathGnmi)
 	} else {
-		valueGnmi, err := values.NativeTypeToGnmiTypedValue(value)
+		valueGnmi, err := encodeValue(value, encoding)
 		if err != nil {
 			log.Warn("Unable to convert native value to gnmiValue", err)
 			return err
@@ -284,13 +578,7 @@ func buildAndSendUpdate(pathGnmi *gnmi.Path, target string, value *devicechange.
 	if errGet != nil {
 		return errGet
 	}
-	err := sendResponse(response, stream)
-	if err != nil {
-		return err
-	}
-	responseSync := buildSyncResponse()
-	err = sendResponse(responseSync, stream)
-	return err
+	return sendResponse(response, stream)
 }
 
 func buildSyncResponse() *gnmi.SubscribeResponse {
@@ -18,6 +18,7 @@ import (
 	"context"
 	"github.com/golang/mock/gomock"
 	topodevice "github.com/onosproject/onos-config/pkg/device"
+	"github.com/onosproject/onos-config/pkg/manager"
 	"github.com/onosproject/onos-config/pkg/store/device/cache"
 	"github.com/onosproject/onos-config/pkg/utils"
 	"github.com/openconfig/gnmi/proto/gnmi"
@@ -53,6 +54,10 @@ func (x gNMISubscribeServerFake) Recv() (*gnmi.SubscribeRequest, error) {
 	return x.Request, nil
 }
 
+func (x gNMISubscribeServerFake) Context() context.Context {
+	return context.Background()
+}
+
 type gNMISubscribeServerPollFake struct {
 	Request     *gnmi.SubscribeRequest
 	PollRequest *gnmi.SubscribeRequest
@@ -76,12 +81,15 @@ func (x gNMISubscribeServerPollFake) Recv() (*gnmi.SubscribeRequest, error) {
 
 }
 
+func (x gNMISubscribeServerPollFake) Context() context.Context {
+	return context.Background()
+}
+
 // Test_SubscribeLeafOnce tests subscribing with mode ONCE and then immediately receiving the subscription for a specific leaf.
 func Test_SubscribeLeafOnce(t *testing.T) {
 	server, mgr, mocks := setUp(t)
 
 	setUpChangesMock(mocks)
-	mocks.MockDeviceCache.EXPECT().GetDevicesByID(gomock.Any()).Return(make([]*cache.Info, 0)).Times(1)
 	mocks.MockDeviceCache.EXPECT().GetDevicesByID(gomock.Any()).Return([]*cache.Info{
 		{
 			DeviceID: "Device1",
@@ -150,11 +158,12 @@ func Test_SubscribeLeafStream(t *testing.T) {
 
 	request := buildRequest(path, gnmi.SubscriptionList_STREAM)
 
-	responsesChan := make(chan *gnmi.SubscribeResponse, 1)
-	serverFake := gNMISubscribeServerFake{
+	responsesChan := make(chan *gnmi.SubscribeResponse, 10)
+	serverFake := gNMISubscribeServerPollFake{
 		Request:   request,
 		Responses: responsesChan,
 		Signal:    make(chan struct{}),
+		first:     true,
 	}
 
 	go func() {
@@ -190,10 +199,12 @@ func Test_SubscribeLeafStream(t *testing.T) {
 	path3Stream := "leaf2a"
 	valueReply := uint(11) // TODO set back to 12 - it should be 12 after the Set()
 
-	//Expecting 1 Update response
-	assertUpdateResponse(t, responsesChan, device1, path1Stream, path2Stream, path3Stream, valueReply, true)
+	//Expecting the current value first
+	assertUpdateResponse(t, responsesChan, device1, path1Stream, path2Stream, path3Stream, uint(13), true)
 	//And one sync response
 	assertSyncResponse(responsesChan, t)
+	//Followed by the update
+	assertUpdateResponse(t, responsesChan, device1, path1Stream, path2Stream, path3Stream, valueReply, true)
 
 }
 
@@ -497,11 +508,12 @@ func Test_SubscribeLeafStreamWithDeviceLoaded(t *testing.T) {
 
 	request := buildRequest(path, gnmi.SubscriptionList_STREAM)
 
-	responsesChan := make(chan *gnmi.SubscribeResponse, 1)
-	serverFake := gNMISubscribeServerFake{
+	responsesChan := make(chan *gnmi.SubscribeResponse, 10)
+	serverFake := gNMISubscribeServerPollFake{
 		Request:   request,
 		Responses: responsesChan,
 		Signal:    make(chan struct{}),
+		first:     true,
 	}
 
 	go func() {
@@ -532,9 +544,11 @@ func Test_SubscribeLeafStreamWithDeviceLoaded(t *testing.T) {
 	//	}()
 	//}()
 	//Sending set request
+	//The set must be done before the next test replaces the manager
+	setDone := make(chan error)
 	go func() {
-		_, err = server.Set(context.Background(), setRequest)
-		assert.NilError(t, err, "Unexpected error doing Set")
+		_, err := server.Set(context.Background(), setRequest)
+		setDone <- err
 	}()
 
 	device1 := "Device1"
@@ -543,10 +557,13 @@ func Test_SubscribeLeafStreamWithDeviceLoaded(t *testing.T) {
 	path3Stream := "leaf2a"
 	valueReply := uint(11) // TODO change back to 12 - the value in the Set()
 
-	//Expecting 1 Update response
-	assertUpdateResponse(t, responsesChan, device1, path1Stream, path2Stream, path3Stream, valueReply, false)
+	//Expecting the current value first
+	assertUpdateResponse(t, responsesChan, device1, path1Stream, path2Stream, path3Stream, uint(13), false)
 	//And one sync response
 	assertSyncResponse(responsesChan, t)
+	//Followed by the update
+	assertUpdateResponse(t, responsesChan, device1, path1Stream, path2Stream, path3Stream, valueReply, false)
+	assert.NilError(t, <-setDone, "Unexpected error doing Set")
 
 }
 
@@ -639,3 +656,196 @@ func assertDeleteResponse(t *testing.T, responsesChan chan *gnmi.SubscribeRespon
 		t.FailNow()
 	}
 }
+
+// Test_SubscribeLeafSample tests subscribing with mode STREAM and a SAMPLE subscription, receiving the value
+// at the start and then again on the next sample interval
+func Test_SubscribeLeafSample(t *testing.T) {
+	server, mgr, mocks := setUp(t)
+	mocks.MockDeviceCache.EXPECT().GetDevicesByID(gomock.Any()).Return([]*cache.Info{
+		{
+			DeviceID: "Device1",
+			Version:  "1.0.0",
+			Type:     "Stratum",
+		},
+	}).AnyTimes()
+	mocks.MockStores.DeviceStore.EXPECT().Get(gomock.Any()).Return(nil, status.Error(codes.NotFound, "device not found")).AnyTimes()
+	setUpChangesMock(mocks)
+
+	var wg sync.WaitGroup
+	defer tearDown(mgr, &wg)
+
+	path, err := utils.ParseGNMIElements([]string{"cont1a", "cont2a", "leaf2a"})
+	assert.NilError(t, err, "Unexpected error doing parsing")
+	path.Target = "Device1"
+
+	request := buildRequest(path, gnmi.SubscriptionList_STREAM)
+	request.GetSubscribe().Subscription[0].Mode = gnmi.SubscriptionMode_SAMPLE
+	request.GetSubscribe().Subscription[0].SampleInterval = uint64(minSampleInterval)
+
+	responsesChan := make(chan *gnmi.SubscribeResponse, 10)
+	serverFake := gNMISubscribeServerPollFake{
+		Request:   request,
+		Responses: responsesChan,
+		Signal:    make(chan struct{}),
+		first:     true,
+	}
+
+	go func() {
+		_ = server.Subscribe(serverFake)
+	}()
+	serverFake.Signal <- struct{}{}
+
+	//Expecting the current value and a sync straight away and the first sample after the sample interval
+	assertUpdateResponse(t, responsesChan, "Device1", "cont1a", "cont2a", "leaf2a", uint(13), true)
+	assertSyncResponse(responsesChan, t)
+	assertUpdateResponse(t, responsesChan, "Device1", "cont1a", "cont2a", "leaf2a", uint(13), true)
+}
+
+// Test_SubscribeStreamUpdatesOnly tests subscribing with mode STREAM and updates_only, where the current
+// values are not sent and the sync response comes before any update
+func Test_SubscribeStreamUpdatesOnly(t *testing.T) {
+	server, mocks, mgr := setUpForGetSetTests(t)
+	setUpChangesMock(mocks)
+	mocks.MockDeviceCache.EXPECT().GetDevicesByID(gomock.Any()).Return([]*cache.Info{
+		{
+			DeviceID: "Device1",
+			Version:  "1.0.0",
+			Type:     "TestDevice",
+		},
+	}).AnyTimes()
+	mocks.MockStores.DeviceStore.EXPECT().Get(gomock.Any()).Return(nil, status.Error(codes.NotFound, "device not found")).AnyTimes()
+
+	var wg sync.WaitGroup
+	defer tearDown(mgr, &wg)
+
+	path, err := utils.ParseGNMIElements([]string{"cont1a", "cont2a", "leaf2a"})
+	assert.NilError(t, err, "Unexpected error doing parsing")
+	path.Target = "Device1"
+
+	request := buildRequest(path, gnmi.SubscriptionList_STREAM)
+	request.GetSubscribe().UpdatesOnly = true
+
+	responsesChan := make(chan *gnmi.SubscribeResponse, 10)
+	serverFake := gNMISubscribeServerPollFake{
+		Request:   request,
+		Responses: responsesChan,
+		Signal:    make(chan struct{}),
+		first:     true,
+	}
+
+	go func() {
+		_ = server.Subscribe(serverFake)
+	}()
+	serverFake.Signal <- struct{}{}
+
+	//Expecting the sync response first and then the update from the device change
+	assertSyncResponse(responsesChan, t)
+	assertUpdateResponse(t, responsesChan, "Device1", "cont1a", "cont2a", "leaf2a", uint(11), true)
+}
+
+// Test_SampleSuppressRedundant tests that unchanged values are only sent again on the heartbeat
+func Test_SampleSuppressRedundant(t *testing.T) {
+	server, _, mocks := setUp(t)
+	mocks.MockDeviceCache.EXPECT().GetDevicesByID(gomock.Any()).Return([]*cache.Info{
+		{
+			DeviceID: "Device1",
+			Version:  "1.0.0",
+			Type:     "Stratum",
+		},
+	}).AnyTimes()
+	mocks.MockStores.DeviceStore.EXPECT().Get(gomock.Any()).Return(nil, status.Error(codes.NotFound, "device not found")).AnyTimes()
+	setUpChangesMock(mocks)
+
+	path, err := utils.ParseGNMIElements([]string{"cont1a", "cont2a", "leaf2a"})
+	assert.NilError(t, err, "Unexpected error doing parsing")
+	path.Target = "Device1"
+
+	responsesChan := make(chan *gnmi.SubscribeResponse, 10)
+	serverFake := gNMISubscribeServerPollFake{
+		Responses: responsesChan,
+	}
+	smp := &sampler{
+		server:            server,
+		mgr:               manager.GetManager(),
+		stream:            serverFake,
+		sub:               &gnmi.Subscription{Path: path, Mode: gnmi.SubscriptionMode_SAMPLE},
+		interval:          minSampleInterval,
+		heartbeatInterval: time.Minute,
+		suppressRedundant: true,
+	}
+
+	start := time.Now()
+	// The first sample is always sent
+	assert.NilError(t, smp.sample(start))
+	assert.Equal(t, len(responsesChan), 1)
+	assertUpdateResponse(t, responsesChan, "Device1", "cont1a", "cont2a", "leaf2a", uint(13), true)
+
+	// The value has not changed, so is suppressed
+	assert.NilError(t, smp.sample(start.Add(time.Second)))
+	assert.Equal(t, len(responsesChan), 0)
+
+	// The heartbeat interval has elapsed, so the value is sent again
+	assert.NilError(t, smp.sample(start.Add(time.Minute)))
+	assert.Equal(t, len(responsesChan), 1)
+	assertUpdateResponse(t, responsesChan, "Device1", "cont1a", "cont2a", "leaf2a", uint(13), true)
+}
+
+// Test_SampleSeeded tests that the values sent in the initial updates are not sent again by the first sample
+func Test_SampleSeeded(t *testing.T) {
+	server, _, mocks := setUp(t)
+	mocks.MockDeviceCache.EXPECT().GetDevicesByID(gomock.Any()).Return([]*cache.Info{
+		{
+			DeviceID: "Device1",
+			Version:  "1.0.0",
+			Type:     "Stratum",
+		},
+	}).AnyTimes()
+	mocks.MockStores.DeviceStore.EXPECT().Get(gomock.Any()).Return(nil, status.Error(codes.NotFound, "device not found")).AnyTimes()
+	setUpChangesMock(mocks)
+
+	path, err := utils.ParseGNMIElements([]string{"cont1a", "cont2a", "leaf2a"})
+	assert.NilError(t, err, "Unexpected error doing parsing")
+	path.Target = "Device1"
+
+	responsesChan := make(chan *gnmi.SubscribeResponse, 10)
+	serverFake := gNMISubscribeServerPollFake{
+		Responses: responsesChan,
+	}
+	smp := &sampler{
+		server:            server,
+		mgr:               manager.GetManager(),
+		stream:            serverFake,
+		sub:               &gnmi.Subscription{Path: path, Mode: gnmi.SubscriptionMode_SAMPLE},
+		interval:          minSampleInterval,
+		heartbeatInterval: time.Minute,
+		suppressRedundant: true,
+	}
+
+	start := time.Now()
+	assert.NilError(t, smp.seed(start))
+	assert.Equal(t, len(responsesChan), 0)
+
+	// The value is the one in the initial updates, so is suppressed
+	assert.NilError(t, smp.sample(start.Add(time.Second)))
+	assert.Equal(t, len(responsesChan), 0)
+
+	// The heartbeat is counted from the start of the subscription
+	assert.NilError(t, smp.sample(start.Add(time.Minute)))
+	assert.Equal(t, len(responsesChan), 1)
+	assertUpdateResponse(t, responsesChan, "Device1", "cont1a", "cont2a", "leaf2a", uint(13), true)
+}
+
+// Test_SubscribeSampleIntervalTooLow tests that a sample interval below the minimum is rejected
+func Test_SubscribeSampleIntervalTooLow(t *testing.T) {
+	server, _, _ := setUp(t)
+	path, err := utils.ParseGNMIElements([]string{"cont1a", "cont2a", "leaf2a"})
+	assert.NilError(t, err, "Unexpected error doing parsing")
+	path.Target = "Device1"
+
+	request := buildRequest(path, gnmi.SubscriptionList_STREAM)
+	request.GetSubscribe().Subscription[0].Mode = gnmi.SubscriptionMode_SAMPLE
+	request.GetSubscribe().Subscription[0].SampleInterval = uint64(time.Millisecond)
+
+	_, _, err = server.buildSamplers(manager.GetManager(), nil, request.GetSubscribe(), "")
+	assert.Equal(t, status.Code(err), codes.InvalidArgument)
+}
@@ -0,0 +1,87 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rbac
+
+import (
+	"context"
+	"strings"
+
+	"github.com/onosproject/onos-config/pkg/utils"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+)
+
+// claimKeys are the claims of a JWT that give the roles of a client
+var claimKeys = []string{"roles", "groups"}
+
+// Authorizer authorizes the operations of the clients of the northbound according to a policy
+type Authorizer struct {
+	policy *Policy
+}
+
+// NewAuthorizer returns a new Authorizer for the policy
+func NewAuthorizer(policy *Policy) *Authorizer {
+	return &Authorizer{
+		policy: policy,
+	}
+}
+
+// Authorize checks that the client of a request is allowed the operation on the resource. A nil Authorizer
+// allows everything.
+func (a *Authorizer) Authorize(ctx context.Context, op Operation, resource Resource) error {
+	if a == nil {
+		return nil
+	}
+	roles := GetRoles(ctx)
+	if !a.policy.IsAllowed(roles, op, resource) {
+		log.Infof("Denied %s of %s on %s to roles %v", op, resource.Path, resource.Device.ID, roles)
+		return errors.NewForbidden("%s of %s on %s is not allowed for roles %v", op, resource.Path, resource.Device.ID, roles)
+	}
+	return nil
+}
+
+// GetRoles gives the roles of the client of a request - the common name and the organizational units of its
+// verified TLS certificate, and the roles and groups claims of its validated JWT. Nothing else that the client
+// sends, such as plain request metadata, gives it a role.
+func GetRoles(ctx context.Context) []string {
+	roles := make([]string, 0)
+	if certificate := utils.GetVerifiedCertificate(ctx); certificate != nil {
+		if certificate.Subject.CommonName != "" {
+			roles = append(roles, certificate.Subject.CommonName)
+		}
+		roles = append(roles, certificate.Subject.OrganizationalUnit...)
+	}
+	if claims := utils.GetValidatedClaims(ctx); claims != nil {
+		for _, key := range claimKeys {
+			switch claim := claims[key].(type) {
+			case string:
+				roles = append(roles, splitClaim(claim)...)
+			case []interface{}:
+				for _, value := range claim {
+					if role, ok := value.(string); ok {
+						roles = append(roles, role)
+					}
+				}
+			}
+		}
+	}
+	return roles
+}
+
+// splitClaim splits a claim holding a list of values
+func splitClaim(value string) []string {
+	return strings.FieldsFunc(value, func(r rune) bool {
+		return r == ',' || r == ';' || r == ' '
+	})
+}
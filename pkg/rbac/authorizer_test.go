@@ -0,0 +1,103 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rbac
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"os"
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/onosproject/onos-config/pkg/utils"
+	"github.com/onosproject/onos-lib-go/pkg/auth"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"gotest.tools/assert"
+)
+
+func certContext(ctx context.Context, subject pkix.Name) context.Context {
+	tlsInfo := credentials.TLSInfo{
+		State: tls.ConnectionState{
+			VerifiedChains: [][]*x509.Certificate{{{Subject: subject}}},
+		},
+	}
+	return peer.NewContext(ctx, &peer.Peer{AuthInfo: tlsInfo})
+}
+
+func tokenContext(ctx context.Context, t *testing.T, claims jwt.MapClaims, key string) context.Context {
+	token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString([]byte(key))
+	assert.NilError(t, err)
+	return metadata.NewIncomingContext(ctx, metadata.Pairs("authorization", "bearer "+token))
+}
+
+func Test_GetRoles(t *testing.T) {
+	defer os.Unsetenv(auth.SharedSecretKey)
+	defer utils.SetJwtAuthentication(false)
+	os.Setenv(auth.SharedSecretKey, "secret")
+
+	assert.DeepEqual(t, []string{}, GetRoles(context.Background()))
+
+	certCtx := certContext(context.Background(), pkix.Name{CommonName: "alice", OrganizationalUnit: []string{"access-team"}})
+	assert.DeepEqual(t, []string{"alice", "access-team"}, GetRoles(certCtx))
+
+	// Plain request metadata gives no role
+	ctx := metadata.NewIncomingContext(certCtx, metadata.Pairs("groups", "noc-readonly", "roles", "admin"))
+	assert.DeepEqual(t, []string{"alice", "access-team"}, GetRoles(ctx))
+
+	// Nor does a JWT unless the clients are authenticated with one
+	claims := jwt.MapClaims{"roles": "admin", "groups": []interface{}{"noc-readonly", "lab"}}
+	ctx = tokenContext(certCtx, t, claims, "secret")
+	assert.DeepEqual(t, []string{"alice", "access-team"}, GetRoles(ctx))
+
+	utils.SetJwtAuthentication(true)
+	assert.DeepEqual(t, []string{"alice", "access-team", "admin", "noc-readonly", "lab"}, GetRoles(ctx))
+
+	// A JWT that is not signed with the key gives no role
+	ctx = tokenContext(certCtx, t, claims, "forged")
+	assert.DeepEqual(t, []string{"alice", "access-team"}, GetRoles(ctx))
+
+	// Certificates that have not been verified give no role
+	tlsInfo := credentials.TLSInfo{
+		State: tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{{Subject: pkix.Name{CommonName: "mallory"}}},
+		},
+	}
+	ctx = peer.NewContext(context.Background(), &peer.Peer{AuthInfo: tlsInfo})
+	assert.DeepEqual(t, []string{}, GetRoles(ctx))
+}
+
+func Test_Authorize(t *testing.T) {
+	policy, err := LoadPolicy("testdata/policy.yaml")
+	assert.NilError(t, err)
+	authorizer := NewAuthorizer(policy)
+
+	ctx := certContext(context.Background(), pkix.Name{CommonName: "bob", OrganizationalUnit: []string{"noc-readonly"}})
+	assert.NilError(t, authorizer.Authorize(ctx, OperationGet, Resource{leaf1, "/interfaces"}))
+	err = authorizer.Authorize(ctx, OperationSet, Resource{leaf1, "/interfaces"})
+	assert.Assert(t, errors.IsForbidden(err))
+	assert.ErrorContains(t, err, "set of /interfaces on leaf-1 is not allowed")
+
+	ctx = certContext(context.Background(), pkix.Name{CommonName: "bob", OrganizationalUnit: []string{"noc-readonly", "access-team"}})
+	assert.NilError(t, authorizer.Authorize(ctx, OperationSet, Resource{leaf1, "/interfaces"}))
+
+	// Without an authorizer everything is allowed
+	var none *Authorizer
+	assert.NilError(t, none.Authorize(context.Background(), OperationSet, Resource{leaf1, "/"}))
+}
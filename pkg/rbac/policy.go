@@ -0,0 +1,182 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package rbac implements the role-based authorization of the operations on the northbound gNMI service.
+package rbac
+
+import (
+	"fmt"
+	"io/ioutil"
+	pathpkg "path"
+	"strings"
+
+	"github.com/onosproject/onos-config/pkg/utils"
+	"github.com/onosproject/onos-lib-go/pkg/logging"
+	"gopkg.in/yaml.v2"
+)
+
+var log = logging.GetLogger("rbac")
+
+// Operation is an operation of the northbound gNMI service
+type Operation string
+
+const (
+	// OperationGet is a gNMI Get
+	OperationGet Operation = "get"
+	// OperationSet is a gNMI Set, checked for each path updated, replaced or deleted
+	OperationSet Operation = "set"
+	// OperationSubscribe is a gNMI Subscribe
+	OperationSubscribe Operation = "subscribe"
+)
+
+// Device is the device that an operation is applied to
+type Device struct {
+	// ID is the identifier of the device
+	ID string
+	// Type is the type of the device, empty if it is not known
+	Type string
+	// Role is the role of the device in the topology, empty if it is not known
+	Role string
+}
+
+// Resource is the path of a device that an operation is applied to
+type Resource struct {
+	Device Device
+	Path   string
+}
+
+// Rule allows some operations on the devices and paths that it matches. A rule matches any device and
+// any path for the criteria that are left empty.
+type Rule struct {
+	// Operations are the operations allowed by the rule, or all of them if empty
+	Operations []Operation `yaml:"operations,omitempty"`
+	// DeviceIDs are patterns of the device IDs, e.g. "leaf-*"
+	DeviceIDs []string `yaml:"deviceIds,omitempty"`
+	// DeviceTypes are the types of the devices
+	DeviceTypes []string `yaml:"deviceTypes,omitempty"`
+	// DeviceRoles are the roles of the devices in the topology, e.g. "leaf"
+	DeviceRoles []string `yaml:"deviceRoles,omitempty"`
+	// Paths are the path prefixes that the paths must be under, e.g. "/interfaces"
+	Paths []string `yaml:"paths,omitempty"`
+}
+
+// Policy maps roles to the rules of what they are allowed. Whatever no rule of a client's roles allows is denied.
+type Policy struct {
+	Roles map[string][]Rule `yaml:"roles"`
+}
+
+// LoadPolicy loads a policy from a YAML file
+func LoadPolicy(file string) (*Policy, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	policy := &Policy{}
+	if err := yaml.UnmarshalStrict(data, policy); err != nil {
+		return nil, fmt.Errorf("invalid policy file %s: %v", file, err)
+	}
+	if err := policy.validate(); err != nil {
+		return nil, fmt.Errorf("invalid policy file %s: %v", file, err)
+	}
+	log.Infof("Loaded policy %s with %d roles", file, len(policy.Roles))
+	return policy, nil
+}
+
+// validate checks that the rules of the policy can be matched
+func (p *Policy) validate() error {
+	for role, rules := range p.Roles {
+		for _, rule := range rules {
+			for _, op := range rule.Operations {
+				if op != OperationGet && op != OperationSet && op != OperationSubscribe {
+					return fmt.Errorf("role %s: unknown operation %q", role, op)
+				}
+			}
+			for _, pattern := range rule.DeviceIDs {
+				if _, err := pathpkg.Match(pattern, ""); err != nil {
+					return fmt.Errorf("role %s: invalid device ID pattern %q", role, pattern)
+				}
+			}
+			for _, prefix := range rule.Paths {
+				if !strings.HasPrefix(prefix, "/") {
+					return fmt.Errorf("role %s: path %q must start with /", role, prefix)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// IsAllowed indicates whether any of the roles is allowed the operation on the resource
+func (p *Policy) IsAllowed(roles []string, op Operation, resource Resource) bool {
+	for _, role := range roles {
+		for _, rule := range p.Roles[role] {
+			if rule.matches(op, resource) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// matches indicates whether the rule allows the operation on the resource
+func (r *Rule) matches(op Operation, resource Resource) bool {
+	if len(r.Operations) > 0 && !containsOperation(r.Operations, op) {
+		return false
+	}
+	if len(r.DeviceIDs) > 0 && !matchesAny(r.DeviceIDs, resource.Device.ID) {
+		return false
+	}
+	if len(r.DeviceTypes) > 0 && !containsString(r.DeviceTypes, resource.Device.Type) {
+		return false
+	}
+	if len(r.DeviceRoles) > 0 && !containsString(r.DeviceRoles, resource.Device.Role) {
+		return false
+	}
+	if len(r.Paths) > 0 {
+		for _, prefix := range r.Paths {
+			if utils.IsPathUnder(resource.Path, prefix) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func containsOperation(ops []Operation, op Operation) bool {
+	for _, o := range ops {
+		if o == op {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+func matchesAny(patterns []string, value string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := pathpkg.Match(pattern, value); ok {
+			return true
+		}
+	}
+	return false
+}
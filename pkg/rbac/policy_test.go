@@ -0,0 +1,87 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rbac
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"gotest.tools/assert"
+)
+
+var (
+	leaf1  = Device{ID: "leaf-1", Type: "Stratum", Role: "leaf"}
+	spine1 = Device{ID: "spine-1", Type: "Stratum", Role: "spine"}
+	lab1   = Device{ID: "lab-1", Type: "Devicesim"}
+)
+
+func Test_LoadPolicy(t *testing.T) {
+	policy, err := LoadPolicy("testdata/policy.yaml")
+	assert.NilError(t, err)
+	assert.Equal(t, 3, len(policy.Roles))
+	assert.Equal(t, 2, len(policy.Roles["access-team"]))
+
+	_, err = LoadPolicy("testdata/missing.yaml")
+	assert.ErrorContains(t, err, "no such file")
+}
+
+func Test_LoadPolicyInvalid(t *testing.T) {
+	dir, err := ioutil.TempDir("", "rbac")
+	assert.NilError(t, err)
+	defer os.RemoveAll(dir)
+
+	invalid := map[string]string{
+		"unknown operation": "roles:\n  r1:\n    - operations: [delete]\n",
+		"must start with /": "roles:\n  r1:\n    - paths: [interfaces]\n",
+		"field devices":     "roles:\n  r1:\n    - devices: [leaf-1]\n",
+	}
+	for expected, content := range invalid {
+		file := filepath.Join(dir, "policy.yaml")
+		assert.NilError(t, ioutil.WriteFile(file, []byte(content), 0644))
+		_, err := LoadPolicy(file)
+		assert.ErrorContains(t, err, expected)
+	}
+}
+
+func Test_PolicyIsAllowed(t *testing.T) {
+	policy, err := LoadPolicy("testdata/policy.yaml")
+	assert.NilError(t, err)
+
+	tests := []struct {
+		name     string
+		roles    []string
+		op       Operation
+		resource Resource
+		allowed  bool
+	}{
+		{"readonly get", []string{"noc-readonly"}, OperationGet, Resource{spine1, "/system/config/hostname"}, true},
+		{"readonly subscribe", []string{"noc-readonly"}, OperationSubscribe, Resource{leaf1, "/"}, true},
+		{"readonly set", []string{"noc-readonly"}, OperationSet, Resource{leaf1, "/interfaces"}, false},
+		{"access set leaf", []string{"access-team"}, OperationSet, Resource{leaf1, "/interfaces/interface[name=eth1]/config/mtu"}, true},
+		{"access set leaf other path", []string{"access-team"}, OperationSet, Resource{leaf1, "/system/config/hostname"}, false},
+		{"access set leaf similar path", []string{"access-team"}, OperationSet, Resource{leaf1, "/interfaces-state"}, false},
+		{"access set spine", []string{"access-team"}, OperationSet, Resource{spine1, "/interfaces"}, false},
+		{"lab any operation", []string{"lab"}, OperationSet, Resource{lab1, "/system"}, true},
+		{"lab other device", []string{"lab"}, OperationGet, Resource{leaf1, "/system"}, false},
+		{"several roles", []string{"noc-readonly", "access-team"}, OperationSet, Resource{leaf1, "/interfaces"}, true},
+		{"unknown role", []string{"guest"}, OperationGet, Resource{leaf1, "/"}, false},
+		{"no role", nil, OperationGet, Resource{leaf1, "/"}, false},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.allowed, policy.IsAllowed(test.roles, test.op, test.resource), test.name)
+	}
+}
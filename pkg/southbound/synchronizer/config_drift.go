@@ -0,0 +1,153 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package synchronizer
+
+import (
+	"context"
+	"fmt"
+
+	devicechange "github.com/onosproject/onos-api/go/onos/config/change/device"
+	networkchange "github.com/onosproject/onos-api/go/onos/config/change/network"
+	devicetype "github.com/onosproject/onos-api/go/onos/config/device"
+	"github.com/onosproject/onos-config/pkg/modelregistry"
+	"github.com/onosproject/onos-config/pkg/modelregistry/jsonvalues"
+	"github.com/onosproject/onos-config/pkg/southbound"
+	"github.com/onosproject/onos-config/pkg/utils"
+	"github.com/onosproject/onos-config/pkg/utils/values"
+	"github.com/openconfig/gnmi/proto/gnmi"
+)
+
+// driftPolicyKey is the device attribute that sets the drift policy of a single device
+const driftPolicyKey = "onos-config.drift-policy"
+
+// DriftPolicy is what is done when the running config of a device differs from its intended config
+type DriftPolicy int
+
+const (
+	// DriftPolicyReport only records the drift, so that it can be listed through diags
+	DriftPolicyReport DriftPolicy = iota
+	// DriftPolicyReconcile records the drift and then raises a network change to set the intended
+	// values back on the device
+	DriftPolicyReconcile
+)
+
+// ParseDriftPolicy parses the drift policy of a device attribute, either "report" or "reconcile"
+func ParseDriftPolicy(policy string) (DriftPolicy, error) {
+	switch policy {
+	case "report":
+		return DriftPolicyReport, nil
+	case "reconcile":
+		return DriftPolicyReconcile, nil
+	}
+	return DriftPolicyReport, fmt.Errorf("unknown drift policy %s", policy)
+}
+
+// ConfigDrift is a path where the running config of a device differs from its intended config
+type ConfigDrift struct {
+	Path string
+	// Intended is the value held in onos-config
+	Intended *devicechange.TypedValue
+	// Actual is the value on the device, nil if the path is missing from the device
+	Actual *devicechange.TypedValue
+	// ChangeID is the network change raised to set the intended value back on the device, if any
+	ChangeID networkchange.ID
+}
+
+// getRunningConfig gets the config partition of the device, as a flat list of paths and values
+func (sync *Synchronizer) getRunningConfig(ctx context.Context, target southbound.TargetIf,
+	mReadWritePaths modelregistry.ReadWritePathMap) ([]*devicechange.PathValue, error) {
+	log.Infof("Getting running config of %s", string(sync.key))
+	requestConfig := &gnmi.GetRequest{
+		Type:     gnmi.GetRequest_CONFIG,
+		Encoding: sync.encoding,
+	}
+	responseConfig, err := target.Get(ctx, requestConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	pathValues := make([]*devicechange.PathValue, 0)
+	for _, notification := range responseConfig.Notification {
+		for _, update := range notification.Update {
+			if sync.encoding == gnmi.Encoding_JSON || sync.encoding == gnmi.Encoding_JSON_IETF {
+				jsonVal := update.Val.GetJsonVal()
+				if jsonVal == nil {
+					jsonVal = update.Val.GetJsonIetfVal()
+				}
+				configValues, err := jsonvalues.DecomposeJSONWithPaths("", jsonVal, nil, mReadWritePaths)
+				if err != nil {
+					return nil, err
+				}
+				pathValues = append(pathValues, configValues...)
+			} else if sync.encoding == gnmi.Encoding_PROTO {
+				typedVal, err := values.GnmiTypedValueToNativeType(update.Val, nil)
+				if err != nil {
+					log.Warn("Error converting gnmi value to Typed"+
+						" Value", update.Val, " for ", update.Path)
+					continue
+				}
+				pathValues = append(pathValues, &devicechange.PathValue{
+					Path:  utils.StrPath(update.Path),
+					Value: typedVal,
+				})
+			}
+		}
+	}
+	return pathValues, nil
+}
+
+// newDriftChange returns a network change that sets the intended value of each drifted path back on
+// the device. Going through the network change store, the change is applied by the device change
+// controller like any other, and is recorded in the change history of the device
+func (sync *Synchronizer) newDriftChange(drift []*ConfigDrift) (*networkchange.NetworkChange, error) {
+	changeValues := make([]*devicechange.ChangeValue, 0, len(drift))
+	for _, d := range drift {
+		changeValues = append(changeValues, &devicechange.ChangeValue{
+			Path:  d.Path,
+			Value: d.Intended,
+		})
+	}
+	change := &devicechange.Change{
+		DeviceID:      devicetype.ID(sync.ID),
+		DeviceVersion: devicetype.Version(sync.Version),
+		DeviceType:    devicetype.Type(sync.Type),
+		Values:        changeValues,
+	}
+	return networkchange.NewNetworkChange("", []*devicechange.Change{change})
+}
+
+// computeConfigDrift compares the intended config of a device with its running config. Paths that are
+// set on the device but not in the intended config are not drift, as they may be device defaults.
+func computeConfigDrift(intended []*devicechange.PathValue, running []*devicechange.PathValue) []*ConfigDrift {
+	runningValues := make(map[string]*devicechange.TypedValue)
+	for _, pathValue := range running {
+		runningValues[pathValue.Path] = pathValue.Value
+	}
+
+	drift := make([]*ConfigDrift, 0)
+	for _, pathValue := range intended {
+		actual, ok := runningValues[pathValue.Path]
+		if ok && actual.GetType() == pathValue.GetValue().GetType() &&
+			actual.ValueToString() == pathValue.GetValue().ValueToString() {
+			continue
+		}
+		drift = append(drift, &ConfigDrift{
+			Path:     pathValue.Path,
+			Intended: pathValue.Value,
+			Actual:   actual,
+		})
+	}
+	return drift
+}
@@ -0,0 +1,129 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package synchronizer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	devicechange "github.com/onosproject/onos-api/go/onos/config/change/device"
+	devicetype "github.com/onosproject/onos-api/go/onos/config/device"
+	topodevice "github.com/onosproject/onos-config/pkg/device"
+	"github.com/onosproject/onos-config/pkg/utils"
+	"github.com/onosproject/onos-config/pkg/utils/values"
+	"github.com/openconfig/gnmi/proto/gnmi"
+	"gotest.tools/assert"
+)
+
+func Test_computeConfigDrift(t *testing.T) {
+	intended := []*devicechange.PathValue{
+		{Path: cont1aCont2aLeaf2a, Value: devicechange.NewTypedValueUint(13, 8)},
+		{Path: cont1aCont2aLeaf2b, Value: devicechange.NewTypedValueString("intended")},
+		{Path: cont1aLeaf1a, Value: devicechange.NewTypedValueString("leaf1aval")},
+	}
+	running := []*devicechange.PathValue{
+		{Path: cont1aCont2aLeaf2a, Value: devicechange.NewTypedValueUint(13, 8)},
+		{Path: cont1aCont2aLeaf2b, Value: devicechange.NewTypedValueString("factory")},
+		{Path: cont1aCont2aLeaf2g, Value: devicechange.NewTypedValueBool(true)},
+	}
+
+	drift := computeConfigDrift(intended, running)
+	assert.Equal(t, len(drift), 2)
+
+	// A value that is different on the device
+	assert.Equal(t, drift[0].Path, cont1aCont2aLeaf2b)
+	assert.Equal(t, drift[0].Intended.ValueToString(), "intended")
+	assert.Equal(t, drift[0].Actual.ValueToString(), "factory")
+
+	// A value that is missing on the device
+	assert.Equal(t, drift[1].Path, cont1aLeaf1a)
+	assert.Equal(t, drift[1].Intended.ValueToString(), "leaf1aval")
+	assert.Assert(t, drift[1].Actual == nil)
+
+	// No drift when the device has the intended values, even if it has others as well
+	assert.Equal(t, len(computeConfigDrift(intended[:1], running)), 0)
+}
+
+func Test_getRunningConfigAndDriftChange(t *testing.T) {
+	mockTarget, device1, _ := synchronizerBootstrap(t)
+	sync := &Synchronizer{
+		Context:  context.Background(),
+		Device:   device1,
+		key:      device1.ID,
+		encoding: gnmi.Encoding_PROTO,
+	}
+
+	leaf2aPath, err := utils.ParseGNMIElements(utils.SplitPath(cont1aCont2aLeaf2a))
+	assert.NilError(t, err)
+	leaf2aValue, err := values.NativeTypeToGnmiTypedValue(devicechange.NewTypedValueUint(12, 8))
+	assert.NilError(t, err)
+
+	mockTarget.EXPECT().Get(
+		gomock.Any(),
+		&gnmi.GetRequest{
+			Type:     gnmi.GetRequest_CONFIG,
+			Encoding: gnmi.Encoding_PROTO,
+		},
+	).Return(&gnmi.GetResponse{
+		Notification: []*gnmi.Notification{
+			{
+				Timestamp: time.Now().Unix(),
+				Update: []*gnmi.Update{
+					{Path: leaf2aPath, Val: leaf2aValue},
+				},
+			},
+		},
+	}, nil)
+
+	running, err := sync.getRunningConfig(context.Background(), mockTarget, nil)
+	assert.NilError(t, err)
+	assert.Equal(t, len(running), 1)
+	assert.Equal(t, running[0].Path, cont1aCont2aLeaf2a)
+
+	intended := []*devicechange.PathValue{
+		{Path: cont1aCont2aLeaf2a, Value: devicechange.NewTypedValueUint(13, 8)},
+	}
+	drift := computeConfigDrift(intended, running)
+	assert.Equal(t, len(drift), 1)
+
+	change, err := sync.newDriftChange(drift)
+	assert.NilError(t, err)
+	assert.Assert(t, change.ID != "")
+	assert.Equal(t, len(change.Changes), 1)
+	assert.Equal(t, change.Changes[0].DeviceID, devicetype.ID(device1.ID))
+	assert.Equal(t, len(change.Changes[0].Values), 1)
+	assert.Equal(t, change.Changes[0].Values[0].Path, cont1aCont2aLeaf2a)
+	assert.Equal(t, change.Changes[0].Values[0].Value.ValueToString(), "13")
+}
+
+func Test_getDriftPolicy(t *testing.T) {
+	session := &Session{
+		device:      &topodevice.Device{ID: "device-1"},
+		driftPolicy: DriftPolicyReconcile,
+	}
+	assert.Equal(t, session.getDriftPolicy(), DriftPolicyReconcile)
+
+	session.device.Attributes = map[string]string{driftPolicyKey: "report"}
+	assert.Equal(t, session.getDriftPolicy(), DriftPolicyReport)
+
+	session.driftPolicy = DriftPolicyReport
+	session.device.Attributes[driftPolicyKey] = "reconcile"
+	assert.Equal(t, session.getDriftPolicy(), DriftPolicyReconcile)
+
+	session.device.Attributes[driftPolicyKey] = "unknown"
+	assert.Equal(t, session.getDriftPolicy(), DriftPolicyReport)
+}
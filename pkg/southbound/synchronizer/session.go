@@ -38,6 +38,8 @@ import (
 	devicetype "github.com/onosproject/onos-api/go/onos/config/device"
 	topodevice "github.com/onosproject/onos-config/pkg/device"
 	"github.com/onosproject/onos-config/pkg/store/change/device"
+	"github.com/onosproject/onos-config/pkg/store/change/device/state"
+	"github.com/onosproject/onos-config/pkg/store/change/network"
 )
 
 const (
@@ -60,6 +62,11 @@ type Session struct {
 	operationalStateCache     map[topodevice.ID]devicechange.TypedValueMap
 	operationalStateCacheLock *sync.RWMutex
 	deviceChangeStore         device.Store
+	deviceStateStore          state.Store
+	networkChangeStore        network.Store
+	configDriftCache          map[topodevice.ID][]*ConfigDrift
+	configDriftCacheLock      *sync.RWMutex
+	driftPolicy               DriftPolicy
 	device                    *topodevice.Device
 	target                    southbound.TargetIf
 	cancel                    context.CancelFunc
@@ -153,6 +160,11 @@ func (s *Session) synchronize() error {
 		log.Warnf("Cannot check for read only paths for target %cm with %cm because "+
 			"Model Plugin not available - continuing", s.device.ID, s.device.Version)
 	}
+	mReadWritePaths, ok := s.modelRegistry.ModelReadWritePaths[modelName]
+	if !ok {
+		log.Warnf("Cannot check for read write paths for target %s with %s because "+
+			"Model Plugin not available - continuing", s.device.ID, s.device.Version)
+	}
 	mStateGetMode := modelregistry.GetStateOpState // default
 	mPlugin, ok := s.modelRegistry.ModelPlugins[modelName]
 	if !ok {
@@ -183,6 +195,9 @@ func (s *Session) synchronize() error {
 	//spawning two go routines to propagate changes and to get operational state
 	//go sync.syncConfigEventsToDevice(target, respChan)
 	s.deviceResponseChan <- events.NewDeviceConnectedEvent(events.EventTypeDeviceConnected, string(s.device.ID))
+	if s.deviceStateStore != nil && s.configDriftCacheLock != nil {
+		go s.reconcileConfig(ctx, sync, mReadWritePaths)
+	}
 	if sync.getStateMode == modelregistry.GetStateOpState {
 		go sync.syncOperationalStateByPartition(ctx, s.target, s.deviceResponseChan)
 	} else if sync.getStateMode == modelregistry.GetStateExplicitRoPaths ||
@@ -192,6 +207,72 @@ func (s *Session) synchronize() error {
 	return nil
 }
 
+// reconcileConfig compares the running config of the device with the intended config in the device state
+// store, and records the paths that have drifted. Depending on the drift policy of the device a network
+// change is then raised to set the intended values back, e.g. when it has been rebooted with its factory config
+func (s *Session) reconcileConfig(ctx context.Context, sync *Synchronizer, mReadWritePaths modelregistry.ReadWritePathMap) {
+	deviceID := devicetype.NewVersionedID(devicetype.ID(s.device.ID), devicetype.Version(s.device.Version))
+	intended, err := s.deviceStateStore.Get(deviceID, 0)
+	if err != nil {
+		log.Warnf("Cannot get intended config of %s: %v", s.device.ID, err)
+		return
+	}
+	running, err := sync.getRunningConfig(ctx, s.target, mReadWritePaths)
+	if err != nil {
+		log.Warnf("Cannot get running config of %s: %v", s.device.ID, err)
+		return
+	}
+
+	drift := computeConfigDrift(intended, running)
+	if len(drift) == 0 {
+		log.Infof("Running config of %s matches the intended config", s.device.ID)
+	} else {
+		log.Warnf("Running config of %s has drifted from the intended config on %d paths", s.device.ID, len(drift))
+		if s.getDriftPolicy() == DriftPolicyReconcile {
+			s.raiseDriftChange(sync, drift)
+		}
+	}
+	s.configDriftCacheLock.Lock()
+	s.configDriftCache[s.device.ID] = drift
+	s.configDriftCacheLock.Unlock()
+}
+
+// raiseDriftChange creates a network change that sets the intended values back on the device
+func (s *Session) raiseDriftChange(sync *Synchronizer, drift []*ConfigDrift) {
+	if s.networkChangeStore == nil {
+		log.Warnf("Cannot reconcile the config of %s without a network change store", s.device.ID)
+		return
+	}
+	change, err := sync.newDriftChange(drift)
+	if err != nil {
+		log.Errorf("Cannot reconcile the config of %s: %v", s.device.ID, err)
+		return
+	}
+	if err := s.networkChangeStore.Create(change); err != nil {
+		log.Errorf("Cannot reconcile the config of %s: %v", s.device.ID, err)
+		return
+	}
+	for _, d := range drift {
+		d.ChangeID = change.ID
+	}
+	log.Infof("Raised network change %s to reconcile the config of %s", change.ID, s.device.ID)
+}
+
+// getDriftPolicy returns the drift policy of the device, which is set with its onos-config.drift-policy
+// attribute, or else the default drift policy
+func (s *Session) getDriftPolicy() DriftPolicy {
+	attribute, ok := s.device.Attributes[driftPolicyKey]
+	if !ok {
+		return s.driftPolicy
+	}
+	policy, err := ParseDriftPolicy(attribute)
+	if err != nil {
+		log.Warnf("Ignoring the drift policy of %s: %v", s.device.ID, err)
+		return s.driftPolicy
+	}
+	return policy
+}
+
 // disconnects the gNMI session from the device
 func (s *Session) disconnect() error {
 	log.Info("Disconnecting device:", s.device)
@@ -205,6 +286,11 @@ func (s *Session) disconnect() error {
 	s.operationalStateCacheLock.Lock()
 	delete(s.operationalStateCache, s.device.ID)
 	s.operationalStateCacheLock.Unlock()
+	if s.configDriftCacheLock != nil {
+		s.configDriftCacheLock.Lock()
+		delete(s.configDriftCache, s.device.ID)
+		s.configDriftCacheLock.Unlock()
+	}
 	return nil
 }
 
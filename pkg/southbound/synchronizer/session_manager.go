@@ -24,6 +24,8 @@ import (
 	"github.com/onosproject/onos-config/pkg/modelregistry"
 	"github.com/onosproject/onos-config/pkg/southbound"
 	"github.com/onosproject/onos-config/pkg/store/change/device"
+	"github.com/onosproject/onos-config/pkg/store/change/device/state"
+	"github.com/onosproject/onos-config/pkg/store/change/network"
 	devicestore "github.com/onosproject/onos-config/pkg/store/device"
 	"github.com/onosproject/onos-config/pkg/store/mastership"
 )
@@ -41,6 +43,11 @@ type SessionManager struct {
 	newTargetFn               func() southbound.TargetIf
 	operationalStateCacheLock *sync.RWMutex
 	deviceChangeStore         device.Store
+	deviceStateStore          state.Store
+	networkChangeStore        network.Store
+	configDriftCache          map[topodevice.ID][]*ConfigDrift
+	configDriftCacheLock      *sync.RWMutex
+	driftPolicy               DriftPolicy
 	mastershipStore           mastership.Store
 	mu                        sync.RWMutex
 }
@@ -134,6 +141,42 @@ func WithDeviceChangeStore(deviceChangeStore device.Store) func(*SessionManager)
 	}
 }
 
+// WithDeviceStateStore sets device state store, which holds the intended config of each device
+func WithDeviceStateStore(deviceStateStore state.Store) func(*SessionManager) {
+	return func(sessionManager *SessionManager) {
+		sessionManager.deviceStateStore = deviceStateStore
+	}
+}
+
+// WithNetworkChangeStore sets network change store, through which config drift is reconciled
+func WithNetworkChangeStore(networkChangeStore network.Store) func(*SessionManager) {
+	return func(sessionManager *SessionManager) {
+		sessionManager.networkChangeStore = networkChangeStore
+	}
+}
+
+// WithConfigDriftCache sets config drift cache
+func WithConfigDriftCache(configDriftCache map[topodevice.ID][]*ConfigDrift) func(*SessionManager) {
+	return func(sessionManager *SessionManager) {
+		sessionManager.configDriftCache = configDriftCache
+	}
+}
+
+// WithConfigDriftCacheLock sets config drift cache lock
+func WithConfigDriftCacheLock(configDriftCacheLock *sync.RWMutex) func(*SessionManager) {
+	return func(sessionManager *SessionManager) {
+		sessionManager.configDriftCacheLock = configDriftCacheLock
+	}
+}
+
+// WithDriftPolicy sets what is done when the config of a device has drifted from its intended config, unless
+// the device has a drift policy of its own
+func WithDriftPolicy(driftPolicy DriftPolicy) func(*SessionManager) {
+	return func(sessionManager *SessionManager) {
+		sessionManager.driftPolicy = driftPolicy
+	}
+}
+
 // Start starts session manager
 func (sm *SessionManager) Start() error {
 	log.Info("Session manager started")
@@ -261,6 +304,11 @@ func (sm *SessionManager) createSession(device *topodevice.Device) error {
 		operationalStateCache:     sm.operationalStateCache,
 		operationalStateCacheLock: sm.operationalStateCacheLock,
 		deviceChangeStore:         sm.deviceChangeStore,
+		deviceStateStore:          sm.deviceStateStore,
+		networkChangeStore:        sm.networkChangeStore,
+		configDriftCache:          sm.configDriftCache,
+		configDriftCacheLock:      sm.configDriftCacheLock,
+		driftPolicy:               sm.driftPolicy,
 		device:                    device,
 		target:                    sm.newTargetFn(),
 		deviceStore:               sm.deviceStore,
@@ -37,6 +37,7 @@ func NewStore(networkChangeStore networkchangestore.Store, deviceSnapshotStore d
 		snapshotStore: deviceSnapshotStore,
 		devices:       make(map[devicetype.VersionedID]*deviceChangeStateStore),
 		waiters:       make(map[networkchange.Revision]chan struct{}),
+		rollbacks:     make(map[networkchange.ID]bool),
 	}
 	if err := store.listen(); err != nil {
 		return nil, err
@@ -57,7 +58,7 @@ type deviceChangeStoreStateStore struct {
 	devices       map[devicetype.VersionedID]*deviceChangeStateStore
 	waiters       map[networkchange.Revision]chan struct{}
 	changeIndex   networkchange.Index
-	rollbackIndex networkchange.Index
+	rollbacks     map[networkchange.ID]bool
 	revision      networkchange.Revision
 	mu            sync.RWMutex
 }
@@ -82,7 +83,12 @@ func (s *deviceChangeStoreStateStore) watch() error {
 func (s *deviceChangeStoreStateStore) processCh(ch chan stream.Event) {
 	for event := range ch {
 		s.mu.Lock()
-		err := s.processChange(event.Object.(*networkchange.NetworkChange))
+		var err error
+		if event.Type == stream.Deleted {
+			s.processDelete(event.Object.(*networkchange.NetworkChange))
+		} else {
+			err = s.processChange(event.Object.(*networkchange.NetworkChange))
+		}
 		s.mu.Unlock()
 		if err != nil {
 			go func() {
@@ -104,13 +110,14 @@ func (s *deviceChangeStoreStateStore) processChange(networkChange *networkchange
 		}
 		s.changeIndex = networkChange.Index
 	case changetype.Phase_ROLLBACK:
-		if networkChange.Index <= s.rollbackIndex {
+		// Changes are not necessarily rolled back in reverse order, so track each rolled back change
+		if s.rollbacks[networkChange.ID] {
 			return nil
 		}
 		if err := s.processNetworkRollback(networkChange); err != nil {
 			return err
 		}
-		s.rollbackIndex = networkChange.Index
+		s.rollbacks[networkChange.ID] = true
 	}
 
 	if networkChange.Revision > s.revision {
@@ -125,6 +132,11 @@ func (s *deviceChangeStoreStateStore) processChange(networkChange *networkchange
 	return nil
 }
 
+// processDelete forgets a change that has been deleted from the change store once it has been snapshotted
+func (s *deviceChangeStoreStateStore) processDelete(networkChange *networkchange.NetworkChange) {
+	delete(s.rollbacks, networkChange.ID)
+}
+
 func (s *deviceChangeStoreStateStore) processNetworkChange(networkChange *networkchange.NetworkChange) error {
 	for _, deviceChange := range networkChange.Changes {
 		state, ok := s.devices[deviceChange.GetVersionedDeviceID()]
@@ -188,14 +200,12 @@ func (s *deviceChangeStoreStateStore) processNetworkRollback(networkChange *netw
 		states[devChange.GetVersionedDeviceID()] = state
 	}
 
+	// Replay all the changes that have not been rolled back, including those after the rolled back
+	// change as it is not necessarily the last one
 	for netChange := range listCh {
-		if netChange.Index >= networkChange.Index {
-			listCtx.Close()
-			break
-		}
 		if netChange.Status.Phase == changetype.Phase_CHANGE {
 			for _, devChange := range netChange.Changes {
-				state, ok := s.devices[devChange.GetVersionedDeviceID()]
+				state, ok := states[devChange.GetVersionedDeviceID()]
 				if ok {
 					for _, value := range devChange.Values {
 						if value.Removed {
@@ -211,6 +221,7 @@ func (s *deviceChangeStoreStateStore) processNetworkRollback(networkChange *netw
 			}
 		}
 	}
+	listCtx.Close()
 	for device, state := range states {
 		s.devices[device] = state
 	}
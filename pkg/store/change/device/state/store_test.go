@@ -15,6 +15,7 @@
 package state
 
 import (
+	changetype "github.com/onosproject/onos-api/go/onos/config/change"
 	devicechange "github.com/onosproject/onos-api/go/onos/config/change/device"
 	networkchange "github.com/onosproject/onos-api/go/onos/config/change/network"
 	"github.com/onosproject/onos-api/go/onos/config/device"
@@ -86,3 +87,54 @@ func TestDeviceStateStore(t *testing.T) {
 	assert.NoError(t, err)
 	assert.Len(t, state, 0)
 }
+
+// TestDeviceStateStoreRollback tests that a change that is not the last one can be rolled back
+func TestDeviceStateStoreRollback(t *testing.T) {
+	changeStore, err := networkchangestore.NewLocalStore()
+	assert.NoError(t, err)
+	snapshotStore, err := devicesnapstore.NewLocalStore()
+	assert.NoError(t, err)
+
+	store, err := NewStore(changeStore, snapshotStore)
+	assert.NoError(t, err)
+	deviceID := device.NewVersionedID("test", "1.0.0")
+
+	newChange := func(path string, value string) *networkchange.NetworkChange {
+		return &networkchange.NetworkChange{
+			Changes: []*devicechange.Change{
+				{
+					DeviceID:      "test",
+					DeviceVersion: "1.0.0",
+					DeviceType:    "Stratum",
+					Values: []*devicechange.ChangeValue{
+						{
+							Path:  path,
+							Value: devicechange.NewTypedValueString(value),
+						},
+					},
+				},
+			},
+		}
+	}
+
+	change1 := newChange("/foo", "Hello world!")
+	err = changeStore.Create(change1)
+	assert.NoError(t, err)
+	change2 := newChange("/bar", "Goodbye world!")
+	err = changeStore.Create(change2)
+	assert.NoError(t, err)
+
+	state, err := store.Get(deviceID, change2.Revision)
+	assert.NoError(t, err)
+	assert.Len(t, state, 2)
+
+	// Roll back the first change, the second one must be kept
+	change1.Status.Phase = changetype.Phase_ROLLBACK
+	err = changeStore.Update(change1)
+	assert.NoError(t, err)
+
+	state, err = store.Get(deviceID, change1.Revision)
+	assert.NoError(t, err)
+	assert.Len(t, state, 1)
+	assert.Equal(t, "/bar", state[0].Path)
+}
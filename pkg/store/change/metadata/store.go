@@ -0,0 +1,281 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metadata
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/atomix/go-client/pkg/client/map"
+	"github.com/atomix/go-client/pkg/client/primitive"
+	"github.com/atomix/go-client/pkg/client/util/net"
+	changetypes "github.com/onosproject/onos-api/go/onos/config/change"
+	networkchange "github.com/onosproject/onos-api/go/onos/config/change/network"
+	devicetype "github.com/onosproject/onos-api/go/onos/config/device"
+	"github.com/onosproject/onos-config/pkg/config"
+	"github.com/onosproject/onos-config/pkg/store/stream"
+	"github.com/onosproject/onos-lib-go/pkg/atomix"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+)
+
+const metadataName = "network-change-metadata"
+
+// Revision is the revision of the metadata of a network change in the store
+type Revision uint64
+
+// ApplyPolicy is how a network change is applied when some of its devices are not connected
+type ApplyPolicy string
+
+const (
+	// ApplyPolicyAtomic holds the network change until all of its devices are connected. It is the default.
+	ApplyPolicyAtomic ApplyPolicy = ""
+	// ApplyPolicyBestEffort applies the network change to the devices that are connected, as long as there is one
+	ApplyPolicyBestEffort ApplyPolicy = "best-effort"
+	// ApplyPolicyQuorum applies the network change to the devices that are connected, once the quorum of them is
+	ApplyPolicyQuorum ApplyPolicy = "quorum"
+)
+
+// Metadata holds the attributes of a network change that the NetworkChange of the onos-api does not carry.
+// It is stored under the ID of the network change before the network change itself.
+type Metadata struct {
+	// ID is the identifier of the network change
+	ID networkchange.ID `json:"-"`
+
+	// NotBefore is the time before which the network change is not applied, if it is scheduled
+	NotBefore *time.Time `json:"not_before,omitempty"`
+
+	// ConfirmTimeout is the time within which the network change must be confirmed once it is complete, if any
+	ConfirmTimeout *time.Duration `json:"confirm_timeout,omitempty"`
+
+	// ConfirmDeadline is the time by which the complete network change must be confirmed, failing which it is
+	// rolled back
+	ConfirmDeadline *time.Time `json:"confirm_deadline,omitempty"`
+
+	// Confirmed indicates whether the network change has been confirmed
+	Confirmed bool `json:"confirmed,omitempty"`
+
+	// Username is the identity of the authenticated client that made the network change, if it was known
+	Username string `json:"username,omitempty"`
+
+	// Description describes what the network change is for
+	Description string `json:"description,omitempty"`
+
+	// Labels are free-form labels attached to the network change, e.g. a ticket number
+	Labels map[string]string `json:"labels,omitempty"`
+
+	// BaseIndex is the index of the network change that the change was based on, if it was made by a
+	// compare-and-set. The change fails if a change made after that index touches the same device paths.
+	BaseIndex *networkchange.Index `json:"base_index,omitempty"`
+
+	// ApplyPolicy is how the network change is applied when some of its devices are not connected
+	ApplyPolicy ApplyPolicy `json:"apply_policy,omitempty"`
+
+	// Quorum is the percentage of the devices that must be connected for a change with the quorum policy
+	Quorum uint32 `json:"quorum,omitempty"`
+
+	// Devices is the final status of the network change on each of its devices, once the change is complete. The
+	// status on a device that the change was deferred on is updated once the change has been applied to it.
+	Devices map[devicetype.ID]*changetypes.Status `json:"devices,omitempty"`
+
+	// Deferred are the devices that a best-effort or quorum network change has not been applied to yet because they
+	// were not connected. Their device changes are left PENDING until they connect.
+	Deferred []devicetype.ID `json:"deferred,omitempty"`
+
+	// RolledBack is the time at which the network change was rolled back, if it was complete by then
+	RolledBack *time.Time `json:"rolled_back,omitempty"`
+
+	// ApplyTimeouts are the devices that did not answer the set request of the network change, or of its rollback,
+	// within their apply timeout, along with that timeout
+	ApplyTimeouts map[devicetype.ID]time.Duration `json:"apply_timeouts,omitempty"`
+
+	// Revision is the revision of the metadata in the store
+	Revision Revision `json:"-"`
+}
+
+// NewAtomixStore returns a new persistent Store
+func NewAtomixStore(config config.Config) (Store, error) {
+	database, err := atomix.GetDatabase(config.Atomix, config.Atomix.GetDatabase(atomix.DatabaseTypeConsensus))
+	if err != nil {
+		return nil, err
+	}
+
+	metadata, err := database.GetMap(context.Background(), metadataName)
+	if err != nil {
+		return nil, errors.FromAtomix(err)
+	}
+
+	return &atomixStore{
+		metadata: metadata,
+	}, nil
+}
+
+// NewLocalStore returns a new local network change metadata store
+func NewLocalStore() (Store, error) {
+	_, address := atomix.StartLocalNode()
+	return newLocalStore(address)
+}
+
+// newLocalStore creates a new local network change metadata store
+func newLocalStore(address net.Address) (Store, error) {
+	name := primitive.Name{
+		Namespace: "local",
+		Name:      metadataName,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	session, err := primitive.NewSession(ctx, primitive.Partition{ID: 1, Address: address})
+	if err != nil {
+		return nil, errors.FromAtomix(err)
+	}
+	metadata, err := _map.New(context.Background(), name, []*primitive.Session{session})
+	if err != nil {
+		return nil, errors.FromAtomix(err)
+	}
+
+	return &atomixStore{
+		metadata: metadata,
+	}, nil
+}
+
+// Store stores the metadata of network changes
+type Store interface {
+	io.Closer
+
+	// Get gets the metadata of a network change, or nil if the change has none
+	Get(id networkchange.ID) (*Metadata, error)
+
+	// Create creates the metadata of a network change
+	Create(metadata *Metadata) error
+
+	// Update updates the metadata of a network change, unless it has been updated since it was read
+	Update(metadata *Metadata) error
+
+	// Delete deletes the metadata of a network change, if it has any
+	Delete(id networkchange.ID) error
+
+	// List lists the metadata of all network changes
+	List(chan<- *Metadata) (stream.Context, error)
+}
+
+// atomixStore is the default implementation of the network change metadata store
+type atomixStore struct {
+	metadata _map.Map
+}
+
+func (s *atomixStore) Get(id networkchange.ID) (*Metadata, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	entry, err := s.metadata.Get(ctx, string(id))
+	if err != nil {
+		err = errors.FromAtomix(err)
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	} else if entry == nil {
+		return nil, nil
+	}
+	return decodeMetadata(entry)
+}
+
+func (s *atomixStore) Create(metadata *Metadata) error {
+	if metadata.ID == "" {
+		return errors.NewInvalid("no network change ID specified")
+	}
+	if metadata.Revision != 0 {
+		return errors.NewInvalid("not a new object")
+	}
+	return s.put(metadata, _map.IfNotSet())
+}
+
+func (s *atomixStore) Update(metadata *Metadata) error {
+	if metadata.Revision == 0 {
+		return errors.NewInvalid("not a stored object")
+	}
+	return s.put(metadata, _map.IfVersion(_map.Version(metadata.Revision)))
+}
+
+// put writes the metadata to the map under the given condition and updates its revision
+func (s *atomixStore) put(metadata *Metadata, opt _map.PutOption) error {
+	bytes, err := json.Marshal(metadata)
+	if err != nil {
+		return errors.NewInvalid("metadata encoding failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	entry, err := s.metadata.Put(ctx, string(metadata.ID), bytes, opt)
+	if err != nil {
+		return errors.FromAtomix(err)
+	}
+	metadata.Revision = Revision(entry.Version)
+	return nil
+}
+
+func (s *atomixStore) Delete(id networkchange.ID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	if _, err := s.metadata.Remove(ctx, string(id)); err != nil {
+		err = errors.FromAtomix(err)
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *atomixStore) List(ch chan<- *Metadata) (stream.Context, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	mapCh := make(chan *_map.Entry)
+	if err := s.metadata.Entries(ctx, mapCh); err != nil {
+		cancel()
+		return nil, errors.FromAtomix(err)
+	}
+
+	go func() {
+		defer close(ch)
+		for entry := range mapCh {
+			if metadata, err := decodeMetadata(entry); err == nil {
+				ch <- metadata
+			}
+		}
+	}()
+	return stream.NewCancelContext(cancel), nil
+}
+
+func (s *atomixStore) Close() error {
+	err := s.metadata.Close(context.Background())
+	if err != nil {
+		return errors.FromAtomix(err)
+	}
+	return nil
+}
+
+func decodeMetadata(entry *_map.Entry) (*Metadata, error) {
+	metadata := &Metadata{}
+	if err := json.Unmarshal(entry.Value, metadata); err != nil {
+		return nil, errors.NewInvalid("metadata decoding failed: %v", err)
+	}
+	metadata.ID = networkchange.ID(entry.Key)
+	metadata.Revision = Revision(entry.Version)
+	return metadata, nil
+}
@@ -0,0 +1,95 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package metadata
+
+import (
+	"testing"
+	"time"
+
+	networkchange "github.com/onosproject/onos-api/go/onos/config/change/network"
+	"github.com/onosproject/onos-lib-go/pkg/atomix"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	change1 = networkchange.ID("change-1")
+	change2 = networkchange.ID("change-2")
+)
+
+func TestMetadataStore(t *testing.T) {
+	_, address := atomix.StartLocalNode()
+
+	store1, err := newLocalStore(address)
+	assert.NoError(t, err)
+	defer store1.Close()
+
+	store2, err := newLocalStore(address)
+	assert.NoError(t, err)
+	defer store2.Close()
+
+	// A change that has no metadata gets none
+	metadata, err := store1.Get(change1)
+	assert.NoError(t, err)
+	assert.Nil(t, metadata)
+
+	notBefore := time.Now().Add(time.Hour).Round(time.Second)
+	metadata1 := &Metadata{
+		ID:        change1,
+		NotBefore: &notBefore,
+	}
+	err = store1.Create(metadata1)
+	assert.NoError(t, err)
+	assert.NotEqual(t, Revision(0), metadata1.Revision)
+
+	err = store1.Create(&Metadata{ID: change2})
+	assert.NoError(t, err)
+
+	// The metadata is shared by the stores
+	metadata, err = store2.Get(change1)
+	assert.NoError(t, err)
+	assert.NotNil(t, metadata)
+	assert.Equal(t, change1, metadata.ID)
+	assert.True(t, notBefore.Equal(*metadata.NotBefore))
+
+	// The metadata of a change can only be created once
+	err = store2.Create(&Metadata{ID: change1})
+	assert.Error(t, err)
+
+	// An update based on an old revision fails
+	metadata.NotBefore = nil
+	err = store2.Update(metadata)
+	assert.NoError(t, err)
+	err = store1.Update(metadata1)
+	assert.Error(t, err)
+
+	ch := make(chan *Metadata)
+	_, err = store1.List(ch)
+	assert.NoError(t, err)
+	ids := make(map[networkchange.ID]bool)
+	for metadata := range ch {
+		ids[metadata.ID] = true
+	}
+	assert.Len(t, ids, 2)
+
+	err = store1.Delete(change1)
+	assert.NoError(t, err)
+	metadata, err = store2.Get(change1)
+	assert.NoError(t, err)
+	assert.Nil(t, metadata)
+
+	// Deleting the metadata of a change that has none is not an error
+	err = store2.Delete(change1)
+	assert.NoError(t, err)
+}
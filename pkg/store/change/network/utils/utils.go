@@ -0,0 +1,50 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"fmt"
+
+	networkchange "github.com/onosproject/onos-api/go/onos/config/change/network"
+	"github.com/onosproject/onos-config/pkg/utils"
+)
+
+// GetIntersectingPaths returns the paths changed by the later NetworkChange that intersect with the paths
+// changed by the given NetworkChange, in the form device:path. Two paths on the same device intersect when
+// they are equal or when one is below the other, e.g. when a container or a whole list is removed.
+func GetIntersectingPaths(change *networkchange.NetworkChange, later *networkchange.NetworkChange) []string {
+	intersecting := make([]string, 0)
+	found := make(map[string]bool)
+	for _, laterChange := range later.Changes {
+		for _, deviceChange := range change.Changes {
+			if deviceChange.DeviceID != laterChange.DeviceID {
+				continue
+			}
+			for _, laterValue := range laterChange.Values {
+				for _, value := range deviceChange.Values {
+					if !utils.IsIntersectingPath(value.Path, laterValue.Path) {
+						continue
+					}
+					path := fmt.Sprintf("%s:%s", laterChange.DeviceID, laterValue.Path)
+					if !found[path] {
+						found[path] = true
+						intersecting = append(intersecting, path)
+					}
+				}
+			}
+		}
+	}
+	return intersecting
+}
@@ -0,0 +1,81 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"testing"
+
+	devicechange "github.com/onosproject/onos-api/go/onos/config/change/device"
+	networkchange "github.com/onosproject/onos-api/go/onos/config/change/network"
+	"github.com/onosproject/onos-api/go/onos/config/device"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	device1 = device.ID("device-1")
+	device2 = device.ID("device-2")
+)
+
+// TestGetIntersectingPaths tests finding the paths of a later change that depend on an earlier change
+func TestGetIntersectingPaths(t *testing.T) {
+	earlier := newChange("change-1", device1, device2)
+	earlier.Changes[0].Values[0].Path = "/a/b"
+	earlier.Changes[1].Values[0].Path = "/x/y"
+
+	// The same path on another device does not intersect
+	later := newChange("change-2", device1)
+	later.Changes[0].Values[0].Path = "/x/y"
+	assert.Len(t, GetIntersectingPaths(earlier, later), 0)
+
+	// A path with a common prefix that is not a parent does not intersect
+	later.Changes[0].Values[0].Path = "/a/bc"
+	assert.Len(t, GetIntersectingPaths(earlier, later), 0)
+
+	// A path below a changed path intersects
+	later.Changes[0].Values[0].Path = "/a/b/c"
+	assert.Equal(t, []string{"device-1:/a/b/c"}, GetIntersectingPaths(earlier, later))
+
+	// A path above a changed path intersects, e.g. when the container is removed
+	later.Changes[0].Values[0].Path = "/a"
+	later.Changes[0].Values[0].Removed = true
+	assert.Equal(t, []string{"device-1:/a"}, GetIntersectingPaths(earlier, later))
+
+	// A list entry intersects with the whole list, but not with another entry of the list
+	earlier.Changes[0].Values[0].Path = "/a/list[name=x]/leaf"
+	later.Changes[0].Values[0].Path = "/a/list"
+	assert.Equal(t, []string{"device-1:/a/list"}, GetIntersectingPaths(earlier, later))
+	later.Changes[0].Values[0].Path = "/a/list[name=y]"
+	assert.Len(t, GetIntersectingPaths(earlier, later), 0)
+}
+
+func newChange(id networkchange.ID, devices ...device.ID) *networkchange.NetworkChange {
+	changes := make([]*devicechange.Change, len(devices))
+	for i, deviceID := range devices {
+		changes[i] = &devicechange.Change{
+			DeviceID:      deviceID,
+			DeviceVersion: "1.0.0",
+			Values: []*devicechange.ChangeValue{
+				{
+					Path:  "foo",
+					Value: devicechange.NewTypedValueString("Hello world!"),
+				},
+			},
+		}
+	}
+	return &networkchange.NetworkChange{
+		ID:      id,
+		Changes: changes,
+	}
+}
@@ -0,0 +1,259 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deadletter
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/atomix/go-client/pkg/client/map"
+	"github.com/atomix/go-client/pkg/client/primitive"
+	"github.com/atomix/go-client/pkg/client/util/net"
+	types "github.com/onosproject/onos-api/go/onos/config"
+	"github.com/onosproject/onos-config/pkg/config"
+	"github.com/onosproject/onos-config/pkg/store/stream"
+	"github.com/onosproject/onos-lib-go/pkg/atomix"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+)
+
+const deadLettersName = "controller-dead-letters"
+
+// DeadLetter is a request that a controller gave up on after failing to reconcile it too many times in a row
+type DeadLetter struct {
+	// Controller is the name of the controller
+	Controller string `json:"-"`
+
+	// ID is the identifier of the request
+	ID types.ID `json:"-"`
+
+	// Attempts is the number of times the request failed to reconcile
+	Attempts int `json:"attempts"`
+
+	// Error is the error of the last attempt
+	Error string `json:"error"`
+
+	// Time is the time at which the request was given up on
+	Time time.Time `json:"time"`
+}
+
+// NewAtomixStore returns a new persistent Store
+func NewAtomixStore(config config.Config) (Store, error) {
+	database, err := atomix.GetDatabase(config.Atomix, config.Atomix.GetDatabase(atomix.DatabaseTypeConsensus))
+	if err != nil {
+		return nil, err
+	}
+
+	letters, err := database.GetMap(context.Background(), deadLettersName)
+	if err != nil {
+		return nil, errors.FromAtomix(err)
+	}
+
+	return &atomixStore{
+		letters: letters,
+	}, nil
+}
+
+// NewLocalStore returns a new local dead letter store
+func NewLocalStore() (Store, error) {
+	_, address := atomix.StartLocalNode()
+	return newLocalStore(address)
+}
+
+// newLocalStore creates a new local dead letter store
+func newLocalStore(address net.Address) (Store, error) {
+	name := primitive.Name{
+		Namespace: "local",
+		Name:      deadLettersName,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	session, err := primitive.NewSession(ctx, primitive.Partition{ID: 1, Address: address})
+	if err != nil {
+		return nil, errors.FromAtomix(err)
+	}
+	letters, err := _map.New(context.Background(), name, []*primitive.Session{session})
+	if err != nil {
+		return nil, errors.FromAtomix(err)
+	}
+
+	return &atomixStore{
+		letters: letters,
+	}, nil
+}
+
+// Store stores the requests that the controllers have given up on, so that they are known to every instance
+// and survive a restart
+type Store interface {
+	io.Closer
+
+	// Get gets the dead letter of a request of a controller, or nil if the controller has not given up on it
+	Get(controller string, id types.ID) (*DeadLetter, error)
+
+	// Put stores a dead letter
+	Put(letter *DeadLetter) error
+
+	// Delete deletes the dead letter of a request of a controller, if there is one
+	Delete(controller string, id types.ID) error
+
+	// List lists the dead letters of all the controllers
+	List(chan<- *DeadLetter) (stream.Context, error)
+
+	// Watch watches the dead letters of all the controllers for changes
+	Watch(chan<- stream.Event) (stream.Context, error)
+}
+
+// atomixStore is the default implementation of the dead letter store
+type atomixStore struct {
+	letters _map.Map
+}
+
+func (s *atomixStore) Get(controller string, id types.ID) (*DeadLetter, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	entry, err := s.letters.Get(ctx, getKey(controller, id))
+	if err != nil {
+		err = errors.FromAtomix(err)
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	} else if entry == nil {
+		return nil, nil
+	}
+	return decodeDeadLetter(entry)
+}
+
+func (s *atomixStore) Put(letter *DeadLetter) error {
+	if letter.Controller == "" {
+		return errors.NewInvalid("no controller specified")
+	}
+	if letter.ID == "" {
+		return errors.NewInvalid("no request ID specified")
+	}
+
+	bytes, err := json.Marshal(letter)
+	if err != nil {
+		return errors.NewInvalid("dead letter encoding failed: %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	if _, err := s.letters.Put(ctx, getKey(letter.Controller, letter.ID), bytes); err != nil {
+		return errors.FromAtomix(err)
+	}
+	return nil
+}
+
+func (s *atomixStore) Delete(controller string, id types.ID) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	if _, err := s.letters.Remove(ctx, getKey(controller, id)); err != nil {
+		err = errors.FromAtomix(err)
+		if errors.IsNotFound(err) {
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *atomixStore) List(ch chan<- *DeadLetter) (stream.Context, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	mapCh := make(chan *_map.Entry)
+	if err := s.letters.Entries(ctx, mapCh); err != nil {
+		cancel()
+		return nil, errors.FromAtomix(err)
+	}
+
+	go func() {
+		defer close(ch)
+		for entry := range mapCh {
+			if letter, err := decodeDeadLetter(entry); err == nil {
+				ch <- letter
+			}
+		}
+	}()
+	return stream.NewCancelContext(cancel), nil
+}
+
+func (s *atomixStore) Watch(ch chan<- stream.Event) (stream.Context, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	mapCh := make(chan *_map.Event)
+	if err := s.letters.Watch(ctx, mapCh); err != nil {
+		cancel()
+		return nil, errors.FromAtomix(err)
+	}
+
+	go func() {
+		defer close(ch)
+		for event := range mapCh {
+			if letter, err := decodeDeadLetter(event.Entry); err == nil {
+				switch event.Type {
+				case _map.EventInserted:
+					ch <- stream.Event{
+						Type:   stream.Created,
+						Object: letter,
+					}
+				case _map.EventUpdated:
+					ch <- stream.Event{
+						Type:   stream.Updated,
+						Object: letter,
+					}
+				case _map.EventRemoved:
+					ch <- stream.Event{
+						Type:   stream.Deleted,
+						Object: letter,
+					}
+				}
+			}
+		}
+	}()
+	return stream.NewCancelContext(cancel), nil
+}
+
+func (s *atomixStore) Close() error {
+	err := s.letters.Close(context.Background())
+	if err != nil {
+		return errors.FromAtomix(err)
+	}
+	return nil
+}
+
+// getKey returns the key of the dead letter of a request of a controller in the map
+func getKey(controller string, id types.ID) string {
+	return controller + "/" + string(id)
+}
+
+func decodeDeadLetter(entry *_map.Entry) (*DeadLetter, error) {
+	letter := &DeadLetter{}
+	if err := json.Unmarshal(entry.Value, letter); err != nil {
+		return nil, errors.NewInvalid("dead letter decoding failed: %v", err)
+	}
+	i := strings.Index(entry.Key, "/")
+	if i < 0 {
+		return nil, errors.NewInvalid("dead letter key %s has no controller", entry.Key)
+	}
+	letter.Controller = entry.Key[:i]
+	letter.ID = types.ID(entry.Key[i+1:])
+	return letter, nil
+}
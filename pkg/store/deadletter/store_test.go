@@ -0,0 +1,125 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package deadletter
+
+import (
+	"testing"
+	"time"
+
+	types "github.com/onosproject/onos-api/go/onos/config"
+	"github.com/onosproject/onos-config/pkg/store/stream"
+	"github.com/onosproject/onos-lib-go/pkg/atomix"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	controller1 = "NetworkChange"
+	controller2 = "DeviceChange"
+	change1     = types.ID("change-1")
+	change2     = types.ID("change-2")
+)
+
+func TestDeadLetterStore(t *testing.T) {
+	_, address := atomix.StartLocalNode()
+
+	store1, err := newLocalStore(address)
+	assert.NoError(t, err)
+	defer store1.Close()
+
+	store2, err := newLocalStore(address)
+	assert.NoError(t, err)
+	defer store2.Close()
+
+	// A request that has not been given up on has no dead letter
+	letter, err := store1.Get(controller1, change1)
+	assert.NoError(t, err)
+	assert.Nil(t, letter)
+
+	events := make(chan stream.Event)
+	ctx, err := store2.Watch(events)
+	assert.NoError(t, err)
+	defer ctx.Close()
+
+	// The dead letters of a request are kept per controller
+	now := time.Now()
+	err = store1.Put(&DeadLetter{
+		Controller: controller1,
+		ID:         change1,
+		Attempts:   3,
+		Error:      "some error",
+		Time:       now,
+	})
+	assert.NoError(t, err)
+	err = store1.Put(&DeadLetter{
+		Controller: controller2,
+		ID:         change1,
+		Attempts:   5,
+		Error:      "another error",
+		Time:       now,
+	})
+	assert.NoError(t, err)
+
+	event := nextEvent(t, events)
+	assert.Equal(t, stream.Created, event.Type)
+	assert.Equal(t, controller1, event.Object.(*DeadLetter).Controller)
+	nextEvent(t, events)
+
+	letter, err = store2.Get(controller1, change1)
+	assert.NoError(t, err)
+	assert.NotNil(t, letter)
+	assert.Equal(t, controller1, letter.Controller)
+	assert.Equal(t, change1, letter.ID)
+	assert.Equal(t, 3, letter.Attempts)
+	assert.Equal(t, "some error", letter.Error)
+	assert.True(t, now.Equal(letter.Time))
+
+	letter, err = store2.Get(controller1, change2)
+	assert.NoError(t, err)
+	assert.Nil(t, letter)
+
+	// List the dead letters of all the controllers
+	ch := make(chan *DeadLetter)
+	_, err = store2.List(ch)
+	assert.NoError(t, err)
+	attempts := make(map[string]int)
+	for letter := range ch {
+		attempts[letter.Controller] = letter.Attempts
+	}
+	assert.Equal(t, map[string]int{controller1: 3, controller2: 5}, attempts)
+
+	// Deleting a dead letter is notified, and deleting one that does not exist is not an error
+	err = store1.Delete(controller1, change1)
+	assert.NoError(t, err)
+	event = nextEvent(t, events)
+	assert.Equal(t, stream.Deleted, event.Type)
+	assert.Equal(t, controller1, event.Object.(*DeadLetter).Controller)
+	assert.Equal(t, change1, event.Object.(*DeadLetter).ID)
+
+	err = store1.Delete(controller1, change1)
+	assert.NoError(t, err)
+	letter, err = store2.Get(controller1, change1)
+	assert.NoError(t, err)
+	assert.Nil(t, letter)
+}
+
+func nextEvent(t *testing.T, ch chan stream.Event) stream.Event {
+	select {
+	case event := <-ch:
+		return event
+	case <-time.After(5 * time.Second):
+		t.FailNow()
+	}
+	return stream.Event{}
+}
@@ -0,0 +1,328 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lock
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/atomix/go-client/pkg/client/map"
+	"github.com/atomix/go-client/pkg/client/primitive"
+	"github.com/atomix/go-client/pkg/client/util/net"
+	devicetype "github.com/onosproject/onos-api/go/onos/config/device"
+	"github.com/onosproject/onos-config/pkg/config"
+	"github.com/onosproject/onos-config/pkg/store/stream"
+	"github.com/onosproject/onos-lib-go/pkg/atomix"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/onosproject/onos-lib-go/pkg/logging"
+)
+
+var log = logging.GetLogger("store", "lock")
+
+const deviceLocksName = "device-locks"
+
+// Revision is the revision of a device lock in the store
+type Revision uint64
+
+// DeviceLock is a lease on the configuration of a device, held by a client until it expires or is released
+type DeviceLock struct {
+	// DeviceID is the identifier of the locked device
+	DeviceID devicetype.ID `json:"-"`
+
+	// Owner is the identity of the client holding the lock
+	Owner string `json:"owner"`
+
+	// Expires is the time at which the lock is released if it has not been renewed
+	Expires time.Time `json:"expires"`
+
+	// Revision is the revision of the lock in the store
+	Revision Revision `json:"-"`
+}
+
+// IsExpired indicates whether the lock has expired
+func (l *DeviceLock) IsExpired() bool {
+	return !time.Now().Before(l.Expires)
+}
+
+// NewAtomixStore returns a new persistent Store
+func NewAtomixStore(config config.Config) (Store, error) {
+	database, err := atomix.GetDatabase(config.Atomix, config.Atomix.GetDatabase(atomix.DatabaseTypeConsensus))
+	if err != nil {
+		return nil, err
+	}
+
+	locks, err := database.GetMap(context.Background(), deviceLocksName)
+	if err != nil {
+		return nil, errors.FromAtomix(err)
+	}
+
+	return &atomixStore{
+		locks: locks,
+	}, nil
+}
+
+// NewLocalStore returns a new local device lock store
+func NewLocalStore() (Store, error) {
+	_, address := atomix.StartLocalNode()
+	return newLocalStore(address)
+}
+
+// newLocalStore creates a new local device lock store
+func newLocalStore(address net.Address) (Store, error) {
+	name := primitive.Name{
+		Namespace: "local",
+		Name:      deviceLocksName,
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	session, err := primitive.NewSession(ctx, primitive.Partition{ID: 1, Address: address})
+	if err != nil {
+		return nil, errors.FromAtomix(err)
+	}
+	locks, err := _map.New(context.Background(), name, []*primitive.Session{session})
+	if err != nil {
+		return nil, errors.FromAtomix(err)
+	}
+
+	return &atomixStore{
+		locks: locks,
+	}, nil
+}
+
+// Store stores the locks held by clients on devices
+type Store interface {
+	io.Closer
+
+	// Get gets the lock on a device, or nil if the device is not locked
+	Get(id devicetype.ID) (*DeviceLock, error)
+
+	// Lock locks the given devices for the owner until the ttl expires, renewing the locks the owner already
+	// holds. Either all the devices are locked or, if any of them is locked by another owner, none of them is
+	// and the locks the owner already held are left as they were.
+	Lock(ids []devicetype.ID, owner string, ttl time.Duration) ([]*DeviceLock, error)
+
+	// Unlock releases the locks held by the owner on the given devices
+	Unlock(ids []devicetype.ID, owner string) error
+
+	// List lists the locks that have not expired
+	List(chan<- *DeviceLock) (stream.Context, error)
+}
+
+// atomixStore is the default implementation of the device lock store
+type atomixStore struct {
+	locks _map.Map
+}
+
+func (s *atomixStore) Get(id devicetype.ID) (*DeviceLock, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	entry, err := s.locks.Get(ctx, string(id))
+	if err != nil {
+		err = errors.FromAtomix(err)
+		if errors.IsNotFound(err) {
+			return nil, nil
+		}
+		return nil, err
+	} else if entry == nil {
+		return nil, nil
+	}
+	lock, err := decodeLock(entry)
+	if err != nil {
+		return nil, err
+	} else if lock.IsExpired() {
+		return nil, nil
+	}
+	return lock, nil
+}
+
+func (s *atomixStore) Lock(ids []devicetype.ID, owner string, ttl time.Duration) ([]*DeviceLock, error) {
+	if owner == "" {
+		return nil, errors.NewInvalid("no owner specified")
+	}
+	if ttl <= 0 {
+		return nil, errors.NewInvalid("lock ttl must be positive")
+	}
+
+	// Devices are always locked in the same order so that two owners locking the same devices do not
+	// repeatedly undo each other's locks
+	sorted := make([]devicetype.ID, len(ids))
+	copy(sorted, ids)
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i] < sorted[j]
+	})
+
+	locks := make([]*DeviceLock, 0, len(sorted))
+	renewed := make([]*DeviceLock, 0, len(sorted))
+	for _, id := range sorted {
+		lock, previous, err := s.lock(id, owner, ttl)
+		if err != nil {
+			// Undo what this call did: the locks it created are released, and the locks the owner already
+			// held are given back their earlier expiry
+			for i, acquired := range locks {
+				if renewed[i] != nil {
+					if errRestore := s.restore(acquired, renewed[i]); errRestore != nil {
+						log.Warnf("Failed to restore lock on %s: %s", acquired.DeviceID, errRestore)
+					}
+				} else if errRelease := s.release(acquired); errRelease != nil {
+					log.Warnf("Failed to release lock on %s: %s", acquired.DeviceID, errRelease)
+				}
+			}
+			return nil, err
+		}
+		locks = append(locks, lock)
+		renewed = append(renewed, previous)
+	}
+	return locks, nil
+}
+
+// lock locks a single device for the owner, replacing an expired lock or renewing the owner's own lock. The lock
+// that was renewed, if any, is returned along with the new one.
+func (s *atomixStore) lock(id devicetype.ID, owner string, ttl time.Duration) (*DeviceLock, *DeviceLock, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	entry, err := s.locks.Get(ctx, string(id))
+	if err != nil {
+		err = errors.FromAtomix(err)
+		if !errors.IsNotFound(err) {
+			return nil, nil, err
+		}
+		entry = nil
+	}
+
+	var opt _map.PutOption = _map.IfNotSet()
+	var previous *DeviceLock
+	if entry != nil {
+		current, err := decodeLock(entry)
+		if err != nil {
+			return nil, nil, err
+		} else if current.Owner != owner && !current.IsExpired() {
+			return nil, nil, errors.NewConflict("device %s is locked by %s until %s", id, current.Owner,
+				current.Expires.Format(time.RFC3339))
+		} else if !current.IsExpired() {
+			previous = current
+		}
+		opt = _map.IfVersion(_map.Version(entry.Version))
+	}
+
+	lock := &DeviceLock{
+		DeviceID: id,
+		Owner:    owner,
+		Expires:  time.Now().Add(ttl),
+	}
+	if err := s.put(ctx, lock, opt); err != nil {
+		return nil, nil, err
+	}
+	return lock, previous, nil
+}
+
+// restore puts back a lock that was renewed by the store, unless the renewed lock has been replaced in the meantime
+func (s *atomixStore) restore(lock *DeviceLock, previous *DeviceLock) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	restored := &DeviceLock{
+		DeviceID: previous.DeviceID,
+		Owner:    previous.Owner,
+		Expires:  previous.Expires,
+	}
+	return s.put(ctx, restored, _map.IfVersion(_map.Version(lock.Revision)))
+}
+
+// put writes a lock to the map under the given condition and updates its revision
+func (s *atomixStore) put(ctx context.Context, lock *DeviceLock, opt _map.PutOption) error {
+	bytes, err := json.Marshal(lock)
+	if err != nil {
+		return errors.NewInvalid("lock encoding failed: %v", err)
+	}
+
+	entry, err := s.locks.Put(ctx, string(lock.DeviceID), bytes, opt)
+	if err != nil {
+		return errors.FromAtomix(err)
+	}
+	lock.Revision = Revision(entry.Version)
+	return nil
+}
+
+// release removes a lock acquired by the store, unless it has been replaced in the meantime
+func (s *atomixStore) release(lock *DeviceLock) error {
+	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
+	defer cancel()
+
+	_, err := s.locks.Remove(ctx, string(lock.DeviceID), _map.IfVersion(_map.Version(lock.Revision)))
+	if err != nil {
+		return errors.FromAtomix(err)
+	}
+	return nil
+}
+
+func (s *atomixStore) Unlock(ids []devicetype.ID, owner string) error {
+	for _, id := range ids {
+		lock, err := s.Get(id)
+		if err != nil {
+			return err
+		} else if lock == nil {
+			continue
+		} else if lock.Owner != owner {
+			return errors.NewForbidden("device %s is locked by %s", id, lock.Owner)
+		}
+		if err := s.release(lock); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *atomixStore) List(ch chan<- *DeviceLock) (stream.Context, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	mapCh := make(chan *_map.Entry)
+	if err := s.locks.Entries(ctx, mapCh); err != nil {
+		cancel()
+		return nil, errors.FromAtomix(err)
+	}
+
+	go func() {
+		defer close(ch)
+		for entry := range mapCh {
+			if lock, err := decodeLock(entry); err == nil && !lock.IsExpired() {
+				ch <- lock
+			}
+		}
+	}()
+	return stream.NewCancelContext(cancel), nil
+}
+
+func (s *atomixStore) Close() error {
+	err := s.locks.Close(context.Background())
+	if err != nil {
+		return errors.FromAtomix(err)
+	}
+	return nil
+}
+
+func decodeLock(entry *_map.Entry) (*DeviceLock, error) {
+	lock := &DeviceLock{}
+	if err := json.Unmarshal(entry.Value, lock); err != nil {
+		return nil, errors.NewInvalid("lock decoding failed: %v", err)
+	}
+	lock.DeviceID = devicetype.ID(entry.Key)
+	lock.Revision = Revision(entry.Version)
+	return lock, nil
+}
@@ -0,0 +1,128 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lock
+
+import (
+	"testing"
+	"time"
+
+	devicetype "github.com/onosproject/onos-api/go/onos/config/device"
+	"github.com/onosproject/onos-lib-go/pkg/atomix"
+	"github.com/onosproject/onos-lib-go/pkg/errors"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	device1 = devicetype.ID("device-1")
+	device2 = devicetype.ID("device-2")
+	device3 = devicetype.ID("device-3")
+	client1 = "client-1"
+	client2 = "client-2"
+)
+
+func TestDeviceLockStore(t *testing.T) {
+	_, address := atomix.StartLocalNode()
+
+	store1, err := newLocalStore(address)
+	assert.NoError(t, err)
+	defer store1.Close()
+
+	store2, err := newLocalStore(address)
+	assert.NoError(t, err)
+	defer store2.Close()
+
+	// A device that has never been locked has no lock
+	lock, err := store1.Get(device1)
+	assert.NoError(t, err)
+	assert.Nil(t, lock)
+
+	// Lock two devices
+	locks, err := store1.Lock([]devicetype.ID{device2, device1}, client1, time.Minute)
+	assert.NoError(t, err)
+	assert.Len(t, locks, 2)
+	assert.Equal(t, device1, locks[0].DeviceID)
+	assert.Equal(t, device2, locks[1].DeviceID)
+
+	lock, err = store2.Get(device1)
+	assert.NoError(t, err)
+	assert.NotNil(t, lock)
+	assert.Equal(t, client1, lock.Owner)
+	assert.True(t, lock.Expires.After(time.Now()))
+	assert.NotEqual(t, Revision(0), lock.Revision)
+
+	// Another client can not lock a device that is already locked, and does not lock any of the other devices
+	_, err = store2.Lock([]devicetype.ID{device3, device2}, client2, time.Minute)
+	assert.Error(t, err)
+	assert.True(t, errors.IsConflict(err))
+	lock, err = store1.Get(device3)
+	assert.NoError(t, err)
+	assert.Nil(t, lock)
+
+	// A failed lock leaves the locks the owner already held as they were
+	_, err = store1.Lock([]devicetype.ID{device3}, client2, time.Minute)
+	assert.NoError(t, err)
+	_, err = store2.Lock([]devicetype.ID{device1, device2, device3}, client1, time.Hour)
+	assert.Error(t, err)
+	assert.True(t, errors.IsConflict(err))
+	lock, err = store1.Get(device1)
+	assert.NoError(t, err)
+	assert.NotNil(t, lock)
+	assert.Equal(t, client1, lock.Owner)
+	assert.True(t, locks[0].Expires.Equal(lock.Expires))
+	lock, err = store1.Get(device2)
+	assert.NoError(t, err)
+	assert.NotNil(t, lock)
+	assert.Equal(t, client1, lock.Owner)
+	assert.True(t, locks[1].Expires.Equal(lock.Expires))
+	err = store1.Unlock([]devicetype.ID{device3}, client2)
+	assert.NoError(t, err)
+
+	// The owner can renew its lock
+	expires := locks[0].Expires
+	locks, err = store2.Lock([]devicetype.ID{device1}, client1, time.Hour)
+	assert.NoError(t, err)
+	assert.Len(t, locks, 1)
+	assert.True(t, locks[0].Expires.After(expires))
+
+	// Only the owner can unlock a device
+	err = store2.Unlock([]devicetype.ID{device1}, client2)
+	assert.Error(t, err)
+	err = store2.Unlock([]devicetype.ID{device1}, client1)
+	assert.NoError(t, err)
+	lock, err = store1.Get(device1)
+	assert.NoError(t, err)
+	assert.Nil(t, lock)
+
+	// An expired lock is not held anymore, and can be taken by another client
+	_, err = store1.Lock([]devicetype.ID{device3}, client1, 100*time.Millisecond)
+	assert.NoError(t, err)
+	time.Sleep(200 * time.Millisecond)
+	lock, err = store2.Get(device3)
+	assert.NoError(t, err)
+	assert.Nil(t, lock)
+	locks, err = store2.Lock([]devicetype.ID{device3}, client2, time.Minute)
+	assert.NoError(t, err)
+	assert.Equal(t, client2, locks[0].Owner)
+
+	// List the locks that are held
+	ch := make(chan *DeviceLock)
+	_, err = store1.List(ch)
+	assert.NoError(t, err)
+	owners := make(map[devicetype.ID]string)
+	for lock := range ch {
+		owners[lock.DeviceID] = lock.Owner
+	}
+	assert.Equal(t, map[devicetype.ID]string{device2: client1, device3: client2}, owners)
+}
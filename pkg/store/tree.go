@@ -17,10 +17,13 @@ package store
 import (
 	"encoding/json"
 	"fmt"
-	devicechange "github.com/onosproject/onos-api/go/onos/config/change/device"
-	"github.com/onosproject/onos-config/pkg/utils"
 	"strconv"
 	"strings"
+
+	devicechange "github.com/onosproject/onos-api/go/onos/config/change/device"
+	"github.com/onosproject/onos-config/pkg/modelregistry"
+	"github.com/onosproject/onos-config/pkg/utils"
+	"github.com/openconfig/goyang/pkg/yang"
 )
 
 const (
@@ -28,19 +31,89 @@ const (
 	equals    = "="
 	bracketsq = "["
 	brktclose = "]"
+	colon     = ":"
 )
 
+// TreeOption sets an optional attribute of the tree built by BuildTree
+type TreeOption func(*treeBuilder)
+
+// WithPathMaps takes the types of the list keys and leaves of the tree from the read write and read only
+// paths of the device's model, rather than guessing them from the values. Either of them may be nil.
+func WithPathMaps(rwPaths modelregistry.ReadWritePathMap, roPaths modelregistry.ReadOnlyPathMap) TreeOption {
+	return func(b *treeBuilder) {
+		b.rwPaths = rwPaths
+		b.roPaths = roPaths
+	}
+}
+
+// WithSchema takes the types of the list keys and leaves of the tree from the YANG schema of the device's
+// model, as given by ModelPlugin.Schema(). Where the schema knows the modules that define the nodes and
+// identities, the member names and identityref values of an RFC 7951 tree are qualified by module.
+func WithSchema(schema map[string]*yang.Entry) TreeOption {
+	return func(b *treeBuilder) {
+		b.schema = schema["Device"]
+	}
+}
+
+// QualifiesNames indicates whether the schema of a model knows the modules that define its nodes, so that the
+// member names of an RFC 7951 tree built with it are qualified by module. The schemas that model plugins give
+// are serialized, which loses the modules.
+func QualifiesNames(schema map[string]*yang.Entry) bool {
+	device := schema["Device"]
+	if device == nil || len(device.Dir) == 0 {
+		return false
+	}
+	for _, child := range device.Dir {
+		if entryModule(child) == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// treeBuilder builds up a JSON tree, typed from the model of the device where there is one
+type treeBuilder struct {
+	jsonRFC7951 bool
+	rwPaths     modelregistry.ReadWritePathMap
+	roPaths     modelregistry.ReadOnlyPathMap
+	schema      *yang.Entry
+	// modelAttribs indexes the path maps by path without indices
+	modelAttribs map[string]modelregistry.ReadOnlyAttrib
+	// entries caches the schema entries by path without indices
+	entries map[string]*yang.Entry
+}
+
+// modelNode is what the model tells of the type of a leaf
+type modelNode struct {
+	valueType devicechange.ValueType
+	typeOpts  []uint8
+	// union is set for a leaf of several types, which can only be guessed from its value
+	union bool
+	// enum gives the names of the values of an enumeration or identityref
+	enum map[int]string
+	// identities gives the modules of the identities of an identityref, where they are known
+	identities map[string]string
+}
+
 // BuildTree is a function that takes an ordered array of ConfigValues and
 // produces a structured formatted JSON tree
 // For YANG the only type of float value is deicmal, which is represented as a
 // string - therefore all float value must be string in JSON
 // Same with int64 and uin64 as per RFC 7951
-func BuildTree(values []*devicechange.PathValue, jsonRFC7951 bool) ([]byte, error) {
+// The types of list keys are guessed from their values, unless the model is
+// given with WithPathMaps or WithSchema
+func BuildTree(values []*devicechange.PathValue, jsonRFC7951 bool, opts ...TreeOption) ([]byte, error) {
+	b := &treeBuilder{
+		jsonRFC7951: jsonRFC7951,
+	}
+	for _, opt := range opts {
+		opt(b)
+	}
 
 	root := make(map[string]interface{})
 	rootif := interface{}(root)
 	for _, cv := range values {
-		err := addPathToTree(cv.Path, cv.GetValue(), &rootif, jsonRFC7951)
+		err := b.addPathToTree(cv.Path, cv.GetValue(), &rootif, "")
 		if err != nil {
 			return nil, err
 		}
@@ -58,7 +131,8 @@ func BuildTree(values []*devicechange.PathValue, jsonRFC7951 bool) ([]byte, erro
 // suitable for using with json.Marshal, which in turn can be used to feed in to
 // ygot.Unmarshall
 // This follows the approach in https://blog.golang.org/json-and-go "Generic JSON with interface{}"
-func addPathToTree(path string, value *devicechange.TypedValue, nodeif *interface{}, jsonRFC7951 bool) error {
+// parentPath is the part of the full path that has already been added to the tree
+func (b *treeBuilder) addPathToTree(path string, value *devicechange.TypedValue, nodeif *interface{}, parentPath string) error {
 	pathelems := utils.SplitPath(path)
 
 	// Convert to its real type
@@ -69,7 +143,9 @@ func addPathToTree(path string, value *devicechange.TypedValue, nodeif *interfac
 
 	if len(pathelems) == 1 && len(value.Bytes) > 0 {
 		// At the end of a line - this is the leaf
-		handleLeafValue(nodemap, value, pathelems, jsonRFC7951)
+		name := b.memberName(parentPath, pathelems[0])
+		handleLeafValue(nodemap, value, name, b.jsonRFC7951)
+		b.applyModel(nodemap, name, value, b.modelNode(parentPath+slash+pathelems[0]))
 
 	} else if strings.Contains(pathelems[0], equals) {
 		// To handle list index items
@@ -80,7 +156,8 @@ func addPathToTree(path string, value *devicechange.TypedValue, nodeif *interfac
 		refinePath = fmt.Sprintf("/%s", refinePath)
 
 		brktIdx := strings.Index(pathelems[0], bracketsq)
-		listName := pathelems[0][:brktIdx]
+		listName := b.memberName(parentPath, pathelems[0][:brktIdx])
+		listPath := parentPath + slash + pathelems[0]
 
 		// Build up a map of keyName to keyVal
 		keyMap := make(map[string]interface{})
@@ -92,15 +169,7 @@ func addPathToTree(path string, value *devicechange.TypedValue, nodeif *interfac
 
 			keyName := keyString[brktIdx+1 : eqIdx]
 			keyVal := keyString[eqIdx+1 : brktIdx2]
-			keyValNum, keyValNumErr := strconv.Atoi(keyVal)
-
-			// TODO: Bug here, see AETHER-506. 'ueid' and 'subscriber-ueid' are
-			// strings composed of digits, and get converted to integers.
-			if keyValNumErr == nil {
-				keyMap[keyName] = keyValNum
-			} else {
-				keyMap[keyName] = keyVal
-			}
+			keyMap[keyName] = b.keyValue(listPath+slash+keyName, keyVal)
 
 			// position to look at next potential key string
 			keyString = keyString[brktIdx2+1:]
@@ -126,7 +195,8 @@ func addPathToTree(path string, value *devicechange.TypedValue, nodeif *interfac
 			}
 			for k, v := range keyMap {
 				if l, ok := lsMap[k]; ok {
-					if l == v {
+					// The key may since have been set from its leaf, with a Go type of another width
+					if fmt.Sprint(l) == fmt.Sprint(v) {
 						foundkeys++
 						listItemMap = lsMap
 					}
@@ -137,7 +207,7 @@ func addPathToTree(path string, value *devicechange.TypedValue, nodeif *interfac
 			listItemMap = keyMap
 		}
 		listItemIf := interface{}(listItemMap)
-		err := addPathToTree(refinePath, value, &listItemIf, jsonRFC7951)
+		err := b.addPathToTree(refinePath, value, &listItemIf, listPath)
 		if err != nil {
 			return err
 		}
@@ -151,20 +221,22 @@ func addPathToTree(path string, value *devicechange.TypedValue, nodeif *interfac
 			return nil
 		}
 		refinePath = fmt.Sprintf("%s%s", slash, refinePath)
+		name := b.memberName(parentPath, pathelems[0])
+		elemPath := parentPath + slash + pathelems[0]
 
-		elemMap, ok := (nodemap)[pathelems[0]]
+		elemMap, ok := (nodemap)[name]
 		if !ok {
 			elemMap = make(map[string]interface{})
 			elemIf := elemMap
 
-			err := addPathToTree(refinePath, value, &elemIf, jsonRFC7951)
+			err := b.addPathToTree(refinePath, value, &elemIf, elemPath)
 			if err != nil {
 				return err
 			}
-			(nodemap)[pathelems[0]] = elemMap
+			(nodemap)[name] = elemMap
 		} else {
 			//Reuse existing elemMap
-			err := addPathToTree(refinePath, value, &elemMap, jsonRFC7951)
+			err := b.addPathToTree(refinePath, value, &elemMap, elemPath)
 			if err != nil {
 				return err
 			}
@@ -174,42 +246,291 @@ func addPathToTree(path string, value *devicechange.TypedValue, nodeif *interfac
 	return nil
 }
 
-func handleLeafValue(nodemap map[string]interface{}, value *devicechange.TypedValue, pathelems []string, jsonRFC7951 bool) {
+// keyValue gives the value of a list key in the type of its leaf in the model. Without a model, or for a
+// key of several types, the type can only be guessed from the value.
+func (b *treeBuilder) keyValue(keyPath string, keyVal string) interface{} {
+	node := b.modelNode(keyPath)
+	if node == nil || node.union {
+		if keyValNum, err := strconv.Atoi(keyVal); err == nil {
+			return keyValNum
+		}
+		return keyVal
+	}
+
+	switch node.valueType {
+	case devicechange.ValueType_INT:
+		if keyValNum, err := strconv.ParseInt(keyVal, 10, 64); err == nil && !b.isStringNumber(node) {
+			return keyValNum
+		}
+	case devicechange.ValueType_UINT:
+		if keyValNum, err := strconv.ParseUint(keyVal, 10, 64); err == nil && !b.isStringNumber(node) {
+			return keyValNum
+		}
+	case devicechange.ValueType_DECIMAL, devicechange.ValueType_FLOAT:
+		if keyValNum, err := strconv.ParseFloat(keyVal, 64); err == nil && !b.jsonRFC7951 {
+			return keyValNum
+		}
+	case devicechange.ValueType_BOOL:
+		if keyValBool, err := strconv.ParseBool(keyVal); err == nil {
+			return keyValBool
+		}
+	case devicechange.ValueType_STRING:
+		return b.identityValue(node, keyVal)
+	}
+	return keyVal
+}
+
+// isStringNumber indicates whether an integer of the model is given as a string, as RFC 7951 does for
+// integers of 64 bits
+func (b *treeBuilder) isStringNumber(node *modelNode) bool {
+	return b.jsonRFC7951 && len(node.typeOpts) > 0 && node.typeOpts[0] > uint8(devicechange.WidthThirtyTwo)
+}
+
+// applyModel corrects the value of a leaf that has been added to the tree, where the type it was stored
+// with falls short of its type in the model
+func (b *treeBuilder) applyModel(nodemap map[string]interface{}, name string, value *devicechange.TypedValue, node *modelNode) {
+	if node == nil || node.union {
+		return
+	}
+	switch value.Type {
+	case devicechange.ValueType_INT, devicechange.ValueType_UINT:
+		if node.valueType == devicechange.ValueType_STRING && node.enum != nil {
+			// An enumeration or identityref that was stored by its value
+			var enumValue int
+			if value.Type == devicechange.ValueType_INT {
+				enumValue = (*devicechange.TypedInt)(value).Int()
+			} else {
+				enumValue = int((*devicechange.TypedUint)(value).Uint())
+			}
+			if enumName, ok := node.enum[enumValue]; ok {
+				nodemap[name] = b.identityValue(node, enumName)
+			}
+		} else if node.valueType == value.Type && len(value.TypeOpts) == 0 && b.isStringNumber(node) {
+			nodemap[name] = value.ValueToString()
+		}
+	case devicechange.ValueType_STRING:
+		if node.valueType == devicechange.ValueType_STRING {
+			nodemap[name] = b.identityValue(node, (*devicechange.TypedString)(value).String())
+		}
+	case devicechange.ValueType_LEAFLIST_INT, devicechange.ValueType_LEAFLIST_UINT:
+		if len(value.TypeOpts) == 0 && b.isStringNumber(node) {
+			var leafList []string
+			if value.Type == devicechange.ValueType_LEAFLIST_INT {
+				ints, _ := (*devicechange.TypedLeafListInt)(value).List()
+				for _, l := range ints {
+					leafList = append(leafList, fmt.Sprintf("%d", l))
+				}
+			} else {
+				uints, _ := (*devicechange.TypedLeafListUint)(value).List()
+				for _, l := range uints {
+					leafList = append(leafList, fmt.Sprintf("%d", l))
+				}
+			}
+			nodemap[name] = leafList
+		}
+	case devicechange.ValueType_LEAFLIST_STRING:
+		if node.valueType == devicechange.ValueType_LEAFLIST_STRING && node.identities != nil {
+			strs := (*devicechange.TypedLeafListString)(value).List()
+			leafList := make([]string, 0, len(strs))
+			for _, str := range strs {
+				leafList = append(leafList, b.identityValue(node, str))
+			}
+			nodemap[name] = leafList
+		}
+	}
+}
+
+// identityValue qualifies the value of an identityref by the module of the identity, as RFC 7951 requires.
+// Any other value is left as it is.
+func (b *treeBuilder) identityValue(node *modelNode, val string) string {
+	if !b.jsonRFC7951 || node.identities == nil {
+		return val
+	}
+	if colonIdx := strings.Index(val, colon); colonIdx >= 0 {
+		val = val[colonIdx+1:]
+	}
+	if module := node.identities[val]; module != "" {
+		return module + colon + val
+	}
+	return val
+}
+
+// memberName gives the name of a node of the tree. In RFC 7951 the name is qualified by the module that
+// defines the node, where it is not the module of its parent.
+func (b *treeBuilder) memberName(parentPath string, name string) string {
+	if !b.jsonRFC7951 || b.schema == nil {
+		return name
+	}
+	parent := b.schemaEntry(modelregistry.RemovePathIndices(parentPath))
+	entry := findChild(parent, name)
+	module := entryModule(entry)
+	if module == "" || module == entryModule(parent) {
+		return name
+	}
+	return module + colon + name
+}
+
+// modelNode gives what the model tells of a leaf, or nil if there is no model or the leaf is not in it
+func (b *treeBuilder) modelNode(path string) *modelNode {
+	pathNoIndices := modelregistry.RemovePathIndices(path)
+	if b.schema != nil {
+		if entry := b.schemaEntry(pathNoIndices); entry != nil && (entry.IsLeaf() || entry.IsLeafList()) {
+			return newSchemaNode(entry)
+		}
+	}
+	if b.rwPaths == nil && b.roPaths == nil {
+		return nil
+	}
+	if b.modelAttribs == nil {
+		b.indexPathMaps()
+	}
+	attrib, ok := b.modelAttribs[pathNoIndices]
+	if !ok {
+		return nil
+	}
+	return &modelNode{
+		valueType: attrib.ValueType,
+		typeOpts:  attrib.TypeOpts,
+		enum:      attrib.Enum,
+	}
+}
+
+// indexPathMaps indexes the leaves of the path maps by their paths without indices
+func (b *treeBuilder) indexPathMaps() {
+	b.modelAttribs = make(map[string]modelregistry.ReadOnlyAttrib)
+	for path, subPaths := range b.roPaths {
+		for subPath, attrib := range subPaths {
+			fullPath := path
+			if subPath != slash {
+				fullPath = path + subPath
+			}
+			b.modelAttribs[modelregistry.RemovePathIndices(fullPath)] = attrib
+		}
+	}
+	for path, elem := range b.rwPaths {
+		b.modelAttribs[modelregistry.RemovePathIndices(path)] = elem.ReadOnlyAttrib
+	}
+}
+
+// schemaEntry gives the entry of the schema at a path without indices, or nil if there is none
+func (b *treeBuilder) schemaEntry(path string) *yang.Entry {
+	if b.entries == nil {
+		b.entries = make(map[string]*yang.Entry)
+	}
+	if entry, ok := b.entries[path]; ok {
+		return entry
+	}
+	entry := b.schema
+	for _, elem := range utils.SplitPath(path) {
+		if entry = findChild(entry, elem); entry == nil {
+			break
+		}
+	}
+	b.entries[path] = entry
+	return entry
+}
+
+// findChild gives the child of a schema entry with the given name, looking through any choice and case
+func findChild(entry *yang.Entry, name string) *yang.Entry {
+	if entry == nil {
+		return nil
+	}
+	if child, ok := entry.Dir[name]; ok {
+		return child
+	}
+	for _, child := range entry.Dir {
+		if child.IsChoice() || child.IsCase() {
+			if found := findChild(child, name); found != nil {
+				return found
+			}
+		}
+	}
+	return nil
+}
+
+func newSchemaNode(entry *yang.Entry) *modelNode {
+	valueType, typeOpts, _ := modelregistry.ToValueType(entry.Type, entry.IsLeafList())
+	node := &modelNode{
+		valueType: valueType,
+		typeOpts:  typeOpts,
+		union:     entry.Type.Kind == yang.Yunion,
+	}
+	switch entry.Type.Kind {
+	case yang.Yenum:
+		if entry.Type.Enum != nil {
+			node.enum = make(map[int]string)
+			for value, name := range entry.Type.Enum.ValueMap() {
+				node.enum[int(value)] = name
+			}
+		}
+	case yang.Yidentityref:
+		if entry.Type.IdentityBase != nil {
+			node.identities = make(map[string]string)
+			for _, identity := range entry.Type.IdentityBase.Values {
+				node.identities[identity.Name] = nodeModule(identity)
+			}
+		}
+	}
+	return node
+}
+
+// entryModule gives the name of the module that defines a schema entry, if it is known
+func entryModule(entry *yang.Entry) string {
+	if entry == nil || entry.Node == nil {
+		return ""
+	}
+	return nodeModule(entry.Node)
+}
+
+// nodeModule gives the name of the module of a YANG node, or of the module a submodule belongs to. Nodes
+// that are not linked up to their module, as in a schema that has been serialized, have no module.
+func nodeModule(node yang.Node) string {
+	module := yang.RootNode(node)
+	if module == nil {
+		return ""
+	}
+	if module.BelongsTo != nil {
+		return module.BelongsTo.Name
+	}
+	return module.Name
+}
+
+func handleLeafValue(nodemap map[string]interface{}, value *devicechange.TypedValue, name string, jsonRFC7951 bool) {
 	switch value.Type {
 	case devicechange.ValueType_EMPTY:
 		// NOOP
 	case devicechange.ValueType_STRING:
-		(nodemap)[pathelems[0]] = (*devicechange.TypedString)(value).String()
+		(nodemap)[name] = (*devicechange.TypedString)(value).String()
 	case devicechange.ValueType_INT:
 		if jsonRFC7951 && len(value.TypeOpts) > 0 && value.TypeOpts[0] > int32(devicechange.WidthThirtyTwo) {
-			(nodemap)[pathelems[0]] = (*devicechange.TypedInt)(value).String()
+			(nodemap)[name] = (*devicechange.TypedInt)(value).String()
 		} else {
-			(nodemap)[pathelems[0]] = (*devicechange.TypedInt)(value).Int()
+			(nodemap)[name] = (*devicechange.TypedInt)(value).Int()
 		}
 	case devicechange.ValueType_UINT:
 		if jsonRFC7951 && len(value.TypeOpts) > 0 && value.TypeOpts[0] > int32(devicechange.WidthThirtyTwo) {
-			(nodemap)[pathelems[0]] = (*devicechange.TypedUint)(value).String()
+			(nodemap)[name] = (*devicechange.TypedUint)(value).String()
 		} else {
-			(nodemap)[pathelems[0]] = (*devicechange.TypedUint)(value).Uint()
+			(nodemap)[name] = (*devicechange.TypedUint)(value).Uint()
 		}
 	case devicechange.ValueType_DECIMAL:
 		if jsonRFC7951 {
-			(nodemap)[pathelems[0]] = (*devicechange.TypedDecimal)(value).String()
+			(nodemap)[name] = (*devicechange.TypedDecimal)(value).String()
 		} else {
-			(nodemap)[pathelems[0]] = (*devicechange.TypedDecimal)(value).Float()
+			(nodemap)[name] = (*devicechange.TypedDecimal)(value).Float()
 		}
 	case devicechange.ValueType_FLOAT:
 		if jsonRFC7951 {
-			(nodemap)[pathelems[0]] = (*devicechange.TypedFloat)(value).String()
+			(nodemap)[name] = (*devicechange.TypedFloat)(value).String()
 		} else {
-			(nodemap)[pathelems[0]] = (*devicechange.TypedFloat)(value).Float32()
+			(nodemap)[name] = (*devicechange.TypedFloat)(value).Float32()
 		}
 	case devicechange.ValueType_BOOL:
-		(nodemap)[pathelems[0]] = (*devicechange.TypedBool)(value).Bool()
+		(nodemap)[name] = (*devicechange.TypedBool)(value).Bool()
 	case devicechange.ValueType_BYTES:
-		(nodemap)[pathelems[0]] = (*devicechange.TypedBytes)(value).ByteArray()
+		(nodemap)[name] = (*devicechange.TypedBytes)(value).ByteArray()
 	case devicechange.ValueType_LEAFLIST_STRING:
-		(nodemap)[pathelems[0]] = (*devicechange.TypedLeafListString)(value).List()
+		(nodemap)[name] = (*devicechange.TypedLeafListString)(value).List()
 	case devicechange.ValueType_LEAFLIST_INT:
 		leafList, width := (*devicechange.TypedLeafListInt)(value).List()
 		if jsonRFC7951 && width > devicechange.WidthThirtyTwo {
@@ -217,9 +538,9 @@ func handleLeafValue(nodemap map[string]interface{}, value *devicechange.TypedVa
 			for _, l := range leafList {
 				asStrList = append(asStrList, fmt.Sprintf("%d", l))
 			}
-			(nodemap)[pathelems[0]] = asStrList
+			(nodemap)[name] = asStrList
 		} else {
-			(nodemap)[pathelems[0]] = leafList
+			(nodemap)[name] = leafList
 		}
 	case devicechange.ValueType_LEAFLIST_UINT:
 		leafList, width := (*devicechange.TypedLeafListUint)(value).List()
@@ -228,20 +549,20 @@ func handleLeafValue(nodemap map[string]interface{}, value *devicechange.TypedVa
 			for _, l := range leafList {
 				asStrList = append(asStrList, fmt.Sprintf("%d", l))
 			}
-			(nodemap)[pathelems[0]] = asStrList
+			(nodemap)[name] = asStrList
 		} else {
-			(nodemap)[pathelems[0]] = leafList
+			(nodemap)[name] = leafList
 		}
 	case devicechange.ValueType_LEAFLIST_BOOL:
-		(nodemap)[pathelems[0]] = (*devicechange.TypedLeafListBool)(value).List()
+		(nodemap)[name] = (*devicechange.TypedLeafListBool)(value).List()
 	case devicechange.ValueType_LEAFLIST_DECIMAL:
-		(nodemap)[pathelems[0]] = (*devicechange.TypedLeafListDecimal)(value).ListFloat()
+		(nodemap)[name] = (*devicechange.TypedLeafListDecimal)(value).ListFloat()
 	case devicechange.ValueType_LEAFLIST_FLOAT:
-		(nodemap)[pathelems[0]] = (*devicechange.TypedLeafListFloat)(value).List()
+		(nodemap)[name] = (*devicechange.TypedLeafListFloat)(value).List()
 	case devicechange.ValueType_LEAFLIST_BYTES:
-		(nodemap)[pathelems[0]] = (*devicechange.TypedLeafListBytes)(value).List()
+		(nodemap)[name] = (*devicechange.TypedLeafListBytes)(value).List()
 	default:
-		(nodemap)[pathelems[0]] = fmt.Sprintf("unexpected %d", value.Type)
+		(nodemap)[name] = fmt.Sprintf("unexpected %d", value.Type)
 	}
 
 }
@@ -17,6 +17,8 @@ package store
 import (
 	"github.com/onosproject/config-models/modelplugin/testdevice-2.0.0/testdevice_2_0_0"
 	devicechange "github.com/onosproject/onos-api/go/onos/config/change/device"
+	"github.com/onosproject/onos-config/pkg/modelregistry"
+	"github.com/openconfig/goyang/pkg/yang"
 	"gotest.tools/assert"
 	"testing"
 )
@@ -138,3 +140,135 @@ func Test_BuildTree(t *testing.T) {
 	assert.Equal(t, ValueLeaftopWxy1234, *model.LeafAtTopLevel)
 
 }
+
+const testJSONDigitKeys = `{
+  "cont1a": {
+    "list2a": [
+      {
+        "name": "123",
+        "tx-power": 5
+      },
+      {
+        "name": "First",
+        "tx-power": 7
+      }
+    ]
+  }
+}`
+
+// Test_BuildTreeWithPathMaps shows that a key that is a string of digits stays a string when the
+// model is given
+func Test_BuildTreeWithPathMaps(t *testing.T) {
+	values := []*devicechange.PathValue{
+		{Path: "/cont1a/list2a[name=123]/tx-power", Value: devicechange.NewTypedValueUint(ValueList2b1PwrT, 16)},
+		{Path: "/cont1a/list2a[name=First]/tx-power", Value: devicechange.NewTypedValueUint(ValueList2b2PwrT, 16)},
+	}
+
+	// Without the model the key is taken as a number, which the model does not accept
+	jsonTree, err := BuildTree(values, true)
+	assert.NilError(t, err)
+	err = testdevice_2_0_0.Unmarshal(jsonTree, new(testdevice_2_0_0.Device))
+	assert.Assert(t, err != nil)
+
+	schema, err := testdevice_2_0_0.UnzipSchema()
+	assert.NilError(t, err)
+	roPaths, rwPaths := modelregistry.ExtractPaths(schema["Device"], yang.TSUnset, "", "")
+	jsonTree, err = BuildTree(values, true, WithPathMaps(rwPaths, roPaths))
+	assert.NilError(t, err)
+	assert.Equal(t, testJSONDigitKeys, string(jsonTree))
+
+	model := new(testdevice_2_0_0.Device)
+	err = testdevice_2_0_0.Unmarshal(jsonTree, model)
+	assert.NilError(t, err)
+	list2a123, ok := model.Cont1A.List2A["123"]
+	assert.Assert(t, ok)
+	assert.Equal(t, ValueList2b1PwrT, int(*list2a123.TxPower))
+
+	// The schema gives the same types
+	jsonTree, err = BuildTree(values, true, WithSchema(schema))
+	assert.NilError(t, err)
+	assert.Equal(t, testJSONDigitKeys, string(jsonTree))
+
+	// but the serialized schema of a model plugin does not give the modules to qualify the names with
+	assert.Assert(t, !QualifiesNames(schema))
+}
+
+const testJSONModules = `{
+  "test-module:cont1a": {
+    "list1": [
+      {
+        "id": 10,
+        "type": "test-types:ethernet"
+      }
+    ],
+    "test-augment:leaf-aug": "augmented"
+  }
+}`
+
+// Test_BuildTreeWithSchema shows that the names and identityref values are qualified by module in RFC 7951
+func Test_BuildTreeWithSchema(t *testing.T) {
+	testModule := &yang.Module{Name: "test-module"}
+	augmentModule := &yang.Module{Name: "test-augment"}
+	typesModule := &yang.Module{Name: "test-types"}
+	schema := map[string]*yang.Entry{
+		"Device": {
+			Name: "device",
+			Kind: yang.DirectoryEntry,
+			Dir: map[string]*yang.Entry{
+				"cont1a": {
+					Name: "cont1a",
+					Kind: yang.DirectoryEntry,
+					Node: &yang.Container{Name: "cont1a", Parent: testModule},
+					Dir: map[string]*yang.Entry{
+						"list1": {
+							Name:     "list1",
+							Kind:     yang.DirectoryEntry,
+							Key:      "id",
+							ListAttr: &yang.ListAttr{},
+							Node:     &yang.List{Name: "list1", Parent: testModule},
+							Dir: map[string]*yang.Entry{
+								"id": {
+									Name: "id",
+									Kind: yang.LeafEntry,
+									Node: &yang.Leaf{Name: "id", Parent: testModule},
+									Type: &yang.YangType{Name: "uint32", Kind: yang.Yuint32},
+								},
+								"type": {
+									Name: "type",
+									Kind: yang.LeafEntry,
+									Node: &yang.Leaf{Name: "type", Parent: testModule},
+									Type: &yang.YangType{
+										Name: "identityref",
+										Kind: yang.Yidentityref,
+										IdentityBase: &yang.Identity{
+											Name: "interface-type",
+											Values: []*yang.Identity{
+												{Name: "ethernet", Parent: typesModule},
+											},
+										},
+									},
+								},
+							},
+						},
+						"leaf-aug": {
+							Name: "leaf-aug",
+							Kind: yang.LeafEntry,
+							Node: &yang.Leaf{Name: "leaf-aug", Parent: augmentModule},
+							Type: &yang.YangType{Name: "string", Kind: yang.Ystring},
+						},
+					},
+				},
+			},
+		},
+	}
+	values := []*devicechange.PathValue{
+		{Path: "/cont1a/leaf-aug", Value: devicechange.NewTypedValueString("augmented")},
+		{Path: "/cont1a/list1[id=10]/id", Value: devicechange.NewTypedValueUint(10, 32)},
+		{Path: "/cont1a/list1[id=10]/type", Value: devicechange.NewTypedValueString("ethernet")},
+	}
+
+	assert.Assert(t, QualifiesNames(schema))
+	jsonTree, err := BuildTree(values, true, WithSchema(schema))
+	assert.NilError(t, err)
+	assert.Equal(t, testJSONModules, string(jsonTree))
+}
@@ -0,0 +1,64 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package store
+
+import (
+	"sync"
+
+	"github.com/golang/mock/gomock"
+	networkchange "github.com/onosproject/onos-api/go/onos/config/change/network"
+	"github.com/onosproject/onos-config/pkg/store/change/metadata"
+	"github.com/onosproject/onos-config/pkg/store/stream"
+)
+
+// SetUpMapBackedNetworkChangeMetadataStore : creates a map backed store for the given mock
+func SetUpMapBackedNetworkChangeMetadataStore(mockMetadataStore *MockNetworkChangeMetadataStore) {
+	mu := &sync.RWMutex{}
+	metadataMap := make(map[networkchange.ID]*metadata.Metadata)
+	put := func(changeMetadata *metadata.Metadata) error {
+		mu.Lock()
+		defer mu.Unlock()
+		changeMetadata.Revision++
+		metadataMap[changeMetadata.ID] = changeMetadata
+		return nil
+	}
+	mockMetadataStore.EXPECT().Create(gomock.Any()).DoAndReturn(put).AnyTimes()
+	mockMetadataStore.EXPECT().Update(gomock.Any()).DoAndReturn(put).AnyTimes()
+	mockMetadataStore.EXPECT().Get(gomock.Any()).DoAndReturn(
+		func(id networkchange.ID) (*metadata.Metadata, error) {
+			mu.RLock()
+			defer mu.RUnlock()
+			return metadataMap[id], nil
+		}).AnyTimes()
+	mockMetadataStore.EXPECT().Delete(gomock.Any()).DoAndReturn(
+		func(id networkchange.ID) error {
+			mu.Lock()
+			defer mu.Unlock()
+			delete(metadataMap, id)
+			return nil
+		}).AnyTimes()
+	mockMetadataStore.EXPECT().List(gomock.Any()).DoAndReturn(
+		func(c chan<- *metadata.Metadata) (stream.Context, error) {
+			go func() {
+				mu.RLock()
+				defer mu.RUnlock()
+				for _, changeMetadata := range metadataMap {
+					c <- changeMetadata
+				}
+				close(c)
+			}()
+			return stream.NewContext(func() {}), nil
+		}).AnyTimes()
+}
@@ -60,7 +60,7 @@ func SetUpMapBackedNetworkChangesStore(mockNetworkChangesStore *MockNetworkChang
 		}).AnyTimes()
 
 	mockNetworkChangesStore.EXPECT().List(gomock.Any()).DoAndReturn(
-		func(c chan<- *networkchange.NetworkChange) error {
+		func(c chan<- *networkchange.NetworkChange) (stream.Context, error) {
 			go func() {
 				mu.RLock()
 				defer mu.RUnlock()
@@ -69,7 +69,7 @@ func SetUpMapBackedNetworkChangesStore(mockNetworkChangesStore *MockNetworkChang
 				}
 				close(c)
 			}()
-			return nil
+			return stream.NewContext(func() {}), nil
 		}).AnyTimes()
 	mockNetworkChangesStore.EXPECT().Watch(gomock.Any(), gomock.Any()).DoAndReturn(
 		func(c chan<- stream.Event, o ...networkstore.WatchOption) (stream.Context, error) {
@@ -24,4 +24,7 @@ type MockStores struct {
 	DeviceSnapshotStore  *MockDeviceSnapshotStore
 	LeadershipStore      *MockLeadershipStore
 	MastershipStore      *MockMastershipStore
+	DeviceLockStore      *MockDeviceLockStore
+	MetadataStore        *MockNetworkChangeMetadataStore
+	DeadLetterStore      *MockDeadLetterStore
 }
@@ -0,0 +1,86 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+	"crypto/x509"
+
+	"github.com/golang-jwt/jwt"
+	grpc_auth "github.com/grpc-ecosystem/go-grpc-middleware/auth"
+	"github.com/onosproject/onos-lib-go/pkg/auth"
+	"github.com/onosproject/onos-lib-go/pkg/grpcinterceptors"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+)
+
+// jwtAuthentication indicates whether the clients are authenticated with a JWT, whose claims can then be trusted
+var jwtAuthentication bool
+
+// SetJwtAuthentication sets whether the clients are authenticated with a JWT. The claims of a JWT are only taken
+// into account when they are, since its signature cannot be checked without the keys of the identity provider.
+func SetJwtAuthentication(enabled bool) {
+	jwtAuthentication = enabled
+}
+
+// GetClientIdentity gives the identity of the client of a gRPC call - the common name of its verified
+// TLS certificate, otherwise the subject of its validated JWT. It is empty if the client is not authenticated.
+func GetClientIdentity(ctx context.Context) string {
+	if certificate := GetVerifiedCertificate(ctx); certificate != nil && certificate.Subject.CommonName != "" {
+		return certificate.Subject.CommonName
+	}
+	if claims := GetValidatedClaims(ctx); claims != nil {
+		if subject, ok := claims["sub"].(string); ok {
+			return subject
+		}
+	}
+	return ""
+}
+
+// GetVerifiedCertificate gives the TLS certificate of the client of a gRPC call if it was verified against the
+// trusted CAs, or nil if it was not. A certificate that was merely presented by the client is not given.
+func GetVerifiedCertificate(ctx context.Context) *x509.Certificate {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return nil
+	}
+	for _, chain := range tlsInfo.State.VerifiedChains {
+		if len(chain) > 0 {
+			return chain[0]
+		}
+	}
+	return nil
+}
+
+// GetValidatedClaims gives the claims of the JWT that the client of a gRPC call gave as its bearer token, once
+// validated. It is nil if the client gave no valid JWT, or if the clients are not authenticated with a JWT.
+func GetValidatedClaims(ctx context.Context) jwt.MapClaims {
+	if !jwtAuthentication {
+		return nil
+	}
+	token, err := grpc_auth.AuthFromMD(ctx, grpcinterceptors.ContextMetadataTokenKey)
+	if err != nil {
+		return nil
+	}
+	claims, err := new(auth.JwtAuthenticator).ParseAndValidate(token)
+	if err != nil {
+		return nil
+	}
+	return jwt.MapClaims(claims)
+}
@@ -0,0 +1,77 @@
+// Copyright 2020-present Open Networking Foundation.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package utils
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"net"
+	"os"
+	"testing"
+
+	"github.com/golang-jwt/jwt"
+	"github.com/onosproject/onos-lib-go/pkg/auth"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"gotest.tools/assert"
+)
+
+// TestGetClientIdentity : GetClientIdentity() from the verified certificate or the validated JWT
+func TestGetClientIdentity(t *testing.T) {
+	assert.Equal(t, "", GetClientIdentity(context.Background()))
+
+	// The address of the client does not identify it
+	addr := &net.TCPAddr{IP: net.ParseIP("10.0.0.1"), Port: 45678}
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: addr})
+	assert.Equal(t, "", GetClientIdentity(ctx))
+
+	// Nor does a certificate that was not verified
+	tlsInfo := credentials.TLSInfo{
+		State: tls.ConnectionState{
+			PeerCertificates: []*x509.Certificate{
+				{Subject: pkix.Name{CommonName: "onos-cli"}},
+			},
+		},
+	}
+	ctx = peer.NewContext(context.Background(), &peer.Peer{Addr: addr, AuthInfo: tlsInfo})
+	assert.Equal(t, "", GetClientIdentity(ctx))
+
+	tlsInfo.State.VerifiedChains = [][]*x509.Certificate{tlsInfo.State.PeerCertificates}
+	ctx = peer.NewContext(context.Background(), &peer.Peer{Addr: addr, AuthInfo: tlsInfo})
+	assert.Equal(t, "onos-cli", GetClientIdentity(ctx))
+}
+
+// TestGetClientIdentityJwt : GetClientIdentity() from the subject of a JWT, only when it is validated
+func TestGetClientIdentityJwt(t *testing.T) {
+	defer os.Unsetenv(auth.SharedSecretKey)
+	defer SetJwtAuthentication(false)
+	os.Setenv(auth.SharedSecretKey, "secret")
+
+	newContext := func(key string) context.Context {
+		token, err := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{"sub": "alice"}).SignedString([]byte(key))
+		assert.NilError(t, err)
+		return metadata.NewIncomingContext(context.Background(), metadata.Pairs("authorization", "bearer "+token))
+	}
+
+	// The JWT is not taken into account unless the clients are authenticated with one
+	assert.Equal(t, "", GetClientIdentity(newContext("secret")))
+
+	SetJwtAuthentication(true)
+	assert.Equal(t, "alice", GetClientIdentity(newContext("secret")))
+	assert.Equal(t, "", GetClientIdentity(newContext("forged")))
+}
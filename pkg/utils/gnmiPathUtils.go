@@ -254,6 +254,22 @@ func strPathV03(path *pb.Path) string {
 	return "/" + strings.Join(path.Element, "/")
 }
 
+// IsPathUnder indicates whether the path is the same as or a descendant of the prefix. The prefix "/" is the root
+// of every path, and a prefix that names a list without keys is the parent of all its entries, e.g. /a/b is a
+// prefix of /a/b[name=x]/c.
+func IsPathUnder(path string, prefix string) bool {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if prefix == "" || path == prefix {
+		return true
+	}
+	return strings.HasPrefix(path, prefix+"/") || strings.HasPrefix(path, prefix+"[")
+}
+
+// IsIntersectingPath indicates whether the two paths are the same or one is a descendant of the other
+func IsIntersectingPath(path1 string, path2 string) bool {
+	return IsPathUnder(path1, path2) || IsPathUnder(path2, path1)
+}
+
 // StrVal will return a string representing the supplied value
 func StrVal(val *pb.TypedValue) string {
 	switch v := val.GetValue().(type) {
@@ -345,3 +345,28 @@ func Test_StrVal_Any(t *testing.T) {
 	result := StrVal(typedValue)
 	assert.Equal(t, expected, result)
 }
+
+func Test_IsPathUnder(t *testing.T) {
+	assert.Assert(t, IsPathUnder("/a/b", "/"))
+	assert.Assert(t, IsPathUnder("/a/b", "/a/b"))
+	assert.Assert(t, IsPathUnder("/a/b/c", "/a/b"))
+	assert.Assert(t, IsPathUnder("/a/b/c", "/a/b/"))
+	assert.Assert(t, !IsPathUnder("/a/bc", "/a/b"))
+	assert.Assert(t, !IsPathUnder("/a", "/a/b"))
+
+	// A list without keys is the parent of its entries, but an entry is not the parent of another entry
+	assert.Assert(t, IsPathUnder("/a/b[name=x]/c", "/a/b"))
+	assert.Assert(t, IsPathUnder("/a/b[name=x]/c", "/a/b[name=x]"))
+	assert.Assert(t, !IsPathUnder("/a/b[name=xy]/c", "/a/b[name=x]"))
+	assert.Assert(t, !IsPathUnder("/a/b[name=y]/c", "/a/b[name=x]"))
+}
+
+func Test_IsIntersectingPath(t *testing.T) {
+	assert.Assert(t, IsIntersectingPath("/a/b", "/a/b"))
+	assert.Assert(t, IsIntersectingPath("/a/b/c", "/a/b"))
+	assert.Assert(t, IsIntersectingPath("/a/b", "/a/b/c"))
+	assert.Assert(t, IsIntersectingPath("/a/b", "/a/b[name=x]/c"))
+	assert.Assert(t, IsIntersectingPath("/a/b[name=x]/c", "/a/b"))
+	assert.Assert(t, !IsIntersectingPath("/a/b[name=x]/c", "/a/b[name=y]/c"))
+	assert.Assert(t, !IsIntersectingPath("/a/b", "/a/c"))
+}